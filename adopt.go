@@ -0,0 +1,136 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Adopt brings an existing Go repository under the generator: it reads the
+// module path from go.mod, keeps existing cmd/ binaries, and writes only the
+// components that are missing (config, logs, pathutil, Taskfile, ...).
+// Existing files are never touched. A manifest marked as adopted is written
+// so later updates stay limited to the generated files.
+func (g *Generator) Adopt(dir string) error {
+	modPath, err := readModulePath(dir)
+	if err != nil {
+		return err
+	}
+	g.Config = NewGenConfig(modPath, dir)
+	g.Results = nil
+	g.Commands = nil
+	release, err := g.lock(g.Config.ProjectPath())
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := g.applyReproducible(); err != nil {
+		return err
+	}
+	g.prev = &Manifest{Adopted: true}
+	if err := g.applyKind(nil); err != nil {
+		return err
+	}
+	if err := g.applyFeatures(nil); err != nil {
+		return err
+	}
+
+	projPath := g.Config.ProjectPath()
+	bins := existingBinaries(projPath)
+	if len(bins) > 0 {
+		g.Config.BinaryName = bins[0]
+	}
+	if err := g.applyBinaries(nil); err != nil {
+		return err
+	}
+	g.applyVendor(nil)
+	if err := g.ResolveVars(nil); err != nil {
+		return err
+	}
+
+	files, err := g.RenderAll()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if g.adoptSkips(projPath, f, bins) {
+			g.Results = append(g.Results, FileResult{Path: f.Path, Template: f.Template, Status: StatusSkipped})
+			continue
+		}
+		if err := g.emit(f); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", f.Path, err)
+		}
+	}
+
+	if err := g.WriteManifest(); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := g.pinDeps(); err != nil {
+		return fmt.Errorf("failed to pin dependencies: %w", err)
+	}
+	if err := g.ModTidy(); err != nil {
+		return fmt.Errorf("go mod tidy failed: %w", err)
+	}
+	if err := g.modVendor(); err != nil {
+		return fmt.Errorf("go mod vendor failed: %w", err)
+	}
+	if err := g.writeAttestations(); err != nil {
+		return fmt.Errorf("failed to write attestations: %w", err)
+	}
+	return g.stampFiles()
+}
+
+// adoptSkips reports whether a rendered file belongs to something the repo
+// already has: the file itself, its package directory, an existing cmd/
+// binary, or (for the root package file) any Go source at the root.
+func (g *Generator) adoptSkips(projPath string, f RenderedFile, bins []string) bool {
+	if _, err := os.Stat(filepath.Join(projPath, filepath.FromSlash(f.Path))); err == nil {
+		return true
+	}
+	top, _, nested := strings.Cut(f.Path, "/")
+	switch {
+	case top == "cmd":
+		return len(bins) > 0
+	case nested:
+		_, err := os.Stat(filepath.Join(projPath, top))
+		return err == nil
+	case strings.HasSuffix(f.Path, ".go"):
+		matches, _ := filepath.Glob(filepath.Join(projPath, "*.go"))
+		return len(matches) > 0
+	}
+	return false
+}
+
+// readModulePath returns the module path declared in dir/go.mod.
+func readModulePath(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("not a Go module: %w", err)
+	}
+	mod := modfile.ModulePath(data)
+	if mod == "" {
+		return "", fmt.Errorf("no module directive in go.mod")
+	}
+	return mod, nil
+}
+
+// existingBinaries lists cmd/<name> directories that contain Go files.
+func existingBinaries(projPath string) []string {
+	entries, err := os.ReadDir(filepath.Join(projPath, "cmd"))
+	if err != nil {
+		return nil
+	}
+	var bins []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if matches, _ := filepath.Glob(filepath.Join(projPath, "cmd", e.Name(), "*.go")); len(matches) > 0 {
+			bins = append(bins, e.Name())
+		}
+	}
+	return bins
+}
@@ -0,0 +1,73 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+func TestReadModulePath(t *testing.T) {
+	tests := []struct {
+		gomod string
+		want  string
+	}{
+		{"module github.com/example/demo\n", "github.com/example/demo"},
+		{"module github.com/example/demo // moved from bitbucket\n\ngo 1.24\n", "github.com/example/demo"},
+		{"// a demo\nmodule \"github.com/example/demo\"\n", "github.com/example/demo"},
+		{"module\tgithub.com/example/demo\n", "github.com/example/demo"},
+		{"// module github.com/example/demo\ngo 1.24\n", ""},
+	}
+	for _, tt := range tests {
+		dir := writeTree(t, map[string]string{"go.mod": tt.gomod})
+		got, err := readModulePath(dir)
+		if got != tt.want || (err == nil) != (tt.want != "") {
+			t.Errorf("readModulePath(%q) = %q, %v; want %q", tt.gomod, got, err, tt.want)
+		}
+	}
+	if _, err := readModulePath(t.TempDir()); err == nil {
+		t.Error("readModulePath() without go.mod succeeded, want an error")
+	}
+}
+
+func TestAdopt(t *testing.T) {
+	const main = "package main\n\nfunc main() {}\n"
+	dir := writeTree(t, map[string]string{
+		"go.mod":              "module github.com/example/tool // the tool\n\ngo 1.24\n",
+		"cmd/toolctl/main.go": main,
+		"config/config.go":    "package config\n",
+	})
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	if err := g.Adopt(dir); err != nil {
+		t.Fatalf("Adopt() error: %v", err)
+	}
+	if g.Config.ModuleURL != "github.com/example/tool" || g.Config.BinaryName != "toolctl" {
+		t.Errorf("Adopt() module %q, binary %q; want github.com/example/tool, toolctl", g.Config.ModuleURL, g.Config.BinaryName)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "cmd", "toolctl", "main.go")); err != nil || string(data) != main {
+		t.Errorf("Adopt() changed cmd/toolctl/main.go: %q, %v", data, err)
+	}
+	status := make(map[string]FileStatus)
+	for _, r := range g.Results {
+		status[r.Path] = r.Status
+	}
+	for path, want := range map[string]FileStatus{
+		"config/config.go":    StatusSkipped,
+		"cmd/toolctl/main.go": StatusSkipped,
+		"logs/logs.go":        StatusCreated,
+	} {
+		if status[path] != want {
+			t.Errorf("%s is %q, want %q", path, status[path], want)
+		}
+	}
+
+	m, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("LoadManifest() error: %v", err)
+	}
+	if !m.Adopted || m.File("logs/logs.go") == nil || m.File("config/config.go") != nil {
+		t.Errorf("manifest = %+v, want it adopted with only the written files", m)
+	}
+}
@@ -0,0 +1,347 @@
+package project
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+// AttestDir holds the attestations written with Generator.Attest.
+const AttestDir = ".project"
+
+const (
+	sbomName       = "sbom.spdx.json"
+	provenanceName = "provenance.intoto.json"
+
+	// builderID identifies this generator in provenance statements.
+	builderID = "https://github.com/robbyriverside/project"
+	buildType = builderID + "/gen@v1"
+)
+
+// attestedFile is one file covered by the attestations.
+type attestedFile struct {
+	Path   string // slash-separated, relative to the project root
+	SHA1   string
+	SHA256 string
+}
+
+// writeAttestations writes an SPDX 2.3 SBOM and an in-toto provenance
+// statement for the files of this run, the template pack they came from,
+// and the modules go.mod requires. Both are plain JSON; sign them with the
+// tooling your organization already uses.
+func (g *Generator) writeAttestations() error {
+	if !g.Attest {
+		return nil
+	}
+	pack, err := g.PackInfo()
+	if err != nil {
+		return err
+	}
+	packDigest, err := g.packDigest()
+	if err != nil {
+		return err
+	}
+	files, err := g.attestedFiles()
+	if err != nil {
+		return err
+	}
+	deps, err := g.requiredModules()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(g.Config.ProjectPath(), AttestDir)
+	sbom, err := json.MarshalIndent(g.sbom(pack, packDigest, files, deps), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := g.writeFile(filepath.Join(dir, sbomName), append(sbom, '\n'), fileutils.DefaultMode); err != nil {
+		return fmt.Errorf("failed to write SBOM: %w", err)
+	}
+	prov, err := json.MarshalIndent(g.provenance(pack, packDigest, files), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := g.writeFile(filepath.Join(dir, provenanceName), append(prov, '\n'), fileutils.DefaultMode); err != nil {
+		return fmt.Errorf("failed to write provenance: %w", err)
+	}
+	return nil
+}
+
+// attestedFiles hashes the files this run generated plus go.mod and go.sum,
+// as they are on disk after tidy, in path order.
+func (g *Generator) attestedFiles() ([]attestedFile, error) {
+	paths := []string{"go.mod", "go.sum"}
+	for _, r := range g.Results {
+		if r.Status != StatusSkipped {
+			paths = append(paths, r.Path)
+		}
+	}
+	sort.Strings(paths)
+
+	var out []attestedFile
+	for _, p := range paths {
+		data, err := g.fsys().ReadFile(filepath.Join(g.Config.ProjectPath(), filepath.FromSlash(p)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s1 := sha1.Sum(data)
+		out = append(out, attestedFile{Path: p, SHA1: hex.EncodeToString(s1[:]), SHA256: checksum(data)})
+	}
+	return out, nil
+}
+
+// packDigest is a sha256 over the pack's file paths and contents, so two
+// copies of the same pack (embedded, ejected or downloaded) digest alike.
+func (g *Generator) packDigest() (string, error) {
+	fsys, err := g.pack()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() || strings.HasSuffix(p, "_test.go") {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s  %s\n", checksum(data), p)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to digest template pack: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SPDX 2.3 document, reduced to the fields this generator fills in.
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Files             []spdxFile         `json:"files"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+	Comment          string            `json:"comment,omitempty"`
+}
+
+type spdxFile struct {
+	FileName  string         `json:"fileName"`
+	SPDXID    string         `json:"SPDXID"`
+	Checksums []spdxChecksum `json:"checksums"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+// spdxID turns a name into the idstring SPDX allows: letters, digits, '.' and '-'.
+func spdxID(kind, name string) string {
+	clean := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '-'
+	}, name)
+	return "SPDXRef-" + kind + "-" + clean
+}
+
+func (g *Generator) sbom(pack *Pack, packDigest string, files []attestedFile, deps map[string]string) *spdxDocument {
+	projectID := spdxID("Package", g.Config.ModuleURL)
+	packID := spdxID("Package", "pack-"+pack.Name)
+
+	// The namespace must be unique per document; derive it from the content
+	h := sha256.New()
+	for _, f := range files {
+		fmt.Fprintf(h, "%s  %s\n", f.SHA256, f.Path)
+	}
+
+	doc := &spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              g.Config.ProjectName,
+		DocumentNamespace: "https://" + g.Config.ModuleURL + "/spdx/" + hex.EncodeToString(h.Sum(nil)),
+		CreationInfo: spdxCreationInfo{
+			Created:  g.now().UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: project-" + Version},
+		},
+		Packages: []spdxPackage{
+			{
+				SPDXID:           projectID,
+				Name:             g.Config.ModuleURL,
+				DownloadLocation: "NOASSERTION",
+				ExternalRefs:     []spdxExternalRef{purl(g.Config.ModuleURL, "")},
+			},
+			{
+				SPDXID:           packID,
+				Name:             pack.Name,
+				VersionInfo:      pack.Version,
+				DownloadLocation: "NOASSERTION",
+				Checksums:        []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: packDigest}},
+				Comment:          "Template pack; the checksum covers its file paths and contents",
+			},
+		},
+		Relationships: []spdxRelationship{
+			{Element: "SPDXRef-DOCUMENT", Type: "DESCRIBES", Related: projectID},
+		},
+	}
+
+	for _, f := range files {
+		id := spdxID("File", f.Path)
+		doc.Files = append(doc.Files, spdxFile{
+			FileName: "./" + f.Path,
+			SPDXID:   id,
+			Checksums: []spdxChecksum{
+				{Algorithm: "SHA1", ChecksumValue: f.SHA1},
+				{Algorithm: "SHA256", ChecksumValue: f.SHA256},
+			},
+		})
+		doc.Relationships = append(doc.Relationships,
+			spdxRelationship{Element: projectID, Type: "CONTAINS", Related: id},
+			spdxRelationship{Element: id, Type: "GENERATED_FROM", Related: packID})
+	}
+
+	pinned := make(map[string]bool)
+	for _, d := range PinnedDeps {
+		pinned[d.Path] = true
+	}
+	paths := make([]string, 0, len(deps))
+	for p := range deps {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		pkg := spdxPackage{
+			SPDXID:           spdxID("Package", p),
+			Name:             p,
+			VersionInfo:      deps[p],
+			DownloadLocation: "https://proxy.golang.org/" + p + "/@v/" + deps[p] + ".zip",
+			ExternalRefs:     []spdxExternalRef{purl(p, deps[p])},
+		}
+		if pinned[p] {
+			pkg.Comment = "Version pinned by the generator"
+		}
+		doc.Packages = append(doc.Packages, pkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{Element: projectID, Type: "DEPENDS_ON", Related: pkg.SPDXID})
+	}
+	return doc
+}
+
+func purl(module, version string) spdxExternalRef {
+	locator := "pkg:golang/" + module
+	if version != "" {
+		locator += "@" + version
+	}
+	return spdxExternalRef{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: locator}
+}
+
+// in-toto Statement v1 carrying a SLSA provenance v1 predicate.
+type intotoStatement struct {
+	Type          string          `json:"_type"`
+	Subject       []intotoSubject `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     slsaProvenance  `json:"predicate"`
+}
+
+type intotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type slsaProvenance struct {
+	BuildDefinition struct {
+		BuildType            string           `json:"buildType"`
+		ExternalParameters   map[string]any   `json:"externalParameters"`
+		ResolvedDependencies []intotoResource `json:"resolvedDependencies"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID      string            `json:"id"`
+			Version map[string]string `json:"version"`
+		} `json:"builder"`
+		Metadata struct {
+			StartedOn string `json:"startedOn"`
+		} `json:"metadata"`
+	} `json:"runDetails"`
+}
+
+type intotoResource struct {
+	Name   string            `json:"name"`
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+func (g *Generator) provenance(pack *Pack, packDigest string, files []attestedFile) *intotoStatement {
+	st := &intotoStatement{
+		Type:          "https://in-toto.io/Statement/v1",
+		PredicateType: "https://slsa.dev/provenance/v1",
+	}
+	for _, f := range files {
+		st.Subject = append(st.Subject, intotoSubject{Name: f.Path, Digest: map[string]string{"sha256": f.SHA256}})
+	}
+
+	p := &st.Predicate
+	p.BuildDefinition.BuildType = buildType
+	p.BuildDefinition.ExternalParameters = map[string]any{
+		"module":   g.Config.ModuleURL,
+		"kind":     g.Config.Kind,
+		"lang":     g.Lang,
+		"features": append([]string{}, g.Features...),
+	}
+	p.BuildDefinition.ResolvedDependencies = []intotoResource{{
+		Name:   pack.Name,
+		URI:    "pack:" + pack.Name + "@" + pack.Version,
+		Digest: map[string]string{"sha256": packDigest},
+	}}
+	p.RunDetails.Builder.ID = builderID
+	p.RunDetails.Builder.Version = map[string]string{"project": Version}
+	p.RunDetails.Metadata.StartedOn = g.now().UTC().Format(time.RFC3339)
+	return st
+}
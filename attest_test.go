@@ -0,0 +1,112 @@
+package project
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+func TestAttestations(t *testing.T) {
+	respond := func(c execx.Call) (execx.Result, error) {
+		if c.String() == "go mod edit -json" {
+			return execx.Result{Stdout: []byte(`{"Require": [{"Path": "github.com/spf13/viper", "Version": "v1.20.1"}]}`)}, nil
+		}
+		return fakeGo(c)
+	}
+	g := &Generator{Runner: &execx.Recorder{Respond: respond}, Attest: true}
+	if err := g.GenerateAll("github.com/example/demo", t.TempDir()); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	pp := g.Config.ProjectPath()
+
+	// Every file the run wrote, plus go.mod, with its sha256 on disk; the
+	// fake go writes no go.sum
+	want := make(map[string]string)
+	for _, r := range append(g.Results, FileResult{Path: "go.mod"}) {
+		if r.Status == StatusSkipped {
+			continue
+		}
+		sum, err := fileChecksum(fileutils.OS, filepath.Join(pp, filepath.FromSlash(r.Path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[r.Path] = sum
+	}
+
+	var sbom spdxDocument
+	readJSON(t, filepath.Join(pp, AttestDir, sbomName), &sbom)
+	if sbom.SPDXVersion != "SPDX-2.3" || sbom.SPDXID != "SPDXRef-DOCUMENT" {
+		t.Errorf("SBOM is %s %s, want SPDX-2.3 SPDXRef-DOCUMENT", sbom.SPDXVersion, sbom.SPDXID)
+	}
+	files := make(map[string]string)
+	for _, f := range sbom.Files {
+		for _, c := range f.Checksums {
+			if c.Algorithm == "SHA256" {
+				files[strings.TrimPrefix(f.FileName, "./")] = c.ChecksumValue
+			}
+		}
+	}
+	checkDigests(t, "SBOM", files, want)
+	var dep *spdxPackage
+	for i := range sbom.Packages {
+		if sbom.Packages[i].Name == "github.com/spf13/viper" {
+			dep = &sbom.Packages[i]
+		}
+	}
+	if dep == nil || dep.VersionInfo != "v1.20.1" {
+		t.Errorf("SBOM packages = %+v, want github.com/spf13/viper v1.20.1", sbom.Packages)
+	}
+
+	var prov intotoStatement
+	readJSON(t, filepath.Join(pp, AttestDir, provenanceName), &prov)
+	if prov.Type != "https://in-toto.io/Statement/v1" || prov.PredicateType != "https://slsa.dev/provenance/v1" {
+		t.Errorf("provenance is %s of %s, want an in-toto v1 statement of SLSA provenance v1", prov.Type, prov.PredicateType)
+	}
+	subjects := make(map[string]string)
+	for _, s := range prov.Subject {
+		subjects[s.Name] = s.Digest["sha256"]
+	}
+	checkDigests(t, "provenance", subjects, want)
+
+	// The subjects agree with what the manifest recorded
+	m, err := LoadManifest(pp)
+	if err != nil {
+		t.Fatalf("LoadManifest() error: %v", err)
+	}
+	for _, f := range m.Files {
+		if subjects[f.Path] != f.SHA256 {
+			t.Errorf("provenance sha256 of %s = %s, manifest has %s", f.Path, subjects[f.Path], f.SHA256)
+		}
+	}
+}
+
+func readJSON(t *testing.T, path string, v any) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("%s: %v", filepath.Base(path), err)
+	}
+}
+
+// checkDigests compares the sha256 digests an attestation lists with want.
+func checkDigests(t *testing.T, what string, got, want map[string]string) {
+	t.Helper()
+	for path, sum := range want {
+		if got[path] != sum {
+			t.Errorf("%s sha256 of %s = %q, file has %s", what, path, got[path], sum)
+		}
+	}
+	for path := range got {
+		if _, ok := want[path]; !ok {
+			t.Errorf("%s lists %s, which the run didn't write", what, path)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package project
+
+import (
+	"fmt"
+
+	"github.com/robbyriverside/project/internal/naming"
+)
+
+// binaryFiles are the templates rendered once per binary, into cmd/<name>.
+// Every binary shares the project's packages (config, logs and so on).
+var binaryFiles = map[string]bool{
+	"main":      true,
+	"context":   true,
+	"docs":      true,
+	"about":     true,
+	"setup":     true,
+	"env":       true,
+	"smoke":     true,
+	"main_test": true,
+	"scaffold":  true,
+	"plugins":   true,
+	"serve":     true,
+
+	"tui-command": true,
+}
+
+// applyBinaries settles the project's binaries and exposes them to
+// templates as g.Config.Binaries: BinaryName first, then those of the
+// previous manifest, then g.Binaries. On a first generation the first of
+// g.Binaries becomes BinaryName.
+func (g *Generator) applyBinaries(prev *Manifest) error {
+	if len(g.Binaries) > 0 && g.Config.Kind == "library" {
+		return fmt.Errorf("library projects have no binaries to add")
+	}
+	if (prev == nil || prev.BinaryName == "") && len(g.Binaries) > 0 {
+		g.Config.BinaryName = g.Binaries[0]
+	}
+
+	names := []string{g.Config.BinaryName}
+	if prev != nil {
+		names = append(names, prev.Binaries...)
+	}
+	seen := make(map[string]bool)
+	var bins []string
+	for _, name := range append(names, g.Binaries...) {
+		if name != naming.BinaryName(name) {
+			return fmt.Errorf("invalid binary name %q (try %q)", name, naming.BinaryName(name))
+		}
+		if !seen[name] {
+			seen[name] = true
+			bins = append(bins, name)
+		}
+	}
+	g.Binaries = bins
+	g.Config.Binaries = bins
+	return nil
+}
+
+// renderBinaries renders a per-binary template once for each binary, each
+// time with a copy of g.Config naming that binary.
+func (g *Generator) renderBinaries(fileType string) ([]RenderedFile, error) {
+	var files []RenderedFile
+	primary := g.Config
+	defer func() { g.Config = primary }()
+	for _, bin := range primary.Binaries {
+		cfg := *primary
+		cfg.BinaryName = bin
+		g.Config = &cfg
+		f, err := g.Render(fileType)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, *f)
+	}
+	return files, nil
+}
+
+// recordedBinaries is what the manifest keeps of g.Binaries: nothing for
+// projects with just the one binary, which binary_name already records.
+func (g *Generator) recordedBinaries() []string {
+	if len(g.Config.Binaries) < 2 {
+		return nil
+	}
+	return g.Config.Binaries
+}
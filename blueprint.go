@@ -0,0 +1,425 @@
+package project
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/robbyriverside/project/internal/jsonschema"
+	"github.com/robbyriverside/project/internal/metadata"
+	"github.com/robbyriverside/project/internal/naming"
+)
+
+// Blueprint declares a whole project in YAML, for gen --file: the module
+// and what gen's flags would say, plus commands, config fields and env
+// targets beyond the scaffold. Checked into a platform repo, it generates
+// the same project every time.
+type Blueprint struct {
+	Module    string   `yaml:"module"`
+	Dir       string   `yaml:"dir,omitempty"`
+	Kind      string   `yaml:"kind,omitempty"`
+	Preset    string   `yaml:"preset,omitempty"`
+	Templates string   `yaml:"templates,omitempty"`
+	Lang      string   `yaml:"lang,omitempty"`
+	Binaries  []string `yaml:"binaries,omitempty"`
+	Features  []string `yaml:"features,omitempty"`
+	Targets   []string `yaml:"targets,omitempty"`
+
+	// Vars are template variables, {{.Vars.name}}. Uses names projects of
+	// the same BlueprintSet whose modules this one imports.
+	Vars map[string]string `yaml:"vars,omitempty"`
+	Uses []string          `yaml:"uses,omitempty"`
+
+	// Catalog feeds catalog-info.yaml, when the catalog feature is on.
+	Catalog Catalog `yaml:"catalog,omitempty"`
+
+	Commands []Subcommand  `yaml:"commands,omitempty"`
+	Config   []ConfigField `yaml:"config,omitempty"`
+	Env      []EnvTarget   `yaml:"env,omitempty"`
+}
+
+//go:embed blueprint.schema.json
+var blueprintSchema []byte
+
+// BlueprintSchema returns the JSON Schema of blueprint files, for editors
+// to check and complete them with.
+func BlueprintSchema() []byte {
+	return blueprintSchema
+}
+
+// BlueprintError lists where a blueprint breaks its schema.
+type BlueprintError struct {
+	File     string // empty when the blueprint didn't come from a file
+	Problems []jsonschema.Error
+}
+
+func (e *BlueprintError) Error() string {
+	file := e.File
+	if file == "" {
+		file = "blueprint"
+	}
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = file + ":" + p.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// LoadBlueprint reads the blueprint of one project at path.
+func LoadBlueprint(path string) (*Blueprint, error) {
+	set, err := readBlueprintFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(set.Projects) != 1 {
+		return nil, fmt.Errorf("%s declares %d projects, want one", filepath.Base(path), len(set.Projects))
+	}
+	return set.Projects[0], nil
+}
+
+// ParseBlueprint decodes the blueprint of one project; see
+// ParseBlueprintSet.
+func ParseBlueprint(data []byte) (*Blueprint, error) {
+	set, err := ParseBlueprintSet(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(set.Projects) != 1 {
+		return nil, fmt.Errorf("blueprint declares %d projects, want one", len(set.Projects))
+	}
+	return set.Projects[0], nil
+}
+
+// Apply sets g up to generate the blueprint. Like Preset.Apply, it sets the
+// kind unless g already names another and adds to the features, binaries
+// and targets g requests; the blueprint's come first, and g's vars and
+// catalog fields win. Its commands, config fields and env targets are
+// checked when g generates. Uses are left to the caller, which knows where
+// each project of the set goes; see ModuleUse.
+func (b *Blueprint) Apply(g *Generator) error {
+	if b.Kind != "" {
+		kind := strings.ToLower(strings.TrimSpace(g.Kind))
+		if kind != "" && kind != b.Kind {
+			return fmt.Errorf("blueprint is for %s projects, not %s", b.Kind, kind)
+		}
+		g.Kind = b.Kind
+	}
+	if g.Lang == "" {
+		g.Lang = b.Lang
+	}
+	g.Features = append(append([]string(nil), b.Features...), g.Features...)
+	g.Binaries = append(append([]string(nil), b.Binaries...), g.Binaries...)
+	g.Targets = append(append([]string(nil), b.Targets...), g.Targets...)
+	g.Subcommands = append(append([]Subcommand(nil), b.Commands...), g.Subcommands...)
+	g.ConfigFields = append(append([]ConfigField(nil), b.Config...), g.ConfigFields...)
+	g.EnvTargets = append(append([]EnvTarget(nil), b.Env...), g.EnvTargets...)
+	if len(b.Vars) > 0 {
+		vars := maps.Clone(b.Vars)
+		maps.Copy(vars, g.Vars)
+		g.Vars = vars
+	}
+	g.Catalog = b.Catalog.merge(g.Catalog)
+	return nil
+}
+
+// Name is the project name the module gives, which other projects of a
+// BlueprintSet use it by.
+func (b *Blueprint) Name() string {
+	name, _ := metadata.DeriveModuleName(b.Module)
+	return name
+}
+
+// Check reports what would stop the blueprint generating beyond its
+// schema: unknown features, features its kind lacks, commands for missing
+// binaries and clashing names. Its preset isn't resolved.
+func (b *Blueprint) Check() error {
+	g := &Generator{Config: NewGenConfig(b.Module, b.Dir)}
+	if err := b.Apply(g); err != nil {
+		return err
+	}
+	if err := g.applyKind(nil); err != nil {
+		return err
+	}
+	if err := g.applyFeatures(nil); err != nil {
+		return err
+	}
+	if err := g.applyBinaries(nil); err != nil {
+		return err
+	}
+	if err := g.applyTargets(nil); err != nil {
+		return err
+	}
+	return g.applyExtras(nil)
+}
+
+// BlueprintSchemaURL is where BlueprintSchema is published, for the
+// yaml-language-server modeline that has editors check blueprints.
+const BlueprintSchemaURL = "https://raw.githubusercontent.com/robbyriverside/project/main/blueprint.schema.json"
+
+// ExportBlueprint returns the blueprint that generates the project m
+// records, for reproducing a project built with flags. What a blueprint of
+// one project can't say, like --vendor, the template pack or the sibling
+// projects it uses, is noted in comments.
+func ExportBlueprint(m *Manifest) ([]byte, error) {
+	b := &Blueprint{
+		Module:   m.ModuleURL,
+		Kind:     m.Kind,
+		Lang:     m.Lang,
+		Binaries: m.Binaries,
+		Features: m.Features,
+		Targets:  m.Targets,
+		Vars:     m.Vars,
+		Catalog:  m.Catalog,
+		Config:   m.ConfigFields,
+		Env:      m.EnvTargets,
+	}
+	if len(b.Binaries) == 0 && m.BinaryName != "" && m.BinaryName != naming.BinaryName(m.ProjectName) {
+		b.Binaries = []string{m.BinaryName}
+	}
+	for _, c := range m.Subcommands {
+		if c.Binary == m.BinaryName {
+			c.Binary = ""
+		}
+		b.Commands = append(b.Commands, c)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# yaml-language-server: $schema=%s\n", BlueprintSchemaURL)
+	fmt.Fprintf(&buf, "# Exported from %s, generated with template pack %s %s\n", m.ProjectName, m.TemplatePack, m.TemplateVersion)
+	var flags []string
+	for _, f := range []struct {
+		set  bool
+		flag string
+	}{{m.Vendor, "--vendor"}, {m.Attest, "--attest"}, {m.Headers, "--headers"}} {
+		if f.set {
+			flags = append(flags, f.flag)
+		}
+	}
+	if len(flags) > 0 {
+		fmt.Fprintf(&buf, "# Also generated with %s, which blueprints don't record\n", strings.Join(flags, " "))
+	}
+	for _, u := range m.Uses {
+		fmt.Fprintf(&buf, "# Uses %s from %s; declare both in one blueprint set with uses to keep it\n", u.Module, u.Dir)
+	}
+	if m.Adopted {
+		buf.WriteString("# Adopted with gen --from-existing: this generates a fresh scaffold, not the original code\n")
+	}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(b); err != nil {
+		return nil, fmt.Errorf("failed to marshal blueprint: %w", err)
+	}
+	return buf.Bytes(), enc.Close()
+}
+
+// Subcommand is a command added to a CLI binary: main.go registers it and
+// cmd/<binary>/<File> holds a stub for its code.
+type Subcommand struct {
+	Name   string `yaml:"name"`             // e.g. sync-users
+	Binary string `yaml:"binary,omitempty"` // empty means the main binary
+	Short  string `yaml:"short"`
+	Long   string `yaml:"long,omitempty"`
+}
+
+// Type is the command's Go type, e.g. SyncUsersCommand.
+func (c Subcommand) Type() string {
+	return naming.CamelCase(c.Name) + "Command"
+}
+
+// Ident starts the command's unexported names, e.g. syncUsers.
+func (c Subcommand) Ident() string {
+	name := naming.CamelCase(c.Name)
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// File is the command's file in cmd/<binary>, e.g. sync_users_cmd.go; the
+// suffix keeps names like test or linux from reading as build constraints.
+func (c Subcommand) File() string {
+	return strings.ReplaceAll(c.Name, "-", "_") + "_cmd.go"
+}
+
+// builtinCommands are the commands the scaffold's binaries already have.
+var builtinCommands = map[string]bool{
+	"about": true, "config": true, "docs": true, "env": true, "help": true,
+	"plugins": true, "serve": true, "setup": true, "version": true,
+}
+
+// commandName matches command and env target names: lowercase words
+// joined by dashes.
+var commandName = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+// normalize fills in the binary and checks c against the CLI's binaries.
+func (c *Subcommand) normalize(gc *GenConfig) error {
+	if !commandName.MatchString(c.Name) {
+		return fmt.Errorf("name must be lowercase words joined by dashes")
+	}
+	if builtinCommands[c.Name] {
+		return fmt.Errorf("%s is a built-in command", c.Name)
+	}
+	if c.Binary == "" {
+		c.Binary = gc.BinaryName
+	}
+	found := false
+	for _, bin := range gc.Binaries {
+		found = found || bin == c.Binary
+	}
+	if !found {
+		return fmt.Errorf("unknown binary %q (binaries: %s)", c.Binary, strings.Join(gc.Binaries, ", "))
+	}
+	if strings.TrimSpace(c.Short) == "" {
+		return fmt.Errorf("missing short description")
+	}
+	return nil
+}
+
+// builtinConfigKeys are the keys of the scaffold's Config struct, by field.
+var builtinConfigKeys = map[string]string{
+	"HomeDir":   "home",
+	"Author":    "author",
+	"LogFmt":    "log_fmt",
+	"RemoteURL": "remote_config",
+	"RemoteTTL": "remote_ttl",
+}
+
+// EnvTarget is a named set of config values, e.g. for staging:
+// env/<name>.env holds them as the CLI's variables, and task run:<name>
+// runs the CLI with that file.
+type EnvTarget struct {
+	Name   string            `yaml:"name"`
+	Values map[string]string `yaml:"values"` // by config key
+}
+
+// Lines returns the target's dotenv lines, e.g. SHOES_LOG_FMT="text", for
+// the CLI's env prefix, sorted by key.
+func (e EnvTarget) Lines(prefix string) []string {
+	lines := make([]string, 0, len(e.Values))
+	for _, key := range sortedKeys(e.Values) {
+		lines = append(lines, prefix+"_"+strings.ToUpper(key)+"="+strconv.Quote(e.Values[key]))
+	}
+	return lines
+}
+
+// configKey matches the yaml keys of the generated Config struct.
+var configKey = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func (e *EnvTarget) normalize() error {
+	if !commandName.MatchString(e.Name) {
+		return fmt.Errorf("name must be lowercase words joined by dashes")
+	}
+	for key := range e.Values {
+		if !configKey.MatchString(key) {
+			return fmt.Errorf("%q is not a config key", key)
+		}
+	}
+	return nil
+}
+
+// applyExtras merges g's commands, config fields and env targets with
+// those of the previous manifest, by name, checks them and exposes them to
+// templates. Only CLIs take them.
+func (g *Generator) applyExtras(prev *Manifest) error {
+	if prev != nil {
+		g.Subcommands = mergeNamed(prev.Subcommands, g.Subcommands, func(c Subcommand) string { return c.Name })
+		g.ConfigFields = mergeNamed(prev.ConfigFields, g.ConfigFields, func(f ConfigField) string { return f.Name })
+		g.EnvTargets = mergeNamed(prev.EnvTargets, g.EnvTargets, func(e EnvTarget) string { return e.Name })
+	}
+	if len(g.Subcommands)+len(g.ConfigFields)+len(g.EnvTargets) > 0 && !g.kind().cli {
+		return fmt.Errorf("only %s projects take commands, config fields and env targets, not %s", cliKinds(), g.Config.Kind)
+	}
+
+	types := make(map[string]string)
+	for i := range g.Subcommands {
+		c := &g.Subcommands[i]
+		if err := c.normalize(g.Config); err != nil {
+			return fmt.Errorf("command %q: %w", c.Name, err)
+		}
+		if other, ok := types[c.Binary+" "+c.Type()]; ok {
+			return fmt.Errorf("commands %s and %s both need the type %s", other, c.Name, c.Type())
+		}
+		types[c.Binary+" "+c.Type()] = c.Name
+	}
+
+	keys := make(map[string]string)
+	for field, key := range builtinConfigKeys {
+		keys[key] = field
+	}
+	for i := range g.ConfigFields {
+		f := &g.ConfigFields[i]
+		if err := f.normalize(); err != nil {
+			return fmt.Errorf("config field %s: %w", f.Name, err)
+		}
+		if _, ok := builtinConfigKeys[f.Name]; ok {
+			return fmt.Errorf("config field %s: the scaffold's Config already has it", f.Name)
+		}
+		if other, ok := keys[f.Key]; ok {
+			return fmt.Errorf("config field %s: key %s is taken by %s", f.Name, f.Key, other)
+		}
+		keys[f.Key] = f.Name
+	}
+
+	for i := range g.EnvTargets {
+		if err := g.EnvTargets[i].normalize(); err != nil {
+			return fmt.Errorf("env target %q: %w", g.EnvTargets[i].Name, err)
+		}
+	}
+
+	g.Config.Subcommands = g.Subcommands
+	g.Config.ConfigFields = g.ConfigFields
+	g.Config.EnvTargets = g.EnvTargets
+	return nil
+}
+
+// mergeNamed returns prev with the items of next replacing those of the
+// same name in place and the rest added after.
+func mergeNamed[T any](prev, next []T, name func(T) string) []T {
+	out := append([]T(nil), prev...)
+	index := make(map[string]int, len(out))
+	for i, v := range out {
+		index[name(v)] = i
+	}
+	for _, v := range next {
+		if i, ok := index[name(v)]; ok {
+			out[i] = v
+			continue
+		}
+		index[name(v)] = len(out)
+		out = append(out, v)
+	}
+	return out
+}
+
+// renderExtras renders subcommand.tmpl once per command and envtarget.tmpl
+// once per env target, each time with a copy of g.Config naming it.
+func (g *Generator) renderExtras() ([]RenderedFile, error) {
+	var files []RenderedFile
+	primary := g.Config
+	defer func() { g.Config = primary }()
+	for _, c := range primary.Subcommands {
+		cfg := *primary
+		cfg.BinaryName, cfg.Subcommand = c.Binary, c
+		g.Config = &cfg
+		f, err := g.Render("subcommand")
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate command %s: %w", c.Name, err)
+		}
+		files = append(files, *f)
+	}
+	for _, e := range primary.EnvTargets {
+		cfg := *primary
+		cfg.EnvTarget = e
+		g.Config = &cfg
+		f, err := g.Render("envtarget")
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate env target %s: %w", e.Name, err)
+		}
+		files = append(files, *f)
+	}
+	return files, nil
+}
@@ -0,0 +1,239 @@
+package project
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+const testBlueprint = `
+module: github.com/example/demo
+binaries: [demo, democtl]
+features: [bench, catalog]
+catalog: {owner: "group:platform"}
+commands:
+  - name: sync-users
+    short: Sync users from the directory
+  - name: rotate-keys
+    binary: democtl
+    short: Rotate signing keys
+config:
+  - name: MaxRetries
+    type: int
+    desc: Retries per request
+    default: "3"
+env:
+  - name: staging
+    values: {log_fmt: text, max_retries: "5"}
+`
+
+func TestBlueprint(t *testing.T) {
+	bp, err := ParseBlueprint([]byte(testBlueprint))
+	if err != nil {
+		t.Fatalf("ParseBlueprint() error: %v", err)
+	}
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	if err := bp.Apply(g); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+	dir := t.TempDir()
+	if err := g.GenerateAll(bp.Module, dir); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	root := g.Config.ProjectPath()
+
+	wants := map[string]string{
+		"cmd/demo/sync_users_cmd.go":     "type SyncUsersCommand struct{}",
+		"cmd/demo/main.go":               "&SyncUsersCommand{},",
+		"cmd/democtl/rotate_keys_cmd.go": "type RotateKeysCommand struct{}",
+		"cmd/democtl/main.go":            "&RotateKeysCommand{},",
+		"config/config.go":               `MaxRetries: "3",`,
+		"env/staging.env":                "DEMO_LOG_FMT=\"text\"\nDEMO_MAX_RETRIES=\"5\"\n",
+		"Taskfile.yaml":                  "DOTENV_PATH: env/staging.env",
+		"catalog-info.yaml":              "lifecycle: experimental\n  owner: group:platform\n",
+	}
+	for rel, want := range wants {
+		data, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			t.Errorf("%s: %v", rel, err)
+			continue
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s is missing %q", rel, want)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "cmd", "democtl", "main.go")); strings.Contains(string(data), "SyncUsersCommand") {
+		t.Error("democtl registers the demo binary's command")
+	}
+
+	// Update keeps what the blueprint added, and code written around the
+	// command's managed region
+	stub := filepath.Join(root, "cmd", "demo", "sync_users_cmd.go")
+	data, err := os.ReadFile(stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(data), `return fmt.Errorf("sync-users is not implemented yet")`, "return nil", 1)
+	if err := os.WriteFile(stub, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	g = &Generator{
+		Runner:      &execx.Recorder{Respond: fakeGo},
+		Subcommands: []Subcommand{{Name: "sync-users", Short: "Sync users from LDAP"}},
+	}
+	if err := g.GenerateAll(bp.Module, dir); err != nil {
+		t.Fatalf("second GenerateAll() error: %v", err)
+	}
+	if len(g.Subcommands) != 2 || len(g.ConfigFields) != 1 || len(g.EnvTargets) != 1 {
+		t.Errorf("update has %d commands, %d config fields, %d env targets; want 2, 1, 1", len(g.Subcommands), len(g.ConfigFields), len(g.EnvTargets))
+	}
+	data, _ = os.ReadFile(stub)
+	if !strings.Contains(string(data), `"Sync users from LDAP"`) || !strings.Contains(string(data), "return nil") {
+		t.Errorf("sync_users_cmd.go = %s\nwant the new description and the edited body", data)
+	}
+}
+
+// An exported blueprint generates the project it was exported from.
+func TestExportBlueprint(t *testing.T) {
+	bp, err := ParseBlueprint([]byte(testBlueprint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}, Vendor: true}
+	if err := bp.Apply(g); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.GenerateAll(bp.Module, t.TempDir()); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	m, err := LoadManifest(g.Config.ProjectPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ExportBlueprint(m)
+	if err != nil {
+		t.Fatalf("ExportBlueprint() error: %v", err)
+	}
+	for _, want := range []string{"$schema=" + BlueprintSchemaURL, "# Also generated with --vendor", "binary: democtl"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("exported blueprint is missing %q:\n%s", want, data)
+		}
+	}
+	got, err := ParseBlueprint(data)
+	if err != nil {
+		t.Fatalf("ParseBlueprint(exported) error: %v\n%s", err, data)
+	}
+	if err := got.Check(); err != nil {
+		t.Errorf("Check() error: %v", err)
+	}
+	if !slices.Equal(got.Binaries, bp.Binaries) || !slices.Equal(got.Features, bp.Features) ||
+		len(got.Commands) != 2 || got.Commands[0].Binary != "" || len(got.Config) != 1 || len(got.Env) != 1 || got.Catalog != bp.Catalog {
+		t.Errorf("exported blueprint = %+v, want the one it was generated from", got)
+	}
+}
+
+func TestBlueprintErrors(t *testing.T) {
+	tests := []struct {
+		name, blueprint, want string
+	}{
+		{"no module", "kind: cli\n", "blueprint:1:1: missing module"},
+		{"unknown key", "module: github.com/example/demo\ncomands: []\n", `blueprint:2:1: unknown key "comands" (did you mean commands?)`},
+		{"built-in command", "module: github.com/example/demo\ncommands: [{name: version, short: v}]\n", `blueprint:2:19: commands[0].name: "version" is not allowed`},
+		{"bad command name", "module: github.com/example/demo\ncommands: [{name: Sync, short: s}]\n", `commands[0].name: "Sync" doesn't match`},
+		{"unknown binary", "module: github.com/example/demo\ncommands: [{name: sync, binary: ctl, short: s}]\n", `unknown binary "ctl"`},
+		{"no short", "module: github.com/example/demo\ncommands: [{name: sync}]\n", "blueprint:2:12: commands[0]: missing short"},
+		{"built-in field", "module: github.com/example/demo\nconfig: [{name: Author}]\n", `config[0].name: "Author" is not allowed`},
+		{"taken key", "module: github.com/example/demo\nconfig: [{name: Format, key: log_fmt}]\n", "taken by LogFmt"},
+		{"wrong type", "module: github.com/example/demo\nbinaries: server\n", "blueprint:2:11: binaries: got string, want array"},
+		{"unknown feature", "module: github.com/example/demo\nfeatures: [bench, benches]\n", `features[1]: "benches" is not one of app, bench`},
+		{"library", "module: github.com/example/demo\nkind: library\nenv: [{name: dev}]\n", "only cli and tui projects"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := ParseBlueprint([]byte(tt.blueprint))
+			if err == nil {
+				g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+				if err = bp.Apply(g); err == nil {
+					err = g.GenerateAll(bp.Module, t.TempDir())
+				}
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// The schema repeats lists the code owns; keep them in step.
+func TestBlueprintSchema(t *testing.T) {
+	var schema struct {
+		ID   string `json:"$id"`
+		Defs struct {
+			Project struct {
+				Properties struct {
+					Kind     struct{ Enum []string }
+					Features struct{ Items struct{ Enum []string } }
+					Commands struct {
+						Items struct {
+							Properties struct {
+								Name struct{ Not struct{ Enum []string } }
+							}
+						}
+					}
+					Config struct {
+						Items struct {
+							Properties struct {
+								Name struct{ Not struct{ Enum []string } }
+								Type struct{ Enum []string }
+							}
+						}
+					}
+				}
+			}
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(BlueprintSchema(), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.ID != BlueprintSchemaURL {
+		t.Errorf("schema $id = %q, want BlueprintSchemaURL %q", schema.ID, BlueprintSchemaURL)
+	}
+	p := schema.Defs.Project.Properties
+	var commands, fields []string
+	for name := range builtinCommands {
+		commands = append(commands, name)
+	}
+	for name := range builtinConfigKeys {
+		fields = append(fields, name)
+	}
+	checks := []struct {
+		what      string
+		got, want []string
+	}{
+		{"kind", p.Kind.Enum, KnownKinds()},
+		{"features", p.Features.Items.Enum, KnownFeatures()},
+		{"built-in commands", p.Commands.Items.Properties.Name.Not.Enum, commands},
+		{"built-in config fields", p.Config.Items.Properties.Name.Not.Enum, fields},
+		{"config types", p.Config.Items.Properties.Type.Enum, ConfigFieldTypes},
+	}
+	for _, c := range checks {
+		got, want := slices.Sorted(slices.Values(c.got)), slices.Sorted(slices.Values(c.want))
+		if !slices.Equal(got, want) {
+			t.Errorf("schema %s = %q, want %q", c.what, got, want)
+		}
+	}
+
+	// Unquoted numbers and booleans are fine where strings are kept
+	bp, err := ParseBlueprint([]byte("module: github.com/example/demo\nconfig: [{name: Retries, default: 3}]\nenv: [{name: dev, values: {verbose: true}}]\n"))
+	if err != nil {
+		t.Fatalf("ParseBlueprint() error: %v", err)
+	}
+	if bp.Config[0].Default != "3" || bp.Env[0].Values["verbose"] != "true" {
+		t.Errorf("blueprint = %+v, want default 3 and verbose true", bp)
+	}
+}
@@ -0,0 +1,240 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/robbyriverside/project/internal/jsonschema"
+)
+
+// BlueprintSet declares projects generated together, e.g. a service, its
+// client library and its CLI. Vars are shared by every project, under each
+// project's own, and a project's uses are generated before it.
+//
+//	vars: {team: payments}
+//	projects:
+//	  - module: github.com/acme/billing
+//	  - module: github.com/acme/billing-client
+//	    kind: library
+//	    uses: [billing]
+type BlueprintSet struct {
+	Vars     map[string]string `yaml:"vars,omitempty"`
+	Projects []*Blueprint      `yaml:"projects"`
+}
+
+// LoadBlueprintSet reads the blueprint at path, of one project or a set,
+// or every *.yaml and *.yml blueprint in the directory at path.
+func LoadBlueprintSet(path string) (*BlueprintSet, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blueprint: %w", err)
+	}
+	if !info.IsDir() {
+		return readBlueprintFile(path)
+	}
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, _ := filepath.Glob(filepath.Join(path, pattern))
+		files = append(files, matches...)
+	}
+	slices.Sort(files)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no blueprints in %s", path)
+	}
+	set := &BlueprintSet{}
+	for _, file := range files {
+		s, err := readBlueprintFile(file)
+		if err != nil {
+			return nil, err
+		}
+		set.Projects = append(set.Projects, s.Projects...)
+	}
+	return set, nil
+}
+
+// readBlueprintFile parses the blueprint at path, naming it in errors.
+func readBlueprintFile(path string) (*BlueprintSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blueprint: %w", err)
+	}
+	set, err := ParseBlueprintSet(data)
+	var bpErr *BlueprintError
+	if errors.As(err, &bpErr) {
+		bpErr.File = path
+		return nil, bpErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return set, nil
+}
+
+// ParseBlueprintSet decodes a blueprint, first checking it against
+// BlueprintSchema; a *BlueprintError locates what doesn't fit, so a typo
+// can't silently leave part of a project out. A blueprint without a
+// projects key is a set of one. The set's vars are merged into each
+// project's.
+func ParseBlueprintSet(data []byte) (*BlueprintSet, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse blueprint: %w", err)
+	}
+	schema, err := jsonschema.Parse(blueprintSchema)
+	if err != nil {
+		return nil, err
+	}
+	if problems := schema.Validate(&doc); len(problems) > 0 {
+		return nil, &BlueprintError{Problems: problems}
+	}
+
+	set := &BlueprintSet{}
+	if isSet(&doc) {
+		if err := doc.Decode(set); err != nil {
+			return nil, fmt.Errorf("failed to parse blueprint: %w", err)
+		}
+	} else {
+		var b Blueprint
+		if err := doc.Decode(&b); err != nil {
+			return nil, fmt.Errorf("failed to parse blueprint: %w", err)
+		}
+		set.Projects = []*Blueprint{&b}
+	}
+	for i, b := range set.Projects {
+		b.Module = strings.TrimSpace(b.Module)
+		if b.Module == "" {
+			return nil, fmt.Errorf("project %d has no module", i+1)
+		}
+		if len(set.Vars) > 0 {
+			vars := maps.Clone(set.Vars)
+			maps.Copy(vars, b.Vars)
+			b.Vars = vars
+		}
+	}
+	return set, nil
+}
+
+// isSet reports whether doc, a valid blueprint, has a projects key.
+func isSet(doc *yaml.Node) bool {
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return false
+	}
+	top := doc.Content[0]
+	for i := 0; i < len(top.Content); i += 2 {
+		if top.Content[i].Value == "projects" {
+			return true
+		}
+	}
+	return false
+}
+
+// Ordered returns the projects with each after those it uses, otherwise in
+// the order declared. Two projects of one name, uses of a project the set
+// doesn't declare and projects using each other are errors.
+func (s *BlueprintSet) Ordered() ([]*Blueprint, error) {
+	byName := make(map[string]*Blueprint, len(s.Projects))
+	for _, b := range s.Projects {
+		if byName[b.Name()] != nil {
+			return nil, fmt.Errorf("two projects are named %s", b.Name())
+		}
+		byName[b.Name()] = b
+	}
+
+	var out []*Blueprint
+	done := make(map[string]bool)
+	var visit func(b *Blueprint, chain []string) error
+	visit = func(b *Blueprint, chain []string) error {
+		name := b.Name()
+		if done[name] {
+			return nil
+		}
+		if i := slices.Index(chain, name); i >= 0 {
+			return fmt.Errorf("projects use each other: %s", strings.Join(append(chain[i:], name), " -> "))
+		}
+		for _, use := range b.Uses {
+			dep := byName[use]
+			if dep == nil {
+				return fmt.Errorf("project %s uses %s, which the blueprint doesn't declare", name, use)
+			}
+			if err := visit(dep, append(chain, name)); err != nil {
+				return err
+			}
+		}
+		done[name] = true
+		out = append(out, b)
+		return nil
+	}
+	for _, b := range s.Projects {
+		if err := visit(b, nil); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Check is Blueprint.Check for every project, after checking their uses.
+func (s *BlueprintSet) Check() error {
+	if _, err := s.Ordered(); err != nil {
+		return err
+	}
+	for _, b := range s.Projects {
+		if err := b.Check(); err != nil {
+			return fmt.Errorf("project %s: %w", b.Name(), err)
+		}
+	}
+	return nil
+}
+
+// ModuleUse is the module of a sibling project that a project imports,
+// replaced in its go.mod by the sibling's directory.
+type ModuleUse struct {
+	Module string `yaml:"module"`
+	Dir    string `yaml:"dir"` // slash-separated, relative to the project
+}
+
+// replacement is Dir as go.mod wants a local path.
+func (u ModuleUse) replacement() string {
+	if path.IsAbs(u.Dir) || strings.HasPrefix(u.Dir, "./") || strings.HasPrefix(u.Dir, "../") {
+		return u.Dir
+	}
+	return "./" + u.Dir
+}
+
+// applyShared merges g's vars and module uses with those of the previous
+// manifest, g's winning, and exposes them to templates.
+func (g *Generator) applyShared(prev *Manifest) {
+	if prev != nil {
+		vars := maps.Clone(prev.Vars)
+		if vars == nil {
+			vars = make(map[string]string)
+		}
+		maps.Copy(vars, g.Vars)
+		g.Vars = vars
+		g.Uses = mergeNamed(prev.Uses, g.Uses, func(u ModuleUse) string { return u.Module })
+	}
+	if len(g.Vars) > 0 {
+		g.Config.Vars = maps.Clone(g.Vars)
+	}
+	g.Config.Uses = g.Uses
+}
+
+// replaceUses points go.mod at the local directories of the sibling
+// modules the project uses, which are only there on the host.
+func (g *Generator) replaceUses() error {
+	if len(g.Uses) == 0 || !g.onHost() {
+		return nil
+	}
+	args := []string{"mod", "edit"}
+	for _, u := range g.Uses {
+		args = append(args, "-replace="+u.Module+"="+u.replacement())
+	}
+	return g.goCmd(args...)
+}
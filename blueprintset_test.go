@@ -0,0 +1,130 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+const testBlueprintSet = `
+vars: {team: payments, tier: "1"}
+projects:
+  - module: github.com/acme/billing-cli
+    uses: [billing, billing-client]
+  - module: github.com/acme/billing-client
+    kind: library
+    uses: [billing]
+    vars: {tier: "2"}
+  - module: github.com/acme/billing
+`
+
+func TestBlueprintSet(t *testing.T) {
+	set, err := ParseBlueprintSet([]byte(testBlueprintSet))
+	if err != nil {
+		t.Fatalf("ParseBlueprintSet() error: %v", err)
+	}
+	if err := set.Check(); err != nil {
+		t.Fatalf("Check() error: %v", err)
+	}
+	ordered, err := set.Ordered()
+	if err != nil {
+		t.Fatalf("Ordered() error: %v", err)
+	}
+	var names []string
+	for _, b := range ordered {
+		names = append(names, b.Name())
+	}
+	if want := []string{"billing", "billing-client", "billing-cli"}; !slices.Equal(names, want) {
+		t.Errorf("Ordered() = %q, want %q", names, want)
+	}
+	if client := ordered[1]; client.Vars["team"] != "payments" || client.Vars["tier"] != "2" {
+		t.Errorf("client vars = %v, want the set's team and its own tier", client.Vars)
+	}
+
+	// The client's go.mod replaces the service with its sibling directory,
+	// and update keeps doing so
+	root := t.TempDir()
+	client := ordered[1]
+	for i := 0; i < 2; i++ {
+		rec := &execx.Recorder{Respond: fakeGo}
+		g := &Generator{Runner: rec}
+		if i == 0 {
+			if err := client.Apply(g); err != nil {
+				t.Fatal(err)
+			}
+			g.Uses = []ModuleUse{{Module: "github.com/acme/billing", Dir: "../billing"}}
+		}
+		if err := g.GenerateAll(client.Module, filepath.Join(root, "billing-client")); err != nil {
+			t.Fatalf("GenerateAll() error: %v", err)
+		}
+		var replaced bool
+		for _, c := range rec.Calls() {
+			replaced = replaced || c.String() == "go mod edit -replace=github.com/acme/billing=../billing"
+		}
+		if !replaced {
+			t.Errorf("run %d didn't replace the billing module: %v", i+1, rec.Calls())
+		}
+		if g.Config.Vars["team"] != "payments" || len(g.Config.Uses) != 1 {
+			t.Errorf("run %d config has vars %v and uses %v, want the blueprint's", i+1, g.Config.Vars, g.Config.Uses)
+		}
+	}
+}
+
+func TestLoadBlueprintSetDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a-service.yaml": "module: github.com/acme/billing\n",
+		"b-clients.yml":  "vars: {team: payments}\nprojects:\n  - module: github.com/acme/billing-client\n    kind: library\n    uses: [billing]\n",
+		"notes.txt":      "not a blueprint",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	set, err := LoadBlueprintSet(dir)
+	if err != nil {
+		t.Fatalf("LoadBlueprintSet() error: %v", err)
+	}
+	if len(set.Projects) != 2 || set.Projects[1].Vars["team"] != "payments" {
+		t.Fatalf("LoadBlueprintSet() = %+v, want both projects, the second with its file's vars", set)
+	}
+	if err := set.Check(); err != nil {
+		t.Errorf("Check() error: %v", err)
+	}
+	if _, err := LoadBlueprint(filepath.Join(dir, "b-clients.yml")); err != nil {
+		t.Errorf("LoadBlueprint() of a set of one: %v", err)
+	}
+}
+
+func TestBlueprintSetErrors(t *testing.T) {
+	tests := []struct {
+		name, blueprint, want string
+	}{
+		{"no projects", "projects: []\n", "blueprint:1:11: projects: got 0 items, want at least 1"},
+		{"located", "projects:\n  - module: github.com/acme/a\n    kinds: cli\n", `blueprint:3:5: projects[0]: unknown key "kinds" (did you mean kind?)`},
+		{"set key in a project", "module: github.com/acme/a\nvars: {bad-name: x}\n", `blueprint:2:8: vars.bad-name: "bad-name" doesn't match`},
+		{"same name", "projects:\n  - module: github.com/acme/a\n  - module: gitlab.com/acme/a\n", "two projects are named a"},
+		{"unknown use", "projects:\n  - module: github.com/acme/a\n    uses: [b]\n", "project a uses b, which the blueprint doesn't declare"},
+		{"cycle", "projects:\n  - {module: github.com/acme/a, uses: [b]}\n  - {module: github.com/acme/b, uses: [c]}\n  - {module: github.com/acme/c, uses: [b]}\n", "projects use each other: b -> c -> b"},
+		{"project check", "projects:\n  - {module: github.com/acme/a, kind: library, commands: [{name: sync, short: s}]}\n", "project a: only cli and tui projects"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := ParseBlueprintSet([]byte(tt.blueprint))
+			if err == nil {
+				err = set.Check()
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := ParseBlueprint([]byte(testBlueprintSet)); err == nil || !strings.Contains(err.Error(), "declares 3 projects, want one") {
+		t.Errorf("ParseBlueprint() of a set = %v, want it to refuse", err)
+	}
+}
@@ -0,0 +1,218 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/robbyriverside/project/internal/archive"
+	"github.com/robbyriverside/project/internal/fileutils"
+	"github.com/robbyriverside/project/internal/semver"
+)
+
+// BundleInfoName is the file at the root of a bundle that describes it.
+const BundleInfoName = "bundle.yaml"
+
+// A bundle holds the template pack under bundlePackDir and a module proxy
+// (the module cache's download directory) under bundleModulesDir, with
+// user presets, if any, at PresetsName.
+const (
+	bundlePackDir    = "pack"
+	bundleModulesDir = "modules"
+)
+
+// BundleInfo describes an air-gapped bundle: everything project gen needs
+// to scaffold working projects on a network without internet access.
+type BundleInfo struct {
+	GeneratorVersion string       `yaml:"generator_version"`
+	TemplatePack     string       `yaml:"template_pack"`
+	TemplateVersion  string       `yaml:"template_version"`
+	Modules          []Dependency `yaml:"modules"` // required by the bundle; the modules they need are in it too
+	Presets          bool         `yaml:"presets,omitempty"`
+	Created          time.Time    `yaml:"created"`
+}
+
+// ParseDependency reads a module path@version, as given to go get.
+func ParseDependency(s string) (Dependency, error) {
+	path, version, ok := strings.Cut(s, "@")
+	if !ok || path == "" || !semver.Valid(version) {
+		return Dependency{}, fmt.Errorf("invalid module %q, expected path@version, e.g. github.com/google/wire@v0.6.0", s)
+	}
+	return Dependency{Path: path, Version: version}, nil
+}
+
+// CreateBundle writes a bundle to w as a .tar.gz: g's template pack, a
+// module proxy holding PinnedDeps, extra and the modules they need, and
+// the presets in presetsFile, skipped when "" or missing. The modules are
+// downloaded into a module cache of the bundle's own, whatever
+// g.GoEnv.ModCache says, so the bundle holds nothing else.
+func (g *Generator) CreateBundle(w io.Writer, presetsFile string, extra []Dependency) (*BundleInfo, error) {
+	fsys, err := g.pack()
+	if err != nil {
+		return nil, err
+	}
+	pack, err := g.PackInfo()
+	if err != nil {
+		return nil, err
+	}
+	stage, err := os.MkdirTemp("", "project-bundle-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stage)
+	if _, err := fileutils.CopyFS(fsys, ".", filepath.Join(stage, bundlePackDir)); err != nil {
+		return nil, fmt.Errorf("failed to copy the template pack: %w", err)
+	}
+
+	cache, err := os.MkdirTemp("", "project-modcache-")
+	if err != nil {
+		return nil, err
+	}
+	defer removeModCache(cache)
+	env := g.GoEnv
+	g.GoEnv.ModCache = cache
+	deps, err := g.WarmModCache(extra...)
+	g.GoEnv = env
+	if err != nil {
+		return nil, fmt.Errorf("failed to download modules: %w", err)
+	}
+	if err := fileutils.CopyTree(filepath.Join(cache, "cache", "download"), filepath.Join(stage, bundleModulesDir)); err != nil {
+		return nil, fmt.Errorf("failed to copy modules: %w", err)
+	}
+
+	info := &BundleInfo{
+		GeneratorVersion: Version,
+		TemplatePack:     pack.Name,
+		TemplateVersion:  pack.Version,
+		Modules:          deps,
+		Created:          time.Now().UTC(),
+	}
+	if presetsFile != "" {
+		data, err := os.ReadFile(presetsFile)
+		switch {
+		case err == nil:
+			if _, err := ParsePresets(data, presetsFile); err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(filepath.Join(stage, PresetsName), data, fileutils.DefaultMode); err != nil {
+				return nil, err
+			}
+			info.Presets = true
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, fmt.Errorf("failed to read presets: %w", err)
+		}
+	}
+
+	data, err := yaml.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(stage, BundleInfoName), data, fileutils.DefaultMode); err != nil {
+		return nil, err
+	}
+	return info, archive.WriteTarGz(w, stage)
+}
+
+// removeModCache deletes a module cache, whose directories the go command
+// makes read-only.
+func removeModCache(dir string) error {
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			os.Chmod(p, 0o755)
+		}
+		return nil
+	})
+	return os.RemoveAll(dir)
+}
+
+// ExtractBundle unpacks the bundle file into dir, which must be missing or
+// empty, and returns what it holds.
+func ExtractBundle(file, dir string) (*BundleInfo, error) {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("directory %s is not empty", dir)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Unpack beside dir, so a failure leaves nothing half written
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".extract-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	if err := archive.ExtractTarGz(f, tmp); err != nil {
+		return nil, fmt.Errorf("failed to read bundle %s: %w", file, err)
+	}
+	info, err := readBundleInfo(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle %s: %w", file, err)
+	}
+	os.Remove(dir)
+	return info, os.Rename(tmp, dir)
+}
+
+// LoadBundle reads the info of the bundle unpacked in dir.
+func LoadBundle(dir string) (*BundleInfo, error) {
+	info, err := readBundleInfo(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle %s: %w", dir, err)
+	}
+	return info, nil
+}
+
+func readBundleInfo(dir string) (*BundleInfo, error) {
+	data, err := os.ReadFile(filepath.Join(dir, BundleInfoName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no %s", BundleInfoName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	info := &BundleInfo{}
+	if err := yaml.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", BundleInfoName, err)
+	}
+	return info, nil
+}
+
+// UseBundle points g at the bundle unpacked in dir: its template pack and
+// presets, and a GOPROXY serving its modules. The checksum database can't
+// be reached offline, so it is skipped for every module (GONOSUMDB=*);
+// go.sum is still written from the bundled modules.
+func (g *Generator) UseBundle(dir string) (*BundleInfo, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	info, err := LoadBundle(dir)
+	if err != nil {
+		return nil, err
+	}
+	g.Bundle = dir
+	g.Templates = os.DirFS(filepath.Join(dir, bundlePackDir))
+	g.GoEnv.Proxy = fileURL(filepath.Join(dir, bundleModulesDir))
+	g.GoEnv.NoSumDB = "*"
+	return info, nil
+}
+
+// fileURL is the file:// URL of the absolute path p.
+func fileURL(p string) string {
+	p = filepath.ToSlash(p)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // C:/... on Windows
+	}
+	return "file://" + p
+}
@@ -0,0 +1,97 @@
+package project
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+func TestBundle(t *testing.T) {
+	// go mod download leaves a module in the cache it was given
+	rec := &execx.Recorder{Respond: func(c execx.Call) (execx.Result, error) {
+		for _, kv := range c.Env {
+			if cache, ok := strings.CutPrefix(kv, "GOMODCACHE="); ok {
+				zip := filepath.Join(cache, "cache", "download", "go.uber.org", "zap", "@v", "v1.27.0.zip")
+				if err := os.MkdirAll(filepath.Dir(zip), 0o755); err != nil {
+					return execx.Result{}, err
+				}
+				return execx.Result{}, os.WriteFile(zip, []byte("zip"), 0o444)
+			}
+		}
+		t.Errorf("%s ran without GOMODCACHE", c)
+		return execx.Result{}, nil
+	}}
+
+	presets := filepath.Join(t.TempDir(), PresetsName)
+	if err := os.WriteFile(presets, []byte("offline:\n  description: Built from the bundle\n  features: [coverage]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wire := Dependency{Path: "github.com/google/wire", Version: "v0.6.0"}
+	g := &Generator{Runner: rec, GoEnv: GoEnv{ModCache: "/shared/cache"}}
+	var buf bytes.Buffer
+	info, err := g.CreateBundle(&buf, presets, []Dependency{wire})
+	if err != nil {
+		t.Fatalf("CreateBundle() error: %v", err)
+	}
+	if !info.Presets || len(info.Modules) != len(PinnedDeps)+1 || info.Modules[len(PinnedDeps)] != wire {
+		t.Errorf("CreateBundle() = %+v, want the presets and the pinned modules plus wire", info)
+	}
+	if g.GoEnv.ModCache != "/shared/cache" {
+		t.Errorf("GoEnv.ModCache = %q after CreateBundle, want it restored", g.GoEnv.ModCache)
+	}
+
+	file := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err := os.WriteFile(file, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "bundles", "offline")
+	if _, err := ExtractBundle(file, dir); err != nil {
+		t.Fatalf("ExtractBundle() error: %v", err)
+	}
+	if _, err := ExtractBundle(file, dir); err == nil {
+		t.Error("ExtractBundle() into a full directory succeeded, want an error")
+	}
+
+	use := &Generator{}
+	got, err := use.UseBundle(dir)
+	if err != nil {
+		t.Fatalf("UseBundle() error: %v", err)
+	}
+	if got.TemplatePack != info.TemplatePack || got.TemplateVersion != info.TemplateVersion {
+		t.Errorf("UseBundle() = %+v, want the pack of %+v", got, info)
+	}
+	if _, err := fs.Stat(use.Templates, PackManifestName); err != nil {
+		t.Errorf("bundled pack has no %s: %v", PackManifestName, err)
+	}
+	modules := strings.TrimPrefix(use.GoEnv.Proxy, "file://")
+	if _, err := os.Stat(filepath.Join(filepath.FromSlash(modules), "go.uber.org", "zap", "@v", "v1.27.0.zip")); err != nil {
+		t.Errorf("GOPROXY %s doesn't serve the bundled modules: %v", use.GoEnv.Proxy, err)
+	}
+	if use.GoEnv.NoSumDB != "*" {
+		t.Errorf("GONOSUMDB = %q, want *", use.GoEnv.NoSumDB)
+	}
+	all, err := use.Presets("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := FindPreset(all, "offline"); err != nil || p.Description != "Built from the bundle" {
+		t.Errorf("FindPreset(offline) = %+v, %v; want the bundled preset", p, err)
+	}
+}
+
+func TestParseDependency(t *testing.T) {
+	d, err := ParseDependency("github.com/google/wire@v0.6.0")
+	if err != nil || d != (Dependency{Path: "github.com/google/wire", Version: "v0.6.0"}) {
+		t.Errorf("ParseDependency() = %+v, %v", d, err)
+	}
+	for _, s := range []string{"github.com/google/wire", "github.com/google/wire@latest", "@v1.0.0"} {
+		if _, err := ParseDependency(s); err == nil {
+			t.Errorf("ParseDependency(%q) succeeded, want an error", s)
+		}
+	}
+}
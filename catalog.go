@@ -0,0 +1,50 @@
+package project
+
+import "path"
+
+// Catalog describes a project to a service catalog such as Backstage, for
+// the catalog-info.yaml the catalog feature writes. A blueprint sets it;
+// fields left empty fall back to the repository owner and the
+// DefaultLifecycle, and the project belongs to no system.
+type Catalog struct {
+	Owner     string `yaml:"owner,omitempty"`
+	System    string `yaml:"system,omitempty"`
+	Lifecycle string `yaml:"lifecycle,omitempty"`
+}
+
+// DefaultLifecycle is the lifecycle of projects whose catalog names none.
+const DefaultLifecycle = "experimental"
+
+// merge returns c with the fields over sets replacing its own.
+func (c Catalog) merge(over Catalog) Catalog {
+	if over.Owner != "" {
+		c.Owner = over.Owner
+	}
+	if over.System != "" {
+		c.System = over.System
+	}
+	if over.Lifecycle != "" {
+		c.Lifecycle = over.Lifecycle
+	}
+	return c
+}
+
+// applyCatalog merges the requested catalog entry over the one the
+// previous manifest records, and gives it to templates, defaults filled
+// in, as g.Config.Catalog.
+func (g *Generator) applyCatalog(prev *Manifest) {
+	if prev != nil {
+		g.Catalog = prev.Catalog.merge(g.Catalog)
+	}
+	c := g.Catalog
+	if c.Owner == "" {
+		c.Owner = "unknown"
+		if g.Config.Owner != "" {
+			c.Owner = path.Base(g.Config.Owner)
+		}
+	}
+	if c.Lifecycle == "" {
+		c.Lifecycle = DefaultLifecycle
+	}
+	g.Config.Catalog = c
+}
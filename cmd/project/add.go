@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+
+	"github.com/robbyriverside/project"
+	"github.com/robbyriverside/project/logs"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+type AddCommand struct{}
+
+func (cmd *AddCommand) Execute(args []string) error {
+	return msg.Errorf("please specify a subcommand: config-field")
+}
+
+// AddConfigFieldCommand adds a key to a generated CLI's config package.
+type AddConfigFieldCommand struct {
+	Dir     string `short:"d" long:"dir" default:"." description:"Project directory containing go.mod"`
+	Name    string `long:"name" required:"yes" description:"Go field name, e.g. MaxRetries"`
+	Type    string `long:"type" choice:"string" choice:"int" choice:"bool" choice:"duration" choice:"list" default:"string" description:"Kind of value, checked by config set"`
+	Key     string `long:"key" description:"Config file key (default: the field name in snake_case)"`
+	Desc    string `long:"desc" description:"Description for config describe and docs/CONFIG.md"`
+	Default string `long:"default" description:"Default value"`
+	GoEnvFlags
+}
+
+func (cmd *AddConfigFieldCommand) Execute(args []string) error {
+	return cmd.ExecuteContext(context.Background(), args)
+}
+
+func (cmd *AddConfigFieldCommand) ExecuteContext(ctx context.Context, args []string) error {
+	gen := &project.Generator{Context: ctx, Log: logs.Logger()}
+	if err := cmd.setGoEnv(gen); err != nil {
+		return err
+	}
+	field := project.ConfigField{Name: cmd.Name, Key: cmd.Key, Type: cmd.Type, Desc: cmd.Desc, Default: cmd.Default}
+	written, err := gen.AddConfigField(cmd.Dir, field)
+	for _, path := range written {
+		detail("  %s %s\n", label("updated", 9), pathutil.Contract(path))
+	}
+	if err != nil {
+		return msg.Errorf("failed to add config field: %w", err)
+	}
+	say("Added %s to %s\n", field.Name, pathutil.Contract(written[0]))
+	return nil
+}
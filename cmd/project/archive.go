@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/robbyriverside/project"
+	"github.com/robbyriverside/project/internal/archive"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// genArchive generates into a temporary directory, go mod steps and all,
+// then packs it into --archive with a directory per project.
+func (cmd *GenCommand) genArchive(ctx context.Context, set *project.BlueprintSet) error {
+	if cmd.Dir != "" || cmd.CreateRemote {
+		return msg.Errorf("--archive can't be used with --dir or --create-remote")
+	}
+	format, err := archive.FormatOf(cmd.Archive)
+	if err != nil {
+		return msg.Errorf("invalid --archive: %w", err)
+	}
+	tmp, err := os.MkdirTemp("", "project-archive-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	// The archive has a directory per project, named after it
+	if set != nil {
+		for _, bp := range set.Projects {
+			bp.Dir = ""
+		}
+	}
+	cmd.parentDir = tmp
+	if err := cmd.genProjects(ctx, set); err != nil {
+		return err
+	}
+	return writeArchive(pathutil.MustExpand(cmd.Archive), tmp, format)
+}
+
+// writeArchive packs dir into the file at path, removing it again if
+// packing fails.
+func writeArchive(path, dir string, format archive.Format) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return msg.Errorf("failed to write archive: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return msg.Errorf("failed to write archive: %w", err)
+	}
+	err = archive.Write(f, dir, format)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return msg.Errorf("failed to write archive: %w", err)
+	}
+	say("Archive written to %s\n", pathutil.Contract(path))
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/robbyriverside/project"
+	config "github.com/robbyriverside/project/config"
+	"github.com/robbyriverside/project/internal/audit"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// auditedCommand is implemented by the commands whose runs go in the
+// audit log. They report the projects they wrote, or tried to.
+type auditedCommand interface {
+	auditProjects() []audit.Project
+}
+
+// commandName is the name of the command the parser ran below root, with
+// its parents', e.g. add config-field.
+func commandName(root *flags.Command) string {
+	var names []string
+	for c := root.Active; c != nil; c = c.Active {
+		names = append(names, c.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+// recordAudit appends a run of the named command that ended with runErr
+// to the audit log, and posts it to the audit webhook if one is set, giving
+// up on the post when ctx is. It only warns when it can't, so the run's
+// own result stands.
+func recordAudit(ctx context.Context, name string, cmd auditedCommand, runErr error) {
+	cfg, err := config.Load()
+	if err != nil {
+		warn("warning: failed to record the run: %v\n", err)
+		return
+	}
+	if cfg.Audit == "off" {
+		return
+	}
+	e := audit.Entry{
+		Time:     time.Now().UTC(),
+		User:     auditUser(),
+		Command:  name,
+		Args:     os.Args[1:],
+		Version:  project.Version,
+		Projects: cmd.auditProjects(),
+		Result:   audit.OK,
+	}
+	e.Host, _ = os.Hostname()
+	if runErr != nil {
+		e.Result, e.Error = audit.Failed, runErr.Error()
+	}
+
+	if err := audit.Append(auditLog(cfg), e); err != nil {
+		warn("warning: failed to record the run: %v\n", err)
+	}
+	if cfg.AuditWebhook != "" {
+		policy, err := retryPolicy(cfg)
+		if err == nil {
+			err = audit.Post(ctx, cfg.AuditWebhook, e, policy)
+		}
+		if err != nil {
+			warn("warning: failed to send the run to audit_webhook: %v\n", err)
+		}
+	}
+}
+
+// auditLog is the configured audit log, ~/.project/audit.jsonl by default.
+func auditLog(cfg *config.Config) string {
+	return pathutil.MustExpand(firstNonEmpty(cfg.AuditLog, "~/.project/audit.jsonl"))
+}
+
+// auditUser is who ran the command: the OS user, else $USER.
+func auditUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return firstNonEmpty(os.Getenv("USER"), "unknown")
+}
+
+// auditProject describes the project gen wrote, or dir if gen never got
+// as far as reading its config.
+func auditProject(gen *project.Generator, dir string) audit.Project {
+	var p audit.Project
+	if gen != nil && gen.Config != nil {
+		p.Module, dir = gen.Config.ModuleURL, gen.Config.ProjectPath()
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	p.Dir = pathutil.Contract(dir)
+	if gen != nil {
+		if pack, err := gen.PackInfo(); err == nil {
+			p.Pack, p.PackVersion = pack.Name, pack.Version
+		}
+	}
+	return p
+}
+
+func (cmd *GenCommand) auditProjects() []audit.Project {
+	out := make([]audit.Project, 0, len(cmd.generated))
+	for _, gen := range cmd.generated {
+		p := auditProject(gen, "")
+		// The directory was temporary; the archive is what's left
+		if cmd.Archive != "" {
+			p.Dir = cmd.Archive
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func (cmd *InitCommand) auditProjects() []audit.Project {
+	return []audit.Project{auditProject(cmd.gen, ".")}
+}
+
+func (cmd *UpdateCommand) auditProjects() []audit.Project {
+	return []audit.Project{auditProject(cmd.gen, cmd.Dir)}
+}
+
+func (cmd *AddConfigFieldCommand) auditProjects() []audit.Project {
+	return []audit.Project{auditProject(nil, cmd.Dir)}
+}
+
+// ---------------------------------------------------------------------
+// audit command
+
+type AuditCommand struct{}
+
+func (cmd *AuditCommand) Execute(args []string) error {
+	return msg.Errorf("please specify a subcommand: list")
+}
+
+// AuditListCommand prints the latest runs in the audit log.
+type AuditListCommand struct {
+	Last   int    `short:"n" long:"last" default:"20" description:"Show the last N runs (0 for all)"`
+	Module string `long:"module" description:"Show only runs that wrote this module"`
+	JSON   bool   `long:"json" description:"Print the runs as JSON lines, as logged"`
+}
+
+func (cmd *AuditListCommand) Execute(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	entries, err := audit.Read(auditLog(cfg))
+	if err != nil {
+		return msg.Errorf("failed to read the audit log: %w", err)
+	}
+	if cmd.Module != "" {
+		entries = slices.DeleteFunc(entries, func(e audit.Entry) bool {
+			return !slices.ContainsFunc(e.Projects, func(p audit.Project) bool { return p.Module == cmd.Module })
+		})
+	}
+	if cmd.Last > 0 && len(entries) > cmd.Last {
+		entries = entries[len(entries)-cmd.Last:]
+	}
+
+	if cmd.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, e := range entries {
+		var projects []string
+		for _, p := range e.Projects {
+			s := firstNonEmpty(p.Module, p.Dir)
+			if p.Pack != "" {
+				s += fmt.Sprintf(" (%s %s)", p.Pack, p.PackVersion)
+			}
+			projects = append(projects, s)
+		}
+		msg.Printf("%s  %-10s %-18s %s %s\n", e.Time.Local().Format("2006-01-02 15:04"), e.User, e.Command, label(e.Result, 6), strings.Join(projects, ", "))
+		// The log has the whole error; its first line is enough here
+		if e.Error != "" {
+			first, _, _ := strings.Cut(e.Error, "\n")
+			msg.Printf("%s\n", indent(first))
+		}
+	}
+	return nil
+}
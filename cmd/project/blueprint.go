@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/robbyriverside/project"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// BlueprintFlags generate a project declared in a blueprint file.
+type BlueprintFlags struct {
+	File string `short:"F" long:"file" description:"Generate the project declared in a blueprint YAML file: module, kind, binaries, features, commands, config fields and env targets; a blueprint of several projects, or a directory of blueprints, generates each under --dir"`
+}
+
+// loadBlueprints reads --file, if given: one project, a set of them, or
+// a directory of blueprints.
+func (cmd *GenCommand) loadBlueprints() (*project.BlueprintSet, error) {
+	if cmd.File == "" {
+		return nil, nil
+	}
+	set, err := project.LoadBlueprintSet(cmd.File)
+	if err == nil {
+		_, err = set.Ordered()
+	}
+	if err != nil {
+		return nil, msg.Errorf("invalid blueprint: %w", err)
+	}
+	return set, nil
+}
+
+// useBlueprint fills in the gitURL, --dir, --templates and --preset the
+// command line leaves out from bp.
+func (cmd *GenCommand) useBlueprint(bp *project.Blueprint) {
+	cmd.Args.GitURL = firstNonEmpty(cmd.Args.GitURL, bp.Module)
+	cmd.Dir = firstNonEmpty(cmd.Dir, bp.Dir)
+	cmd.Templates = firstNonEmpty(cmd.Templates, bp.Templates)
+	cmd.Preset = firstNonEmpty(cmd.Preset, bp.Preset)
+}
+
+// genSet generates every project of a blueprint set under --dir, each
+// after those it uses, whose modules its go.mod then replaces with their
+// directories. All are planned and checked before any is written.
+func (cmd *GenCommand) genSet(ctx context.Context, set *project.BlueprintSet) error {
+	if cmd.Args.GitURL != "" {
+		return msg.Errorf("a blueprint of several projects takes no gitURL")
+	}
+	ordered, err := set.Ordered()
+	if err != nil {
+		return msg.Errorf("invalid blueprint: %w", err)
+	}
+
+	planned := make(map[string]*project.Generator, len(ordered))
+	gens := make([]*project.Generator, 0, len(ordered))
+	for _, bp := range ordered {
+		c := *cmd
+		c.Dir, c.parentDir = "", firstNonEmpty(cmd.parentDir, cmd.Dir)
+		c.useBlueprint(bp)
+		gen, err := c.plan(ctx, bp)
+		// c is a copy, so the audit log gets its generator from here
+		cmd.generated = c.generated
+		if err != nil {
+			return msg.Errorf("project %s: %w", bp.Name(), err)
+		}
+		for _, name := range bp.Uses {
+			dep := planned[name].Config
+			rel, err := filepath.Rel(gen.Config.ProjectPath(), dep.ProjectPath())
+			if err != nil {
+				return msg.Errorf("project %s: %w", bp.Name(), err)
+			}
+			gen.Uses = append(gen.Uses, project.ModuleUse{Module: dep.ModuleURL, Dir: filepath.ToSlash(rel)})
+		}
+		planned[bp.Name()] = gen
+		gens = append(gens, gen)
+	}
+
+	for _, gen := range gens {
+		if err := cmd.generate(gen); err != nil {
+			return msg.Errorf("project %s: %w", gen.Config.ProjectName, err)
+		}
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------
+// blueprint command
+
+type BlueprintCommand struct{}
+
+func (cmd *BlueprintCommand) Execute(args []string) error {
+	return msg.Errorf("please specify a subcommand: validate, schema or export")
+}
+
+// BlueprintValidateCommand checks blueprint files without generating
+// anything, e.g. in CI or a pre-commit hook.
+type BlueprintValidateCommand struct {
+	Args struct {
+		Files []string `positional-arg-name:"file" required:"1" description:"Blueprint YAML files"`
+	} `positional-args:"yes"`
+}
+
+func (cmd *BlueprintValidateCommand) Execute(args []string) error {
+	invalid := 0
+	for _, file := range cmd.Args.Files {
+		set, err := project.LoadBlueprintSet(file)
+		if err == nil {
+			err = set.Check()
+		}
+		var bpErr *project.BlueprintError
+		switch {
+		case err == nil:
+			say("%s: ok\n", file)
+			continue
+		case errors.As(err, &bpErr):
+			// already located as file:line:column
+		default:
+			err = fmt.Errorf("%s: %w", file, err)
+		}
+		invalid++
+		// The problems are what validate is for, so they print even with -q
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if invalid > 0 {
+		return msg.Errorf("%d of %d blueprints are invalid", invalid, len(cmd.Args.Files))
+	}
+	return nil
+}
+
+// BlueprintSchemaCommand prints the JSON Schema of blueprint files.
+type BlueprintSchemaCommand struct {
+	Output string `short:"o" long:"output" description:"Write to a file (e.g. blueprint.schema.json) instead of stdout"`
+}
+
+func (cmd *BlueprintSchemaCommand) Execute(args []string) error {
+	return writeDoc(cmd.Output, project.BlueprintSchema())
+}
+
+// BlueprintExportCommand writes the blueprint of a generated project, so a
+// project built with flags can be reproduced or used as a starting point.
+type BlueprintExportCommand struct {
+	Dir    string `short:"d" long:"dir" default:"." description:"Project directory containing .project.yaml"`
+	Output string `short:"o" long:"output" description:"Write to a file (e.g. blueprint.yaml) instead of stdout"`
+}
+
+func (cmd *BlueprintExportCommand) Execute(args []string) error {
+	m, err := project.LoadManifest(pathutil.MustExpand(cmd.Dir))
+	if err != nil {
+		return err
+	}
+	data, err := project.ExportBlueprint(m)
+	if err != nil {
+		return err
+	}
+	return writeDoc(cmd.Output, data)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/robbyriverside/project"
+	logs "github.com/robbyriverside/project/logs"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// BundleCommand groups the bundle subcommands.
+type BundleCommand struct{}
+
+func (cmd *BundleCommand) Execute(args []string) error {
+	return msg.Errorf("please specify a subcommand: create or apply")
+}
+
+// BundleCreateCommand writes the template pack, the modules generated
+// projects need and the user's presets into one file.
+type BundleCreateCommand struct {
+	Output  string   `short:"o" long:"output" description:"Bundle file to write (default: project-bundle-<pack>-<version>.tar.gz)"`
+	Modules []string `long:"module" description:"Also bundle a module the pinned ones don't cover, at a version, e.g. github.com/google/wire@v0.6.0 for --with-wire (repeatable)"`
+	TemplateFlags
+	GoEnvFlags
+}
+
+func (cmd *BundleCreateCommand) Execute(args []string) error {
+	return cmd.ExecuteContext(context.Background(), args)
+}
+
+func (cmd *BundleCreateCommand) ExecuteContext(ctx context.Context, args []string) error {
+	var extra []project.Dependency
+	for _, m := range cmd.Modules {
+		d, err := project.ParseDependency(m)
+		if err != nil {
+			return err
+		}
+		extra = append(extra, d)
+	}
+	gen := &project.Generator{Context: ctx, Log: logs.Logger()}
+	if err := cmd.setGoEnv(gen); err != nil {
+		return err
+	}
+	if err := cmd.setTemplates(gen); err != nil {
+		return err
+	}
+	out := cmd.Output
+	if out == "" {
+		pack, err := gen.PackInfo()
+		if err != nil {
+			return err
+		}
+		out = fmt.Sprintf("project-bundle-%s-%s.tar.gz", pack.Name, pack.Version)
+	}
+	out = pathutil.MustExpand(out)
+
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return msg.Errorf("failed to create bundle: %w", err)
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return msg.Errorf("failed to create bundle: %w", err)
+	}
+	info, err := gen.CreateBundle(f, userPresets(), extra)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	printCommands(gen.Commands)
+	if err != nil {
+		os.Remove(out)
+		return msg.Errorf("failed to create bundle: %w", err)
+	}
+	for _, d := range info.Modules {
+		detail("  %s %s\n", d.Path, d.Version)
+	}
+	if info.Presets {
+		detail("  presets from %s\n", pathutil.Contract(userPresets()))
+	}
+	say("Bundled %s templates %s and %d modules with their dependencies in %s\n",
+		info.TemplatePack, info.TemplateVersion, len(info.Modules), pathutil.Contract(out))
+	return nil
+}
+
+// BundleApplyCommand unpacks a bundle for gen --bundle.
+type BundleApplyCommand struct {
+	Args struct {
+		Bundle string `positional-arg-name:"bundle" required:"true" description:"Bundle file written by bundle create"`
+	} `positional-args:"yes"`
+
+	Dir string `short:"d" long:"dir" description:"Directory to unpack into (default: ~/.project/bundles/<bundle file name>)"`
+}
+
+func (cmd *BundleApplyCommand) Execute(args []string) error {
+	file := pathutil.MustExpand(cmd.Args.Bundle)
+	dir := cmd.Dir
+	if dir == "" {
+		name := filepath.Base(file)
+		for _, ext := range []string{".tar.gz", ".tgz"} {
+			name = strings.TrimSuffix(name, ext)
+		}
+		dir = filepath.Join("~/.project/bundles", name)
+	}
+	dir = pathutil.MustExpand(dir)
+
+	info, err := project.ExtractBundle(file, dir)
+	if err != nil {
+		return msg.Errorf("failed to apply bundle: %w", err)
+	}
+	warnBundleVersion(info)
+	say("Unpacked %s templates %s and %d modules into %s\nGenerate with: project gen --bundle %s <module>\n",
+		info.TemplatePack, info.TemplateVersion, len(info.Modules), pathutil.Contract(dir), pathutil.Contract(dir))
+	return nil
+}
+
+// openBundle unpacks the --bundle file into a temporary directory, which
+// cleanup removes, or takes the directory bundle apply unpacked it into.
+func (cmd *GenCommand) openBundle() (cleanup func(), err error) {
+	if cmd.Templates != "" {
+		return nil, msg.Errorf("--bundle and --templates can't be used together")
+	}
+	src := pathutil.MustExpand(cmd.Bundle)
+	if fi, err := os.Stat(src); err == nil && fi.IsDir() {
+		info, err := project.LoadBundle(src)
+		if err != nil {
+			return nil, err
+		}
+		warnBundleVersion(info)
+		cmd.bundleDir = src
+		return func() {}, nil
+	}
+
+	tmp, err := os.MkdirTemp("", "project-bundle-")
+	if err != nil {
+		return nil, err
+	}
+	cmd.bundleDir = filepath.Join(tmp, "bundle")
+	info, err := project.ExtractBundle(src, cmd.bundleDir)
+	if err != nil {
+		os.RemoveAll(tmp)
+		return nil, msg.Errorf("failed to open bundle: %w", err)
+	}
+	warnBundleVersion(info)
+	return func() { os.RemoveAll(tmp) }, nil
+}
+
+// setPack points gen at the --bundle, else the --templates pack. The
+// bundle replaces the GOPROXY setGoEnv set, so it comes after it.
+func (cmd *GenCommand) setPack(gen *project.Generator) error {
+	if cmd.bundleDir == "" {
+		return cmd.setTemplates(gen)
+	}
+	_, err := gen.UseBundle(cmd.bundleDir)
+	return err
+}
+
+// warnBundleVersion warns that a bundle from another generator release
+// may lack the modules this one pins.
+func warnBundleVersion(info *project.BundleInfo) {
+	if info.GeneratorVersion != project.Version {
+		warn("warning: the bundle was created by project %s, not %s; its modules may not match the pinned ones\n",
+			info.GeneratorVersion, project.Version)
+	}
+}
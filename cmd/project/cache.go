@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/robbyriverside/project"
+	config "github.com/robbyriverside/project/config"
+	"github.com/robbyriverside/project/internal/packcache"
+	"github.com/robbyriverside/project/internal/packsign"
+	logs "github.com/robbyriverside/project/logs"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// TemplateFlags select the template pack for commands that render.
+type TemplateFlags struct {
+	Templates          string `short:"t" long:"templates" description:"Template pack directory or .tar.gz URL (*.tmpl files plus optional static/)"`
+	InsecureSkipVerify bool   `long:"insecure-skip-verify" description:"Use a --templates URL even if its signature is missing or untrusted"`
+}
+
+// setTemplates points gen at the --templates pack: a local directory, or
+// an http(s) URL of a .tar.gz pack resolved through the pack cache.
+// Remote packs must be signed by a key in the trust store, since their
+// templates generate code that gets built and run.
+// An empty source leaves the embedded pack in place.
+func (f *TemplateFlags) setTemplates(gen *project.Generator) error {
+	switch {
+	case f.Templates == "":
+		return nil
+	case packcache.IsURL(f.Templates):
+		cache, err := newPackCache()
+		if err != nil {
+			return err
+		}
+		entry, err := cache.Fetch(f.Templates)
+		if err != nil {
+			return msg.Errorf("failed to fetch template pack: %w", err)
+		}
+		if entry.Offline {
+			warn("warning: %s is unreachable, using cached pack sha256:%s\n", entry.URL, entry.SHA256)
+		}
+		if _, err := packsign.Verify(entry.Archive, trustDir()); err != nil {
+			if !f.InsecureSkipVerify {
+				return msg.Errorf("refusing unverified template pack (use --insecure-skip-verify to override): %w", err)
+			}
+			warn("warning: using unverified template pack: %v\n", err)
+		}
+		gen.Templates = os.DirFS(entry.Dir)
+	default:
+		gen.Templates = os.DirFS(pathutil.MustExpand(f.Templates))
+	}
+	return nil
+}
+
+// newPackCache opens the default pack cache, retrying downloads as
+// config retries says.
+func newPackCache() (*packcache.Cache, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	cache := packcache.New(packcache.DefaultDir())
+	if cache.Retry, err = retryPolicy(cfg); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// trustDir is the configured trust store, ~/.project/trust by default.
+func trustDir() string {
+	dir, err := config.Get("trust_dir")
+	if err != nil || dir == "" {
+		dir = "~/.project/trust"
+	}
+	return pathutil.MustExpand(dir)
+}
+
+// ---------------------------------------------------------------------
+// cache command
+
+type CacheCommand struct{}
+
+func (cmd *CacheCommand) Execute(args []string) error {
+	return msg.Errorf("please specify a subcommand: clean or warm")
+}
+
+// CacheCleanCommand removes every downloaded template pack.
+type CacheCleanCommand struct{}
+
+func (cmd *CacheCleanCommand) Execute(args []string) error {
+	dir := packcache.DefaultDir()
+	if err := packcache.New(dir).Clean(); err != nil {
+		return err
+	}
+	say("Removed %s\n", pathutil.Contract(dir))
+	return nil
+}
+
+// CacheWarmCommand downloads the pinned scaffold dependencies into the
+// module cache that later runs share.
+type CacheWarmCommand struct {
+	GoEnvFlags
+}
+
+func (cmd *CacheWarmCommand) Execute(args []string) error {
+	return cmd.ExecuteContext(context.Background(), args)
+}
+
+func (cmd *CacheWarmCommand) ExecuteContext(ctx context.Context, args []string) error {
+	gen := &project.Generator{Context: ctx, Log: logs.Logger()}
+	if err := cmd.setGoEnv(gen); err != nil {
+		return err
+	}
+	deps, err := gen.WarmModCache()
+	printCommands(gen.Commands)
+	if err != nil {
+		return msg.Errorf("failed to warm the module cache: %w", err)
+	}
+	for _, d := range deps {
+		detail("  %s %s\n", d.Path, d.Version)
+	}
+	if gen.GoEnv.ModCache != "" {
+		say("Warmed %s with %d pinned modules and their dependencies\n", pathutil.Contract(gen.GoEnv.ModCache), len(deps))
+	} else {
+		say("Warmed the module cache with %d pinned modules and their dependencies\n", len(deps))
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------
+// pack command
+
+type PackCommand struct{}
+
+func (cmd *PackCommand) Execute(args []string) error {
+	return msg.Errorf("please specify a subcommand: verify")
+}
+
+// PackVerifyCommand checks a pack archive's signature against the trust store.
+type PackVerifyCommand struct {
+	Args struct {
+		Pack string `positional-arg-name:"pack" required:"true" description:"A .tar.gz template pack: a local file or an http(s) URL"`
+	} `positional-args:"yes"`
+}
+
+func (cmd *PackVerifyCommand) Execute(args []string) error {
+	archive := pathutil.MustExpand(cmd.Args.Pack)
+	if packcache.IsURL(cmd.Args.Pack) {
+		cache, err := newPackCache()
+		if err != nil {
+			return err
+		}
+		entry, err := cache.Fetch(cmd.Args.Pack)
+		if err != nil {
+			return msg.Errorf("failed to fetch template pack: %w", err)
+		}
+		archive = entry.Archive
+	}
+
+	dir := trustDir()
+	res, err := packsign.Verify(archive, dir)
+	if err != nil {
+		return msg.Errorf("failed to verify %s: %w", cmd.Args.Pack, err)
+	}
+	say("Verified %s\n  signature: %s (%s)\n  key:       %s\n",
+		cmd.Args.Pack, pathutil.Contract(res.Signature), res.Key.Tool, pathutil.Contract(res.Key.Path))
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jessevdk/go-flags"
+)
+
+// ContextCommander is implemented by commands that can be cancelled: their
+// context ends on Ctrl-C or SIGTERM, or when --timeout passes, which kills
+// the go commands and hooks they run. They keep an Execute for go-flags
+// that runs ExecuteContext with context.Background.
+type ContextCommander interface {
+	ExecuteContext(ctx context.Context, args []string) error
+}
+
+// commandContext is the context of a run of cmd: bound to signals for a
+// ContextCommander, so Ctrl-C still kills the others outright.
+func commandContext(cmd flags.Commander) (context.Context, context.CancelFunc) {
+	if _, ok := cmd.(ContextCommander); !ok {
+		return context.Background(), func() {}
+	}
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// runCommand runs cmd for the parser's CommandHandler, with ctx further
+// bound to opts.Timeout.
+func runCommand(ctx context.Context, opts *Options, cmd flags.Commander, args []string) error {
+	c, ok := cmd.(ContextCommander)
+	if !ok {
+		if cmd == nil {
+			return nil
+		}
+		return cmd.Execute(args)
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	err := c.ExecuteContext(ctx, args)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return msg.Errorf("timed out after %s: %w", opts.Timeout, err)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return msg.Errorf("interrupted: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/diff"
+	"github.com/robbyriverside/project/internal/term"
+)
+
+// DiffFlags choose how diff and update --diff show changes.
+type DiffFlags struct {
+	SideBySide bool `short:"y" long:"side-by-side" description:"Show changes in two columns, the working tree on the left"`
+	NoPager    bool `long:"no-pager" description:"Don't pipe diffs longer than the terminal through $PAGER"`
+}
+
+// diffView gathers diffs and shows them once complete, through the pager
+// when they don't fit on the terminal.
+type diffView struct {
+	DiffFlags
+	color bool
+	buf   bytes.Buffer
+}
+
+func (f DiffFlags) newView() *diffView {
+	return &diffView{DiffFlags: f, color: term.Color(os.Stdout)}
+}
+
+// File adds the state line of one file and its unified diff, if any.
+func (v *diffView) File(state, path, unified string) {
+	v.buf.WriteString(msg.Sprintf("%s %s\n", label(state, 10), path))
+	if unified == "" {
+		return
+	}
+	if v.SideBySide {
+		width, _ := term.Size(os.Stdout)
+		v.buf.WriteString(v.paint(diff.SideBySide(unified, width), sideBySideColor(diff.ColumnWidth(width))))
+		return
+	}
+	v.buf.WriteString(v.paint(unified, unifiedColor))
+}
+
+// paint colors each line of text by the color pick returns for it.
+func (v *diffView) paint(text string, pick func(line string) term.Style) string {
+	if !v.color {
+		return text
+	}
+	var b strings.Builder
+	for _, line := range diff.Lines(text) {
+		body := strings.TrimSuffix(line, "\n")
+		if style := pick(body); style != "" {
+			body = style.Wrap(body)
+		}
+		if strings.HasSuffix(line, "\n") {
+			body += "\n"
+		}
+		b.WriteString(body)
+	}
+	return b.String()
+}
+
+func unifiedColor(line string) term.Style {
+	switch {
+	case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+		return term.Bold
+	case strings.HasPrefix(line, "@@"):
+		return term.Cyan
+	case strings.HasPrefix(line, "-"):
+		return term.Red
+	case strings.HasPrefix(line, "+"):
+		return term.Green
+	}
+	return ""
+}
+
+// sideBySideColor colors rows by the gutter mark after columns col wide.
+func sideBySideColor(col int) func(line string) term.Style {
+	return func(line string) term.Style {
+		if strings.HasPrefix(line, "@@") {
+			return term.Cyan
+		}
+		row := []rune(line)
+		if len(row) <= col+1 {
+			return ""
+		}
+		switch row[col+1] {
+		case '<':
+			return term.Red
+		case '>':
+			return term.Green
+		case '|':
+			return term.Yellow
+		}
+		return ""
+	}
+}
+
+// Show writes what was gathered to stdout, paging it when it is longer
+// than the terminal and the pager can be started.
+func (v *diffView) Show() error {
+	_, height := term.Size(os.Stdout)
+	if term.IsTerminal(os.Stdout) && !v.NoPager && strings.Count(v.buf.String(), "\n") >= height && v.page() {
+		return nil
+	}
+	_, err := io.Copy(os.Stdout, &v.buf)
+	return err
+}
+
+// page runs PROJECT_PAGER or PAGER, else less, on the output, reporting
+// whether it started. Like git, it sets LESS=FRX when unset so less keeps
+// colors and exits at once when everything fits.
+func (v *diffView) page() bool {
+	args := strings.Fields(firstNonEmpty(os.Getenv("PROJECT_PAGER"), os.Getenv("PAGER"), "less"))
+	if len(args) == 0 || args[0] == "cat" {
+		return false
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(v.buf.Bytes())
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		return false
+	}
+	cmd.Wait() // quitting early is not a failure
+	return true
+}
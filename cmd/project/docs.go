@@ -0,0 +1,174 @@
+// Code generated from templates/docs.tmpl by TestDocsSource; DO NOT EDIT.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// DocsCommand handles the 'docs' subcommand
+type DocsCommand struct{}
+
+func (cmd *DocsCommand) Execute(args []string) error {
+	return fmt.Errorf("Please specify one command of: %s", strings.Join([]string{"man", "markdown"}, ", "))
+}
+
+// DocsManCommand writes a man(1) page for the whole command tree.
+type DocsManCommand struct {
+	Output string `short:"o" long:"output" description:"Write to a file (e.g. project.1) instead of stdout"`
+
+	parser *flags.Parser
+}
+
+func (cmd *DocsManCommand) Execute(args []string) error {
+	var buf bytes.Buffer
+	cmd.parser.WriteManPage(&buf)
+	return writeDoc(cmd.Output, buf.Bytes())
+}
+
+// DocsMarkdownCommand writes a Markdown CLI reference for the whole command tree.
+type DocsMarkdownCommand struct {
+	Output string `short:"o" long:"output" description:"Write to a file (e.g. docs/CLI.md) instead of stdout"`
+
+	parser *flags.Parser
+}
+
+func (cmd *DocsMarkdownCommand) Execute(args []string) error {
+	var buf bytes.Buffer
+	writeMarkdown(&buf, cmd.parser)
+	return writeDoc(cmd.Output, buf.Bytes())
+}
+
+// writeDoc prints data, or writes it to path when one is given.
+func writeDoc(path string, data []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(pathutil.MustExpand(path), data, 0644)
+}
+
+// writeMarkdown renders the parser's global options and every visible
+// command, depth first, with its usage line, arguments and options.
+func writeMarkdown(w io.Writer, p *flags.Parser) {
+	fmt.Fprintf(w, "# %s\n\n", p.Name)
+	if p.ShortDescription != "" {
+		fmt.Fprintf(w, "%s\n\n", p.ShortDescription)
+	}
+	fmt.Fprintf(w, "Usage: `%s [OPTIONS] <command>`\n\n", p.Name)
+	writeOptions(w, "Global options", p.Command)
+
+	for _, c := range p.Commands() {
+		writeCommand(w, p.Name, c, 2)
+	}
+}
+
+// writeCommand documents c under a heading of the given level, then its subcommands.
+func writeCommand(w io.Writer, prefix string, c *flags.Command, level int) {
+	if c.Hidden {
+		return
+	}
+	path := prefix + " " + c.Name
+	fmt.Fprintf(w, "%s %s\n\n", strings.Repeat("#", level), path)
+	if c.ShortDescription != "" {
+		fmt.Fprintf(w, "%s\n\n", c.ShortDescription)
+	}
+	if c.LongDescription != "" && c.LongDescription != c.ShortDescription {
+		fmt.Fprintf(w, "%s\n\n", c.LongDescription)
+	}
+
+	usage := path
+	if len(collectOptions(c.Group)) > 0 {
+		usage += " [OPTIONS]"
+	}
+	for _, a := range c.Args() {
+		if a.Required > 0 {
+			usage += " <" + a.Name + ">"
+		} else {
+			usage += " [" + a.Name + "]"
+		}
+	}
+	if len(c.Commands()) > 0 {
+		usage += " <command>"
+	}
+	fmt.Fprintf(w, "Usage: `%s`\n\n", usage)
+
+	if args := c.Args(); len(args) > 0 {
+		fmt.Fprintf(w, "| Argument | Description |\n|---|---|\n")
+		for _, a := range args {
+			fmt.Fprintf(w, "| `%s` | %s |\n", a.Name, escapeCell(a.Description))
+		}
+		fmt.Fprintln(w)
+	}
+	writeOptions(w, "Options", c)
+
+	for _, sub := range c.Commands() {
+		writeCommand(w, path, sub, level+1)
+	}
+}
+
+// writeOptions writes a table of c's visible options, if it has any.
+func writeOptions(w io.Writer, title string, c *flags.Command) {
+	opts := collectOptions(c.Group)
+	if len(opts) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n\n| Option | Description | Default |\n|---|---|---|\n", title)
+	for _, o := range opts {
+		desc := o.Description
+		if len(o.Choices) > 0 {
+			desc += " (one of: " + strings.Join(o.Choices, ", ") + ")"
+		}
+		def := ""
+		if len(o.Default) > 0 {
+			def = "`" + strings.Join(o.Default, ", ") + "`"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s |\n", optionName(o), escapeCell(desc), def)
+	}
+	fmt.Fprintln(w)
+}
+
+// collectOptions returns the visible options of g and its nested groups,
+// leaving out the -h/--help that every command has.
+func collectOptions(g *flags.Group) []*flags.Option {
+	var out []*flags.Option
+	for _, o := range g.Options() {
+		if !o.Hidden && o.LongName != "help" {
+			out = append(out, o)
+		}
+	}
+	for _, sub := range g.Groups() {
+		out = append(out, collectOptions(sub)...)
+	}
+	return out
+}
+
+// optionName formats an option as "`-d`, `--dir <value>`".
+func optionName(o *flags.Option) string {
+	arg := ""
+	if kind := o.Field().Type.Kind(); kind != reflect.Bool && kind != reflect.Func {
+		arg = " <value>"
+	}
+	var names []string
+	if o.ShortName != 0 {
+		names = append(names, "`-"+string(o.ShortName)+"`")
+	}
+	if o.LongName != "" {
+		names = append(names, "`--"+o.LongNameWithNamespace()+arg+"`")
+	}
+	return strings.Join(names, ", ")
+}
+
+// escapeCell keeps text from breaking a Markdown table row.
+func escapeCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/format"
+	"os"
+	"testing"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/robbyriverside/project"
+)
+
+var update = flag.Bool("update", false, "rewrite docs.go from templates/docs.tmpl")
+
+// docsHeader starts docs.go, ahead of the rendered template.
+const docsHeader = "// Code generated from templates/docs.tmpl by TestDocsSource; DO NOT EDIT.\n\n"
+
+// TestDocsSource checks that docs.go is the docs template as the generator
+// renders it for this module, so the two can't drift. -update rewrites it.
+func TestDocsSource(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	gen := &project.Generator{Config: project.NewGenConfig("github.com/robbyriverside/project", t.TempDir())}
+	f, err := gen.Render("docs")
+	if err != nil {
+		t.Fatalf("Render(docs) error: %v", err)
+	}
+	src, err := format.Source(f.Data)
+	if err != nil {
+		t.Fatalf("docs.tmpl doesn't render valid Go: %v", err)
+	}
+	want := append([]byte(docsHeader), src...)
+	if *update {
+		if err := os.WriteFile("docs.go", want, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := os.ReadFile("docs.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("docs.go isn't templates/docs.tmpl as rendered; run go test ./cmd/project -run TestDocsSource -update")
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var opts struct {
+		Verbose bool `short:"v" long:"verbose" description:"Say more"`
+	}
+	var greet struct {
+		Name  string `long:"name" default:"world" description:"Who to greet"`
+		Style string `long:"style" choice:"plain" choice:"loud" description:"How | to greet"`
+		Args  struct {
+			Lang string `positional-arg-name:"lang" description:"Greeting language" required:"yes"`
+			Rest string `positional-arg-name:"rest" description:"More"`
+		} `positional-args:"yes"`
+	}
+	var hidden, wave, hand struct{}
+
+	p := flags.NewNamedParser("demo", flags.Default)
+	p.ShortDescription = "A demo"
+	if _, err := p.AddGroup("Application Options", "", &opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.AddCommand("greet", "Greet someone", "Prints a greeting\nin the language given", &greet); err != nil {
+		t.Fatal(err)
+	}
+	h, err := p.AddCommand("secret", "Hidden", "", &hidden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Hidden = true
+	w, err := p.AddCommand("wave", "Wave", "Wave", &wave)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.AddCommand("hand", "Wave a hand", "", &hand); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	writeMarkdown(&buf, p)
+	want := "# demo\n\nA demo\n\nUsage: `demo [OPTIONS] <command>`\n\n" +
+		"Global options:\n\n| Option | Description | Default |\n|---|---|---|\n| `-v`, `--verbose` | Say more |  |\n\n" +
+		"## demo greet\n\nGreet someone\n\nPrints a greeting\nin the language given\n\n" +
+		"Usage: `demo greet [OPTIONS] <lang> [rest]`\n\n" +
+		"| Argument | Description |\n|---|---|\n| `lang` | Greeting language |\n| `rest` | More |\n\n" +
+		"Options:\n\n| Option | Description | Default |\n|---|---|---|\n" +
+		"| `--name <value>` | Who to greet | `world` |\n" +
+		"| `--style <value>` | How \\| to greet (one of: plain, loud) |  |\n\n" +
+		"## demo wave\n\nWave\n\nUsage: `demo wave <command>`\n\n" +
+		"### demo wave hand\n\nWave a hand\n\nUsage: `demo wave hand`\n\n"
+	if got := buf.String(); got != want {
+		t.Errorf("writeMarkdown() =\n%s\nwant:\n%s", got, want)
+	}
+}
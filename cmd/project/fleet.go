@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/robbyriverside/project"
+	config "github.com/robbyriverside/project/config"
+	logs "github.com/robbyriverside/project/logs"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// FleetFlags pick the repositories a fleet command works on and how.
+type FleetFlags struct {
+	Repos    string `long:"repos" description:"YAML file listing the repositories, as for project watch"`
+	Org      string `long:"org" description:"Work on every repository of a forge org, user or group, e.g. github.com/acme"`
+	Parallel int    `short:"j" long:"parallel" default:"4" description:"Repositories to clone and work on at once"`
+	JSON     bool   `long:"json" description:"Print the report as JSON"`
+}
+
+// FleetCommand groups the fleet subcommands.
+type FleetCommand struct{}
+
+func (cmd *FleetCommand) Execute(args []string) error {
+	return msg.Errorf("please specify a subcommand: verify, update or score")
+}
+
+// run clones each repository, runs op on it and reports the results,
+// printing a row for each with row. The error says how many repositories
+// failed; the results are returned either way.
+func (f *FleetFlags) run(ctx context.Context, op project.FleetOp, row func(project.FleetResult) (state, detail string)) ([]project.FleetResult, error) {
+	list, err := f.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	opts := project.FleetOptions{Parallel: f.Parallel, Token: forgeToken}
+	if !f.JSON {
+		say("Working on %d repositories from %s\n", len(list.Repos), list.Source)
+		opts.Progress = func(res project.FleetResult) {
+			detail("  finished  %s\n", res.Repo)
+		}
+	}
+	results := project.RunFleet(ctx, list.Repos, opts, op)
+
+	failed := 0
+	if f.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return results, err
+		}
+	}
+	for _, res := range results {
+		state, text := "failed", res.Error
+		if res.Error == "" {
+			state, text = row(res)
+		} else {
+			failed++
+		}
+		if !f.JSON {
+			line := fmt.Sprintf("%s %s %s", label(state, 9), res.Repo, text)
+			msg.Printf("%s\n", strings.TrimRight(line, " "))
+		}
+	}
+	if failed > 0 {
+		return results, msg.Errorf("%d of %d repositories failed", failed, len(results))
+	}
+	return results, nil
+}
+
+// list loads the --repos file or lists the --org's repositories.
+func (f *FleetFlags) list(ctx context.Context) (*project.RepoList, error) {
+	switch {
+	case f.Repos != "" && f.Org != "":
+		return nil, msg.Errorf("--repos and --org can't be used together")
+	case f.Repos != "":
+		data, err := os.ReadFile(pathutil.MustExpand(f.Repos))
+		if err != nil {
+			return nil, msg.Errorf("failed to read the repo list: %w", err)
+		}
+		return project.ParseRepoList(data, f.Repos)
+	case f.Org != "":
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, err
+		}
+		policy, err := retryPolicy(cfg)
+		if err != nil {
+			return nil, err
+		}
+		host, _, _ := strings.Cut(f.Org, "/")
+		return project.OrgRepoList(ctx, f.Org, forgeToken(host), policy)
+	default:
+		return nil, msg.Errorf("please name the repositories with --repos or --org")
+	}
+}
+
+// FleetVerifyCommand verifies every repository against its manifest.
+type FleetVerifyCommand struct {
+	FleetFlags
+}
+
+func (cmd *FleetVerifyCommand) Execute(args []string) error {
+	return cmd.ExecuteContext(context.Background(), args)
+}
+
+func (cmd *FleetVerifyCommand) ExecuteContext(ctx context.Context, args []string) error {
+	results, err := cmd.run(ctx, project.FleetVerify, func(res project.FleetResult) (string, string) {
+		var changed int
+		for _, f := range res.Verify.Files {
+			if f.State == project.VerifyModified || f.State == project.VerifyMissing {
+				changed++
+			}
+		}
+		if changed == 0 {
+			return "ok", ""
+		}
+		return "modified", msg.Sprintf("%d files differ from %s", changed, project.ManifestName)
+	})
+	if err != nil {
+		return err
+	}
+	for _, res := range results {
+		if !res.Verify.Clean() {
+			return msg.Errorf("some repositories differ from %s", project.ManifestName)
+		}
+	}
+	return nil
+}
+
+// FleetUpdateCommand updates every repository and opens a pull request for
+// each that changed.
+type FleetUpdateCommand struct {
+	FleetFlags
+	Branch string `long:"branch" description:"Branch for the pull requests (default: project-update-<pack version>)"`
+	TemplateFlags
+	GoEnvFlags
+}
+
+func (cmd *FleetUpdateCommand) Execute(args []string) error {
+	return cmd.ExecuteContext(context.Background(), args)
+}
+
+func (cmd *FleetUpdateCommand) ExecuteContext(ctx context.Context, args []string) error {
+	// Resolve the pack once, not per repository
+	pack := &project.Generator{}
+	if err := cmd.setTemplates(pack); err != nil {
+		return err
+	}
+	newGen := func() (*project.Generator, error) {
+		gen := &project.Generator{Templates: pack.Templates, Context: ctx, Log: logs.Logger()}
+		return gen, cmd.setGoEnv(gen)
+	}
+	_, err := cmd.run(ctx, project.FleetUpdate(newGen, cmd.Branch), func(res project.FleetResult) (string, string) {
+		if res.PullRequest == "" {
+			return "unchanged", ""
+		}
+		return "updated", res.PullRequest
+	})
+	return err
+}
+
+// FleetScoreCommand grades every repository against the organization's rubric.
+type FleetScoreCommand struct {
+	FleetFlags
+	Rubric string `long:"rubric" description:"Rubric file or URL (default: config rubric, else the built-in one)"`
+}
+
+func (cmd *FleetScoreCommand) Execute(args []string) error {
+	return cmd.ExecuteContext(context.Background(), args)
+}
+
+func (cmd *FleetScoreCommand) ExecuteContext(ctx context.Context, args []string) error {
+	rubric, err := orgRubric(cmd.Rubric)
+	if err != nil {
+		return err
+	}
+	results, err := cmd.run(ctx, project.FleetScore(rubric), func(res project.FleetResult) (string, string) {
+		state := "ok"
+		if !res.Score.Passed() {
+			state = "warning"
+		}
+		return state, msg.Sprintf("%d/100", res.Score.Score)
+	})
+
+	// The summary covers the repositories that could be scored
+	var scored, total, passed int
+	for _, res := range results {
+		if res.Score == nil {
+			continue
+		}
+		scored++
+		total += res.Score.Score
+		if res.Score.Passed() {
+			passed++
+		}
+	}
+	if scored > 0 && !cmd.JSON {
+		say("Average score %d/100; %d of %d repositories pass\n", total/scored, passed, scored)
+	}
+	if err != nil {
+		return err
+	}
+	if passed < scored {
+		return msg.Errorf("%d repositories score below the pass mark", scored-passed)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/robbyriverside/project"
+	config "github.com/robbyriverside/project/config"
+	"github.com/robbyriverside/project/internal/retry"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// GoEnvFlags set the module environment of the go commands a run starts,
+// for modules on private hosts. Each falls back to its config key.
+type GoEnvFlags struct {
+	GoProxy   string `long:"goproxy" description:"GOPROXY for go mod steps (default: config goproxy, else the environment's)"`
+	GoPrivate string `long:"goprivate" description:"GOPRIVATE for go mod steps, e.g. github.com/acme/* (default: config goprivate, else the environment's)"`
+	GoNoSumDB string `long:"gonosumdb" description:"GONOSUMDB for go mod steps (default: config gonosumdb, else GOPRIVATE)"`
+	ModCache  string `long:"modcache" description:"GOMODCACHE shared by the go mod steps of every project, see cache warm (default: config modcache, else the environment's)"`
+}
+
+// setGoEnv fills gen.GoEnv from the flags and config, gen.GoTimeout
+// from config go_timeout, and gen.Retry from config retries.
+func (f *GoEnvFlags) setGoEnv(gen *project.Generator) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	gen.GoEnv = project.GoEnv{
+		Proxy:   firstNonEmpty(f.GoProxy, cfg.GoProxy),
+		Private: firstNonEmpty(f.GoPrivate, cfg.GoPrivate),
+		NoSumDB: firstNonEmpty(f.GoNoSumDB, cfg.GoNoSumDB),
+	}
+	if dir := firstNonEmpty(f.ModCache, cfg.ModCache); dir != "" {
+		// The go command wants GOMODCACHE absolute
+		if gen.GoEnv.ModCache, err = filepath.Abs(pathutil.MustExpand(dir)); err != nil {
+			return err
+		}
+	}
+	if cfg.GoTimeout != "" && cfg.GoTimeout != "0" {
+		if gen.GoTimeout, err = time.ParseDuration(cfg.GoTimeout); err != nil {
+			return msg.Errorf("invalid go_timeout %q: %w", cfg.GoTimeout, err)
+		}
+	}
+	gen.Retry, err = retryPolicy(cfg)
+	return err
+}
+
+// maxRetryDelay caps the wait between tries.
+const maxRetryDelay = 30 * time.Second
+
+// retryPolicy reads config retries and retry_delay, warning of each retry.
+func retryPolicy(cfg *config.Config) (retry.Policy, error) {
+	p := retry.Policy{Attempts: 1, MaxDelay: maxRetryDelay, OnRetry: func(attempt int, err error, wait time.Duration) {
+		warn("warning: %v\nretrying in %s (try %d)\n", err, wait, attempt+1)
+	}}
+	var err error
+	if cfg.Retries != "" {
+		if p.Attempts, err = strconv.Atoi(cfg.Retries); err != nil || p.Attempts < 1 {
+			return p, msg.Errorf("invalid retries %q: want a number of at least 1", cfg.Retries)
+		}
+	}
+	if cfg.RetryDelay != "" {
+		if p.Delay, err = time.ParseDuration(cfg.RetryDelay); err != nil {
+			return p, msg.Errorf("invalid retry_delay %q: %w", cfg.RetryDelay, err)
+		}
+	}
+	return p, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
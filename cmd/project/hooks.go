@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robbyriverside/project"
+	config "github.com/robbyriverside/project/config"
+	"github.com/robbyriverside/project/internal/sandbox"
+)
+
+// hookPolicy builds the pack hook policy from the hooks, hook_allow,
+// hook_network and hook_timeout config keys. hook_network=fallback runs
+// hooks with the network where isolation is unavailable instead of
+// failing them.
+func hookPolicy() (*project.HookPolicy, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	policy := &project.HookPolicy{
+		Enabled: cfg.Hooks == "on",
+		Policy: sandbox.Policy{
+			Allow:    strings.Fields(cfg.HookAllow),
+			Network:  cfg.HookNetwork == "true",
+			Fallback: cfg.HookNetwork == "fallback",
+		},
+	}
+	if cfg.HookTimeout != "" {
+		if policy.Timeout, err = time.ParseDuration(cfg.HookTimeout); err != nil {
+			return nil, msg.Errorf("invalid hook_timeout %q: %w", cfg.HookTimeout, err)
+		}
+	}
+	return policy, nil
+}
+
+// printHooks reports each pack hook with its captured output, indented:
+// all of them with -v, else those that didn't run or failed.
+func printHooks(results []project.HookResult) {
+	for _, r := range results {
+		report := detail
+		if r.Status != project.HookRan {
+			report = say
+		}
+		note := ""
+		switch r.Status {
+		case project.HookRan, project.HookFailed:
+			switch {
+			case r.Fallback:
+				report = say
+				note = " (with network: isolation is unavailable here)"
+			case errors.Is(r.Err, sandbox.ErrIsolation):
+				note = " (set config hook_network=fallback to run it with network)"
+			case !r.Isolated:
+				note = " (with network)"
+			}
+		case project.HookDenied:
+			note = fmt.Sprintf(" (%s is not in hook_allow)", r.Command[0])
+		case project.HookDisabled:
+			note = " (set config hooks=on to run)"
+		}
+		report("  hook %s %s: %s%s\n", label(string(r.Status), 8), r.Name, strings.Join(r.Command, " "), note)
+		if strings.TrimSpace(r.Output) != "" {
+			report("%s\n", indent(r.Output))
+		}
+		if r.Err != nil {
+			report("    error: %v\n", r.Err)
+		}
+	}
+}
+
+// printCommands reports each go command of the run with its duration and
+// captured output, indented: all of them with -v, else those that failed.
+func printCommands(results []project.CommandResult) {
+	for _, r := range results {
+		status, report := "ran", detail
+		if r.Err != nil {
+			status, report = "failed", say
+		}
+		report("  %s %s (%s)\n", label(status, 9), strings.Join(r.Args, " "), r.Duration.Round(time.Millisecond))
+		if strings.TrimSpace(string(r.Output)) != "" {
+			report("%s\n", indent(string(r.Output)))
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/robbyriverside/project"
+	"github.com/robbyriverside/project/internal/jsonrpc"
+	"github.com/robbyriverside/project/internal/metadata"
+	logs "github.com/robbyriverside/project/logs"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// ---------------------------------------------------------------------
+// ide-server command
+
+// IDEServerCommand serves the generator to editor extensions over JSON-RPC
+// on stdin and stdout, framed as the Language Server Protocol frames it,
+// so a VS Code or GoLand plugin can start it as a child process. Its
+// methods are initialize, listTemplates, previewRender, generate, diff and
+// shutdown; generate sends each step, file and command of the run as a
+// $/progress notification.
+type IDEServerCommand struct {
+	Dir string `short:"d" long:"dir" default:"." description:"Directory relative project directories are resolved against, usually the workspace"`
+
+	// TemplateFlags pick the pack the methods list and render from
+	TemplateFlags
+
+	// GoEnvFlags set GOPROXY and friends for go mod init and tidy
+	GoEnvFlags
+}
+
+func (cmd *IDEServerCommand) Execute(args []string) error {
+	return cmd.ExecuteContext(context.Background(), args)
+}
+
+func (cmd *IDEServerCommand) ExecuteContext(ctx context.Context, args []string) error {
+	gen := &project.Generator{}
+	if err := cmd.setTemplates(gen); err != nil {
+		return err
+	}
+	if _, err := gen.PackInfo(); err != nil {
+		return err
+	}
+	s := &ideServer{cmd: cmd, dir: pathutil.MustExpand(cmd.Dir), templates: gen.Templates}
+	return s.rpc().Serve(ctx, os.Stdin, os.Stdout)
+}
+
+// ideServer implements the methods. Nothing may print to stdout, which
+// carries the protocol.
+type ideServer struct {
+	cmd       *IDEServerCommand
+	dir       string
+	templates fs.FS // nil for the embedded pack
+}
+
+func (s *ideServer) rpc() *jsonrpc.Server {
+	return &jsonrpc.Server{Methods: map[string]jsonrpc.Handler{
+		"initialize":    s.initialize,
+		"shutdown":      func(context.Context, *jsonrpc.Call) (any, error) { return nil, nil },
+		"listTemplates": s.listTemplates,
+		"previewRender": s.previewRender,
+		"generate":      s.generate,
+		"diff":          s.diff,
+	}}
+}
+
+func (s *ideServer) initialize(ctx context.Context, call *jsonrpc.Call) (any, error) {
+	return map[string]any{
+		"serverInfo": map[string]string{"name": "project", "version": project.Version},
+		"methods":    []string{"listTemplates", "previewRender", "generate", "diff"},
+		"steps":      project.StepNames(),
+	}, nil
+}
+
+func (s *ideServer) listTemplates(ctx context.Context, call *jsonrpc.Call) (any, error) {
+	gen := &project.Generator{Templates: s.templates}
+	p, err := gen.PackInfo()
+	if err != nil {
+		return nil, err
+	}
+	templates, err := gen.TemplateNames()
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		packInfo
+		Templates []string `json:"templates"`
+		Kinds     []string `json:"kinds"`
+		Features  []string `json:"features"`
+	}{
+		packInfo:  packInfo{Name: defaultPack, Pack: p.Name, Version: p.Version, Description: p.Description, Languages: p.Languages},
+		Templates: templates,
+		Kinds:     project.KnownKinds(),
+		Features:  project.KnownFeatures(),
+	}, nil
+}
+
+// ideProject is the project a previewRender or generate asks for, as the
+// playground's form describes it, plus where generate writes it.
+type ideProject struct {
+	playgroundRequest
+	Lang string `json:"lang"`
+	Dir  string `json:"dir"`
+}
+
+// generator reads the call's project and sets up a generator for it.
+func (s *ideServer) generator(ctx context.Context, call *jsonrpc.Call) (*project.Generator, *metadata.Module, *ideProject, error) {
+	var in ideProject
+	if err := json.Unmarshal(call.Params, &in); err != nil {
+		return nil, nil, nil, err
+	}
+	ref, err := expandModule(in.Module)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	mod, err := metadata.Parse(ref)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid module: %w", err)
+	}
+	policy, err := orgPolicy()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	gen := &project.Generator{
+		Templates: s.templates,
+		Lang:      in.Lang,
+		Kind:      in.Kind,
+		Features:  in.Features,
+		Binaries:  in.Binaries,
+		Vars:      in.Vars,
+		Policy:    policy,
+		Context:   ctx,
+		Log:       logs.Logger(),
+	}
+	return gen, mod, &in, nil
+}
+
+// previewRender renders the project without writing it.
+func (s *ideServer) previewRender(ctx context.Context, call *jsonrpc.Call) (any, error) {
+	gen, mod, _, err := s.generator(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	files, err := gen.Preview(mod.Path)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"files": previewFiles(files)}, nil
+}
+
+// generate runs a full generation, go mod steps and all, into dir, or a
+// directory named after the repository under the server's, reporting its
+// progress as it goes.
+func (s *ideServer) generate(ctx context.Context, call *jsonrpc.Call) (any, error) {
+	gen, mod, in, err := s.generator(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	dir := s.resolve(firstNonEmpty(in.Dir, mod.Repo))
+	hooks, err := hookPolicy()
+	if err != nil {
+		return nil, err
+	}
+	gen.HookPolicy = hooks
+	if err := s.cmd.setGoEnv(gen); err != nil {
+		return nil, err
+	}
+	gen.Events = func(e project.Event) {
+		if err := call.Progress(e); err != nil {
+			logs.Logger().Warnw("progress", "error", err)
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := gen.GenerateAll(mod.Path, dir); err != nil {
+		return nil, err
+	}
+	files := make([]ideFile, 0, len(gen.Results))
+	for _, r := range gen.Results {
+		files = append(files, ideFile{Path: r.Path, Template: r.Template, Status: string(r.Status)})
+	}
+	return map[string]any{
+		"dir":    gen.Config.ProjectPath(),
+		"module": gen.Config.ModuleURL,
+		"files":  files,
+	}, nil
+}
+
+// ideFile is one file a generate wrote or left alone.
+type ideFile struct {
+	Path     string `json:"path"`
+	Template string `json:"template"`
+	Status   string `json:"status"`
+}
+
+// ideDiff is one file of a diff.
+type ideDiff struct {
+	Path     string `json:"path"`
+	Template string `json:"template"`
+	State    string `json:"state"`
+	Diff     string `json:"diff,omitempty"`
+}
+
+// diff compares a generated project with what the templates render now.
+func (s *ideServer) diff(ctx context.Context, call *jsonrpc.Call) (any, error) {
+	var in struct {
+		Dir string `json:"dir"`
+	}
+	if err := json.Unmarshal(call.Params, &in); err != nil {
+		return nil, err
+	}
+	if in.Dir == "" {
+		return nil, fmt.Errorf("missing dir")
+	}
+	gen := &project.Generator{Templates: s.templates, Context: ctx, Log: logs.Logger()}
+	diffs, err := gen.Diff(s.resolve(in.Dir))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ideDiff, 0, len(diffs))
+	for _, d := range diffs {
+		out = append(out, ideDiff{Path: d.Path, Template: d.Template, State: string(d.State), Diff: d.Diff})
+	}
+	return map[string]any{"files": out}, nil
+}
+
+// resolve makes dir absolute against the server's directory.
+func (s *ideServer) resolve(dir string) string {
+	dir = pathutil.MustExpand(dir)
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(s.dir, dir)
+}
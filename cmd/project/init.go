@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+
+	"github.com/robbyriverside/project"
+	"github.com/robbyriverside/project/internal/gitops"
+	"github.com/robbyriverside/project/internal/metadata"
+	logs "github.com/robbyriverside/project/logs"
+)
+
+// ---------------------------------------------------------------------
+// init command
+
+// InitCommand generates into the current directory, for repos that already
+// exist: a fresh clone, or a directory with a README and little else.
+type InitCommand struct {
+	Args struct {
+		Module string `positional-arg-name:"module-path" description:"Module path, repository URL or bare name (default: from git remote origin, else prompted)"`
+	} `positional-args:"yes"`
+
+	TemplateFlags
+	Force        bool   `short:"f" long:"force" description:"Generate even if files would be overwritten or go.mod names another module"`
+	BreakLock    bool   `long:"break-lock" description:"Generate even if another run holds .project.lock"`
+	Fsync        bool   `long:"fsync" description:"Sync each generated file to disk"`
+	Attest       bool   `long:"attest" description:"Write an SPDX SBOM and in-toto provenance to .project/ (kept up to date by update)"`
+	Vendor       bool   `long:"vendor" description:"Run go mod vendor after tidy and commit vendor/ (kept up to date by update)"`
+	Headers      bool   `long:"headers" description:"Start generated files with a provenance header marking them safe to edit or DO NOT EDIT, per the pack (kept by update)"`
+	Reproducible bool   `long:"reproducible" description:"Fix template time and file mtimes at SOURCE_DATE_EPOCH (default 1970-01-01)"`
+	Lang         string `long:"lang" description:"Language for generated docs, e.g. ja (falls back to the pack default)"`
+	Kind         string `short:"k" long:"kind" choice:"cli" choice:"library" choice:"tui" description:"Kind of project to generate (default: cli)"`
+	Preset       string `long:"preset" description:"Start from a named preset of kind and features, e.g. api, or source#name for one published by a pack URL, presets file URL or git repo (github.com/acme/golden-paths#service)"`
+
+	Binaries []string `long:"binary" description:"Generate cmd/<name> for a binary (repeatable; the first is the main one), e.g. --binary server --binary ctl"`
+	Targets  []string `long:"target" description:"Cross-compile every binary for GOOS/GOARCH with task build:all, into dist/ with checksums (repeatable), e.g. --target linux/amd64 --target darwin/arm64"`
+
+	VarFlags
+	GoEnvFlags
+	StepFlags
+	FeatureFlags
+
+	// gen is the run's generator, for the audit log
+	gen *project.Generator
+}
+
+func (cmd *InitCommand) Execute(args []string) error {
+	return cmd.ExecuteContext(context.Background(), args)
+}
+
+func (cmd *InitCommand) ExecuteContext(ctx context.Context, args []string) error {
+	moduleURL, err := cmd.modulePath()
+	if err != nil {
+		return err
+	}
+
+	policy, err := hookPolicy()
+	if err != nil {
+		return err
+	}
+	orgPolicy, err := orgPolicy()
+	if err != nil {
+		return err
+	}
+	gen := &project.Generator{
+		Config:       project.NewGenConfig(moduleURL, "."),
+		Fsync:        cmd.Fsync,
+		Lang:         cmd.Lang,
+		Kind:         cmd.Kind,
+		Features:     cmd.Names(),
+		Binaries:     cmd.Binaries,
+		Targets:      cmd.Targets,
+		HookPolicy:   policy,
+		Policy:       orgPolicy,
+		BreakLock:    cmd.BreakLock,
+		Reproducible: cmd.Reproducible,
+		Vendor:       cmd.Vendor,
+		Attest:       cmd.Attest,
+		Headers:      cmd.Headers,
+		Context:      ctx,
+		Log:          logs.Logger(),
+	}
+	cmd.gen = gen
+	if err := cmd.setVars(gen); err != nil {
+		return err
+	}
+	if err := cmd.setGoEnv(gen); err != nil {
+		return err
+	}
+	cmd.setSteps(gen)
+	if err := cmd.setTemplates(gen); err != nil {
+		return err
+	}
+	if err := applyPreset(gen, cmd.Preset, &cmd.TemplateFlags); err != nil {
+		return err
+	}
+	warnLang(gen)
+	if err := askVars(gen); err != nil {
+		return err
+	}
+
+	for _, w := range gen.Config.Warnings {
+		warn("warning: %s\n", w)
+	}
+
+	// Files already here are fine; only overwriting them is not
+	if !cmd.Force {
+		if err := gen.PreflightInit(); err != nil {
+			return err
+		}
+	}
+
+	if err := gen.GenerateAll(moduleURL, "."); err != nil {
+		printCommands(gen.Commands)
+		printHooks(gen.HookResults)
+		return msg.Errorf("failed to generate project: %w", err)
+	}
+
+	printSummary(gen.Results)
+	printCommands(gen.Commands)
+	printHooks(gen.HookResults)
+	say("Project generated in %s\nModule URL: %s\n", gen.Config.ProjectPath(), moduleURL)
+	rememberModule(gen.Config)
+	return nil
+}
+
+// modulePath takes the module path from the argument, else the git remote
+// origin of the current directory, else asks for it on stdin.
+func (cmd *InitCommand) modulePath() (string, error) {
+	ref := cmd.Args.Module
+	if ref == "" {
+		if url, err := gitops.RemoteURL(".", "origin"); err == nil {
+			ref = url
+		}
+	}
+	if ref == "" {
+		msg.Fprintf(os.Stderr, "Module path (e.g. github.com/you/app): ")
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		ref = strings.TrimSpace(line)
+	}
+	if ref == "" {
+		return "", msg.Errorf("no module path: pass one, or add a git remote origin")
+	}
+	ref, err := expandModule(ref)
+	if err != nil {
+		return "", err
+	}
+	mod, err := metadata.Parse(ref)
+	if err != nil {
+		return "", msg.Errorf("invalid module path %q: %w", ref, err)
+	}
+	return mod.Path, nil
+}
@@ -1,33 +1,249 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 
 	// Hypothetical references to your config, logs packages, etc.
 	"github.com/robbyriverside/project"
 	config "github.com/robbyriverside/project/config"
+	"github.com/robbyriverside/project/internal/metadata"
+	"github.com/robbyriverside/project/internal/term"
 	logs "github.com/robbyriverside/project/logs"
+	"github.com/robbyriverside/project/pathutil"
 )
 
 // Top-level CLI options
 type Options struct {
-	Verbose bool `short:"v" long:"verbose" description:"Enable verbose logging"`
+	Quiet   bool          `short:"q" long:"quiet" description:"Print only errors and the output a command exists for"`
+	Verbose []bool        `short:"v" long:"verbose" description:"Show every file and command of a run; -vv also traces each step to stderr"`
+	Timeout time.Duration `long:"timeout" description:"Cancel gen, init, update and upgrade-deps after this long, e.g. 5m (default: no limit)"`
+	NoColor bool          `long:"no-color" description:"Don't color output (also when NO_COLOR is set or output isn't a terminal)"`
 }
 
 func main() {
+	setupMessages()
+
 	var opts Options
 	parser := flags.NewParser(&opts, flags.Default)
+	parser.CommandHandler = func(cmd flags.Commander, args []string) error {
+		// Options are parsed by now, so logging is set up before the command runs
+		term.NoColor = opts.NoColor
+		setLevel(opts.Quiet, len(opts.Verbose))
+		logs.Options.Verbose = level >= Debug
+		logs.InitLogger(os.Getenv("ENV"))
+		logs.Logger().Debug("CLI started. All set.")
+		ctx, stop := commandContext(cmd)
+		defer stop()
+		err := runCommand(ctx, &opts, cmd, args)
+		if c, ok := cmd.(auditedCommand); ok {
+			recordAudit(ctx, commandName(parser.Command), c, err)
+		}
+		return err
+	}
+	parser.Name = "project"
+	parser.ShortDescription = "Scaffold and maintain Go projects"
+	parser.LongDescription = "Generates Go CLI and library projects from template packs, and keeps them up to date with update, diff and upgrade-deps."
 
 	parser.AddCommand("gen",
 		"Generate a new Go CLI project",
-		"Scaffolds a baseline CLI with config, logs, etc.",
+		"Scaffolds a baseline CLI with config, logs, etc. Variables the template pack declares in pack.yaml are set with --var or a blueprint's vars; on a terminal gen asks for the others, offering their defaults, and without one it takes the defaults and fails if a required variable has none",
 		&GenCommand{},
 	)
 
+	parser.AddCommand("init",
+		"Generate a project in the current directory",
+		"Generates into the current directory, taking the module path from the argument, the git remote origin, or a prompt; files already there are kept unless the project would overwrite them with other content",
+		&InitCommand{},
+	)
+
+	parser.AddCommand("update",
+		"Regenerate a project from its manifest",
+		"Runs template migrations, then re-renders the templates recorded in .project.yaml, skipping files whose content is unchanged. With --pr, in a git repository with no uncommitted changes, the update is made on a new branch, committed and pushed, and a pull request (GitLab: merge request) is opened into the current branch with a changelog of the pack versions, migrations and changed files",
+		&UpdateCommand{},
+	)
+
+	parser.AddCommand("diff",
+		"Compare a project against its templates",
+		"Re-renders the manifest's templates in memory and shows unified diffs against the working tree, without writing anything",
+		&DiffCommand{},
+	)
+
+	parser.AddCommand("explain",
+		"Show which template produced a file",
+		"Finds the project manifest above a generated file and reports its template, pack version, the variables the template uses, and whether the file was modified since generation",
+		&ExplainCommand{},
+	)
+
+	parser.AddCommand("verify",
+		"Check generated files against the manifest",
+		"Re-hashes the files listed in .project.yaml and reports those modified or missing since generation, plus extra files the manifest doesn't list; exits non-zero if any listed file changed",
+		&VerifyCommand{},
+	)
+
+	parser.AddCommand("status",
+		"Summarize a project's health",
+		"Checks that .project.yaml is there and valid, counts the files that drifted from the templates, compares the template pack the project was generated with to the one in use and the scaffold dependencies in go.mod to their pins, and lists recommended components the project lacks: CI, lint config, tests, README and LICENSE. Nothing is written",
+		&StatusCommand{})
+
+	parser.AddCommand("score",
+		"Grade a repository against the golden path",
+		"Scores a Go repository out of 100 on weighted checks: tests in every package, a CI pipeline that runs them, a linter config, security files (SECURITY.md, Dependabot or Renovate, govulncheck), README quality and a coverage task. An organization can reweigh them and add checks for files of its own in a rubric set with --rubric or config rubric, whose pass mark makes the command exit non-zero below it",
+		&ScoreCommand{})
+
+	parser.AddCommand("upgrade-deps",
+		"Upgrade scaffold dependencies",
+		"Bumps zap, go-flags and yaml in a generated project to the versions pinned by this generator, then runs go mod tidy",
+		&UpgradeDepsCommand{},
+	)
+
+	parser.AddCommand("rename-module",
+		"Move a project to a new module path",
+		"For a repository that was moved or renamed: rewrites go.mod, the imports of every Go file, the module path in the generated Taskfile, CI and other text files, and .project.yaml. Files left as generated still count as unchanged for update. The host must stay the same",
+		&RenameModuleCommand{},
+	)
+
+	parser.AddCommand("rename",
+		"Rename a project and its binary",
+		"Renames the project's package and, when it was named after the project, its binary: moves <name>.go and cmd/<name> and updates the Taskfile, README, Dockerfile, the package's users and .project.yaml. Files left as generated are rendered under the new name and still count as unchanged for update; edited ones only have the names replaced. The module path stays; see rename-module. Try --dry-run first",
+		&RenameCommand{},
+	)
+
+	parser.AddCommand("render",
+		"Render a single template",
+		"Renders one named template with the given module and --var overrides to stdout (or --output), without generating a project",
+		&RenderCommand{},
+	)
+
+	parser.AddCommand("eject-templates",
+		"Export the embedded templates",
+		"Writes the embedded template pack into a directory for customization with --templates; each file notes the generator version it came from",
+		&EjectTemplatesCommand{},
+	)
+
+	cacheParser, _ := parser.AddCommand("cache",
+		"Manage downloaded template packs and modules",
+		"Packs fetched with --templates <url> are cached by content hash under ~/.project/cache (or $PROJECT_CACHE) and reused offline. Go modules go to the module cache, which runs share when --modcache or config modcache names one",
+		&CacheCommand{},
+	)
+	cacheParser.AddCommand("clean", "Remove all cached template packs", "",
+		&CacheCleanCommand{})
+	cacheParser.AddCommand("warm", "Download the pinned dependencies into the module cache",
+		"Fills the module cache (--modcache or config modcache, else the go command's) with the modules generated projects require, at their pinned versions, and the modules they need, so go mod tidy in gen, update, serve and fleet runs sharing that cache doesn't download them again",
+		&CacheWarmCommand{})
+
+	bundleParser, _ := parser.AddCommand("bundle",
+		"Carry the generator to a network without internet access",
+		"A bundle is one .tar.gz file holding the template pack (--templates, else the embedded one), a module proxy with the pinned dependencies and every module they need, and the presets file named by config presets. Move it to the offline network and run gen --bundle <file>, or unpack it once with bundle apply and pass the directory: gen then uses the bundle's templates and presets and serves go mod from its modules, with the checksum database off",
+		&BundleCommand{},
+	)
+	bundleParser.AddCommand("create", "Write a bundle of templates, modules and presets",
+		"Downloads the modules into a module cache of the bundle's own, so it holds nothing else. Modules of features without pinned versions, like --with-wire or --with-itest, are only included when named with --module",
+		&BundleCreateCommand{})
+	bundleParser.AddCommand("apply", "Unpack a bundle for gen --bundle", "",
+		&BundleApplyCommand{})
+
+	packParser, _ := parser.AddCommand("pack",
+		"Inspect template packs",
+		"Remote template packs must be signed with minisign (<pack>.minisig) or cosign (<pack>.sig) by a key in the trust store (config trust_dir)",
+		&PackCommand{},
+	)
+	packParser.AddCommand("verify", "Check a pack's signature against the trust store", "",
+		&PackVerifyCommand{})
+
+	presetsParser, _ := parser.AddCommand("presets",
+		"Show gen presets",
+		"Presets bundle a project kind and features for gen --preset; the shipped ones can be extended or overridden by name in the template pack's presets.yaml and then the file named by config presets. Teams can also publish presets at a URL or in a git repo, used as --preset <source>#<name>",
+		&PresetsCommand{},
+	)
+	presetsParser.AddCommand("list", "List the available presets", "",
+		&PresetsListCommand{})
+	presetsParser.AddCommand("describe", "Show what a preset includes", "",
+		&PresetsDescribeCommand{})
+
+	blueprintParser, _ := parser.AddCommand("blueprint",
+		"Check blueprint files",
+		"Blueprints declare a project for gen --file. Editors with the YAML language server complete and check them when the file starts with # yaml-language-server: $schema=https://raw.githubusercontent.com/robbyriverside/project/main/blueprint.schema.json",
+		&BlueprintCommand{},
+	)
+	blueprintParser.AddCommand("validate", "Check blueprints without generating them",
+		"Reports every problem with its file, line and column, and exits non-zero when any blueprint is invalid",
+		&BlueprintValidateCommand{})
+	blueprintParser.AddCommand("schema", "Print the JSON Schema of blueprints", "",
+		&BlueprintSchemaCommand{})
+	blueprintParser.AddCommand("export", "Write the blueprint of a generated project",
+		"Reads .project.yaml and writes the blueprint that generates the same project with gen --file; settings blueprints can't hold, like --vendor, are noted in comments",
+		&BlueprintExportCommand{})
+
+	auditParser, _ := parser.AddCommand("audit",
+		"Show the log of generation runs",
+		"Each gen, init, update and add run is recorded with who ran it, when, the command line, the modules and template pack version it wrote, and whether it succeeded: one JSON line per run in config audit_log (~/.project/audit.jsonl), also POSTed to config audit_webhook when set. Set config audit=off to stop recording",
+		&AuditCommand{},
+	)
+	auditParser.AddCommand("list", "List the latest runs", "",
+		&AuditListCommand{})
+
+	parser.AddCommand("serve",
+		"Serve the generator as an HTTP API",
+		"For self-service scaffolding portals: GET /packs lists the template packs, GET /templates?pack=<name> one pack's templates, kinds, features and variables, and POST /generate?pack=<name> takes a blueprint and returns the projects as a .tar.gz, or with push=true creates and pushes a repository for each (public=true, description=<text>). Requests need the bearer token when one is set",
+		&ServeCommand{},
+	)
+
+	parser.AddCommand("watch",
+		"Check generated repositories for drift on every push",
+		"Listens for push webhooks from GitHub or GitLab at POST /webhook. A push to the branch of a repository in the --repos list clones it, verifies it against its manifest and diffs it against the templates as project verify and diff do, and files an issue on it when generated files were edited or deleted and when template updates are available, unless the same issue is already open. Pushes must be signed with the webhook secret when one is set. The list is YAML: repos, each with a repo such as github.com/acme/api and an optional branch (default: the repository's default branch), and labels for the issues",
+		&WatchCommand{},
+	)
+
+	fleetParser, _ := parser.AddCommand("fleet",
+		"Verify, update or score many repositories at once",
+		"Clones each repository named in the --repos list (the format project watch reads) or belonging to the --org, --parallel at a time, shallowly and into a temporary directory, runs the subcommand on it and reports the results for all of them, as JSON with --json. The API token for cloning and pull requests comes from config forge_token, else GITHUB_TOKEN or GITLAB_TOKEN",
+		&FleetCommand{},
+	)
+	fleetParser.AddCommand("verify", "Verify every repository against its manifest",
+		"Exits non-zero when a repository fails or its generated files differ from .project.yaml",
+		&FleetVerifyCommand{})
+	fleetParser.AddCommand("update", "Open a template update pull request on every repository",
+		"Runs project update --pr on each repository: the update is made on a new branch, pushed and proposed as a pull request (GitLab: merge request) with a changelog. Repositories already up to date are left alone; ones generated with another template pack fail",
+		&FleetUpdateCommand{})
+	fleetParser.AddCommand("score", "Grade every repository against the golden path",
+		"Runs project score on each repository and reports the average. Exits non-zero when a repository scores below the rubric's pass mark",
+		&FleetScoreCommand{})
+
+	parser.AddCommand("playground",
+		"Try the templates out in a local web page",
+		"Serves a page on this machine where you pick a kind and features and set vars, and see every file as gen would write it, re-rendered as you type. Download the result as a .tar.gz or .zip, or write it into a new directory under --dir",
+		&PlaygroundCommand{},
+	)
+
+	parser.AddCommand("mcp",
+		"Serve the generator to AI assistants over MCP",
+		"Speaks the Model Context Protocol on stdin and stdout, for assistants to start as a tool server. Its tools are list_templates, list_presets, describe_preset and generate, which takes a blueprint as an object checked against the blueprint schema and writes each project to a new directory under --dir",
+		&MCPCommand{},
+	)
+
+	parser.AddCommand("ide-server",
+		"Serve the generator to editor extensions over JSON-RPC",
+		"Speaks JSON-RPC 2.0 on stdin and stdout with the Content-Length framing of the Language Server Protocol, for editor extensions to start as a child process. Its methods are initialize, listTemplates, previewRender, which renders a project without writing it, generate, which writes it and sends each step, file and command as a $/progress notification, diff and shutdown; the exit notification stops the server",
+		&IDEServerCommand{},
+	)
+
+	addParser, _ := parser.AddCommand("add",
+		"Add to a generated project",
+		"Extends the code of a generated project in place with codemods, keeping it gofmt-formatted",
+		&AddCommand{},
+	)
+	addParser.AddCommand("config-field", "Add a key to the generated config package",
+		"Appends a field to the Config struct in config/config.go, sets its default, then regenerates docs/CONFIG.md and .env.example; config set checks values against --type",
+		&AddConfigFieldCommand{})
+
 	// 1) Register the parent 'config' command
 	cfgParser, _ := parser.AddCommand(
 		"config",
@@ -48,17 +264,23 @@ func main() {
 	parser.AddCommand("version", "Show version info", "",
 		&VersionCommand{})
 
+	docsParser, _ := parser.AddCommand("docs",
+		"Generate CLI documentation",
+		"Writes a man page or a Markdown reference built from the command definitions",
+		&DocsCommand{},
+	)
+	docsParser.AddCommand("man", "Write a man(1) page", "",
+		&DocsManCommand{parser: parser})
+	docsParser.AddCommand("markdown", "Write a Markdown CLI reference", "",
+		&DocsMarkdownCommand{parser: parser})
+
+	localizeCommands(parser.Command)
+
 	// Parse
 	_, err := parser.Parse()
 	if err != nil {
 		os.Exit(1)
 	}
-
-	// After parse, set up logging
-	logs.Options.Verbose = opts.Verbose
-	logs.InitLogger(os.Getenv("ENV"))
-
-	logs.Logger().Info("CLI started. All set.")
 }
 
 // ---------------------------------------------------------------------
@@ -69,7 +291,7 @@ type ConfigCommand struct{}
 func (cmd *ConfigCommand) Execute(args []string) error {
 	if len(args) == 0 {
 		// If user just runs 'fibber config' with no subcommand
-		return fmt.Errorf("please specify a subcommand: describe, set, or get")
+		return msg.Errorf("please specify a subcommand: describe, set, or get")
 	}
 	return nil // let subcommand logic run
 }
@@ -80,7 +302,7 @@ func (cmd *ConfigCommand) Execute(args []string) error {
 type ConfigDescribeCommand struct{}
 
 func (cmd *ConfigDescribeCommand) Execute(args []string) error {
-	fmt.Println("Fibber config file:", config.Path())
+	msg.Printf("Fibber config file: %s\n", config.Path())
 
 	lines, err := config.Describe()
 	if err != nil {
@@ -94,70 +316,709 @@ func (cmd *ConfigDescribeCommand) Execute(args []string) error {
 
 // gen command
 type GenCommand struct {
-	// A required positional argument for the GitHub URL, e.g. "https://github.com/rrs/shoes"
+	// A required positional argument for the repository URL, e.g. "https://github.com/rrs/shoes"
 	Args struct {
-		GitURL string `positional-arg-name:"gitURL" required:"true" description:"GitHub URL (same as git clone URL)"`
+		GitURL string `positional-arg-name:"gitURL" description:"Repository URL (same as git clone URL), module path, or a bare name completed by config module_host and module_owner; not needed with --from-existing, or --file when the blueprint names the module"`
 	} `positional-args:"yes"`
 
 	// An optional flag to override the output directory, defaults to repo name
-	Dir string `short:"d" long:"dir" description:"Output directory (defaults to repository name)"`
+	Dir string `short:"d" long:"dir" description:"Output directory (defaults to repository name); may use template fields, e.g. ~/src/{{.Owner}}/{{.ProjectName}}"`
+
+	// TemplateFlags point at a template pack directory or URL instead of the embedded one
+	TemplateFlags
+
+	// FromExisting adopts the Go repo in --dir (default ".") instead of starting fresh
+	FromExisting bool `long:"from-existing" description:"Adopt an existing Go repo: detect its module and binaries, generate only missing components"`
+
+	// Force skips the safety checks on the output directory
+	Force bool `short:"f" long:"force" description:"Generate even if the directory is non-empty, inside a Go module, or in a dirty git repo"`
+
+	// BreakLock takes over .project.lock from a run that died without releasing it
+	BreakLock bool `long:"break-lock" description:"Generate even if another run holds .project.lock"`
+
+	// Fsync makes every generated file durable before moving on
+	Fsync bool `long:"fsync" description:"Sync each generated file to disk"`
+
+	// Vendor commits the dependencies, for builds without module downloads
+	Vendor bool `long:"vendor" description:"Run go mod vendor after tidy and commit vendor/ (kept up to date by update)"`
+
+	// Attest records an SBOM and provenance for audits of where the code came from
+	Attest bool `long:"attest" description:"Write an SPDX SBOM and in-toto provenance to .project/ (kept up to date by update)"`
+
+	// Headers stamp files with where they came from and whether to edit them
+	Headers bool `long:"headers" description:"Start generated files with a provenance header marking them safe to edit or DO NOT EDIT, per the pack (kept by update)"`
+
+	// Reproducible pins the clock and file times so repeated runs match byte for byte
+	Reproducible bool `long:"reproducible" description:"Fix template time and file mtimes at SOURCE_DATE_EPOCH (default 1970-01-01)"`
+
+	// Lang picks localized README/CONTRIBUTING templates when the pack has them
+	Lang string `long:"lang" description:"Language for generated docs, e.g. ja (falls back to the pack default)"`
+
+	// Kind picks what to scaffold: a CLI (the default) or a library
+	Kind string `short:"k" long:"kind" choice:"cli" choice:"library" choice:"tui" description:"Kind of project to generate (default: cli)"`
+
+	// Preset bundles a kind and features; --kind and --with-* flags add to it
+	Preset string `long:"preset" description:"Start from a named preset of kind and features, e.g. api, or source#name for one published by a pack URL, presets file URL or git repo (github.com/acme/golden-paths#service)"`
+
+	// Binaries replaces the single cmd/<project> with one cmd/<name> per flag
+	Binaries []string `long:"binary" description:"Generate cmd/<name> for a binary (repeatable; the first is the main one), e.g. --binary server --binary ctl"`
+
+	// Targets add a build:all task cross-compiling for each
+	Targets []string `long:"target" description:"Cross-compile every binary for GOOS/GOARCH with task build:all, into dist/ with checksums (repeatable), e.g. --target linux/amd64 --target darwin/arm64"`
+
+	// VarFlags set template vars, such as those the pack declares
+	VarFlags
+
+	// GoEnvFlags set GOPROXY and friends for go mod init and tidy
+	GoEnvFlags
+
+	// StepFlags run part of the generation, e.g. only render
+	StepFlags
+
+	FeatureFlags
+
+	// BlueprintFlags declare the whole project in a file instead
+	BlueprintFlags
+
+	// CreateRemote creates the hosted repo, pushes the scaffold and protects the default branch
+	CreateRemote bool   `long:"create-remote" description:"Create the GitHub/GitLab repository via API and push the initial commit"`
+	Description  string `long:"description" description:"Repository description for --create-remote"`
+	Public       bool   `long:"public" description:"Make the repository created by --create-remote public (default private)"`
+
+	// Bundle generates offline from the pack and modules of an air-gapped bundle
+	Bundle string `long:"bundle" description:"Generate without internet access from a bundle file written by bundle create, or the directory bundle apply unpacked one into"`
+
+	// Archive packs the result into a file instead of leaving a directory
+	Archive string `long:"archive" description:"Write the project to a .tar.gz or .zip, e.g. dist/demo.tar.gz, instead of a directory; go mod steps run in a temporary one"`
+
+	// bundleDir is the unpacked --bundle
+	bundleDir string
+
+	// parentDir holds the projects of a blueprint set, from --dir, or
+	// those packed by --archive
+	parentDir string
+
+	// generated are the run's generators, for the audit log
+	generated []*project.Generator
 }
 
 func (cmd *GenCommand) Execute(args []string) error {
-	// Convert GitHub URL to module URL and get repo name
-	// Examples:
-	// https://github.com/user/repo.git -> github.com/user/repo
-	// git@github.com:user/repo.git -> github.com/user/repo
-	gitURL := cmd.Args.GitURL
-	moduleURL := gitURL
-	var repoName string
+	return cmd.ExecuteContext(context.Background(), args)
+}
 
-	// Handle https:// URLs
-	if strings.HasPrefix(gitURL, "https://github.com/") {
-		moduleURL = strings.TrimPrefix(gitURL, "https://")
+func (cmd *GenCommand) ExecuteContext(ctx context.Context, args []string) error {
+	if cmd.Bundle != "" {
+		cleanup, err := cmd.openBundle()
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+	}
+	if cmd.FromExisting {
+		return cmd.adopt(ctx)
+	}
+	set, err := cmd.loadBlueprints()
+	if err != nil {
+		return err
 	}
+	if cmd.Archive != "" {
+		return cmd.genArchive(ctx, set)
+	}
+	return cmd.genProjects(ctx, set)
+}
 
-	// Handle git@ URLs
-	if strings.HasPrefix(gitURL, "git@github.com:") {
-		moduleURL = strings.Replace(strings.TrimPrefix(gitURL, "git@"), ":", "/", 1)
+// genProjects generates the project the command line or blueprint names,
+// or every project of a blueprint set.
+func (cmd *GenCommand) genProjects(ctx context.Context, set *project.BlueprintSet) error {
+	if set != nil && len(set.Projects) > 1 {
+		return cmd.genSet(ctx, set)
 	}
+	var bp *project.Blueprint
+	if set != nil {
+		bp = set.Projects[0]
+		cmd.useBlueprint(bp)
+	}
+	gen, err := cmd.plan(ctx, bp)
+	if err != nil {
+		return err
+	}
+	return cmd.generate(gen)
+}
 
-	// Remove .git suffix if present
-	moduleURL = strings.TrimSuffix(moduleURL, ".git")
+// plan sets up the generator of one project, from bp when not nil, and
+// checks it can be written.
+func (cmd *GenCommand) plan(ctx context.Context, bp *project.Blueprint) (*project.Generator, error) {
+	if cmd.Args.GitURL == "" {
+		return nil, msg.Errorf("the required argument `gitURL` was not provided")
+	}
 
-	// Extract repository name from moduleURL
-	parts := strings.Split(moduleURL, "/")
-	if len(parts) >= 3 {
-		repoName = parts[len(parts)-1]
-	} else {
-		return fmt.Errorf("invalid GitHub URL format")
+	// A bare project name gets the usual host and owner
+	ref, err := expandModule(cmd.Args.GitURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert the clone URL to a module path and get repo name
+	// Examples:
+	// https://github.com/user/repo.git -> github.com/user/repo
+	// git@github.com:user/repo.git -> github.com/user/repo
+	// https://gitlab.com/org/group/subgroup/repo -> gitlab.com/org/group/subgroup/repo
+	mod, err := metadata.Parse(ref)
+	if err != nil {
+		return nil, msg.Errorf("invalid repository URL: %w", err)
 	}
+	moduleURL := mod.Path
+	repoName := mod.Repo
 
-	// Use repository name as output directory if --dir not specified
+	// Use repository name as output directory if --dir not specified;
+	// projects of a set go under --dir instead
 	outputDir := cmd.Dir
 	if outputDir == "" {
 		outputDir = repoName
 	}
-
-	// Create output directory if it doesn't exist
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+	if cmd.parentDir != "" && !filepath.IsAbs(outputDir) && !strings.HasPrefix(outputDir, "~") {
+		outputDir = filepath.Join(cmd.parentDir, outputDir)
 	}
 
 	// Create your Generator with a TmplDir pointing to where your .tmpl files live
+	policy, err := hookPolicy()
+	if err != nil {
+		return nil, err
+	}
+	orgPolicy, err := orgPolicy()
+	if err != nil {
+		return nil, err
+	}
 	gen := &project.Generator{
-		Config: project.NewGenConfig(moduleURL, outputDir),
+		Config:       project.NewGenConfig(moduleURL, outputDir),
+		Fsync:        cmd.Fsync,
+		Lang:         cmd.Lang,
+		Kind:         cmd.Kind,
+		Features:     cmd.Names(),
+		Binaries:     cmd.Binaries,
+		Targets:      cmd.Targets,
+		HookPolicy:   policy,
+		Policy:       orgPolicy,
+		BreakLock:    cmd.BreakLock,
+		Reproducible: cmd.Reproducible,
+		Vendor:       cmd.Vendor,
+		Attest:       cmd.Attest,
+		Headers:      cmd.Headers,
+		Context:      ctx,
+		Log:          logs.Logger(),
+	}
+	cmd.generated = append(cmd.generated, gen)
+	if err := cmd.setVars(gen); err != nil {
+		return nil, err
+	}
+	if bp != nil {
+		if err := bp.Apply(gen); err != nil {
+			return nil, err
+		}
+	}
+	if err := cmd.setGoEnv(gen); err != nil {
+		return nil, err
+	}
+	cmd.setSteps(gen)
+	if err := cmd.setPack(gen); err != nil {
+		return nil, err
+	}
+	if err := applyPreset(gen, cmd.Preset, &cmd.TemplateFlags); err != nil {
+		return nil, err
+	}
+	warnLang(gen)
+	if err := askVars(gen); err != nil {
+		return nil, err
+	}
+	if err := gen.Config.ResolveOutputDir(); err != nil {
+		return nil, err
+	}
+
+	for _, w := range gen.Config.Warnings {
+		warn("warning: %s\n", w)
+	}
+
+	// Refuse to merge into existing work unless forced
+	if !cmd.Force {
+		if err := gen.Preflight(); err != nil {
+			return nil, err
+		}
+	}
+	return gen, nil
+}
+
+// generate writes the project gen plans, then creates its remote if asked.
+func (cmd *GenCommand) generate(gen *project.Generator) error {
+	// Create output directory if it doesn't exist
+	if err := os.MkdirAll(gen.Config.ProjectPath(), 0755); err != nil {
+		return msg.Errorf("failed to create output directory: %w", err)
 	}
 
 	// Call GenerateAll with the processed moduleURL & dir
-	if err := gen.GenerateAll(moduleURL, outputDir); err != nil {
-		return fmt.Errorf("failed to generate project: %w", err)
+	moduleURL := gen.Config.ModuleURL
+	if err := gen.GenerateAll(moduleURL, gen.Config.OutputDir); err != nil {
+		printCommands(gen.Commands)
+		printHooks(gen.HookResults)
+		return msg.Errorf("failed to generate project: %w", err)
+	}
+
+	printSummary(gen.Results)
+	printCommands(gen.Commands)
+	printHooks(gen.HookResults)
+	if cmd.Archive == "" {
+		say("Project generated in %s\nModule URL: %s\n", gen.Config.ProjectPath(), moduleURL)
+	}
+	rememberModule(gen.Config)
+
+	if cmd.CreateRemote {
+		return cmd.createRemote(gen)
+	}
+	return nil
+}
+
+// createRemote creates and pushes the repository, then protects its default branch.
+func (cmd *GenCommand) createRemote(gen *project.Generator) error {
+	token := forgeToken(gen.Config.Host)
+	cloneURL, err := gen.CreateRemote(project.RemoteOptions{
+		Token:       token,
+		Description: cmd.Description,
+		Private:     !cmd.Public,
+	})
+	if err != nil {
+		return msg.Errorf("failed to create remote repository: %w", err)
+	}
+	say("Repository created and pushed: %s\n", cloneURL)
+
+	// Protection can fail on plans without it; the repo itself is fine
+	if err := gen.ProtectRemote(token); err != nil {
+		warn("warning: %v\n", err)
+	}
+	return nil
+}
+
+// FeatureFlags are the --with-* options for optional components, shared by gen and update.
+type FeatureFlags struct {
+	WithBench bool `long:"with-bench" description:"Add a benchmarks file plus bench and profile Task targets"`
+	WithFuzz  bool `long:"with-fuzz" description:"Add an example fuzz test and a fuzz Task target"`
+
+	WithCatalog bool `long:"with-catalog" description:"Add a Backstage catalog-info.yaml, with the owner, system and lifecycle from the blueprint's catalog"`
+
+	WithCoverage bool `long:"with-coverage" description:"Add cover/cover-html Task targets, a threshold script and CI coverage upload"`
+	WithItest    bool `long:"with-itest" description:"Add an itest/ package with testcontainers Postgres and Kafka helpers, an itest task and CI job"`
+	WithMocks    bool `long:"with-mocks" description:"Add go:generate mockgen directives (go.uber.org/mock) and a generate Task target"`
+	WithPlugins  bool `long:"with-plugins" description:"Add kubectl-style plugins: <binary>-<command> executables on PATH become commands, plus an example plugin"`
+
+	WithApp  bool `long:"with-app" description:"Add an app/ package wiring config, logger, store and server by constructor injection, plus a serve command"`
+	WithWire bool `long:"with-wire" description:"Like --with-app, wired by google/wire from a provider set, plus a wire Task target"`
+
+	WithWasm bool `long:"with-wasm" description:"Add cmd/<binary>-wasm for browsers (GOOS=js), a web/index.html harness, a build:wasm Task target and a CI artifact"`
+
+	WithRemoteConfig bool `long:"with-remote-config" description:"Add config.RemoteStore, layering config fetched from an HTTPS URL, Consul or etcd over the file, with a local fallback and TTL refresh"`
+
+	Private bool `long:"private" description:"Set GOPRIVATE and token-authenticated git in CI, for private modules on the project's host and owner"`
+}
+
+// Names returns the features enabled by the flags.
+func (f FeatureFlags) Names() []string {
+	var names []string
+	if f.WithBench {
+		names = append(names, "bench")
+	}
+	if f.WithFuzz {
+		names = append(names, "fuzz")
+	}
+	if f.WithCatalog {
+		names = append(names, "catalog")
+	}
+	if f.WithCoverage {
+		names = append(names, "coverage")
+	}
+	if f.WithItest {
+		names = append(names, "itest")
+	}
+	if f.WithMocks {
+		names = append(names, "mocks")
+	}
+	if f.WithPlugins {
+		names = append(names, "plugins")
+	}
+	if f.WithApp {
+		names = append(names, "app")
+	}
+	if f.WithWire {
+		names = append(names, "wire")
+	}
+	if f.WithWasm {
+		names = append(names, "wasm")
+	}
+	if f.WithRemoteConfig {
+		names = append(names, "remoteconfig")
+	}
+	if f.Private {
+		names = append(names, "private")
+	}
+	return names
+}
+
+// warnLang notes when the pack has no templates for the requested --lang.
+func warnLang(gen *project.Generator) {
+	if gen.Lang == "" {
+		return
+	}
+	pack, err := gen.PackInfo()
+	if err != nil || pack.Supports(gen.Lang) {
+		return
+	}
+	warn("warning: template pack %s has no %q templates, using %s\n", pack.Name, gen.Lang, pack.DefaultLanguage())
+}
+
+// forgeToken finds an API token: the forge_token config key, then the host's usual env var.
+func forgeToken(host string) string {
+	if token, err := config.Get("forge_token"); err == nil && token != "" {
+		return token
+	}
+	if strings.Contains(host, "gitlab") {
+		return os.Getenv("GITLAB_TOKEN")
+	}
+	return os.Getenv("GITHUB_TOKEN")
+}
+
+// adopt runs gen --from-existing against an existing repo.
+func (cmd *GenCommand) adopt(ctx context.Context) error {
+	dir := cmd.Dir
+	if dir == "" {
+		dir = "."
+	}
+	gen := &project.Generator{Fsync: cmd.Fsync, Lang: cmd.Lang, Kind: cmd.Kind, Features: cmd.Names(), BreakLock: cmd.BreakLock, Reproducible: cmd.Reproducible, Vendor: cmd.Vendor, Attest: cmd.Attest, Headers: cmd.Headers, Context: ctx, Log: logs.Logger()}
+	cmd.generated = append(cmd.generated, gen)
+	if err := cmd.setVars(gen); err != nil {
+		return err
+	}
+	if err := cmd.setGoEnv(gen); err != nil {
+		return err
+	}
+	if err := cmd.setPack(gen); err != nil {
+		return err
+	}
+	if err := applyPreset(gen, cmd.Preset, &cmd.TemplateFlags); err != nil {
+		return err
+	}
+	warnLang(gen)
+	if err := gen.Adopt(dir); err != nil {
+		return msg.Errorf("failed to adopt project: %w", err)
+	}
+
+	printSummary(gen.Results)
+	printCommands(gen.Commands)
+	say("Adopted %s\nModule URL: %s\n", dir, gen.Config.ModuleURL)
+	return nil
+}
+
+// ---------------------------------------------------------------------
+// update command
+
+type UpdateCommand struct {
+	Dir string `short:"d" long:"dir" default:"." description:"Project directory containing .project.yaml"`
+	TemplateFlags
+	Fsync        bool   `long:"fsync" description:"Sync each generated file to disk"`
+	BreakLock    bool   `long:"break-lock" description:"Update even if another run holds .project.lock"`
+	Reproducible bool   `long:"reproducible" description:"Fix template time and file mtimes at SOURCE_DATE_EPOCH (default 1970-01-01)"`
+	Attest       bool   `long:"attest" description:"Start writing an SPDX SBOM and in-toto provenance to .project/"`
+	Headers      bool   `long:"headers" description:"Start adding provenance headers; local edits to files marked safe to edit are then kept"`
+	Vendor       bool   `long:"vendor" description:"Start vendoring: run go mod vendor after tidy from now on"`
+	Lang         string `long:"lang" description:"Switch generated docs to this language (default: as recorded in .project.yaml)"`
+
+	// Binaries named here are added to those the project already has
+	Binaries []string `long:"binary" description:"Add cmd/<name> for another binary (repeatable)"`
+	Targets  []string `long:"target" description:"Add a GOOS/GOARCH for task build:all to cross-compile for (repeatable)"`
+
+	// Diff shows what update is about to overwrite
+	Diff bool `long:"diff" description:"First show the diffs of files with local edits, which update replaces"`
+	DiffFlags
+
+	// PR puts the update on a branch and opens a pull request for it
+	PR       bool   `long:"pr" description:"Update on a new branch, commit, push it and open a pull request with a changelog"`
+	PRBranch string `long:"pr-branch" description:"Branch for --pr (default: project-update-<pack version>)"`
+
+	VarFlags
+	GoEnvFlags
+	StepFlags
+
+	// Features named here are added to those the project already has
+	FeatureFlags
+
+	// gen is the run's generator, for the audit log
+	gen *project.Generator
+}
+
+func (cmd *UpdateCommand) Execute(args []string) error {
+	return cmd.ExecuteContext(context.Background(), args)
+}
+
+func (cmd *UpdateCommand) ExecuteContext(ctx context.Context, args []string) error {
+	policy, err := hookPolicy()
+	if err != nil {
+		return err
+	}
+	orgPolicy, err := orgPolicy()
+	if err != nil {
+		return err
+	}
+	gen := &project.Generator{Fsync: cmd.Fsync, Lang: cmd.Lang, Features: cmd.Names(), Binaries: cmd.Binaries, Targets: cmd.Targets, HookPolicy: policy, Policy: orgPolicy, BreakLock: cmd.BreakLock, Reproducible: cmd.Reproducible, Vendor: cmd.Vendor, Attest: cmd.Attest, Headers: cmd.Headers, Context: ctx, Log: logs.Logger()}
+	cmd.gen = gen
+	if err := cmd.setVars(gen); err != nil {
+		return err
+	}
+	if err := cmd.setGoEnv(gen); err != nil {
+		return err
+	}
+	cmd.setSteps(gen)
+	if err := cmd.setTemplates(gen); err != nil {
+		return err
+	}
+	warnLang(gen)
+	if cmd.Diff {
+		if err := cmd.showLocalEdits(); err != nil {
+			return err
+		}
+	}
+	if cmd.PR {
+		return cmd.pullRequest(gen)
+	}
+	applied, err := gen.Update(cmd.Dir)
+	for _, m := range applied {
+		say("  migrated  %s %s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		printCommands(gen.Commands)
+		printHooks(gen.HookResults)
+		return msg.Errorf("failed to update project: %w", err)
+	}
+
+	printSummary(gen.Results)
+	printCommands(gen.Commands)
+	printHooks(gen.HookResults)
+	return nil
+}
+
+// pullRequest runs the update on a branch and opens a pull request for it.
+func (cmd *UpdateCommand) pullRequest(gen *project.Generator) error {
+	m, err := project.LoadManifest(cmd.Dir)
+	if err != nil {
+		return msg.Errorf("failed to update project: %w", err)
+	}
+	host, _, _ := strings.Cut(m.ModuleURL, "/")
+	url, applied, err := gen.UpdatePullRequest(cmd.Dir, project.PullRequestOptions{Token: forgeToken(host), Branch: cmd.PRBranch})
+	for _, m := range applied {
+		say("  migrated  %s %s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		printCommands(gen.Commands)
+		printHooks(gen.HookResults)
+		return msg.Errorf("failed to update project: %w", err)
 	}
+	if url == "" {
+		say("Project is up to date; no pull request opened\n")
+		return nil
+	}
+	printSummary(gen.Results)
+	printCommands(gen.Commands)
+	printHooks(gen.HookResults)
+	say("Opened %s\n", url)
+	return nil
+}
+
+// showLocalEdits shows the diffs of customized and conflicting files, whose
+// local edits update replaces with the templates' output.
+func (cmd *UpdateCommand) showLocalEdits() error {
+	gen := &project.Generator{Lang: cmd.Lang, Features: cmd.Names(), Binaries: cmd.Binaries, Targets: cmd.Targets, Log: logs.Logger()}
+	if err := cmd.setTemplates(gen); err != nil {
+		return err
+	}
+	diffs, err := gen.Diff(cmd.Dir)
+	if err != nil {
+		return msg.Errorf("failed to diff project: %w", err)
+	}
+	view := cmd.newView()
+	for _, d := range diffs {
+		if d.State == project.DiffCustomized || d.State == project.DiffConflict {
+			view.File(string(d.State), d.Path, d.Diff)
+		}
+	}
+	return view.Show()
+}
+
+// ---------------------------------------------------------------------
+// diff command
+
+type DiffCommand struct {
+	Dir string `short:"d" long:"dir" default:"." description:"Project directory containing .project.yaml"`
+	TemplateFlags
+	All bool `short:"a" long:"all" description:"Also list files that match their templates"`
+	DiffFlags
+}
+
+func (cmd *DiffCommand) Execute(args []string) error {
+	gen := &project.Generator{Log: logs.Logger()}
+	if err := cmd.setTemplates(gen); err != nil {
+		return err
+	}
+	diffs, err := gen.Diff(cmd.Dir)
+	if err != nil {
+		return msg.Errorf("failed to diff project: %w", err)
+	}
+
+	view := cmd.newView()
+	for _, d := range diffs {
+		if d.State == project.DiffClean && !cmd.All {
+			continue
+		}
+		view.File(string(d.State), d.Path, d.Diff)
+	}
+	return view.Show()
+}
+
+// ---------------------------------------------------------------------
+// explain command
+
+type ExplainCommand struct {
+	Args struct {
+		File string `positional-arg-name:"file" required:"true" description:"A file inside a generated project"`
+	} `positional-args:"yes"`
+
+	TemplateFlags
+}
+
+func (cmd *ExplainCommand) Execute(args []string) error {
+	gen := &project.Generator{Log: logs.Logger()}
+	if err := cmd.setTemplates(gen); err != nil {
+		return err
+	}
+	ex, err := gen.Explain(pathutil.MustExpand(cmd.Args.File))
+	if err != nil {
+		return msg.Errorf("failed to explain %s: %w", cmd.Args.File, err)
+	}
+
+	status := "unmodified since generation"
+	switch {
+	case ex.Missing:
+		status = "missing from the working tree"
+	case ex.Modified:
+		status = "modified since generation"
+	}
+	msg.Printf("file:      %s\nproject:   %s\ntemplate:  %s\npack:      %s %s\nstatus:    %s\n",
+		ex.Path, ex.ProjectDir, ex.Template, ex.Pack, ex.PackVersion, status)
+	if len(ex.Vars) > 0 {
+		msg.Printf("variables:\n")
+		for _, k := range ex.SortedVars() {
+			msg.Printf("  %-20s %s\n", k, ex.Vars[k])
+		}
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------
+// verify command
+
+type VerifyCommand struct {
+	Dir  string `short:"d" long:"dir" default:"." description:"Project directory containing .project.yaml"`
+	JSON bool   `long:"json" description:"Print the report as JSON"`
+	All  bool   `short:"a" long:"all" description:"Also list files that match the manifest"`
+}
 
-	fmt.Printf("Project generated in ./%s\nModule URL: %s\n", outputDir, moduleURL)
+func (cmd *VerifyCommand) Execute(args []string) error {
+	report, err := project.Verify(pathutil.MustExpand(cmd.Dir))
+	if err != nil {
+		return msg.Errorf("failed to verify project: %w", err)
+	}
+
+	if cmd.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		for _, f := range report.Files {
+			if f.State == project.VerifyOK && !cmd.All {
+				continue
+			}
+			msg.Printf("%s %s\n", label(string(f.State), 10), f.Path)
+		}
+	}
+	if !report.Clean() {
+		return msg.Errorf("project files differ from %s", project.ManifestName)
+	}
 	return nil
 }
 
+// ---------------------------------------------------------------------
+// upgrade-deps command
+
+type UpgradeDepsCommand struct {
+	Dir    string `short:"d" long:"dir" default:"." description:"Project directory containing go.mod"`
+	DryRun bool   `short:"n" long:"dry-run" description:"Only report what would change"`
+	GoEnvFlags
+}
+
+func (cmd *UpgradeDepsCommand) Execute(args []string) error {
+	return cmd.ExecuteContext(context.Background(), args)
+}
+
+func (cmd *UpgradeDepsCommand) ExecuteContext(ctx context.Context, args []string) error {
+	gen := &project.Generator{Context: ctx, Log: logs.Logger()}
+	if err := cmd.setGoEnv(gen); err != nil {
+		return err
+	}
+	changes, err := gen.UpgradeDeps(cmd.Dir, cmd.DryRun)
+	if err != nil {
+		return msg.Errorf("failed to upgrade dependencies: %w", err)
+	}
+
+	upgraded := 0
+	for _, c := range changes {
+		if c.From == c.To {
+			detail("  current   %s %s\n", c.Path, c.From)
+			continue
+		}
+		upgraded++
+		say("  upgraded  %s %s -> %s\n", c.Path, c.From, c.To)
+	}
+	if cmd.DryRun {
+		say("%d dependencies would be upgraded (dry run)\n", upgraded)
+	} else {
+		say("%d dependencies upgraded\n", upgraded)
+	}
+	return nil
+}
+
+// printSummary lists each generated file with what happened to it.
+func printSummary(results []project.FileResult) {
+	counts := map[project.FileStatus]int{}
+	for _, r := range results {
+		counts[r.Status]++
+		detail("  %s %s\n", label(string(r.Status), 9), r.Path)
+	}
+	summary := msg.Sprintf("%d created, %d updated, %d unchanged",
+		counts[project.StatusCreated], counts[project.StatusUpdated], counts[project.StatusUnchanged])
+	if n := counts[project.StatusSkipped]; n > 0 {
+		summary += msg.Sprintf(", %d skipped", n)
+	}
+	if n := counts[project.StatusKept]; n > 0 {
+		summary += msg.Sprintf(", %d kept", n)
+	}
+	if n := counts[project.StatusMerged]; n > 0 {
+		summary += msg.Sprintf(", %d merged", n)
+	}
+	if n := counts[project.StatusConflict]; n > 0 {
+		summary += msg.Sprintf(", %d with conflicts", n)
+	}
+	say("%s\n", summary)
+	if counts[project.StatusConflict] > 0 {
+		say("Resolve the <<<<<<< current / >>>>>>> generated markers in the files with conflicts\n")
+	}
+}
+
 // ---------------------------------------------------------------------
 // config set
 
@@ -170,11 +1031,11 @@ type ConfigSetCommand struct {
 
 func (cmd *ConfigSetCommand) Execute(args []string) error {
 	if err := config.Set(cmd.Args.Key, cmd.Args.Value); err != nil {
-		return fmt.Errorf("error setting config key '%s': %w", cmd.Args.Key, err)
+		return msg.Errorf("error setting config key '%s': %w", cmd.Args.Key, err)
 	}
 
 	val, _ := config.Get(cmd.Args.Key)
-	fmt.Printf("%s = %s\n", cmd.Args.Key, val)
+	say("%s = %s\n", cmd.Args.Key, val)
 	return nil
 }
 
@@ -202,6 +1063,6 @@ func (cmd *ConfigGetCommand) Execute(args []string) error {
 type VersionCommand struct{}
 
 func (cmd *VersionCommand) Execute(args []string) error {
-	fmt.Println("Project CLI - version 0.0.1 (dev)")
+	msg.Printf("Project CLI - version %s (dev)\n", project.Version)
 	return nil
 }
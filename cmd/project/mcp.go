@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/robbyriverside/project"
+	"github.com/robbyriverside/project/internal/mcp"
+	logs "github.com/robbyriverside/project/logs"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// ---------------------------------------------------------------------
+// mcp command
+
+// MCPCommand serves the generator to AI assistants over the Model Context
+// Protocol on stdin and stdout. Its tools list the templates and presets
+// and generate the projects of a blueprint, which the generate tool's
+// input schema describes in full.
+type MCPCommand struct {
+	Dir string `short:"d" long:"dir" default:"." description:"Directory generated projects are written under"`
+
+	// TemplateFlags pick the pack the tools list and generate from
+	TemplateFlags
+
+	// GoEnvFlags set GOPROXY and friends for go mod init and tidy
+	GoEnvFlags
+}
+
+func (cmd *MCPCommand) Execute(args []string) error {
+	return cmd.ExecuteContext(context.Background(), args)
+}
+
+func (cmd *MCPCommand) ExecuteContext(ctx context.Context, args []string) error {
+	gen := &project.Generator{}
+	if err := cmd.setTemplates(gen); err != nil {
+		return err
+	}
+	if _, err := gen.PackInfo(); err != nil {
+		return err
+	}
+	t := &mcpTools{cmd: cmd, dir: pathutil.MustExpand(cmd.Dir), templates: gen.Templates}
+	tools, err := t.tools()
+	if err != nil {
+		return err
+	}
+	s := &mcp.Server{Name: "project", Version: project.Version, Tools: tools}
+	return s.Serve(ctx, os.Stdin, os.Stdout)
+}
+
+// mcpTools implements the tools. Nothing may print to stdout, which
+// carries the protocol.
+type mcpTools struct {
+	cmd       *MCPCommand
+	dir       string
+	templates fs.FS // nil for the embedded pack
+}
+
+func (t *mcpTools) tools() ([]mcp.Tool, error) {
+	generateSchema, err := blueprintInputSchema()
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.Tool{
+		{
+			Name:        "list_templates",
+			Description: "Describe the template pack: its name and version, its templates, and the project kinds and optional features a blueprint can ask for.",
+			InputSchema: json.RawMessage(`{"type": "object", "properties": {}, "additionalProperties": false}`),
+			Call:        t.listTemplates,
+		},
+		{
+			Name:        "list_presets",
+			Description: "List the presets, named bundles of a kind and features that a blueprint can pick with preset.",
+			InputSchema: json.RawMessage(`{"type": "object", "properties": {}, "additionalProperties": false}`),
+			Call:        t.listPresets,
+		},
+		{
+			Name:        "describe_preset",
+			Description: "Show the kind, features and template pack a preset expands to, and where it was defined.",
+			InputSchema: json.RawMessage(`{"type": "object", "properties": {"name": {"type": "string", "description": "Preset name, from list_presets"}}, "required": ["name"], "additionalProperties": false}`),
+			Call:        t.describePreset,
+		},
+		{
+			Name:        "generate",
+			Description: "Generate Go projects from a blueprint: one project, or a set of projects that use each other. Each project is written to its own new directory, named after it, and is ready to build.",
+			InputSchema: generateSchema,
+			Call:        t.generate,
+		},
+	}, nil
+}
+
+// blueprintInputSchema is the generate tool's input: the blueprint, as an
+// object the published schema checks, and where to write the projects.
+func blueprintInputSchema() (json.RawMessage, error) {
+	var blueprint map[string]any
+	if err := json.Unmarshal(project.BlueprintSchema(), &blueprint); err != nil {
+		return nil, err
+	}
+	// The blueprint's $refs point at the root's $defs, so they move up
+	defs := blueprint["$defs"]
+	for _, key := range []string{"$schema", "$id", "$defs"} {
+		delete(blueprint, key)
+	}
+	return json.Marshal(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"blueprint": blueprint,
+			"dir": map[string]any{
+				"type":        "string",
+				"description": "Directory to write the projects under, relative to the server's; default the server's directory",
+			},
+		},
+		"required":             []string{"blueprint"},
+		"additionalProperties": false,
+		"$defs":                defs,
+	})
+}
+
+func (t *mcpTools) listTemplates(ctx context.Context, args json.RawMessage) (any, error) {
+	gen := &project.Generator{Templates: t.templates}
+	p, err := gen.PackInfo()
+	if err != nil {
+		return nil, err
+	}
+	templates, err := gen.TemplateNames()
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		packInfo
+		Templates []string `json:"templates"`
+		Kinds     []string `json:"kinds"`
+		Features  []string `json:"features"`
+	}{
+		packInfo:  packInfo{Name: defaultPack, Pack: p.Name, Version: p.Version, Description: p.Description, Languages: p.Languages},
+		Templates: templates,
+		Kinds:     project.KnownKinds(),
+		Features:  project.KnownFeatures(),
+	}, nil
+}
+
+// presetInfo is a preset as the tools report it.
+type presetInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Kind        string   `json:"kind,omitempty"`
+	Features    []string `json:"features,omitempty"`
+	Templates   string   `json:"templates,omitempty"`
+	Source      string   `json:"source,omitempty"`
+}
+
+func (t *mcpTools) presets() ([]project.Preset, error) {
+	return findPresets(&project.Generator{Templates: t.templates, Log: logs.Logger()}, "")
+}
+
+func (t *mcpTools) listPresets(ctx context.Context, args json.RawMessage) (any, error) {
+	presets, err := t.presets()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]presetInfo, 0, len(presets))
+	for _, p := range presets {
+		out = append(out, presetInfo{Name: p.Name, Description: p.Description})
+	}
+	return map[string]any{"presets": out}, nil
+}
+
+func (t *mcpTools) describePreset(ctx context.Context, args json.RawMessage) (any, error) {
+	var in struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(args, &in); err != nil {
+		return nil, err
+	}
+	presets, err := t.presets()
+	if err != nil {
+		return nil, err
+	}
+	p, err := project.FindPreset(presets, in.Name)
+	if err != nil {
+		return nil, err
+	}
+	source := p.Source
+	if filepath.IsAbs(source) {
+		source = pathutil.Contract(source)
+	}
+	return presetInfo{
+		Name:        p.Name,
+		Description: p.Description,
+		Kind:        firstNonEmpty(p.Kind, project.DefaultKind),
+		Features:    p.Features,
+		Templates:   p.Templates,
+		Source:      source,
+	}, nil
+}
+
+// generated is a project the generate tool wrote.
+type generated struct {
+	Name   string `json:"name"`
+	Module string `json:"module"`
+	Dir    string `json:"dir"`
+	Files  int    `json:"files"`
+}
+
+// generate writes the projects of the blueprint under the tool's dir. It
+// won't touch directories that already exist, and the server, not the
+// blueprint, picks the templates and directories.
+func (t *mcpTools) generate(ctx context.Context, args json.RawMessage) (any, error) {
+	var in struct {
+		Blueprint json.RawMessage `json:"blueprint"`
+		Dir       string          `json:"dir"`
+	}
+	if err := json.Unmarshal(args, &in); err != nil {
+		return nil, err
+	}
+	if len(in.Blueprint) == 0 {
+		return nil, fmt.Errorf("missing blueprint")
+	}
+	// JSON is YAML, so the blueprint is checked as gen --file would
+	set, err := project.ParseBlueprintSet(in.Blueprint)
+	if err != nil {
+		return nil, err
+	}
+	if err := set.Check(); err != nil {
+		return nil, err
+	}
+	root := t.dir
+	if in.Dir != "" {
+		if !filepath.IsLocal(in.Dir) {
+			return nil, fmt.Errorf("dir %q must be relative and stay under %s", in.Dir, pathutil.Contract(t.dir))
+		}
+		root = filepath.Join(t.dir, in.Dir)
+	}
+	for _, bp := range set.Projects {
+		if bp.Templates != "" || bp.Dir != "" {
+			return nil, fmt.Errorf("project %s: templates and dir are set by the server, not the blueprint", bp.Name())
+		}
+		dir := filepath.Join(root, bp.Name())
+		if _, err := os.Stat(dir); err == nil {
+			return nil, fmt.Errorf("%s already exists", pathutil.Contract(dir))
+		}
+	}
+
+	gens, err := generateSet(ctx, set, t.templates, root, &t.cmd.GoEnvFlags, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]generated, 0, len(gens))
+	for _, gen := range gens {
+		out = append(out, generated{
+			Name:   gen.Config.ProjectName,
+			Module: gen.Config.ModuleURL,
+			Dir:    pathutil.Contract(gen.Config.ProjectPath()),
+			Files:  len(gen.Results),
+		})
+	}
+	logs.Logger().Infow("generate", "projects", len(out))
+	return map[string]any{"projects": out}, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+
+	config "github.com/robbyriverside/project/config"
+	"github.com/robbyriverside/project/i18n"
+)
+
+// The tool's own translations, one <lang>.yaml per language.
+//
+//go:embed messages
+var messagesFS embed.FS
+
+// msg prints the tool's messages in the user's language; English until
+// main calls setupMessages.
+var msg = i18n.New().Printer("")
+
+// setupMessages picks the message language: config lang, else the locale
+// from LC_ALL, LC_MESSAGES or LANG.
+func setupMessages() {
+	c := i18n.New()
+	if err := c.Load(messagesFS, "messages"); err != nil {
+		fmt.Fprintln(os.Stderr, "warning:", err)
+	}
+	lang, err := config.Get("lang")
+	if err != nil || lang == "" {
+		lang = i18n.Locale()
+	}
+	msg = c.Printer(lang)
+}
+
+// localizeCommands translates the descriptions of cmd, its options and
+// arguments, and its subcommands, so help and the docs commands use them.
+func localizeCommands(cmd *flags.Command) {
+	cmd.ShortDescription = msg.Sprintf(cmd.ShortDescription)
+	if cmd.LongDescription != "" {
+		cmd.LongDescription = msg.Sprintf(cmd.LongDescription)
+	}
+	localizeOptions(cmd.Group)
+	for _, a := range cmd.Args() {
+		a.Description = msg.Sprintf(a.Description)
+	}
+	for _, sub := range cmd.Commands() {
+		localizeCommands(sub)
+	}
+}
+
+func localizeOptions(g *flags.Group) {
+	for _, o := range g.Options() {
+		o.Description = msg.Sprintf(o.Description)
+	}
+	for _, sub := range g.Groups() {
+		localizeOptions(sub)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/robbyriverside/project"
+	config "github.com/robbyriverside/project/config"
+	"github.com/robbyriverside/project/internal/gitops"
+)
+
+// expandModule completes a bare project name such as "shoes" into a module
+// path. The host and owner come from config module_host and module_owner,
+// else from the last generated module, else github.com and git config
+// github.user. Anything with a slash or colon is already a path or URL.
+func expandModule(ref string) (string, error) {
+	if ref == "" || strings.ContainsAny(ref, "/:") {
+		return ref, nil
+	}
+
+	host, _ := config.Get("module_host")
+	owner, _ := config.Get("module_owner")
+	if last, _ := config.Get("last_module"); last != "" {
+		lastHost, lastOwner, _ := strings.Cut(last, "/")
+		if host == "" {
+			host = lastHost
+		}
+		if owner == "" && lastHost == host {
+			owner = lastOwner
+		}
+	}
+	if host == "" {
+		host = "github.com"
+	}
+	if owner == "" && host == "github.com" {
+		owner = gitops.Config(".", "github.user")
+	}
+	if owner == "" {
+		return "", msg.Errorf("no owner for %s: pass a full module path, or set config module_owner", ref)
+	}
+	return host + "/" + owner + "/" + ref, nil
+}
+
+// rememberModule records the host and owner of a generated module, the
+// default for the next bare project name.
+func rememberModule(gc *project.GenConfig) {
+	if gc.Host == "" || gc.Owner == "" {
+		return
+	}
+	if err := config.Set("last_module", gc.Host+"/"+gc.Owner); err != nil {
+		warn("warning: %v\n", err)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project"
+	config "github.com/robbyriverside/project/config"
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/gitops"
+)
+
+// fakeGitUser answers git config --get github.user with user, or fails as
+// git does when it isn't set.
+func fakeGitUser(user string) *execx.Recorder {
+	return &execx.Recorder{Respond: func(c execx.Call) (execx.Result, error) {
+		if c.String() != "git config --get github.user" || user == "" {
+			return execx.Result{}, errors.New("exit status 1")
+		}
+		return execx.Result{Stdout: []byte(user + "\n")}, nil
+	}}
+}
+
+func TestExpandModule(t *testing.T) {
+	defer func(r execx.Runner) { gitops.Runner = r }(gitops.Runner)
+
+	tests := []struct {
+		name   string
+		ref    string
+		config map[string]string // module_host, module_owner, last_module
+		user   string            // git config github.user
+		want   string
+		err    string
+	}{
+		{name: "full path", ref: "gitlab.com/acme/shoes", config: map[string]string{"module_host": "example.com", "module_owner": "other"}, want: "gitlab.com/acme/shoes"},
+		{name: "URL", ref: "https://github.com/acme/shoes", want: "https://github.com/acme/shoes"},
+		{name: "scp-style URL", ref: "git@github.com:acme/shoes.git", want: "git@github.com:acme/shoes.git"},
+		{name: "empty", ref: "", want: ""},
+		{name: "bare, github user", ref: "shoes", user: "octo", want: "github.com/octo/shoes"},
+		{name: "bare, no owner", ref: "shoes", err: "no owner for shoes"},
+		{name: "bare, host and owner", ref: "shoes", config: map[string]string{"module_host": "git.example.com", "module_owner": "platform"}, user: "octo", want: "git.example.com/platform/shoes"},
+		{name: "bare, owner only", ref: "shoes", config: map[string]string{"module_owner": "acme"}, user: "octo", want: "github.com/acme/shoes"},
+		{name: "bare, host only", ref: "shoes", config: map[string]string{"module_host": "gitlab.com"}, user: "octo", err: "no owner for shoes"},
+		{name: "bare, last module", ref: "shoes", config: map[string]string{"last_module": "gitlab.com/acme/tools"}, user: "octo", want: "gitlab.com/acme/tools/shoes"},
+		{name: "bare, last module on the same host", ref: "shoes", config: map[string]string{"module_host": "gitlab.com", "last_module": "gitlab.com/team"}, want: "gitlab.com/team/shoes"},
+		{name: "bare, last module on another host", ref: "shoes", config: map[string]string{"module_host": "github.com", "last_module": "gitlab.com/team"}, user: "octo", want: "github.com/octo/shoes"},
+		{name: "bare, owner over last module", ref: "shoes", config: map[string]string{"module_owner": "acme", "last_module": "github.com/team"}, want: "github.com/acme/shoes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			gitops.Runner = fakeGitUser(tt.user)
+			for k, v := range tt.config {
+				if err := config.Set(k, v); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := expandModule(tt.ref)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expandModule(%q) = %q, %v; want an error about %q", tt.ref, got, err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("expandModule(%q) = %q, %v; want %q", tt.ref, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRememberModule(t *testing.T) {
+	defer func(r execx.Runner) { gitops.Runner = r }(gitops.Runner)
+	gitops.Runner = fakeGitUser("octo")
+	t.Setenv("HOME", t.TempDir())
+
+	tests := []struct {
+		module string // generated
+		want   string // what a bare name expands to next
+	}{
+		{"gitlab.com/team/api", "gitlab.com/team/shoes"},
+		{"gitlab.com/acme/tools/cli", "gitlab.com/acme/tools/shoes"},
+		{"github.com/acme/web", "github.com/acme/shoes"},
+		{"localapp", "github.com/acme/shoes"}, // no host or owner to remember
+	}
+	for _, tt := range tests {
+		rememberModule(project.NewGenConfig(tt.module, t.TempDir()))
+		if got, err := expandModule("shoes"); err != nil || got != tt.want {
+			t.Errorf("after %s, expandModule(shoes) = %q, %v; want %q", tt.module, got, err, tt.want)
+		}
+	}
+}
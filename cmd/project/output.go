@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/term"
+)
+
+// Level is how much the tool reports. Commands print through say, detail
+// and warn, so -q and -v mean the same for every command.
+type Level int
+
+const (
+	Quiet   Level = iota // errors, and the output a command exists for
+	Normal               // plus notes, warnings and a summary of each run
+	Verbose              // plus every file and command of a run
+	Debug                // plus trace logs of each step on stderr
+)
+
+// level is set from -q and -v before a command runs.
+var level = Normal
+
+// setLevel maps -q and the number of -v flags to the level.
+func setLevel(quiet bool, verbose int) {
+	switch {
+	case quiet:
+		level = Quiet
+	case verbose > 1:
+		level = Debug
+	case verbose == 1:
+		level = Verbose
+	default:
+		level = Normal
+	}
+}
+
+// say prints a note or summary, unless -q.
+func say(key string, args ...any) {
+	if level >= Normal {
+		msg.Printf(key, args...)
+	}
+}
+
+// detail prints a line about one file or command, with -v.
+func detail(key string, args ...any) {
+	if level >= Verbose {
+		msg.Printf(key, args...)
+	}
+}
+
+// warn prints a warning on stderr, unless -q.
+func warn(key string, args ...any) {
+	if level >= Normal {
+		msg.Fprintf(os.Stderr, key, args...)
+	}
+}
+
+// indent prefixes each line of captured output for a report.
+func indent(out string) string {
+	return "    " + strings.ReplaceAll(strings.TrimRight(out, "\n"), "\n", "\n    ")
+}
+
+// styles color the file states and statuses commands report.
+var styles = map[string]term.Style{
+	"created":    term.Green,
+	"updated":    term.Yellow,
+	"unchanged":  term.Dim,
+	"skipped":    term.Dim,
+	"kept":       term.Cyan,
+	"merged":     term.Yellow,
+	"failed":     term.Red,
+	"denied":     term.Yellow,
+	"disabled":   term.Dim,
+	"customized": term.Yellow,
+	"drifted":    term.Cyan,
+	"conflict":   term.Red,
+	"missing":    term.Red,
+	"new":        term.Green,
+	"modified":   term.Yellow,
+	"extra":      term.Cyan,
+	"moved":      term.Cyan,
+	"ok":         term.Green,
+	"warning":    term.Yellow,
+	"unknown":    term.Dim,
+}
+
+// label pads a state or status to width, coloring it when stdout is.
+func label(s string, width int) string {
+	padded := fmt.Sprintf("%-*s", width, s)
+	if style, ok := styles[s]; ok && term.Color(os.Stdout) {
+		return style.Wrap(s) + padded[len(s):]
+	}
+	return padded
+}
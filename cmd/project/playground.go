@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/robbyriverside/project"
+	"github.com/robbyriverside/project/internal/archive"
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/metadata"
+	logs "github.com/robbyriverside/project/logs"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// ---------------------------------------------------------------------
+// playground command
+
+//go:embed playground.html
+var playgroundPage []byte
+
+// maxPreviewFile is the largest file the page shows; bigger ones are
+// listed with their size only.
+const maxPreviewFile = 256 << 10
+
+// PlaygroundCommand serves a local page for trying a template pack out:
+// pick a kind and features, set vars, and see each file as it would be
+// generated. The result can be downloaded or written under --dir.
+type PlaygroundCommand struct {
+	Addr string `long:"addr" default:"localhost:8070" description:"Address to listen on; only loopback addresses are allowed"`
+	Dir  string `short:"d" long:"dir" default:"." description:"Directory the page writes projects into"`
+
+	// TemplateFlags pick the pack to try out
+	TemplateFlags
+
+	// GoEnvFlags set GOPROXY and friends for go mod init and tidy
+	GoEnvFlags
+}
+
+func (cmd *PlaygroundCommand) Execute(args []string) error {
+	return cmd.ExecuteContext(context.Background(), args)
+}
+
+func (cmd *PlaygroundCommand) ExecuteContext(ctx context.Context, args []string) error {
+	// Resolve the pack once, so a bad --templates fails now rather than on
+	// the first request
+	gen := &project.Generator{}
+	if err := cmd.setTemplates(gen); err != nil {
+		return err
+	}
+	if _, err := gen.PackInfo(); err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", cmd.Addr)
+	if err != nil {
+		return msg.Errorf("failed to listen: %w", err)
+	}
+	// The page writes projects to disk, so it's for this machine only
+	if !isLoopback(ln.Addr()) {
+		ln.Close()
+		return msg.Errorf("the playground only listens on loopback addresses, not %s", ln.Addr())
+	}
+	p := &playground{cmd: cmd, dir: pathutil.MustExpand(cmd.Dir), templates: gen.Templates, port: listenPort(ln.Addr())}
+	srv := &http.Server{Handler: p.routes(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	say("Playground running on http://%s\n", ln.Addr())
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// playground serves the page and renders what it asks for.
+type playground struct {
+	cmd       *PlaygroundCommand
+	dir       string
+	templates fs.FS        // nil for the embedded pack
+	port      string       // the port it listens on
+	runner    execx.Runner // runs go for generated projects; nil for the host
+}
+
+func (p *playground) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", p.handlePage)
+	mux.HandleFunc("GET /options", p.handleOptions)
+	mux.Handle("POST /preview", requireJSON(p.handlePreview))
+	mux.Handle("POST /download", requireJSON(p.handleDownload))
+	mux.Handle("POST /write", requireJSON(p.handleWrite))
+	return p.localOnly(mux)
+}
+
+// localOnly refuses requests that don't name the playground by a loopback
+// host and its port. A site that rebinds its own name to 127.0.0.1 can
+// reach the port, but its requests still carry that name.
+func (p *playground) localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, port, err := net.SplitHostPort(r.Host)
+		if err != nil || port != p.port || !isLoopbackHost(host) {
+			http.Error(w, "the playground only answers to localhost", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopbackHost reports whether host is localhost or a loopback address.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// listenPort is the port of a listener's address.
+func listenPort(addr net.Addr) string {
+	_, port, _ := net.SplitHostPort(addr.String())
+	return port
+}
+
+// requireJSON refuses POSTs that aren't JSON. Another site's page can't
+// send JSON cross-origin without a CORS preflight, which the playground
+// never answers; localOnly covers sites that make themselves same-origin
+// by rebinding their name to localhost.
+func requireJSON(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if typ, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); typ != "application/json" {
+			http.Error(w, "want a JSON body", http.StatusUnsupportedMediaType)
+			return
+		}
+		next(w, r)
+	})
+}
+
+func (p *playground) handlePage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(playgroundPage)
+}
+
+func (p *playground) handleOptions(w http.ResponseWriter, r *http.Request) {
+	pack, err := (&project.Generator{Templates: p.templates}).PackInfo()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, struct {
+		Pack     string   `json:"pack"`
+		Version  string   `json:"version"`
+		Kinds    []string `json:"kinds"`
+		Kind     string   `json:"kind"`
+		Features []string `json:"features"`
+		Dir      string   `json:"dir"`
+	}{pack.Name, pack.Version, project.KnownKinds(), project.DefaultKind, project.KnownFeatures(), pathutil.Contract(p.dir)})
+}
+
+// playgroundRequest is the page's form, sent with every request.
+type playgroundRequest struct {
+	Module   string            `json:"module"`
+	Kind     string            `json:"kind"`
+	Features []string          `json:"features"`
+	Binaries []string          `json:"binaries"`
+	Vars     map[string]string `json:"vars"`
+}
+
+// generator reads the form and sets up a generator for it.
+func (p *playground) generator(w http.ResponseWriter, r *http.Request) (*project.Generator, *metadata.Module, error) {
+	var req playgroundRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBlueprintSize)).Decode(&req); err != nil {
+		return nil, nil, err
+	}
+	ref, err := expandModule(req.Module)
+	if err != nil {
+		return nil, nil, err
+	}
+	mod, err := metadata.Parse(ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid module: %w", err)
+	}
+	// Download and write both generate into a directory named for the
+	// repository
+	if !filepath.IsLocal(mod.Repo) || mod.Repo != filepath.Base(mod.Repo) {
+		return nil, nil, fmt.Errorf("invalid module: %s doesn't name a directory", mod.Path)
+	}
+	// The policy is checked on previews too, so its violations show as the
+	// form changes
+	policy, err := orgPolicy()
+	if err != nil {
+		return nil, nil, err
+	}
+	gen := &project.Generator{
+		Templates: p.templates,
+		Kind:      req.Kind,
+		Features:  req.Features,
+		Binaries:  req.Binaries,
+		Vars:      req.Vars,
+		Policy:    policy,
+		Context:   r.Context(),
+		Log:       logs.Logger(),
+	}
+	return gen, mod, nil
+}
+
+// previewFile is one rendered file as the page lists it. Content is left
+// out of binary and very large files.
+type previewFile struct {
+	Path     string `json:"path"`
+	Template string `json:"template"`
+	Size     int64  `json:"size"`
+	Content  string `json:"content,omitempty"`
+	Shown    bool   `json:"shown"`
+}
+
+func (p *playground) handlePreview(w http.ResponseWriter, r *http.Request) {
+	gen, mod, err := p.generator(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	files, err := gen.Preview(mod.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, previewFiles(files))
+}
+
+// previewFiles lists rendered files as the page shows them.
+func previewFiles(files []project.RenderedFile) []previewFile {
+	out := make([]previewFile, 0, len(files))
+	for _, f := range files {
+		pf := previewFile{Path: f.Path, Template: f.Template, Size: f.Size}
+		if !f.Streamed() {
+			pf.Size = int64(len(f.Data))
+			if pf.Size <= maxPreviewFile && utf8.Valid(f.Data) {
+				pf.Content, pf.Shown = string(f.Data), true
+			}
+		}
+		out = append(out, pf)
+	}
+	return out
+}
+
+// handleDownload generates the project in a temporary directory, go mod
+// steps and all, and sends it as a .tar.gz, or a .zip with format=zip.
+func (p *playground) handleDownload(w http.ResponseWriter, r *http.Request) {
+	gen, mod, err := p.generator(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	root, err := os.MkdirTemp("", "project-playground-")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(root)
+	if err := p.generate(gen, mod, filepath.Join(root, mod.Repo)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	format, contentType := archive.TarGz, "application/gzip"
+	if r.URL.Query().Get("format") == "zip" {
+		format, contentType = archive.Zip, "application/zip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", mod.Repo+"."+string(format)))
+	if err := archive.Write(w, root, format); err != nil {
+		logs.Logger().Warnw("download", "error", err)
+	}
+}
+
+// handleWrite generates the project in a new directory under --dir. It
+// won't touch a directory that already exists.
+func (p *playground) handleWrite(w http.ResponseWriter, r *http.Request) {
+	gen, mod, err := p.generator(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dir := filepath.Join(p.dir, mod.Repo)
+	if _, err := os.Stat(dir); err == nil {
+		http.Error(w, fmt.Sprintf("%s already exists", pathutil.Contract(dir)), http.StatusConflict)
+		return
+	}
+	if err := p.generate(gen, mod, dir); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	say("Project generated in %s\nModule URL: %s\n", gen.Config.ProjectPath(), gen.Config.ModuleURL)
+	writeJSON(w, struct {
+		Dir string `json:"dir"`
+	}{pathutil.Contract(gen.Config.ProjectPath())})
+}
+
+// generate runs a full generation of mod into dir, as gen would.
+func (p *playground) generate(gen *project.Generator, mod *metadata.Module, dir string) error {
+	policy, err := hookPolicy()
+	if err != nil {
+		return err
+	}
+	gen.HookPolicy = policy
+	gen.Runner = p.runner
+	if err := p.cmd.setGoEnv(gen); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return gen.GenerateAll(mod.Path, dir)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+// fakeGo answers go commands without running them: go mod init writes a
+// go.mod and go mod edit -json reports no requires.
+func fakeGo(c execx.Call) (execx.Result, error) {
+	switch {
+	case strings.HasPrefix(c.String(), "go mod init "):
+		return execx.Result{}, os.WriteFile(filepath.Join(c.Dir, "go.mod"), []byte("module "+c.Args[2]+"\n"), 0o644)
+	case c.String() == "go mod edit -json":
+		return execx.Result{Stdout: []byte("{}")}, nil
+	}
+	return execx.Result{}, nil
+}
+
+// newPlayground serves a playground writing under a temporary directory,
+// with a home of its own so no user config applies.
+func newPlayground(t *testing.T) (*playground, *httptest.Server) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	p := &playground{cmd: &PlaygroundCommand{}, dir: t.TempDir(), runner: &execx.Recorder{Respond: fakeGo}}
+	srv := httptest.NewServer(p.routes())
+	t.Cleanup(srv.Close)
+	p.port = listenPort(srv.Listener.Addr())
+	return p, srv
+}
+
+// post sends body as JSON to the playground at path.
+func post(t *testing.T, srv *httptest.Server, path, body string) *http.Response {
+	t.Helper()
+	resp, err := srv.Client().Post(srv.URL+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+const demoForm = `{"module": "github.com/example/demo", "features": ["bench"], "vars": {"team": "core"}}`
+
+func TestPlaygroundLocalOnly(t *testing.T) {
+	_, srv := newPlayground(t)
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	for host, want := range map[string]int{
+		"localhost:" + port:     http.StatusOK,
+		"127.0.0.1:" + port:     http.StatusOK,
+		"evil.example:" + port:  http.StatusForbidden, // rebound to 127.0.0.1
+		"localhost:1":           http.StatusForbidden,
+		"localhost":             http.StatusForbidden,
+		"evil.example.:" + port: http.StatusForbidden,
+	} {
+		req, _ := http.NewRequest("GET", srv.URL+"/options", nil)
+		req.Host = host
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET /options with Host %s = %d, want %d", host, resp.StatusCode, want)
+		}
+	}
+
+	// A form post, which needs no CORS preflight, is refused
+	resp, err := srv.Client().Post(srv.URL+"/write", "text/plain", strings.NewReader(demoForm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("POST /write as text/plain = %d, want %d", resp.StatusCode, http.StatusUnsupportedMediaType)
+	}
+}
+
+func TestPlaygroundPreview(t *testing.T) {
+	_, srv := newPlayground(t)
+	resp := post(t, srv, "/preview", demoForm)
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("POST /preview = %d: %s", resp.StatusCode, body)
+	}
+	var files []previewFile
+	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
+		t.Fatal(err)
+	}
+	shown := make(map[string]string)
+	for _, f := range files {
+		if f.Shown {
+			shown[f.Path] = f.Content
+		}
+	}
+	if !strings.Contains(shown["cmd/demo/main.go"], "package main") {
+		t.Errorf("preview shows main.go as %q", shown["cmd/demo/main.go"])
+	}
+	if _, ok := shown["bench_test.go"]; !ok {
+		t.Errorf("preview is missing the bench feature's bench_test.go")
+	}
+
+	resp = post(t, srv, "/preview", `{"module": "github.com/example/demo", "features": ["nosuch"]}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST /preview with an unknown feature = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestPlaygroundDownload(t *testing.T) {
+	_, srv := newPlayground(t)
+	resp := post(t, srv, "/download", demoForm)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/gzip" {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("POST /download = %d %s: %s", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	for _, want := range []string{"demo/go.mod", "demo/cmd/demo/main.go", "demo/bench_test.go"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("download holds %v, want %s", names, want)
+		}
+	}
+}
+
+func TestPlaygroundWrite(t *testing.T) {
+	p, srv := newPlayground(t)
+	resp := post(t, srv, "/write", demoForm)
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("POST /write = %d: %s", resp.StatusCode, body)
+	}
+	if _, err := os.Stat(filepath.Join(p.dir, "demo", "cmd", "demo", "main.go")); err != nil {
+		t.Errorf("POST /write didn't write the project: %v", err)
+	}
+
+	// An existing directory is left alone
+	resp = post(t, srv, "/write", demoForm)
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("second POST /write = %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+}
+
+func TestPlaygroundEscapingModule(t *testing.T) {
+	p, srv := newPlayground(t)
+	for _, path := range []string{"/download", "/write"} {
+		resp := post(t, srv, path, `{"module": "github.com/acme/.."}`)
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), "invalid module") {
+			t.Errorf("POST %s = %d %s, want %d", path, resp.StatusCode, body, http.StatusBadRequest)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(p.dir), "go.mod")); !os.IsNotExist(err) {
+		t.Errorf("a project was written outside the playground's directory: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+
+	"github.com/robbyriverside/project"
+	config "github.com/robbyriverside/project/config"
+	"github.com/robbyriverside/project/internal/packcache"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// orgPolicy loads the organization policy the policy config key names, a
+// file or a URL; nil if it names none. A URL that can't be reached falls
+// back to its cached copy, so a policy never lapses offline.
+func orgPolicy() (*project.Policy, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	source := cfg.Policy
+	if source == "" {
+		return nil, nil
+	}
+	data, offline, err := orgFile(source)
+	if err != nil {
+		return nil, msg.Errorf("failed to load the policy: %w", err)
+	}
+	if offline {
+		warn("warning: %s is unreachable, using the cached policy\n", source)
+	}
+	return project.ParsePolicy(data, source)
+}
+
+// orgFile reads a file an organization publishes for its projects, such
+// as its policy: a local file, or a URL fetched through the pack cache.
+// offline is set when the URL couldn't be reached and the cached copy was
+// used.
+func orgFile(source string) (data []byte, offline bool, err error) {
+	if !packcache.IsURL(source) {
+		data, err = os.ReadFile(pathutil.MustExpand(source))
+		return data, false, err
+	}
+	cache, err := newPackCache()
+	if err != nil {
+		return nil, false, err
+	}
+	return cache.Get(source)
+}
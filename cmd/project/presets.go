@@ -0,0 +1,226 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/robbyriverside/project"
+	config "github.com/robbyriverside/project/config"
+	"github.com/robbyriverside/project/internal/packcache"
+	logs "github.com/robbyriverside/project/logs"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// userPresets is the configured presets file, ~/.project/presets.yaml by default.
+func userPresets() string {
+	file, err := config.Get("presets")
+	if err != nil || file == "" {
+		file = "~/.project/presets.yaml"
+	}
+	return pathutil.MustExpand(file)
+}
+
+// splitPresetRef separates a source#name preset reference. A #sha256=
+// fragment pins a pack URL and is not a preset name.
+func splitPresetRef(ref string) (source, name string, ok bool) {
+	i := strings.LastIndex(ref, "#")
+	if i < 0 || strings.Contains(ref[i+1:], "=") {
+		return "", ref, false
+	}
+	return ref[:i], ref[i+1:], true
+}
+
+// findPresets returns the presets for a --preset or --from source: those
+// published at source, else the shipped, pack and user presets of gen.
+func findPresets(gen *project.Generator, source string) ([]project.Preset, error) {
+	if source == "" {
+		return gen.Presets(userPresets())
+	}
+	presets, err := remotePresets(source)
+	if err != nil {
+		return nil, msg.Errorf("failed to load presets from %s: %w", source, err)
+	}
+	return presets, nil
+}
+
+// remotePresets loads the presets published at source, so a platform team
+// can hand every developer the same golden paths. source is one of:
+//
+//   - a template pack URL (.tar.gz) with presets.yaml beside pack.yaml;
+//     its presets generate from that pack unless they name another
+//   - any other URL, read as a presets file
+//   - a git repository, e.g. github.com/acme/golden-paths, with
+//     presets.yaml at its root
+//
+// Remote presets may only name templates by URL, so the pack is fetched
+// and its signature checked like any other --templates URL.
+func remotePresets(source string) ([]project.Preset, error) {
+	cache, err := newPackCache()
+	if err != nil {
+		return nil, err
+	}
+	var presets []project.Preset
+	switch {
+	case packcache.IsURL(source) && isArchive(source):
+		entry, ferr := cache.Fetch(source)
+		if ferr != nil {
+			return nil, ferr
+		}
+		warnOffline(entry.Offline, source)
+		presets, err = readPresets(filepath.Join(entry.Dir, project.PresetsName), source)
+		for i := range presets {
+			if presets[i].Templates == "" {
+				presets[i].Templates = source
+			}
+		}
+	case packcache.IsURL(source):
+		data, offline, gerr := cache.Get(source)
+		if gerr != nil {
+			return nil, gerr
+		}
+		warnOffline(offline, source)
+		presets, err = project.ParsePresets(data, source)
+	default:
+		dir, offline, rerr := cache.Repo(source)
+		if rerr != nil {
+			return nil, rerr
+		}
+		warnOffline(offline, source)
+		presets, err = readPresets(filepath.Join(dir, project.PresetsName), source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range presets {
+		if p.Templates != "" && !packcache.IsURL(p.Templates) {
+			return nil, msg.Errorf("preset %s must name its templates by URL, not %q", p.Name, p.Templates)
+		}
+	}
+	return presets, nil
+}
+
+func readPresets(path, source string) ([]project.Preset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, msg.Errorf("failed to read %s: %w", project.PresetsName, err)
+	}
+	return project.ParsePresets(data, source)
+}
+
+// isArchive reports whether a URL, less any fragment, names a .tar.gz pack.
+func isArchive(url string) bool {
+	url, _, _ = strings.Cut(url, "#")
+	return strings.HasSuffix(url, ".tar.gz") || strings.HasSuffix(url, ".tgz")
+}
+
+func warnOffline(offline bool, source string) {
+	if offline {
+		warn("warning: %s is unreachable, using cached presets\n", source)
+	}
+}
+
+// applyPreset expands a --preset into gen's kind and features; an empty
+// ref leaves gen as it is. A preset naming a template pack switches gen to
+// it, unless --templates or a bundle chose one already.
+func applyPreset(gen *project.Generator, ref string, tf *TemplateFlags) error {
+	if ref == "" {
+		return nil
+	}
+	source, name, _ := splitPresetRef(ref)
+	presets, err := findPresets(gen, source)
+	if err != nil {
+		return err
+	}
+	p, err := project.FindPreset(presets, name)
+	if err != nil {
+		return err
+	}
+	if p.Templates != "" && tf.Templates == "" && gen.Bundle == "" {
+		tf.Templates = p.Templates
+		if err := tf.setTemplates(gen); err != nil {
+			return err
+		}
+	}
+	return p.Apply(gen)
+}
+
+// ---------------------------------------------------------------------
+// presets command
+
+type PresetsCommand struct{}
+
+func (cmd *PresetsCommand) Execute(args []string) error {
+	return msg.Errorf("please specify a subcommand: list, describe")
+}
+
+// PresetSource selects where the presets commands look.
+type PresetSource struct {
+	From string `long:"from" description:"Show the presets published at a pack URL, presets file URL or git repo instead"`
+	TemplateFlags
+}
+
+func (f *PresetSource) presets() ([]project.Preset, error) {
+	gen := &project.Generator{Log: logs.Logger()}
+	if f.From == "" {
+		if err := f.setTemplates(gen); err != nil {
+			return nil, err
+		}
+	}
+	return findPresets(gen, f.From)
+}
+
+// PresetsListCommand prints each preset with its description.
+type PresetsListCommand struct {
+	PresetSource
+}
+
+func (cmd *PresetsListCommand) Execute(args []string) error {
+	presets, err := cmd.presets()
+	if err != nil {
+		return err
+	}
+	for _, p := range presets {
+		msg.Printf("%-12s %s\n", p.Name, p.Description)
+	}
+	return nil
+}
+
+// PresetsDescribeCommand shows the kind and features a preset expands to.
+type PresetsDescribeCommand struct {
+	Args struct {
+		Name string `positional-arg-name:"preset" required:"true" description:"Preset name"`
+	} `positional-args:"yes"`
+
+	PresetSource
+}
+
+func (cmd *PresetsDescribeCommand) Execute(args []string) error {
+	presets, err := cmd.presets()
+	if err != nil {
+		return err
+	}
+	p, err := project.FindPreset(presets, cmd.Args.Name)
+	if err != nil {
+		return err
+	}
+	kind := p.Kind
+	if kind == "" {
+		kind = project.DefaultKind
+	}
+	features := strings.Join(p.Features, ", ")
+	if features == "" {
+		features = "(none)"
+	}
+	source := p.Source
+	if filepath.IsAbs(source) {
+		source = pathutil.Contract(source)
+	}
+	msg.Printf("preset:      %s\ndescription: %s\nkind:        %s\nfeatures:    %s\nsource:      %s\n",
+		p.Name, p.Description, kind, features, source)
+	if p.Templates != "" {
+		msg.Printf("templates:   %s\n", p.Templates)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project"
+)
+
+func TestSplitPresetRef(t *testing.T) {
+	tests := []struct {
+		ref, source, name string
+		ok                bool
+	}{
+		{"api", "", "api", false},
+		{"https://example.com/presets.yaml#api", "https://example.com/presets.yaml", "api", true},
+		{"github.com/acme/golden-paths#service", "github.com/acme/golden-paths", "service", true},
+		{"https://example.com/pack.tar.gz#sha256=abc", "", "https://example.com/pack.tar.gz#sha256=abc", false},
+		{"https://example.com/pack.tar.gz#sha256=abc#api", "https://example.com/pack.tar.gz#sha256=abc", "api", true},
+	}
+	for _, tt := range tests {
+		source, name, ok := splitPresetRef(tt.ref)
+		if source != tt.source || name != tt.name || ok != tt.ok {
+			t.Errorf("splitPresetRef(%q) = %q, %q, %v; want %q, %q, %v", tt.ref, source, name, ok, tt.source, tt.name, tt.ok)
+		}
+	}
+}
+
+// packTarball builds a template pack .tar.gz holding files.
+func packTarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: "pack/" + name, Mode: 0o644, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRemotePresets(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PROJECT_CACHE", t.TempDir())
+
+	const golden = "service:\n  description: Golden service\n  kind: cli\n  features: [coverage]\n"
+	files := map[string]string{
+		"/presets.yaml": golden + "  templates: https://example.com/go-service.tar.gz\n",
+		"/local.yaml":   "service:\n  templates: ./my-pack\n",
+		"/pack.tar.gz":  string(packTarball(t, map[string]string{"pack.yaml": "name: golden\nversion: 1.0.0\n", "presets.yaml": golden})),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	// A presets file keeps the pack its presets name
+	presets, err := remotePresets(srv.URL + "/presets.yaml")
+	if err != nil {
+		t.Fatalf("remotePresets(presets file) error: %v", err)
+	}
+	if len(presets) != 1 || presets[0].Name != "service" || presets[0].Templates != "https://example.com/go-service.tar.gz" || presets[0].Source != srv.URL+"/presets.yaml" {
+		t.Errorf("remotePresets(presets file) = %+v", presets)
+	}
+
+	// A pack's presets generate from the pack
+	presets, err = remotePresets(srv.URL + "/pack.tar.gz")
+	if err != nil {
+		t.Fatalf("remotePresets(pack) error: %v", err)
+	}
+	if len(presets) != 1 || presets[0].Templates != srv.URL+"/pack.tar.gz" {
+		t.Errorf("remotePresets(pack) = %+v, want the pack as templates", presets)
+	}
+
+	if _, err := remotePresets(srv.URL + "/missing.yaml"); err == nil {
+		t.Error("remotePresets(missing file) succeeded, want an error")
+	}
+	if _, err := remotePresets(srv.URL + "/local.yaml"); err == nil || !strings.Contains(err.Error(), "by URL") {
+		t.Errorf("remotePresets(local templates) = %v, want templates named by URL", err)
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "presets.yaml"), []byte(golden), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, argv := range [][]string{{"init", "-q"}, {"add", "-A"}, {"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "presets"}} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, argv...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", argv, err, out)
+		}
+	}
+	presets, err = remotePresets("file://" + repo)
+	if err != nil {
+		t.Fatalf("remotePresets(git repo) error: %v", err)
+	}
+	if len(presets) != 1 || presets[0].Name != "service" || presets[0].Templates != "" || presets[0].Kind != "cli" {
+		t.Errorf("remotePresets(git repo) = %+v", presets)
+	}
+}
+
+func TestApplyPreset(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PROJECT_CACHE", t.TempDir())
+
+	pack := packTarball(t, map[string]string{"pack.yaml": "name: golden\nversion: 1.0.0\n"})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/golden.tar.gz":
+			w.Write(pack)
+		case "/presets.yaml":
+			w.Write([]byte("service:\n  kind: cli\n  features: [itest]\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	user := "golden:\n  kind: cli\n  features: [bench]\n  templates: " + srv.URL + "/golden.tar.gz\n" +
+		"cli:\n  description: Ours\n  kind: cli\n  features: [fuzz]\n"
+	if err := os.MkdirAll(filepath.Join(home, ".project"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".project", "presets.yaml"), []byte(user), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		ref       string
+		kind      string   // --kind
+		features  []string // --feature
+		templates string   // --templates
+		wantKind  string
+		want      []string
+		wantPack  bool   // gen switched to the preset's pack
+		err       string // "" for success
+	}{
+		{name: "no preset", kind: "library", features: []string{"bench"}, wantKind: "library", want: []string{"bench"}},
+		{name: "shipped", ref: "api", wantKind: "cli", want: []string{"coverage", "itest", "mocks"}},
+		{name: "case and space", ref: " API ", wantKind: "cli", want: []string{"coverage", "itest", "mocks"}},
+		{name: "features add to the preset's", ref: "api", features: []string{"bench"}, wantKind: "cli", want: []string{"coverage", "itest", "mocks", "bench"}},
+		{name: "same kind", ref: "library", kind: "library", wantKind: "library", want: []string{"bench", "coverage"}},
+		{name: "other kind", ref: "library", kind: "cli", err: "is for library projects, not cli"},
+		{name: "user overrides shipped", ref: "cli", wantKind: "cli", want: []string{"fuzz"}},
+		{name: "preset pack", ref: "golden", wantKind: "cli", want: []string{"bench"}, wantPack: true},
+		{name: "templates override the preset pack", ref: "golden", templates: t.TempDir(), wantKind: "cli", want: []string{"bench"}},
+		{name: "remote", ref: srv.URL + "/presets.yaml#service", wantKind: "cli", want: []string{"itest"}},
+		{name: "unknown", ref: "nosuch", err: `unknown preset "nosuch"`},
+		{name: "unknown remote", ref: srv.URL + "/presets.yaml#nosuch", err: `unknown preset "nosuch"`},
+		{name: "unreachable remote", ref: srv.URL + "/missing.yaml#service", err: "failed to load presets"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := &project.Generator{Kind: tt.kind, Features: tt.features}
+			tf := &TemplateFlags{Templates: tt.templates, InsecureSkipVerify: true}
+			if err := tf.setTemplates(gen); err != nil {
+				t.Fatal(err)
+			}
+			explicit := gen.Templates
+
+			err := applyPreset(gen, tt.ref, tf)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("applyPreset(%q) = %v, want an error about %q", tt.ref, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyPreset(%q) error: %v", tt.ref, err)
+			}
+			if gen.Kind != tt.wantKind || !slices.Equal(gen.Features, tt.want) {
+				t.Errorf("applyPreset(%q) = kind %q, features %q; want %q, %q", tt.ref, gen.Kind, gen.Features, tt.wantKind, tt.want)
+			}
+			switch {
+			case tt.wantPack:
+				if tf.Templates != srv.URL+"/golden.tar.gz" || gen.Templates == nil {
+					t.Errorf("applyPreset(%q) templates = %q, want the preset's pack", tt.ref, tf.Templates)
+				}
+			case tf.Templates != tt.templates || gen.Templates != explicit:
+				t.Errorf("applyPreset(%q) templates = %q, want %q kept", tt.ref, tf.Templates, tt.templates)
+			}
+		})
+	}
+}
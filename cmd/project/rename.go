@@ -0,0 +1,100 @@
+package main
+
+import (
+	"github.com/robbyriverside/project"
+	"github.com/robbyriverside/project/internal/diff"
+	logs "github.com/robbyriverside/project/logs"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// ---------------------------------------------------------------------
+// rename-module command
+
+// RenameModuleCommand moves a generated project to a new module path after
+// its repository moved or was renamed.
+type RenameModuleCommand struct {
+	Args struct {
+		Module string `positional-arg-name:"module-path" required:"true" description:"New module path, repository URL or bare name"`
+	} `positional-args:"yes"`
+
+	Dir       string `short:"d" long:"dir" default:"." description:"Project directory containing .project.yaml"`
+	BreakLock bool   `long:"break-lock" description:"Rename even if another run holds .project.lock"`
+}
+
+func (cmd *RenameModuleCommand) Execute(args []string) error {
+	ref, err := expandModule(cmd.Args.Module)
+	if err != nil {
+		return err
+	}
+	gen := &project.Generator{BreakLock: cmd.BreakLock, Log: logs.Logger()}
+	m, err := project.LoadManifest(cmd.Dir)
+	if err != nil {
+		return err
+	}
+	changed, err := gen.RenameModule(cmd.Dir, ref)
+	for _, path := range changed {
+		detail("  %s %s\n", label("updated", 9), pathutil.Contract(path))
+	}
+	if err != nil {
+		return msg.Errorf("failed to rename module: %w", err)
+	}
+	say("Module renamed from %s to %s in %d files\n", m.ModuleURL, gen.Config.ModuleURL, len(changed))
+	return nil
+}
+
+// ---------------------------------------------------------------------
+// rename command
+
+// RenameCommand renames a generated project and its binary in place. With
+// --dry-run it shows what would move and change instead.
+type RenameCommand struct {
+	Args struct {
+		Name string `positional-arg-name:"name" required:"true" description:"New project name"`
+	} `positional-args:"yes"`
+
+	Dir       string `short:"d" long:"dir" default:"." description:"Project directory containing .project.yaml"`
+	DryRun    bool   `short:"n" long:"dry-run" description:"Show the files that would move and their changes, without writing"`
+	BreakLock bool   `long:"break-lock" description:"Rename even if another run holds .project.lock"`
+	TemplateFlags
+	DiffFlags
+}
+
+func (cmd *RenameCommand) Execute(args []string) error {
+	gen := &project.Generator{BreakLock: cmd.BreakLock, Log: logs.Logger()}
+	if err := cmd.setTemplates(gen); err != nil {
+		return err
+	}
+	m, err := project.LoadManifest(cmd.Dir)
+	if err != nil {
+		return err
+	}
+	changes, err := gen.Rename(cmd.Dir, cmd.Args.Name, cmd.DryRun)
+	if err != nil {
+		return msg.Errorf("failed to rename project: %w", err)
+	}
+
+	if cmd.DryRun {
+		view := cmd.newView()
+		for _, c := range changes {
+			state, path := "updated", c.Path
+			if c.Moved() {
+				state, path = "moved", c.From+" -> "+c.Path
+			}
+			var unified string
+			if c.Edited() {
+				unified = diff.Unified(c.From, c.Path, string(c.Old), string(c.New), 3)
+			}
+			view.File(state, path, unified)
+		}
+		return view.Show()
+	}
+	for _, c := range changes {
+		if c.Moved() {
+			detail("  %s %s -> %s\n", label("moved", 9), c.From, c.Path)
+		} else {
+			detail("  %s %s\n", label("updated", 9), c.Path)
+		}
+	}
+	say("Project renamed from %s to %s in %d files\n", m.ProjectName, gen.Config.ProjectName, len(changes))
+	return nil
+}
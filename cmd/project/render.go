@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/robbyriverside/project"
+	"github.com/robbyriverside/project/internal/fileutils"
+	logs "github.com/robbyriverside/project/logs"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// ---------------------------------------------------------------------
+// render command
+
+// RenderCommand renders one template to stdout, for pack authors iterating on a file.
+type RenderCommand struct {
+	Args struct {
+		Template string `positional-arg-name:"template" required:"true" description:"Template name, e.g. main or main.tmpl"`
+	} `positional-args:"yes"`
+
+	Module string   `short:"m" long:"module" default:"github.com/example/app" description:"Module path used to derive the config"`
+	Vars   []string `long:"var" description:"Override a config field or set a template var (key=value, repeatable)"`
+	TemplateFlags
+	Output   string   `short:"o" long:"output" description:"Write to a file instead of stdout"`
+	Features []string `long:"feature" description:"Render as if an optional feature were enabled, e.g. bench (repeatable)"`
+}
+
+func (cmd *RenderCommand) Execute(args []string) error {
+	gen := &project.Generator{Config: project.NewGenConfig(cmd.Module, ""), Log: logs.Logger()}
+	if err := cmd.setTemplates(gen); err != nil {
+		return err
+	}
+	for _, kv := range cmd.Vars {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return msg.Errorf("invalid --var %q, expected key=value", kv)
+		}
+		gen.Config.SetVar(key, value)
+	}
+	gen.Config.Features = make(map[string]bool)
+	for _, name := range cmd.Features {
+		gen.Config.Features[name] = true
+	}
+
+	f, err := gen.Render(strings.TrimSuffix(cmd.Args.Template, ".tmpl"))
+	if err != nil {
+		return err
+	}
+
+	r, err := f.Reader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if cmd.Output == "" {
+		_, err := io.Copy(os.Stdout, r)
+		return err
+	}
+	return fileutils.WriteFrom(pathutil.MustExpand(cmd.Output), r, f.Mode)
+}
+
+// ---------------------------------------------------------------------
+// eject-templates command
+
+// EjectTemplatesCommand dumps the embedded pack for customization.
+type EjectTemplatesCommand struct {
+	Args struct {
+		Dir string `positional-arg-name:"dir" required:"true" description:"Directory to write the templates into"`
+	} `positional-args:"yes"`
+
+	Force bool `short:"f" long:"force" description:"Overwrite files in a non-empty directory"`
+}
+
+func (cmd *EjectTemplatesCommand) Execute(args []string) error {
+	dir := pathutil.MustExpand(cmd.Args.Dir)
+	written, err := project.EjectTemplates(dir, cmd.Force)
+	if err != nil {
+		return msg.Errorf("failed to eject templates: %w", err)
+	}
+	for _, p := range written {
+		detail("  ejected   %s\n", p)
+	}
+	say("%d files written to %s\nUse them with: project gen <url> --templates %s\n", len(written), dir, cmd.Args.Dir)
+	return nil
+}
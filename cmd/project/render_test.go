@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	pack := t.TempDir()
+	greet := "{{.ProjectName}} by {{.Owner}} for {{.Vars.team}}{{if .Features.bench}} with bench{{end}}\n"
+	if err := os.WriteFile(filepath.Join(pack, "greet.tmpl"), []byte(greet), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		vars     []string
+		features []string
+		want     string
+	}{
+		{name: "plain", template: "greet", vars: []string{"team=core"}, want: "demo by example for core\n"},
+		{name: ".tmpl suffix", template: "greet.tmpl", vars: []string{"team=core"}, want: "demo by example for core\n"},
+		{name: "field override", template: "greet", vars: []string{"owner=acme", "team=a=b"}, want: "demo by acme for a=b\n"},
+		{name: "feature", template: "greet", vars: []string{"team=core"}, features: []string{"bench"}, want: "demo by example for core with bench\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "out", "greet.txt")
+			cmd := &RenderCommand{Module: "github.com/example/demo", Vars: tt.vars, Output: out, Features: tt.features}
+			cmd.Templates = pack
+			cmd.Args.Template = tt.template
+			if err := cmd.Execute(nil); err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+			got, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("rendered %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, kv := range []string{"team", "=core"} {
+		cmd := &RenderCommand{Module: "github.com/example/demo", Vars: []string{kv}}
+		cmd.Templates = pack
+		cmd.Args.Template = "greet"
+		if err := cmd.Execute(nil); err == nil || !strings.Contains(err.Error(), "expected key=value") {
+			t.Errorf("Execute() with --var %q = %v, want an error about key=value", kv, err)
+		}
+	}
+}
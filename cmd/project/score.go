@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/robbyriverside/project"
+	config "github.com/robbyriverside/project/config"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// ScoreCommand grades a repository against the organization's rubric.
+type ScoreCommand struct {
+	Dir    string `short:"d" long:"dir" default:"." description:"Repository to score"`
+	Rubric string `long:"rubric" description:"Rubric file or URL (default: config rubric, else the built-in one)"`
+	JSON   bool   `long:"json" description:"Print the scorecard as JSON"`
+}
+
+func (cmd *ScoreCommand) Execute(args []string) error {
+	rubric, err := orgRubric(cmd.Rubric)
+	if err != nil {
+		return err
+	}
+	card, err := project.Score(pathutil.MustExpand(cmd.Dir), rubric)
+	if err != nil {
+		return msg.Errorf("failed to score project: %w", err)
+	}
+
+	if cmd.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(card); err != nil {
+			return err
+		}
+	} else {
+		for _, c := range card.Checks {
+			state := "ok"
+			switch {
+			case c.Earned == 0:
+				state = "missing"
+			case c.Earned < 1:
+				state = "warning"
+			}
+			line := fmt.Sprintf("%-10s %3d/%-3d %s %s", c.Name, c.Points(), c.Weight, label(state, 8), c.Detail)
+			msg.Printf("%s\n", strings.TrimRight(line, " "))
+		}
+		msg.Printf("score      %d/100 (rubric: %s)\n", card.Score, card.Rubric)
+	}
+	if !card.Passed() {
+		return msg.Errorf("score %d is below the pass mark of %d", card.Score, card.Pass)
+	}
+	return nil
+}
+
+// orgRubric loads the rubric source names, or else the one the rubric
+// config key names; nil, the built-in rubric, if neither does. Like a
+// policy, a rubric URL that can't be reached falls back to its cached copy.
+func orgRubric(source string) (*project.Rubric, error) {
+	if source == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, err
+		}
+		if source = cfg.Rubric; source == "" {
+			return nil, nil
+		}
+	}
+	data, offline, err := orgFile(source)
+	if err != nil {
+		return nil, msg.Errorf("failed to load the rubric: %w", err)
+	}
+	if offline {
+		warn("warning: %s is unreachable, using the cached rubric\n", source)
+	}
+	return project.ParseRubric(data, source)
+}
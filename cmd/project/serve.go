@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robbyriverside/project"
+	"github.com/robbyriverside/project/internal/archive"
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/metadata"
+	logs "github.com/robbyriverside/project/logs"
+)
+
+// ---------------------------------------------------------------------
+// serve command
+
+// maxBlueprintSize bounds the blueprint a POST /generate can send.
+const maxBlueprintSize = 1 << 20
+
+// ServeCommand runs the generator as an HTTP API, for self-service
+// scaffolding portals:
+//
+//	GET  /packs                 the template packs on offer
+//	GET  /templates?pack=name   a pack's templates, kinds, features and variables
+//	POST /generate?pack=name    a blueprint in, the projects as a .tar.gz out,
+//	                            or a .zip with format=zip; with push=true
+//	                            they are pushed to new remotes instead
+type ServeCommand struct {
+	Addr  string   `long:"addr" default:"localhost:8080" description:"Address to listen on, e.g. :8080 for every interface"`
+	Token string   `long:"token" description:"Require this bearer token on every request (default: $PROJECT_SERVE_TOKEN)"`
+	Packs []string `long:"pack" description:"Offer another template pack as name=source, a directory or .tar.gz URL (repeatable)"`
+
+	// TemplateFlags pick the default pack and whether remote ones must be signed
+	TemplateFlags
+
+	// GoEnvFlags set GOPROXY and friends for go mod init and tidy
+	GoEnvFlags
+}
+
+func (cmd *ServeCommand) Execute(args []string) error {
+	return cmd.ExecuteContext(context.Background(), args)
+}
+
+func (cmd *ServeCommand) ExecuteContext(ctx context.Context, args []string) error {
+	s, err := cmd.newServer()
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", cmd.Addr)
+	if err != nil {
+		return msg.Errorf("failed to listen: %w", err)
+	}
+	if s.token == "" && !isLoopback(ln.Addr()) {
+		warn("warning: serving on %s without --token; anyone who can reach it can generate and push projects\n", ln.Addr())
+	}
+	srv := &http.Server{Handler: s.routes(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	say("Serving the generator API on http://%s\n", ln.Addr())
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// isLoopback reports whether addr only takes local connections.
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
+
+// server holds what every request shares: the packs, resolved once.
+type server struct {
+	cmd    *ServeCommand
+	token  string
+	names  []string         // pack names in the order given, default first
+	packs  map[string]fs.FS // nil for the embedded pack
+	runner execx.Runner     // runs go for generated projects; nil for the host
+}
+
+// defaultPack is the name of the --templates pack, or the embedded one.
+const defaultPack = "default"
+
+// newServer resolves --templates and each --pack.
+func (cmd *ServeCommand) newServer() (*server, error) {
+	s := &server{
+		cmd:   cmd,
+		token: firstNonEmpty(cmd.Token, os.Getenv("PROJECT_SERVE_TOKEN")),
+		names: []string{defaultPack},
+		packs: make(map[string]fs.FS),
+	}
+	sources := append([]string{defaultPack + "=" + cmd.Templates}, cmd.Packs...)
+	for _, src := range sources {
+		name, source, ok := strings.Cut(src, "=")
+		if !ok || name == "" {
+			return nil, msg.Errorf("invalid --pack %q, expected name=source", src)
+		}
+		gen := &project.Generator{}
+		tf := TemplateFlags{Templates: source, InsecureSkipVerify: cmd.InsecureSkipVerify}
+		if err := tf.setTemplates(gen); err != nil {
+			return nil, err
+		}
+		if _, err := gen.PackInfo(); err != nil {
+			return nil, msg.Errorf("pack %s: %w", name, err)
+		}
+		if !slices.Contains(s.names, name) {
+			s.names = append(s.names, name)
+		}
+		s.packs[name] = gen.Templates
+	}
+	return s, nil
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /packs", s.handlePacks)
+	mux.HandleFunc("GET /templates", s.handleTemplates)
+	mux.HandleFunc("POST /generate", s.handleGenerate)
+	return s.authorize(mux)
+}
+
+// authorize checks the bearer token, when the server has one.
+func (s *server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			got, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+				http.Error(w, "missing or wrong bearer token", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// packInfo is a pack as GET /packs lists it.
+type packInfo struct {
+	Name        string   `json:"name"`
+	Pack        string   `json:"pack"`
+	Version     string   `json:"version"`
+	Description string   `json:"description,omitempty"`
+	Languages   []string `json:"languages,omitempty"`
+}
+
+func (s *server) handlePacks(w http.ResponseWriter, r *http.Request) {
+	var out []packInfo
+	for _, name := range s.names {
+		p, err := (&project.Generator{Templates: s.packs[name]}).PackInfo()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		out = append(out, packInfo{Name: name, Pack: p.Name, Version: p.Version, Description: p.Description, Languages: p.Languages})
+	}
+	writeJSON(w, out)
+}
+
+func (s *server) handleTemplates(w http.ResponseWriter, r *http.Request) {
+	fsys, name, err := s.pack(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	gen := &project.Generator{Templates: fsys}
+	p, err := gen.PackInfo()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	templates, err := gen.TemplateNames()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, struct {
+		packInfo
+		Templates []string               `json:"templates"`
+		Kinds     []string               `json:"kinds"`
+		Features  []string               `json:"features"`
+		Variables []project.PackVariable `json:"variables,omitempty"`
+	}{
+		packInfo:  packInfo{Name: name, Pack: p.Name, Version: p.Version, Description: p.Description, Languages: p.Languages},
+		Templates: templates,
+		Kinds:     project.KnownKinds(),
+		Features:  project.KnownFeatures(),
+		Variables: p.Variables,
+	})
+}
+
+// pack returns the pack the request's pack parameter names.
+func (s *server) pack(r *http.Request) (fs.FS, string, error) {
+	name := firstNonEmpty(r.URL.Query().Get("pack"), defaultPack)
+	fsys, ok := s.packs[name]
+	if !ok {
+		return nil, "", fmt.Errorf("no pack %q (packs: %s)", name, strings.Join(s.names, ", "))
+	}
+	return fsys, name, nil
+}
+
+// pushed is a project POST /generate?push=true created a remote for.
+type pushed struct {
+	Name     string `json:"name"`
+	Module   string `json:"module"`
+	CloneURL string `json:"clone_url"`
+}
+
+// handleGenerate generates the projects of the blueprint in the body, then
+// sends them as a .tar.gz or .zip with a directory per project, or pushes each to
+// a new repository when push=true. Blueprints can't pick their own
+// templates or output directories here.
+func (s *server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	fsys, _, err := s.pack(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBlueprintSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	set, err := project.ParseBlueprintSet(data)
+	if err == nil {
+		err = checkServed(set)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	root, err := os.MkdirTemp("", "project-serve-")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(root)
+	gens, err := generateSet(r.Context(), set, fsys, root, &s.cmd.GoEnvFlags, s.runner)
+	if err != nil {
+		logs.Logger().Warnw("generate", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logs.Logger().Infow("generate", "projects", len(gens), "remote", r.URL.Query().Get("push"))
+
+	if r.URL.Query().Get("push") == "true" {
+		s.push(w, r, gens)
+		return
+	}
+	name := "projects"
+	if len(gens) == 1 {
+		name = gens[0].Config.ProjectName
+	}
+	format, contentType := archive.TarGz, "application/gzip"
+	if r.URL.Query().Get("format") == "zip" {
+		format, contentType = archive.Zip, "application/zip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+string(format)))
+	if err := archive.Write(w, root, format); err != nil {
+		logs.Logger().Warnw("generate", "error", err)
+	}
+}
+
+// checkServed checks the set as gen --file would, and refuses what a
+// request mustn't choose on the server: template packs, output
+// directories, names that reach outside the request's directory and
+// presets fetched from elsewhere.
+func checkServed(set *project.BlueprintSet) error {
+	for _, bp := range set.Projects {
+		name := bp.Name()
+		switch {
+		case !filepath.IsLocal(name) || name == "." || name != filepath.Base(name):
+			return fmt.Errorf("module %s doesn't name a directory the server can write", bp.Module)
+		case bp.Templates != "":
+			return fmt.Errorf("project %s: templates are picked with the pack parameter", bp.Name())
+		case bp.Dir != "":
+			return fmt.Errorf("project %s: dir can't be set on the server", bp.Name())
+		case strings.Contains(bp.Preset, "#"):
+			return fmt.Errorf("project %s: only the server's presets can be used", bp.Name())
+		}
+	}
+	return set.Check()
+}
+
+// generateSet writes each project of set to its own directory under root,
+// each after those it uses. They all generate from fsys, whatever their
+// presets say. runner runs go for them; nil for the host.
+func generateSet(ctx context.Context, set *project.BlueprintSet, fsys fs.FS, root string, env *GoEnvFlags, runner execx.Runner) ([]*project.Generator, error) {
+	ordered, err := set.Ordered()
+	if err != nil {
+		return nil, err
+	}
+	policy, err := hookPolicy()
+	if err != nil {
+		return nil, err
+	}
+	orgPolicy, err := orgPolicy()
+	if err != nil {
+		return nil, err
+	}
+	var gens []*project.Generator
+	byName := make(map[string]*project.Generator, len(ordered))
+	for _, bp := range ordered {
+		ref, err := expandModule(bp.Module)
+		if err != nil {
+			return nil, err
+		}
+		mod, err := metadata.Parse(ref)
+		if err != nil {
+			return nil, fmt.Errorf("project %s: invalid module: %w", bp.Name(), err)
+		}
+		gen := &project.Generator{
+			Config:     project.NewGenConfig(mod.Path, filepath.Join(root, bp.Name())),
+			Templates:  fsys,
+			HookPolicy: policy,
+			Policy:     orgPolicy,
+			Context:    ctx,
+			Log:        logs.Logger(),
+			Runner:     runner,
+		}
+		for _, name := range bp.Uses {
+			gen.Uses = append(gen.Uses, project.ModuleUse{Module: byName[name].Config.ModuleURL, Dir: "../" + name})
+		}
+		if err := bp.Apply(gen); err != nil {
+			return nil, err
+		}
+		if err := env.setGoEnv(gen); err != nil {
+			return nil, err
+		}
+		// The caller picked the templates, whatever the preset says
+		if err := applyPreset(gen, bp.Preset, &TemplateFlags{Templates: defaultPack}); err != nil {
+			return nil, err
+		}
+		if err := gen.GenerateAll(gen.Config.ModuleURL, gen.Config.OutputDir); err != nil {
+			return nil, fmt.Errorf("project %s: %w", bp.Name(), err)
+		}
+		byName[bp.Name()] = gen
+		gens = append(gens, gen)
+	}
+	return gens, nil
+}
+
+// push creates a repository for each generated project and pushes it,
+// private unless public=true.
+func (s *server) push(w http.ResponseWriter, r *http.Request, gens []*project.Generator) {
+	q := r.URL.Query()
+	var out []pushed
+	for _, gen := range gens {
+		token := forgeToken(gen.Config.Host)
+		cloneURL, err := gen.CreateRemote(project.RemoteOptions{
+			Token:       token,
+			Description: q.Get("description"),
+			Private:     q.Get("public") != "true",
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("project %s: %v", gen.Config.ProjectName, err), http.StatusBadGateway)
+			return
+		}
+		if err := gen.ProtectRemote(token); err != nil {
+			logs.Logger().Warnw("protect", "project", gen.Config.ProjectName, "error", err)
+		}
+		out = append(out, pushed{Name: gen.Config.ProjectName, Module: gen.Config.ModuleURL, CloneURL: cloneURL})
+	}
+	writeJSON(w, out)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
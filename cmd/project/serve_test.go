@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project"
+	"github.com/robbyriverside/project/internal/archive"
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+// newServe serves the embedded pack behind token, with a home of its own
+// so no user config applies.
+func newServe(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PROJECT_CACHE", t.TempDir())
+	t.Setenv("PROJECT_SERVE_TOKEN", "")
+	s, err := (&ServeCommand{Token: token}).newServer()
+	if err != nil {
+		t.Fatalf("newServer() error: %v", err)
+	}
+	s.runner = &execx.Recorder{Respond: fakeGo}
+	srv := httptest.NewServer(s.routes())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// request sends method path with body and the bearer token, if any.
+func request(t *testing.T, srv *httptest.Server, method, path, token, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServeAuthorize(t *testing.T) {
+	srv := newServe(t, "secret")
+	for token, want := range map[string]int{
+		"":       http.StatusUnauthorized,
+		"wrong":  http.StatusUnauthorized,
+		"Secret": http.StatusUnauthorized,
+		"secret": http.StatusOK,
+	} {
+		if resp := request(t, srv, "GET", "/packs", token, ""); resp.StatusCode != want {
+			t.Errorf("GET /packs with token %q = %d, want %d", token, resp.StatusCode, want)
+		}
+	}
+	if resp := request(t, srv, "POST", "/generate", "", "module: github.com/example/demo\n"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("POST /generate without a token = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestServePacks(t *testing.T) {
+	srv := newServe(t, "")
+	resp := request(t, srv, "GET", "/packs", "", "")
+	var packs []packInfo
+	if err := json.NewDecoder(resp.Body).Decode(&packs); err != nil {
+		t.Fatal(err)
+	}
+	if len(packs) != 1 || packs[0].Name != defaultPack || packs[0].Version == "" {
+		t.Errorf("GET /packs = %+v, want the default pack", packs)
+	}
+
+	for _, path := range []string{"/templates?pack=nosuch", "/generate?pack=nosuch"} {
+		method := "GET"
+		if strings.HasPrefix(path, "/generate") {
+			method = "POST"
+		}
+		resp := request(t, srv, method, path, "", "module: github.com/example/demo\n")
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusNotFound || !strings.Contains(string(body), `no pack "nosuch"`) {
+			t.Errorf("%s %s = %d %s, want %d", method, path, resp.StatusCode, body, http.StatusNotFound)
+		}
+	}
+}
+
+func TestServeRefuses(t *testing.T) {
+	srv := newServe(t, "")
+	tests := []struct {
+		name      string
+		blueprint string
+		want      string
+	}{
+		{"templates", "module: github.com/example/demo\ntemplates: ./my-pack\n", "templates are picked with the pack parameter"},
+		{"dir", "module: github.com/example/demo\ndir: /tmp/demo\n", "dir can't be set on the server"},
+		{"remote preset", "module: github.com/example/demo\npreset: https://example.com/presets.yaml#api\n", "only the server's presets"},
+		{"in a set", "projects:\n  - module: github.com/example/api\n  - module: github.com/example/web\n    dir: web\n", "project web: dir"},
+		{"invalid", "module: [nope]\n", "blueprint"},
+		{"escaping name", "module: github.com/acme/..\n", "doesn't name a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := request(t, srv, "POST", "/generate", "", tt.blueprint)
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), tt.want) {
+				t.Errorf("POST /generate = %d %s, want %d about %q", resp.StatusCode, body, http.StatusBadRequest, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeGenerate(t *testing.T) {
+	srv := newServe(t, "")
+	resp := request(t, srv, "POST", "/generate", "", "module: github.com/example/demo\nfeatures: [bench]\n")
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("POST /generate = %d %s", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "application/gzip" {
+		t.Errorf("Content-Type = %q, want application/gzip", got)
+	}
+	if got := resp.Header.Get("Content-Disposition"); !strings.Contains(got, `filename="demo.tar.gz"`) {
+		t.Errorf("Content-Disposition = %q, want demo.tar.gz", got)
+	}
+
+	// The tarball holds the project as generated, manifest and all
+	dir := t.TempDir()
+	if err := archive.ExtractTarGz(resp.Body, dir); err != nil {
+		t.Fatalf("ExtractTarGz() error: %v", err)
+	}
+	demo := filepath.Join(dir, "demo")
+	m, err := project.LoadManifest(demo)
+	if err != nil {
+		t.Fatalf("LoadManifest() error: %v", err)
+	}
+	if m.ModuleURL != "github.com/example/demo" {
+		t.Errorf("manifest module = %q, want github.com/example/demo", m.ModuleURL)
+	}
+	report, err := project.Verify(demo)
+	if err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+	if !report.Clean() {
+		t.Errorf("Verify() of the extracted project isn't clean: %+v", report.Files)
+	}
+	if _, err := os.Stat(filepath.Join(demo, "go.mod")); err != nil {
+		t.Errorf("go.mod missing: %v", err)
+	}
+}
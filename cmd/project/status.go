@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/robbyriverside/project"
+	"github.com/robbyriverside/project/logs"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// StatusCommand shows a dashboard of a generated project's health.
+type StatusCommand struct {
+	Dir  string `short:"d" long:"dir" default:"." description:"Project directory containing .project.yaml"`
+	JSON bool   `long:"json" description:"Print the report as JSON"`
+	TemplateFlags
+}
+
+func (cmd *StatusCommand) Execute(args []string) error {
+	gen := &project.Generator{Log: logs.Logger()}
+	if err := cmd.setTemplates(gen); err != nil {
+		return err
+	}
+	report, err := gen.Status(pathutil.MustExpand(cmd.Dir))
+	if err != nil {
+		return msg.Errorf("failed to check project: %w", err)
+	}
+
+	if cmd.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	msg.Printf("project   %s\n", pathutil.Contract(report.ProjectDir))
+	for _, row := range statusRows(report) {
+		line := fmt.Sprintf("%-9s %s %s", row.name, label(row.state, 8), row.detail)
+		msg.Printf("%s\n", strings.TrimRight(line, " "))
+	}
+	if report.Healthy() {
+		say("All checks passed\n")
+	}
+	return nil
+}
+
+// statusRow is one line of the dashboard: a check, how it went and why.
+type statusRow struct {
+	name, state, detail string
+}
+
+// The states of a dashboard row.
+const (
+	rowOK      = "ok"
+	rowWarning = "warning"
+	rowMissing = "missing"
+	rowUnknown = "unknown" // the check couldn't run
+)
+
+// statusRows lays report out as the dashboard shows it.
+func statusRows(r *project.StatusReport) []statusRow {
+	var rows []statusRow
+	switch m := r.Manifest; {
+	case !m.Present:
+		rows = append(rows, statusRow{"manifest", rowMissing, msg.Sprintf("no %s; adopt the project with gen --from-existing", project.ManifestName)})
+	case !m.Valid:
+		rows = append(rows, statusRow{"manifest", rowWarning, m.Error})
+	default:
+		rows = append(rows, statusRow{"manifest", rowOK, msg.Sprintf("generated by project %s", m.GeneratorVersion)})
+	}
+
+	switch d := r.Drift; {
+	case d.Error != "":
+		rows = append(rows, statusRow{"drift", rowUnknown, d.Error})
+	case d.Count() == 0:
+		rows = append(rows, statusRow{"drift", rowOK, msg.Sprintf("every file matches its template")})
+	default:
+		var parts []string
+		for _, state := range []project.DiffState{project.DiffCustomized, project.DiffDrifted, project.DiffConflict, project.DiffMissing, project.DiffNew} {
+			if n := d.Files[state]; n > 0 {
+				parts = append(parts, msg.Sprintf("%d %s", n, state))
+			}
+		}
+		rows = append(rows, statusRow{"drift", rowWarning, msg.Sprintf("%d files differ (%s); see project diff", d.Count(), strings.Join(parts, ", "))})
+	}
+
+	switch p := r.Pack; {
+	case p.Error != "":
+		rows = append(rows, statusRow{"pack", rowUnknown, p.Error})
+	case p.Behind:
+		rows = append(rows, statusRow{"pack", rowWarning, msg.Sprintf("%s %s, %s is available; run project update", p.Name, p.Version, p.Latest)})
+	default:
+		rows = append(rows, statusRow{"pack", rowOK, msg.Sprintf("%s %s is the latest", p.Name, p.Version)})
+	}
+
+	switch d := r.Deps; {
+	case d.Error != "":
+		rows = append(rows, statusRow{"deps", rowUnknown, d.Error})
+	case len(d.Stale()) > 0:
+		var parts []string
+		for _, m := range d.Stale() {
+			parts = append(parts, msg.Sprintf("%s %s < %s", m.Path, m.Version, m.Pinned))
+		}
+		rows = append(rows, statusRow{"deps", rowWarning, msg.Sprintf("%s; run project upgrade-deps", strings.Join(parts, ", "))})
+	default:
+		rows = append(rows, statusRow{"deps", rowOK, msg.Sprintf("%d pinned modules up to date", len(d.Modules))})
+	}
+
+	for _, c := range r.Components {
+		if c.Present {
+			rows = append(rows, statusRow{c.Name, rowOK, ""})
+		} else {
+			rows = append(rows, statusRow{c.Name, rowMissing, msg.Sprintf(componentHints[c.Name])})
+		}
+	}
+	return rows
+}
+
+// componentHints say how to add a missing recommended component.
+var componentHints = map[string]string{
+	"ci":      "no CI pipeline; project update restores the generated one",
+	"lint":    "no golangci-lint config (.golangci.yml)",
+	"tests":   "no _test.go files",
+	"readme":  "no README.md",
+	"license": "no LICENSE file",
+}
@@ -0,0 +1,15 @@
+package main
+
+import "github.com/robbyriverside/project"
+
+// StepFlags pick which steps of generation run, to debug one stage or
+// regenerate files without touching go.mod.
+type StepFlags struct {
+	SkipSteps []string `long:"skip-step" choice:"render" choice:"modinit" choice:"replace" choice:"tidy" choice:"postprocess" choice:"attest" choice:"stamp" description:"Leave out a step of generation (repeatable), e.g. --skip-step tidy"`
+	OnlySteps []string `long:"only-step" choice:"render" choice:"modinit" choice:"replace" choice:"tidy" choice:"postprocess" choice:"attest" choice:"stamp" description:"Run only this step of generation (repeatable), e.g. --only-step render"`
+}
+
+func (f *StepFlags) setSteps(gen *project.Generator) {
+	gen.SkipSteps = f.SkipSteps
+	gen.OnlySteps = f.OnlySteps
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/robbyriverside/project"
+	"github.com/robbyriverside/project/internal/term"
+)
+
+// VarFlags set template variables, such as those a template pack declares.
+type VarFlags struct {
+	Vars []string `long:"var" description:"Set a template var, {{.Vars.key}}, such as one the template pack declares (key=value, repeatable)"`
+}
+
+// setVars adds the --var values to gen.Vars, replacing any of the same name.
+func (f *VarFlags) setVars(gen *project.Generator) error {
+	for _, kv := range f.Vars {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return msg.Errorf("invalid --var %q, expected key=value", kv)
+		}
+		if gen.Vars == nil {
+			gen.Vars = make(map[string]string)
+		}
+		gen.Vars[key] = value
+	}
+	return nil
+}
+
+// askVars asks on the terminal for the pack's variables that no --var or
+// blueprint set. Without a terminal it leaves them to GenerateAll, which
+// takes their defaults and fails on required ones.
+func askVars(gen *project.Generator) error {
+	if !term.IsTerminal(os.Stdin) {
+		return nil
+	}
+	in := bufio.NewReader(os.Stdin)
+	return gen.ResolveVars(func(v project.PackVariable, def string) (string, error) {
+		for {
+			answer, err := promptVar(in, v, def)
+			if answer == "" {
+				answer = def
+			}
+			if _, perr := v.Parse(answer); perr != nil && err == nil {
+				msg.Fprintf(os.Stderr, "  %v\n", perr)
+				continue
+			}
+			return answer, nil
+		}
+	})
+}
+
+// promptVar prints the question for v, with its choices and default, and
+// reads one line of answer. At the end of input the answer is "".
+func promptVar(in *bufio.Reader, v project.PackVariable, def string) (string, error) {
+	question := firstNonEmpty(v.Prompt, v.Name)
+	switch v.Type {
+	case project.VarChoice:
+		question += " (" + strings.Join(v.Choices, ", ") + ")"
+	case project.VarBool:
+		question += " (y/n)"
+	}
+	if def != "" {
+		question += " [" + def + "]"
+	}
+	msg.Fprintf(os.Stderr, "%s: ", question)
+	line, err := in.ReadString('\n')
+	if err == io.EOF && line == "" {
+		fmt.Fprintln(os.Stderr)
+	}
+	return strings.TrimSpace(line), err
+}
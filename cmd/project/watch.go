@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/robbyriverside/project"
+	config "github.com/robbyriverside/project/config"
+	"github.com/robbyriverside/project/internal/forge"
+	logs "github.com/robbyriverside/project/logs"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// WatchCommand listens for push webhooks from the registered repositories
+// and checks each push for drift from the templates, filing issues:
+//
+//	POST /webhook   a GitHub or GitLab push webhook
+type WatchCommand struct {
+	Addr   string `long:"addr" default:"localhost:8090" description:"Address to listen on, e.g. :8090 for every interface"`
+	Repos  string `long:"repos" required:"true" description:"YAML file listing the repositories to watch"`
+	Secret string `long:"secret" description:"Webhook secret the forge signs pushes with (default: $PROJECT_WEBHOOK_SECRET)"`
+	Scan   bool   `long:"scan" description:"Check every repository once at startup"`
+
+	// TemplateFlags pick the pack repositories are checked against
+	TemplateFlags
+}
+
+func (cmd *WatchCommand) Execute(args []string) error {
+	return cmd.ExecuteContext(context.Background(), args)
+}
+
+func (cmd *WatchCommand) ExecuteContext(ctx context.Context, args []string) error {
+	data, err := os.ReadFile(pathutil.MustExpand(cmd.Repos))
+	if err != nil {
+		return msg.Errorf("failed to read the watch list: %w", err)
+	}
+	list, err := project.ParseRepoList(data, cmd.Repos)
+	if err != nil {
+		return err
+	}
+	w := &watcher{
+		list:   list,
+		secret: firstNonEmpty(cmd.Secret, os.Getenv("PROJECT_WEBHOOK_SECRET")),
+		gen:    &project.Generator{Log: logs.Logger()},
+		queue:  make(chan project.ListedRepo, 64),
+	}
+	if err := cmd.setTemplates(w.gen); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if w.gen.Retry, err = retryPolicy(cfg); err != nil {
+		return err
+	}
+	if w.secret == "" {
+		warn("warning: watching without --secret; anyone who can reach it can trigger checks\n")
+	}
+
+	ln, err := net.Listen("tcp", cmd.Addr)
+	if err != nil {
+		return msg.Errorf("failed to listen: %w", err)
+	}
+	srv := &http.Server{Handler: w.routes(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	go w.run(ctx)
+	if cmd.Scan {
+		for _, r := range list.Repos {
+			w.enqueue(r)
+		}
+	}
+
+	say("Watching %d repositories for pushes on http://%s/webhook\n", len(list.Repos), ln.Addr())
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// watcher checks pushed repositories one at a time, so a burst of pushes
+// queues rather than cloning them all at once.
+type watcher struct {
+	list   *project.RepoList
+	secret string
+	gen    *project.Generator
+	queue  chan project.ListedRepo
+}
+
+func (w *watcher) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webhook", w.handleWebhook)
+	return mux
+}
+
+// handleWebhook queues a check of a push to a registered repo's branch
+// and acknowledges every other genuine webhook.
+func (w *watcher) handleWebhook(rw http.ResponseWriter, r *http.Request) {
+	ev, err := forge.ParsePush(r, w.secret)
+	switch {
+	case errors.Is(err, forge.ErrSignature):
+		http.Error(rw, err.Error(), http.StatusUnauthorized)
+		return
+	case errors.Is(err, forge.ErrNotPush):
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	case err != nil:
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	repo := w.list.Find(ev.Repo)
+	if repo == nil {
+		http.Error(rw, "repository is not on the watch list", http.StatusNotFound)
+		return
+	}
+	if branch := firstNonEmpty(repo.Branch, ev.DefaultBranch); ev.Branch != branch {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+	detail("Push to %s %s at %s\n", repo.Repo, ev.Branch, ev.After)
+	if !w.enqueue(*repo) {
+		http.Error(rw, "too many checks queued", http.StatusServiceUnavailable)
+		return
+	}
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+// enqueue queues a check of repo unless the queue is full.
+func (w *watcher) enqueue(repo project.ListedRepo) bool {
+	select {
+	case w.queue <- repo:
+		return true
+	default:
+		return false
+	}
+}
+
+// run checks queued repos until ctx is done.
+func (w *watcher) run(ctx context.Context) {
+	for {
+		var repo project.ListedRepo
+		select {
+		case <-ctx.Done():
+			return
+		case repo = <-w.queue:
+		}
+		check, err := w.gen.CheckRepo(repo, forgeToken(repo.Host()), w.list.Labels)
+		switch {
+		case err != nil:
+			warn("warning: %v\n", err)
+		case check.Drifted():
+			say("%s has drifted: %s\n", repo.Repo, strings.Join(check.Issues, ", "))
+		default:
+			say("%s is up to date\n", repo.Repo)
+		}
+	}
+}
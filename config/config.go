@@ -7,6 +7,7 @@
 //	config.Save(cfg) // writes it back
 //	config.Set("home", "/path/to")  // modifies a key
 //	config.Get("home")              // retrieves a key
+//	config.GetDuration("hook_timeout") // and parses it; also GetInt, GetBool, GetStringSlice
 //	lines, _ := config.Describe()   // shows current fields + descriptions
 package config
 
@@ -17,25 +18,88 @@ import (
 	"os/user"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/robbyriverside/project/pathutil"
 )
 
 // Config holds all user-facing config fields.
 // Each field is annotated with YAML plus a custom 'config' tag
 // that includes desc= and default= pairs for reflection in Describe().
+// Fields tagged secret=true are masked when described.
 type Config struct {
 	HomeDir string `yaml:"home" config:"desc=Base directory for storing data,default=~/myapp"`
 	Author  string `yaml:"author" config:"desc=Default author name for new items"`
 	LogFmt  string `yaml:"log_fmt" config:"desc=Log output format (json, formatted, text),default=json"`
+
+	// ForgeToken is stored in the config file, which is written 0600 for that reason.
+	ForgeToken string `yaml:"forge_token,omitempty" config:"desc=API token for gen --create-remote (else GITHUB_TOKEN/GITLAB_TOKEN),secret=true"`
+
+	// Lang picks the language of the tool's own messages.
+	Lang string `yaml:"lang" config:"desc=Language of project's messages such as ja (empty follows LC_ALL or LC_MESSAGES or LANG)"`
+
+	// Module defaults complete bare project names, as in gen shoes.
+	ModuleHost  string `yaml:"module_host" config:"desc=Host for bare project names such as gen shoes (empty uses the last module's then github.com)"`
+	ModuleOwner string `yaml:"module_owner" config:"desc=Owner for bare project names (empty uses the last module's then git config github.user)"`
+	LastModule  string `yaml:"last_module,omitempty" config:"desc=Host and owner of the last generated module (set by gen and init)"`
+
+	// Go module settings apply to the go mod steps of gen, init and update.
+	GoProxy   string `yaml:"goproxy" config:"desc=GOPROXY for go mod steps (empty keeps the environment's)"`
+	GoPrivate string `yaml:"goprivate" config:"desc=GOPRIVATE for go mod steps such as github.com/acme/* (empty keeps the environment's)"`
+	GoNoSumDB string `yaml:"gonosumdb" config:"desc=GONOSUMDB for go mod steps (empty keeps the environment's)"`
+	ModCache  string `yaml:"modcache" config:"desc=GOMODCACHE for go mod steps; share one across runs and warm it with project cache warm (empty keeps the environment's)"`
+	GoTimeout string `yaml:"go_timeout" config:"desc=Time limit for each go mod step (0 for none),default=5m"`
+
+	// Retry settings repeat downloads and API calls that fail on the network.
+	Retries    string `yaml:"retries" config:"desc=Tries for go mod tidy, pack downloads and forge API calls that fail on the network (1 for no retries),default=3"`
+	RetryDelay string `yaml:"retry_delay" config:"desc=Wait after the first failed try; doubled after each one up to 30s,default=2s"`
+
+	// TrustDir holds the public keys remote template packs must be signed with.
+	TrustDir string `yaml:"trust_dir" config:"desc=Directory of public keys trusted to sign template packs (minisign *.pub, cosign *.pem),default=~/.project/trust"`
+
+	// Presets extends the presets shipped for gen --preset.
+	Presets string `yaml:"presets" config:"desc=YAML file of presets for gen --preset extending the shipped ones,default=~/.project/presets.yaml"`
+
+	// Policy holds generated projects to an organization's rules.
+	Policy string `yaml:"policy" config:"desc=Organization policy file or URL that generated projects must satisfy (empty for none)"`
+
+	// Rubric weighs the checks project score grades repositories on.
+	Rubric string `yaml:"rubric" config:"desc=Organization rubric file or URL that project score grades with (empty for the built-in one)"`
+
+	// Audit settings record each gen, init, update and add run.
+	Audit        string `yaml:"audit" config:"desc=Whether gen, init, update and add runs are recorded in audit_log (on or off),default=on"`
+	AuditLog     string `yaml:"audit_log" config:"desc=File that project audit list reads, one JSON line per run,default=~/.project/audit.jsonl"`
+	AuditWebhook string `yaml:"audit_webhook" config:"desc=URL each audit entry is also POSTed to as JSON (empty for none)"`
+
+	// Hook settings gate the commands template packs declare in pack.yaml.
+	Hooks       string `yaml:"hooks" config:"desc=Whether template pack hooks may run (on or off),default=off"`
+	HookAllow   string `yaml:"hook_allow" config:"desc=Space-separated programs that hooks may run,default=go gofmt"`
+	HookNetwork string `yaml:"hook_network" config:"desc=Let hooks use the network (true or false, or fallback to allow it only where it can't be cut off); isolation needs Linux,default=false"`
+	HookTimeout string `yaml:"hook_timeout" config:"desc=Time limit for each hook,default=2m"`
 }
 
 // defaultConfig includes built-in fallback fields (like user name).
 var defaultConfig = Config{
-	HomeDir: "~/myapp",
-	LogFmt:  "json",
-	Author:  fallbackAuthor(),
+	HomeDir:  "~/myapp",
+	LogFmt:   "json",
+	Author:   fallbackAuthor(),
+	TrustDir: "~/.project/trust",
+	Presets:  "~/.project/presets.yaml",
+	Audit:    "on",
+	AuditLog: "~/.project/audit.jsonl",
+
+	Hooks:       "off",
+	HookAllow:   "go gofmt",
+	HookNetwork: "false",
+	HookTimeout: "2m",
+	GoTimeout:   "5m",
+	Retries:     "3",
+	RetryDelay:  "2s",
 }
 
 // fallbackAuthor tries to glean a user name from the environment or OS user.
@@ -92,7 +156,8 @@ func Save(cfg *Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
-	if err := os.WriteFile(path, out, 0644); err != nil {
+	// 0600 since the config may hold secrets such as forge_token
+	if err := os.WriteFile(path, out, 0600); err != nil {
 		return fmt.Errorf("failed to write config: %w", err)
 	}
 	return nil
@@ -109,14 +174,18 @@ func Set(key, value string) error {
 
 	found := false
 	for i := 0; i < rv.NumField(); i++ {
-		yamlTag := rt.Field(i).Tag.Get("yaml")
+		yamlTag := yamlKey(rt.Field(i))
 		if yamlTag == key {
-			// If setting 'home', convert to absolute path
+			// If setting 'home', expand ~ and env vars, then store in ~ form
 			if key == "home" {
-				absPath, err := filepath.Abs(value)
-				if err == nil {
-					value = absPath
+				expanded, err := pathutil.Expand(value)
+				if err != nil {
+					return fmt.Errorf("invalid home path %q: %w", value, err)
+				}
+				if absPath, err := filepath.Abs(expanded); err == nil {
+					expanded = absPath
 				}
+				value = pathutil.Contract(expanded)
 			}
 			rv.Field(i).SetString(value)
 			found = true
@@ -131,6 +200,7 @@ func Set(key, value string) error {
 }
 
 // Get retrieves one field's value from the config.
+// The 'home' key is returned with ~ and env vars expanded.
 func Get(key string) (string, error) {
 	cfg, err := Load()
 	if err != nil {
@@ -140,14 +210,77 @@ func Get(key string) (string, error) {
 	rt := rv.Type()
 
 	for i := 0; i < rv.NumField(); i++ {
-		yamlTag := rt.Field(i).Tag.Get("yaml")
+		yamlTag := yamlKey(rt.Field(i))
 		if yamlTag == key {
-			return rv.Field(i).String(), nil
+			value := rv.Field(i).String()
+			if key == "home" {
+				return pathutil.Expand(value)
+			}
+			return value, nil
 		}
 	}
 	return "", fmt.Errorf("unknown config key: %s", key)
 }
 
+// GetInt returns a key's value as an integer; unset is 0.
+func GetInt(key string) (int, error) {
+	value, err := Get(key)
+	if err != nil || strings.TrimSpace(value) == "" {
+		return 0, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("config key %s: %q is not an integer", key, value)
+	}
+	return n, nil
+}
+
+// GetBool returns a key's value as a bool; besides true and false it
+// accepts on/off and yes/no. Unset is false.
+func GetBool(key string) (bool, error) {
+	value, err := Get(key)
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "false", "0", "off", "no":
+		return false, nil
+	case "true", "1", "on", "yes":
+		return true, nil
+	}
+	return false, fmt.Errorf("config key %s: %q is not a boolean", key, value)
+}
+
+// GetDuration returns a key's value as a duration such as 90s or 5m; unset
+// and "0" are 0.
+func GetDuration(key string) (time.Duration, error) {
+	value, err := Get(key)
+	if err != nil {
+		return 0, err
+	}
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("config key %s: %q is not a duration", key, value)
+	}
+	return d, nil
+}
+
+// GetStringSlice returns a key's value split on commas and whitespace,
+// e.g. "go gofmt" or "a, b"; unset is nil.
+func GetStringSlice(key string) ([]string, error) {
+	value, err := Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	}), nil
+}
+
 // Describe returns a human-readable listing of config fields,
 // showing the current value, default, and a short description.
 func Describe() ([]string, error) {
@@ -161,7 +294,7 @@ func Describe() ([]string, error) {
 	var out []string
 	for i := 0; i < rv.NumField(); i++ {
 		field := rt.Field(i)
-		yamlTag := field.Tag.Get("yaml")
+		yamlTag := yamlKey(field)
 		descTag := field.Tag.Get("config")
 
 		parts := parseTag(descTag)
@@ -172,6 +305,9 @@ func Describe() ([]string, error) {
 			value = parts["default"]
 		}
 		desc := parts["desc"]
+		if parts["secret"] == "true" {
+			value = mask(value)
+		}
 
 		out = append(out, fmt.Sprintf("  %s = %s\n    → %s", yamlTag, value, desc))
 	}
@@ -197,7 +333,7 @@ func DescribeJSON() ([]byte, error) {
 	results := make(map[string]fieldMeta)
 	for i := 0; i < rv.NumField(); i++ {
 		field := rt.Field(i)
-		yamlKey := field.Tag.Get("yaml")
+		key := yamlKey(field)
 		tag := field.Tag.Get("config")
 		parts := parseTag(tag)
 
@@ -205,7 +341,10 @@ func DescribeJSON() ([]byte, error) {
 		if val == "" {
 			val = parts["default"]
 		}
-		results[yamlKey] = fieldMeta{
+		if parts["secret"] == "true" {
+			val = mask(val)
+		}
+		results[key] = fieldMeta{
 			Value:   val,
 			Desc:    parts["desc"],
 			Default: parts["default"],
@@ -215,6 +354,20 @@ func DescribeJSON() ([]byte, error) {
 	return json.MarshalIndent(results, "", "  ")
 }
 
+// yamlKey returns the field's yaml name without options like omitempty.
+func yamlKey(field reflect.StructField) string {
+	key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	return key
+}
+
+// mask hides a secret value, keeping only whether it is set.
+func mask(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "********"
+}
+
 // parseTag splits the config tag e.g. 'desc=...,default=...' into a map.
 func parseTag(tag string) map[string]string {
 	out := make(map[string]string)
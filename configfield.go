@@ -0,0 +1,180 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robbyriverside/project/internal/codemod"
+	"github.com/robbyriverside/project/internal/fileutils"
+	"github.com/robbyriverside/project/internal/naming"
+)
+
+// ConfigFieldTypes are the kinds of value a generated config key can hold.
+var ConfigFieldTypes = []string{"string", "int", "bool", "duration", "list"}
+
+// ConfigField describes a key to add to a generated CLI's config.
+type ConfigField struct {
+	Name    string `yaml:"name"`           // exported Go field name, e.g. MaxRetries
+	Key     string `yaml:"key,omitempty"`  // yaml key; empty means the snake_case of Name
+	Type    string `yaml:"type,omitempty"` // one of ConfigFieldTypes; empty means string
+	Desc    string `yaml:"desc,omitempty"`
+	Default string `yaml:"default,omitempty"`
+}
+
+// Tag returns the struct tag of f in the generated Config, without the
+// backquotes; templates render blueprint fields with it.
+func (f ConfigField) Tag() string {
+	return f.codemod().Tag()
+}
+
+// EnvVar is the environment variable overriding f in a CLI with prefix.
+func (f ConfigField) EnvVar(prefix string) string {
+	return prefix + "_" + strings.ToUpper(f.Key)
+}
+
+// configFields renders the pack's config template and reads its Config
+// struct as the generated config.Fields() does, so the config reference
+// and .env.example a project starts with list what the struct holds.
+func (g *Generator) configFields() ([]codemod.FieldDoc, error) {
+	f, err := g.Render("config")
+	if err != nil {
+		return nil, err
+	}
+	src := f.Data
+	if f.Open != nil {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		if src, err = io.ReadAll(rc); err != nil {
+			return nil, err
+		}
+	}
+	return codemod.ConfigFields(src, g.Config.EnvPrefix)
+}
+
+func (f ConfigField) codemod() codemod.ConfigField {
+	return codemod.ConfigField{Name: f.Name, Key: f.Key, Type: f.Type, Desc: f.Desc, Default: f.Default}
+}
+
+// AddConfigField adds f to the Config struct and defaults of the CLI in
+// projectDir, then regenerates docs/CONFIG.md and, when the project has one,
+// .env.example from the updated package. It returns the files it wrote.
+func (g *Generator) AddConfigField(projectDir string, f ConfigField) ([]string, error) {
+	if _, err := g.openProject(projectDir); err != nil {
+		return nil, err
+	}
+	if !g.kind().cli {
+		return nil, fmt.Errorf("only %s projects have a config package, not %s", cliKinds(), g.Config.Kind)
+	}
+	if err := f.normalize(); err != nil {
+		return nil, err
+	}
+	dir := g.Config.ProjectPath()
+	release, err := g.lock(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	src := filepath.Join(dir, "config", "config.go")
+	if err := codemod.AddConfigField(src, f.codemod()); err != nil {
+		return nil, err
+	}
+	written := []string{src}
+
+	docs := []struct {
+		path string
+		args []string
+	}{
+		{filepath.Join(dir, "docs", "CONFIG.md"), nil},
+		{filepath.Join(dir, ".env.example"), []string{"--dotenv"}},
+	}
+	for _, d := range docs {
+		if _, err := os.Stat(d.path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		args := append([]string{"run", "./cmd/" + g.Config.BinaryName, "config", "docs"}, d.args...)
+		out, err := g.runGo(args...)
+		if err != nil {
+			return written, fmt.Errorf("failed to regenerate %s: %w", filepath.Base(d.path), err)
+		}
+		if err := fileutils.WriteFile(d.path, out, fileutils.DefaultMode); err != nil {
+			return written, err
+		}
+		written = append(written, d.path)
+	}
+	return written, nil
+}
+
+// normalize fills in the key and type and checks that f can be written
+// into a struct tag.
+func (f *ConfigField) normalize() error {
+	if !token.IsIdentifier(f.Name) || !token.IsExported(f.Name) {
+		return fmt.Errorf("field name %q is not an exported Go identifier", f.Name)
+	}
+	if f.Key == "" {
+		f.Key = naming.SnakeCase(f.Name)
+	}
+	if f.Type == "" {
+		f.Type = "string"
+	}
+	known := false
+	for _, t := range ConfigFieldTypes {
+		known = known || t == f.Type
+	}
+	if !known {
+		return fmt.Errorf("unknown type %q; want one of %s", f.Type, strings.Join(ConfigFieldTypes, ", "))
+	}
+
+	for name, v := range map[string]string{"key": f.Key, "desc": f.Desc, "default": f.Default} {
+		if strings.ContainsAny(v, "`\"\n") {
+			return fmt.Errorf("%s %q can't contain quotes, backquotes or newlines", name, v)
+		}
+		// The config tag splits on commas; a later part with = starts a new entry
+		if _, rest, ok := strings.Cut(v, ","); ok && strings.Contains(rest, "=") {
+			return fmt.Errorf("%s %q can't contain = after a comma", name, v)
+		}
+	}
+	if strings.ContainsAny(f.Key, ", ") {
+		return fmt.Errorf("key %q can't contain commas or spaces", f.Key)
+	}
+	return checkConfigValue(f.Type, f.Default)
+}
+
+// checkConfigValue checks value the way the generated config package's Set
+// does for a key of type kind.
+func checkConfigValue(kind, value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	var err error
+	switch kind {
+	case "int":
+		_, err = strconv.Atoi(value)
+	case "bool":
+		switch strings.ToLower(value) {
+		case "false", "0", "off", "no", "true", "1", "on", "yes":
+		default:
+			err = errors.New("not a boolean")
+		}
+	case "duration":
+		if value != "0" {
+			_, err = time.ParseDuration(value)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("default %q is not a valid %s", value, kind)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+func TestConfigDocs(t *testing.T) {
+	pack := embeddedPack(t)
+	// A key the pack adds to its Config struct, with a deprecated one
+	cfg := string(pack["config.tmpl"].Data)
+	const logFmt = "  LogFmt  string `yaml:\"log_fmt\""
+	if !strings.Contains(cfg, logFmt) {
+		t.Fatalf("config.tmpl has no LogFmt field to add after")
+	}
+	cfg = strings.Replace(cfg, logFmt, "  Region string `yaml:\"region\" config:\"desc=Region to call, e.g. eu-west-1,default=eu-west-1\"`\n"+
+		"  Zone   string `yaml:\"zone\" config:\"deprecated=use region\"`\n"+logFmt, 1)
+	pack["config.tmpl"].Data = []byte(cfg)
+
+	g := &Generator{
+		Runner:       &execx.Recorder{Respond: fakeGo},
+		Templates:    pack,
+		Features:     []string{"remoteconfig"},
+		ConfigFields: []ConfigField{{Name: "MaxRetries", Key: "max_retries", Type: "int", Desc: "Retries per request", Default: "3"}},
+	}
+	dir := t.TempDir()
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+
+	doc, err := os.ReadFile(filepath.Join(dir, "docs", "CONFIG.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := "| Key | Type | Default | Env | Description |\n|-----|------|---------|-----|-------------|\n" +
+		"| `home` | string | `~/dev/demo` | `DEMO_HOME` | Base directory for storing data |\n" +
+		"| `author` | string |  | `DEMO_AUTHOR` | Default author name for new items |\n" +
+		"| `region` | string | `eu-west-1` | `DEMO_REGION` | Region to call, e.g. eu-west-1 |\n" +
+		"| `zone` | string |  | `DEMO_ZONE` | Deprecated, use region. |\n" +
+		"| `log_fmt` | string | `json` | `DEMO_LOG_FMT` | Log output format (json, formatted, text) |\n" +
+		"| `remote_config` | string |  | `DEMO_REMOTE_CONFIG` | Config to fetch from an https URL or consul://host:port/key or etcd://host:port/key (empty for none) |\n" +
+		"| `remote_ttl` | string | `1m` | `DEMO_REMOTE_TTL` | How long a fetched remote config is used before fetching it again |\n" +
+		"| `max_retries` | int | `3` | `DEMO_MAX_RETRIES` | Retries per request |\n"
+	if !strings.HasSuffix(string(doc), table) {
+		t.Errorf("CONFIG.md =\n%s\nwant it to end with\n%s", doc, table)
+	}
+
+	env, err := os.ReadFile(filepath.Join(dir, ".env.example"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"\n# Region to call, e.g. eu-west-1\n# DEMO_REGION=eu-west-1\n", "\n# DEMO_REMOTE_TTL=1m\n", "\n# Retries per request\n# DEMO_MAX_RETRIES=3\n"} {
+		if !strings.Contains(string(env), want) {
+			t.Errorf(".env.example is missing %q:\n%s", want, env)
+		}
+	}
+	if strings.Contains(string(env), "DEMO_ZONE") {
+		t.Errorf(".env.example lists the deprecated zone key:\n%s", env)
+	}
+}
@@ -0,0 +1,182 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/semver"
+)
+
+// Dependency is a module the templates import, pinned to a tested version.
+type Dependency struct {
+	Path    string
+	Version string
+	Kind    string // only projects of this kind import it; empty for all
+}
+
+// PinnedDeps are the versions this generator release scaffolds with.
+// Bump them together with the templates that use them.
+var PinnedDeps = []Dependency{
+	{Path: "github.com/jessevdk/go-flags", Version: "v1.6.1"},
+	{Path: "go.uber.org/zap", Version: "v1.27.0"},
+	{Path: "gopkg.in/yaml.v3", Version: "v3.0.1"},
+	{Path: "github.com/charmbracelet/bubbletea", Version: "v1.3.4", Kind: "tui"},
+	{Path: "github.com/charmbracelet/bubbles", Version: "v0.20.0", Kind: "tui"},
+	{Path: "github.com/charmbracelet/lipgloss", Version: "v1.0.0", Kind: "tui"},
+}
+
+// DepChange reports one pinned dependency's version before and after an upgrade.
+// From == To means the project was already current (or ahead) and was left alone.
+type DepChange struct {
+	Path string
+	From string
+	To   string
+}
+
+// pinDeps requires the pinned versions in go.mod so tidy doesn't pick newer, untested ones.
+func (g *Generator) pinDeps() error {
+	args := []string{"mod", "edit"}
+	for _, d := range PinnedDeps {
+		if d.Kind == "" || d.Kind == g.Config.Kind {
+			args = append(args, "-require="+d.Path+"@"+d.Version)
+		}
+	}
+	return g.goCmd(args...)
+}
+
+// UpgradeDeps raises the project's scaffold dependencies to PinnedDeps, never
+// downgrading, then runs go mod tidy. With dryRun it only reports what would change.
+func (g *Generator) UpgradeDeps(projectDir string, dryRun bool) ([]DepChange, error) {
+	g.Config = NewGenConfig("", projectDir)
+	m, err := loadManifestIfExists(g.fsys(), projectDir)
+	if err != nil {
+		return nil, err
+	}
+	g.applyVendor(m)
+	required, err := g.requiredModules()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []DepChange
+	args := []string{"mod", "edit"}
+	for _, d := range PinnedDeps {
+		current, ok := required[d.Path]
+		if !ok {
+			continue // project doesn't use it (anymore)
+		}
+		change := DepChange{Path: d.Path, From: current, To: current}
+		if semver.Compare(current, d.Version) < 0 {
+			change.To = d.Version
+			args = append(args, "-require="+d.Path+"@"+d.Version)
+		}
+		changes = append(changes, change)
+	}
+
+	if dryRun || len(args) == 2 {
+		return changes, nil
+	}
+	if err := g.goCmd(args...); err != nil {
+		return nil, fmt.Errorf("failed to update go.mod: %w", err)
+	}
+	if err := g.ModTidy(); err != nil {
+		return nil, fmt.Errorf("go mod tidy failed: %w", err)
+	}
+	if err := g.modVendor(); err != nil {
+		return nil, fmt.Errorf("go mod vendor failed: %w", err)
+	}
+	return changes, nil
+}
+
+// requiredModules reads the require block of the project's go.mod.
+func (g *Generator) requiredModules() (map[string]string, error) {
+	out, err := g.runGo("mod", "edit", "-json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+
+	var mod struct {
+		Require []struct {
+			Path    string
+			Version string
+		}
+	}
+	if err := json.Unmarshal(out, &mod); err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+	required := make(map[string]string)
+	for _, r := range mod.Require {
+		required[r.Path] = r.Version
+	}
+	return required, nil
+}
+
+// goCmd runs a go subcommand in the project folder, discarding its stdout.
+func (g *Generator) goCmd(args ...string) error {
+	_, err := g.runGo(args...)
+	return err
+}
+
+// WarmModCache downloads PinnedDeps of every kind and extra, and the
+// modules they need, into the module cache g.GoEnv names (the go command's
+// own if empty), so the go mod tidy of each project generated later finds
+// them there. It works in a throwaway module and returns the modules it
+// pinned.
+func (g *Generator) WarmModCache(extra ...Dependency) ([]Dependency, error) {
+	tmp, err := os.MkdirTemp("", "project-warm-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	deps := append(slices.Clone(PinnedDeps), extra...)
+	var b strings.Builder
+	b.WriteString("module project.local/warm\n\nrequire (\n")
+	for _, d := range deps {
+		fmt.Fprintf(&b, "\t%s %s\n", d.Path, d.Version)
+	}
+	b.WriteString(")\n")
+	if err := os.WriteFile(filepath.Join(tmp, "go.mod"), []byte(b.String()), 0o644); err != nil {
+		return nil, err
+	}
+	g.Config = NewGenConfig("", tmp)
+	if err := g.goNet("mod", "download", "all"); err != nil {
+		return nil, err
+	}
+
+	// A project that imports only some of the modules selects its own
+	// versions of those they share, so every version in the graph is needed
+	out, err := g.runGo("mod", "graph")
+	if err != nil {
+		return nil, err
+	}
+	if versions := graphVersions(out); len(versions) > 0 {
+		if err := g.goNet(append([]string{"mod", "download"}, versions...)...); err != nil {
+			return nil, err
+		}
+	}
+	return deps, nil
+}
+
+// graphVersions lists the module@version nodes of go mod graph output,
+// sorted, leaving out the main module and the go and toolchain versions.
+func graphVersions(graph []byte) []string {
+	seen := make(map[string]bool)
+	for _, node := range strings.Fields(string(graph)) {
+		path, _, ok := strings.Cut(node, "@")
+		if ok && path != "go" && path != "toolchain" {
+			seen[node] = true
+		}
+	}
+	versions := make([]string, 0, len(seen))
+	for v := range seen {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
@@ -0,0 +1,112 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+func TestWarmModCache(t *testing.T) {
+	var gomod string
+	rec := &execx.Recorder{Respond: func(c execx.Call) (execx.Result, error) {
+		data, err := os.ReadFile(filepath.Join(c.Dir, "go.mod"))
+		gomod = string(data)
+		if c.String() == "go mod graph" {
+			graph := "project.local/warm go.uber.org/zap@v1.27.0\n" +
+				"go.uber.org/zap@v1.27.0 go@1.19\n" +
+				"go.uber.org/zap@v1.27.0 go.uber.org/multierr@v1.10.0\n" +
+				"project.local/warm go.uber.org/multierr@v1.11.0\n"
+			return execx.Result{Stdout: []byte(graph)}, err
+		}
+		return execx.Result{}, err
+	}}
+	cache := t.TempDir()
+	g := &Generator{Runner: rec, GoEnv: GoEnv{ModCache: cache}}
+	deps, err := g.WarmModCache()
+	if err != nil {
+		t.Fatalf("WarmModCache() error: %v", err)
+	}
+	if len(deps) != len(PinnedDeps) {
+		t.Errorf("warmed %d modules, want %d", len(deps), len(PinnedDeps))
+	}
+
+	calls := rec.Calls()
+	want := []string{
+		"go mod download all",
+		"go mod graph",
+		// every version a project might select, not just the warm module's
+		"go mod download go.uber.org/multierr@v1.10.0 go.uber.org/multierr@v1.11.0 go.uber.org/zap@v1.27.0",
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %q", calls, want)
+	}
+	for i, c := range calls {
+		if c.String() != want[i] || !slices.Contains(c.Env, "GOMODCACHE="+cache) {
+			t.Errorf("call %d = %s with %v, want %s with GOMODCACHE set", i, c, c.Env, want[i])
+		}
+	}
+	for _, d := range PinnedDeps {
+		if !strings.Contains(gomod, d.Path+" "+d.Version) {
+			t.Errorf("go.mod doesn't require %s %s:\n%s", d.Path, d.Version, gomod)
+		}
+	}
+	if _, err := os.Stat(calls[0].Dir); !os.IsNotExist(err) {
+		t.Errorf("scratch module %s was left behind", calls[0].Dir)
+	}
+}
+
+func TestUpgradeDeps(t *testing.T) {
+	// zap is behind the pins, go-flags ahead of them, yaml current and
+	// the tui modules aren't required
+	const required = `{"Require": [
+		{"Path": "go.uber.org/zap", "Version": "v1.26.0"},
+		{"Path": "github.com/jessevdk/go-flags", "Version": "v1.7.0"},
+		{"Path": "gopkg.in/yaml.v3", "Version": "v3.0.1"}
+	]}`
+	const gomod = "module github.com/example/demo\n\ngo 1.22\n"
+	wantChanges := []DepChange{
+		{Path: "github.com/jessevdk/go-flags", From: "v1.7.0", To: "v1.7.0"},
+		{Path: "go.uber.org/zap", From: "v1.26.0", To: "v1.27.0"},
+		{Path: "gopkg.in/yaml.v3", From: "v3.0.1", To: "v3.0.1"},
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		rec := &execx.Recorder{Respond: func(c execx.Call) (execx.Result, error) {
+			if c.String() == "go mod edit -json" {
+				return execx.Result{Stdout: []byte(required)}, nil
+			}
+			return execx.Result{}, nil
+		}}
+		g := &Generator{Runner: rec}
+		changes, err := g.UpgradeDeps(dir, dryRun)
+		if err != nil {
+			t.Fatalf("UpgradeDeps(dryRun=%v) error: %v", dryRun, err)
+		}
+		if !slices.Equal(changes, wantChanges) {
+			t.Errorf("UpgradeDeps(dryRun=%v) = %+v, want %+v", dryRun, changes, wantChanges)
+		}
+
+		var calls []string
+		for _, c := range rec.Calls() {
+			calls = append(calls, c.String())
+		}
+		want := []string{"go mod edit -json"}
+		if !dryRun {
+			want = append(want, "go mod edit -require=go.uber.org/zap@v1.27.0", "go mod tidy")
+		}
+		if !slices.Equal(calls, want) {
+			t.Errorf("UpgradeDeps(dryRun=%v) ran %q, want %q", dryRun, calls, want)
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); dryRun && (err != nil || string(data) != gomod) {
+			t.Errorf("go.mod after a dry run = %q, %v; want it untouched", data, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package project
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/robbyriverside/project/internal/diff"
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+// DiffState classifies how a project file relates to its manifest and templates.
+type DiffState string
+
+const (
+	DiffClean      DiffState = "clean"      // working tree matches the templates
+	DiffCustomized DiffState = "customized" // user edited the file; templates unchanged
+	DiffDrifted    DiffState = "drifted"    // templates changed; file untouched since generation
+	DiffConflict   DiffState = "conflict"   // both the file and its template changed
+	DiffMissing    DiffState = "missing"    // file was deleted from the working tree
+	DiffNew        DiffState = "new"        // template renders a file the manifest doesn't know
+)
+
+// FileDiff is the comparison of one rendered file against the working tree.
+type FileDiff struct {
+	Path     string
+	Template string
+	State    DiffState
+	Diff     string // unified diff from working tree to template output
+}
+
+// Diff re-renders the templates recorded in projectDir's manifest in memory
+// and compares them with the working tree. Nothing is written.
+func (g *Generator) Diff(projectDir string) ([]FileDiff, error) {
+	m, err := g.openProject(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := g.plannedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []FileDiff
+	for _, f := range files {
+		out = append(out, compareFile(g.fsys(), m, g.Config.ProjectPath(), f))
+	}
+	return out, nil
+}
+
+// openProject loads projectDir's manifest and rebuilds the config it was
+// generated with, so templates render as they would on update.
+func (g *Generator) openProject(projectDir string) (*Manifest, error) {
+	m, err := loadManifest(g.fsys(), projectDir)
+	if err != nil {
+		return nil, err
+	}
+	g.Config = NewGenConfig(m.ModuleURL, projectDir)
+	g.prev = m
+	g.applyName(m)
+	if m.BinaryName != "" {
+		g.Config.BinaryName = m.BinaryName
+	}
+	if g.Lang == "" {
+		g.Lang = m.Lang
+	}
+	if err := g.applyKind(m); err != nil {
+		return nil, err
+	}
+	if err := g.applyFeatures(m); err != nil {
+		return nil, err
+	}
+	if err := g.applyBinaries(m); err != nil {
+		return nil, err
+	}
+	if err := g.applyTargets(m); err != nil {
+		return nil, err
+	}
+	if err := g.applyExtras(m); err != nil {
+		return nil, err
+	}
+	g.applyShared(m)
+	if err := g.ResolveVars(nil); err != nil {
+		return nil, err
+	}
+	g.applyCatalog(m)
+	g.applyVendor(m)
+	g.Headers = g.Headers || m.Headers
+	return m, nil
+}
+
+// compareFile diffs one rendered file and decides who changed it.
+func compareFile(fsys fileutils.FS, m *Manifest, projectPath string, f RenderedFile) FileDiff {
+	fd := FileDiff{Path: f.Path, Template: f.Template}
+	entry := m.File(f.Path)
+
+	destPath := filepath.Join(projectPath, filepath.FromSlash(f.Path))
+	onDisk, err := fileChecksum(fsys, destPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		fd.State = DiffMissing
+		if entry == nil {
+			fd.State = DiffNew
+		}
+		if !f.Streamed() {
+			fd.Diff = diff.Unified("/dev/null", f.Path+" (templates)", "", string(f.Data), 3)
+		}
+		return fd
+	}
+
+	// Streamed files are too large to diff line by line; compare checksums only
+	rendered := f.Checksum()
+	if !f.Streamed() && rendered != onDisk {
+		current, _ := fsys.ReadFile(destPath)
+		fd.Diff = diff.Unified(f.Path+" (working tree)", f.Path+" (templates)", string(current), string(f.Data), 3)
+	}
+
+	switch {
+	case rendered == onDisk:
+		fd.State = DiffClean
+	case entry == nil:
+		fd.State = DiffNew
+	case onDisk != entry.SHA256 && rendered == entry.SHA256:
+		fd.State = DiffCustomized
+	case onDisk == entry.SHA256:
+		fd.State = DiffDrifted
+	default:
+		fd.State = DiffConflict
+	}
+	return fd
+}
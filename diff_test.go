@@ -0,0 +1,55 @@
+package project
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+func TestCompareFile(t *testing.T) {
+	const generated, edited, newer = "v1\n", "v1 edited\n", "v2\n"
+	tests := []struct {
+		name     string
+		disk     string // "" when the file is gone
+		rendered string
+		recorded bool // in the manifest, as generated
+		want     DiffState
+	}{
+		{"clean", generated, generated, true, DiffClean},
+		{"customized", edited, generated, true, DiffCustomized},
+		{"drifted", generated, newer, true, DiffDrifted},
+		{"conflict", edited, newer, true, DiffConflict},
+		{"missing", "", generated, true, DiffMissing},
+		{"new and absent", "", generated, false, DiffNew},
+		{"new and present", edited, generated, false, DiffNew},
+		{"unrecorded but equal", generated, generated, false, DiffClean},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := &fileutils.MemFS{}
+			dir := filepath.Join(string(filepath.Separator), "demo")
+			if tt.disk != "" {
+				if err := fileutils.WriteFile(filepath.Join(dir, "README.md"), []byte(tt.disk), 0, fileutils.WithFS(mem)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			m := &Manifest{}
+			if tt.recorded {
+				m.Files = []ManifestFile{{Path: "README.md", Template: "readme.tmpl", SHA256: checksum([]byte(generated))}}
+			}
+
+			got := compareFile(mem, m, dir, RenderedFile{Path: "README.md", Template: "readme.tmpl", Data: []byte(tt.rendered)})
+			if got.State != tt.want {
+				t.Errorf("State = %s, want %s", got.State, tt.want)
+			}
+			if wantDiff := tt.disk != tt.rendered; (got.Diff != "") != wantDiff {
+				t.Errorf("Diff = %q, want a diff %v", got.Diff, wantDiff)
+			}
+			if got.Diff != "" && !strings.Contains(got.Diff, "+"+strings.TrimSpace(tt.rendered)) {
+				t.Errorf("Diff = %q, want it to add the rendered content", got.Diff)
+			}
+		})
+	}
+}
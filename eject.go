@@ -0,0 +1,77 @@
+package project
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+// EjectTemplates writes the embedded template pack into dir so it can be
+// customized and used with --templates. Each template and YAML file gets a
+// provenance header naming the generator and pack version it came from;
+// static/ assets are copied verbatim. It returns the paths written.
+func EjectTemplates(dir string, force bool) ([]string, error) {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 && !force {
+		return nil, fmt.Errorf("directory %s is not empty (use --force to overwrite)", dir)
+	}
+
+	g := &Generator{}
+	fsys, err := g.pack()
+	if err != nil {
+		return nil, err
+	}
+	pack, err := g.PackInfo()
+	if err != nil {
+		return nil, err
+	}
+	provenance := fmt.Sprintf("Ejected from project %s (%s templates %s). Use with --templates.", Version, pack.Name, pack.Version)
+
+	var written []string
+	err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p == staticDir {
+				copied, err := fileutils.CopyFS(fsys, staticDir, filepath.Join(dir, staticDir))
+				for _, c := range copied {
+					written = append(written, path.Join(staticDir, c))
+				}
+				if err != nil {
+					return err
+				}
+				return fs.SkipDir
+			}
+			return nil
+		}
+		// The embedded folder also holds the template tests
+		if strings.HasSuffix(p, ".go") {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		switch path.Ext(p) {
+		case ".tmpl":
+			// The comment takes the line break, so the template's own
+			// leading whitespace is kept
+			data = append([]byte("{{/* "+provenance+"\n*/}}"), data...)
+		case ".yaml", ".yml":
+			data = append([]byte("# "+provenance+"\n"), data...)
+		}
+
+		if err := fileutils.WriteFile(filepath.Join(dir, filepath.FromSlash(p)), data, fileutils.DefaultMode); err != nil {
+			return err
+		}
+		written = append(written, p)
+		return nil
+	})
+	return written, err
+}
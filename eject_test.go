@@ -0,0 +1,108 @@
+package project
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+func TestEjectTemplates(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pack")
+	written, err := EjectTemplates(dir, false)
+	if err != nil {
+		t.Fatalf("EjectTemplates() error: %v", err)
+	}
+	if len(written) == 0 {
+		t.Fatal("EjectTemplates() wrote nothing")
+	}
+
+	pack, err := (&Generator{}).PackInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	provenance := "Ejected from project " + Version + " (" + pack.Name + " templates " + pack.Version + "). Use with --templates."
+	embedded := embeddedPack(t)
+	for _, p := range written {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(p)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var header string
+		switch filepath.Ext(p) {
+		case ".tmpl":
+			header = "{{/* " + provenance + "\n*/}}"
+		case ".yaml":
+			header = "# " + provenance + "\n"
+		}
+		if f := embedded[p]; f == nil || string(data) != header+string(f.Data) {
+			t.Errorf("%s isn't the embedded file behind the header %q", p, header)
+		}
+	}
+	if slices.ContainsFunc(written, func(p string) bool { return strings.HasSuffix(p, ".go") }) {
+		t.Errorf("ejected the template tests: %v", written)
+	}
+
+	// A non-empty directory is only written with force
+	if _, err := EjectTemplates(dir, false); err == nil || !strings.Contains(err.Error(), "not empty") {
+		t.Errorf("EjectTemplates() into a non-empty dir = %v, want an error", err)
+	}
+	if _, err := EjectTemplates(dir, true); err != nil {
+		t.Errorf("EjectTemplates(force) error: %v", err)
+	}
+}
+
+func TestEjectTemplatesRoundTrip(t *testing.T) {
+	pack := filepath.Join(t.TempDir(), "pack")
+	if _, err := EjectTemplates(pack, false); err != nil {
+		t.Fatal(err)
+	}
+	// A template starting with whitespace keeps it behind the header
+	main, err := os.ReadFile(filepath.Join(pack, "main.tmpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _, _ := strings.Cut(string(main), "*/}}")
+	if err := os.WriteFile(filepath.Join(pack, "indent.tmpl"), []byte(header+"*/}}\n\tindented\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	generate := func(templates fs.FS) map[string]string {
+		g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}, Templates: templates}
+		if err := g.GenerateAll("github.com/example/demo", t.TempDir()); err != nil {
+			t.Fatalf("GenerateAll() error: %v", err)
+		}
+		files := make(map[string]string)
+		for _, r := range g.Results {
+			data, err := os.ReadFile(filepath.Join(g.Config.ProjectPath(), filepath.FromSlash(r.Path)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			files[r.Path] = string(data)
+		}
+		return files
+	}
+	want := generate(embeddedPack(t))
+	got := generate(os.DirFS(pack))
+	if len(got) != len(want) {
+		t.Errorf("generated %d files from the ejected pack, %d from the embedded one", len(got), len(want))
+	}
+	for p, data := range want {
+		if got[p] != data {
+			t.Errorf("%s from the ejected pack =\n%s\nwant\n%s", p, got[p], data)
+		}
+	}
+
+	g := &Generator{Templates: os.DirFS(pack), Config: NewGenConfig("github.com/example/demo", "")}
+	f, err := g.Render("indent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(f.Data) != "\n\tindented\n" {
+		t.Errorf("Render(indent) = %q, want its leading whitespace kept", f.Data)
+	}
+}
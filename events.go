@@ -0,0 +1,52 @@
+package project
+
+import "time"
+
+// EventKind names what an Event reports.
+type EventKind string
+
+const (
+	EventStepStart EventKind = "step-start" // a step of GenerateAll begins
+	EventStepDone  EventKind = "step-done"  // and ends, with its error if any
+	EventFile      EventKind = "file"       // a file was written or left alone
+	EventCommand   EventKind = "command"    // a go command finished
+	EventHook      EventKind = "hook"       // a pack hook finished or was skipped
+)
+
+// Event is one thing a run did, reported through Generator.Events as it
+// happens, so editors and other front ends can show progress. Fields that
+// don't apply to the kind are left zero.
+type Event struct {
+	Kind EventKind `json:"kind"`
+
+	// Step is the step's name, and Index and Total its place among the
+	// steps of the run, counting from 1
+	Step  string `json:"step,omitempty"`
+	Index int    `json:"index,omitempty"`
+	Total int    `json:"total,omitempty"`
+
+	Path   string     `json:"path,omitempty"`   // slash path of the file
+	Status FileStatus `json:"status,omitempty"` // what happened to the file
+
+	Hook       string     `json:"hook,omitempty"`
+	HookStatus HookStatus `json:"hookStatus,omitempty"`
+
+	Command  []string      `json:"command,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Err      string        `json:"error,omitempty"`
+}
+
+// event reports e to g.Events, if set.
+func (g *Generator) event(e Event) {
+	if g.Events != nil {
+		g.Events(e)
+	}
+}
+
+// errString is err's message, or empty for nil.
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
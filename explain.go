@@ -0,0 +1,184 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+// Explanation says where a generated file came from.
+type Explanation struct {
+	ProjectDir  string
+	Path        string // slash-separated, relative to ProjectDir
+	Template    string
+	Pack        string
+	PackVersion string
+	Modified    bool // content differs from what was generated
+	Missing     bool
+
+	// Vars maps each template field the template references, e.g.
+	// ".BinaryName", to its value for this project.
+	Vars map[string]string
+}
+
+// Explain maps a file inside a generated project back to the template that
+// produced it, using the manifest of the nearest enclosing project.
+func (g *Generator) Explain(path string) (*Explanation, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	projectDir, err := findProjectDir(filepath.Dir(abs))
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(projectDir, abs)
+	if err != nil {
+		return nil, err
+	}
+	rel = filepath.ToSlash(rel)
+
+	m, err := g.openProject(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	entry := m.File(rel)
+	if entry == nil {
+		return nil, fmt.Errorf("%s is not listed in %s", rel, ManifestPath(projectDir))
+	}
+
+	ex := &Explanation{
+		ProjectDir:  projectDir,
+		Path:        rel,
+		Template:    entry.Template,
+		Pack:        m.TemplatePack,
+		PackVersion: m.TemplateVersion,
+	}
+	sum, err := fileChecksum(fileutils.OS, abs)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		ex.Missing = true
+	case err != nil:
+		return nil, err
+	default:
+		ex.Modified = sum != entry.SHA256
+	}
+
+	// Static assets are copied verbatim and use no variables
+	if !strings.HasPrefix(entry.Template, staticDir+"/") {
+		if ex.Vars, err = g.templateVars(entry.Template); err != nil {
+			return nil, err
+		}
+	}
+	return ex, nil
+}
+
+// findProjectDir walks up from dir to the first directory holding a manifest.
+func findProjectDir(dir string) (string, error) {
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(ManifestPath(d)); err == nil {
+			return d, nil
+		}
+		if filepath.Dir(d) == d {
+			return "", fmt.Errorf("no %s found in %s or any parent directory", ManifestName, dir)
+		}
+	}
+}
+
+// templateVars lists the fields a pack template references, evaluated
+// against g.Config. Templates missing from the current pack yield an error.
+func (g *Generator) templateVars(name string) (map[string]string, error) {
+	tpl, err := g.readTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]bool)
+	collectFields(tpl.Tree.Root, fields, true)
+
+	vars := make(map[string]string)
+	for field := range fields {
+		var b strings.Builder
+		t, err := template.New(field).Option("missingkey=zero").Parse("{{" + field + "}}")
+		if err != nil {
+			return nil, err
+		}
+		if err := t.Execute(&b, g.Config); err != nil {
+			vars[field] = "(" + err.Error() + ")"
+			continue
+		}
+		vars[field] = b.String()
+	}
+	return vars, nil
+}
+
+// collectFields records every .Field chain used under node, e.g.
+// ".Features.bench". root says whether dot is still the template's data:
+// inside range and with it is something else, so only $-rooted chains
+// such as $.ProjectName count there.
+func collectFields(node parse.Node, out map[string]bool, root bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			collectFields(c, out, root)
+		}
+	case *parse.ActionNode:
+		collectFields(n.Pipe, out, root)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			collectFields(c, out, root)
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			collectFields(a, out, root)
+		}
+	case *parse.FieldNode:
+		if root {
+			out["."+strings.Join(n.Ident, ".")] = true
+		}
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			out["."+strings.Join(n.Ident[1:], ".")] = true
+		}
+	case *parse.IfNode:
+		collectBranch(&n.BranchNode, out, root, root)
+	case *parse.RangeNode:
+		collectBranch(&n.BranchNode, out, root, false)
+	case *parse.WithNode:
+		collectBranch(&n.BranchNode, out, root, false)
+	case *parse.TemplateNode:
+		collectFields(n.Pipe, out, root)
+	}
+}
+
+// collectBranch walks an if, range or with: its pipeline and else branch
+// see the enclosing dot, its body sees body's.
+func collectBranch(b *parse.BranchNode, out map[string]bool, root, body bool) {
+	collectFields(b.Pipe, out, root)
+	collectFields(b.List, out, body)
+	collectFields(b.ElseList, out, root)
+}
+
+// SortedVars returns the Vars keys in order, for display.
+func (ex *Explanation) SortedVars() []string {
+	keys := make([]string, 0, len(ex.Vars))
+	for k := range ex.Vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,89 @@
+package project
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+func TestCollectFields(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		want []string
+	}{
+		{`{{.ProjectName}} {{.Features.bench}}`, []string{".Features.bench", ".ProjectName"}},
+		{`{{if .Vendor}}{{.ModuleURL}}{{else}}{{.Host}}{{end}}`, []string{".Host", ".ModuleURL", ".Vendor"}},
+		// Inside range and with, dot is the element; only $ reaches the data
+		{`{{range .Binaries}}{{.Name}} {{$.ModuleURL}}{{end}}`, []string{".Binaries", ".ModuleURL"}},
+		{`{{range .ConfigFields}}{{.EnvVar $.EnvPrefix}}{{else}}{{.Owner}}{{end}}`, []string{".ConfigFields", ".EnvPrefix", ".Owner"}},
+		{`{{with .Vars.license}}{{.}}{{.Text}}{{else}}{{.Kind}}{{end}}`, []string{".Kind", ".Vars.license"}},
+		{`{{range $i, $b := .Binaries}}{{if $i}}, {{end}}{{$b.Name}}{{end}}`, []string{".Binaries"}},
+		{`{{with .Owner}}{{range $.Binaries}}{{if $.Kind}}{{.Deep}}{{end}}{{end}}{{end}}`, []string{".Binaries", ".Kind", ".Owner"}},
+	}
+	for _, tt := range tests {
+		tpl, err := template.New("t").Parse(tt.tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fields := make(map[string]bool)
+		collectFields(tpl.Tree.Root, fields, true)
+		if got := slices.Sorted(maps.Keys(fields)); !slices.Equal(got, tt.want) {
+			t.Errorf("collectFields(%s) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	dir := t.TempDir()
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+
+	// Every variable of every template evaluates against the project
+	for _, r := range g.Results {
+		ex, err := (&Generator{}).Explain(filepath.Join(dir, filepath.FromSlash(r.Path)))
+		if err != nil {
+			t.Fatalf("Explain(%s) error: %v", r.Path, err)
+		}
+		if ex.Path != r.Path || ex.Template != r.Template || ex.Modified || ex.Missing {
+			t.Errorf("Explain(%s) = %+v, want %s from %s, unmodified", r.Path, ex, r.Path, r.Template)
+		}
+		for k, v := range ex.Vars {
+			if strings.HasPrefix(v, "(") {
+				t.Errorf("Explain(%s) var %s = %s", r.Path, k, v)
+			}
+		}
+	}
+
+	readme := filepath.Join(dir, "README.md")
+	ex, err := (&Generator{}).Explain(readme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex.Vars[".ModuleURL"] != "github.com/example/demo" || ex.Vars[".Owner"] != "example" {
+		t.Errorf("README.md vars = %v, want .ModuleURL and .Owner", ex.Vars)
+	}
+
+	if err := os.WriteFile(readme, []byte("# mine\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if ex, err := (&Generator{}).Explain(readme); err != nil || !ex.Modified {
+		t.Errorf("Explain() of an edited file = %+v, %v; want it modified", ex, err)
+	}
+	if err := os.Remove(readme); err != nil {
+		t.Fatal(err)
+	}
+	if ex, err := (&Generator{}).Explain(readme); err != nil || !ex.Missing {
+		t.Errorf("Explain() of a deleted file = %+v, %v; want it missing", ex, err)
+	}
+	if _, err := (&Generator{}).Explain(filepath.Join(dir, "go.mod")); err == nil {
+		t.Error("Explain(go.mod) succeeded, want an error: no template made it")
+	}
+}
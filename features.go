@@ -0,0 +1,130 @@
+package project
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// featureFiles lists the optional features and the extra templates each
+// one renders. Templates can also test a feature with {{if .Features.name}}.
+// A feature's tasks go in its own taskfile-<name> template; see
+// componentTaskfiles.
+var featureFiles = map[string][]string{
+	"app":          {"app", "app-store", "app-server", "serve", "air", "taskfile-app"},
+	"bench":        {"bench", "taskfile-bench"},
+	"catalog":      {"catalog"},
+	"coverage":     {"coverage", "taskfile-coverage"},
+	"fuzz":         {"fuzz", "taskfile-fuzz"},
+	"itest":        {"itest", "itest-test", "taskfile-itest"},
+	"mocks":        {"taskfile-mocks"},
+	"plugins":      {"plugins", "plugin-example", "taskfile-plugins"},
+	"private":      nil,
+	"remoteconfig": {"config-remote"},
+	"wasm":         {"wasm", "wasm-html", "taskfile-wasm"},
+	"wire":         {"wire", "wire-gen", "taskfile-wire"},
+}
+
+// featureRequires lists the features each one builds on; enabling it
+// enables them too.
+var featureRequires = map[string][]string{
+	"wire": {"app"},
+}
+
+// KnownFeatures returns the names of all optional features, sorted.
+func KnownFeatures() []string {
+	names := make([]string, 0, len(featureFiles))
+	for name := range featureFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyFeatures merges the requested features with those recorded in the
+// previous manifest, so updates keep what the project was generated with,
+// and exposes the result to templates as g.Config.Features.
+func (g *Generator) applyFeatures(prev *Manifest) error {
+	set := make(map[string]bool)
+	var names []string
+	if prev != nil {
+		names = append(names, prev.Features...)
+	}
+	for _, name := range append(names, g.Features...) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := featureFiles[name]; !ok {
+			return fmt.Errorf("unknown feature %q (known: %s)", name, strings.Join(KnownFeatures(), ", "))
+		}
+		if !g.kind().supports(name) {
+			return fmt.Errorf("feature %s is not available for %s projects", name, g.Config.Kind)
+		}
+		set[name] = true
+		for _, req := range featureRequires[name] {
+			set[req] = true
+		}
+	}
+
+	g.Features = g.Features[:0]
+	for _, name := range KnownFeatures() {
+		if set[name] {
+			g.Features = append(g.Features, name)
+		}
+	}
+	g.Config.Features = set
+	g.Config.Taskfiles = componentTaskfiles(g.fileTypes())
+	return nil
+}
+
+// kind returns the settings of the project's kind, the default kind if unset.
+func (g *Generator) kind() projectKind {
+	if k, ok := kinds[g.Config.Kind]; ok {
+		return k
+	}
+	return kinds[DefaultKind]
+}
+
+// ciFileType picks the CI template: GitLab CI for GitLab hosts, else GitHub Actions.
+func (g *Generator) ciFileType() string {
+	if strings.Contains(g.Config.Host, "gitlab") {
+		return "gitlab-ci"
+	}
+	return "github-ci"
+}
+
+// TaskfilesDir holds the component taskfiles Taskfile.yaml includes.
+const TaskfilesDir = "taskfiles"
+
+// taskfilePrefix starts the templates of component taskfiles:
+// taskfile-docker renders taskfiles/docker.yaml.
+const taskfilePrefix = "taskfile-"
+
+// componentTaskfiles names the component taskfiles among types, for
+// Taskfile.yaml to include. A component adds tasks by rendering its own
+// taskfile, which Taskfile.yaml picks up, rather than by editing it.
+func componentTaskfiles(types []string) []string {
+	var names []string
+	for _, ft := range types {
+		if name, ok := strings.CutPrefix(ft, taskfilePrefix); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// isTaskfile reports whether fileType renders a Taskfile, whose VAR:
+// patterns become task variables.
+func isTaskfile(fileType string) bool {
+	return fileType == "taskfile" || strings.HasPrefix(fileType, taskfilePrefix)
+}
+
+// fileTypes returns the templates to render: those of the project kind,
+// the CI pipeline for the project's host, and those of each enabled feature.
+func (g *Generator) fileTypes() []string {
+	types := append(append([]string(nil), g.kind().files...), g.ciFileType())
+	for _, name := range KnownFeatures() {
+		if g.Config.Features[name] {
+			types = append(types, featureFiles[name]...)
+		}
+	}
+	return types
+}
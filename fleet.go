@@ -0,0 +1,159 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/robbyriverside/project/internal/forge"
+	"github.com/robbyriverside/project/internal/gitops"
+	"github.com/robbyriverside/project/internal/retry"
+)
+
+// FleetOptions configures RunFleet.
+type FleetOptions struct {
+	Parallel int                      // clones worked on at once, default 4
+	Token    func(host string) string // API token for a forge, nil for none
+
+	// Progress, if set, is called as each repository finishes, from the
+	// goroutine that worked on it.
+	Progress func(FleetResult)
+}
+
+// FleetOp is what project fleet does to the clone of one repository in
+// dir, filling in res. token is the API token for the repository's forge.
+type FleetOp func(repo ListedRepo, dir, token string, res *FleetResult) error
+
+// FleetResult is the outcome for one repository. Which of Verify, Score and
+// PullRequest are set depends on the operation.
+type FleetResult struct {
+	Repo        string        `json:"repo"`
+	Verify      *VerifyReport `json:"verify,omitempty"`
+	Score       *Scorecard    `json:"score,omitempty"`
+	Migrations  []string      `json:"migrations,omitempty"`   // applied by update, as "<version> <name>"
+	PullRequest string        `json:"pull_request,omitempty"` // opened by update, "" when up to date
+	Error       string        `json:"error,omitempty"`
+}
+
+// RunFleet makes a shallow clone of each repo, Parallel at a time, runs op
+// on it and removes it again. A repo that fails to clone or whose op fails
+// has its result's Error set; the others carry on. Once ctx is done no more
+// clones start. Results are in the order of repos.
+func RunFleet(ctx context.Context, repos []ListedRepo, opts FleetOptions, op FleetOp) []FleetResult {
+	parallel := opts.Parallel
+	if parallel < 1 {
+		parallel = 4
+	}
+	results := make([]FleetResult, len(repos))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		results[i].Repo = repo.Repo
+		if err := acquire(ctx, sem); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		wg.Add(1)
+		go func(res *FleetResult, repo ListedRepo) {
+			defer func() { <-sem; wg.Done() }()
+			if err := runFleetOp(ctx, repo, opts, op, res); err != nil {
+				res.Error = err.Error()
+			}
+			if opts.Progress != nil {
+				opts.Progress(*res)
+			}
+		}(&results[i], repo)
+	}
+	wg.Wait()
+	return results
+}
+
+// acquire takes a slot in sem, unless ctx is done first.
+func acquire(ctx context.Context, sem chan struct{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case sem <- struct{}{}:
+		return nil
+	}
+}
+
+// runFleetOp clones repo into a temporary directory and runs op there.
+func runFleetOp(ctx context.Context, repo ListedRepo, opts FleetOptions, op FleetOp, res *FleetResult) error {
+	var token, auth string
+	if opts.Token != nil {
+		token = opts.Token(repo.Host())
+	}
+	if token != "" {
+		auth = forge.For(ctx, repo.Host(), token, retry.Policy{}).PushAuth()
+	}
+
+	tmp, err := os.MkdirTemp("", "project-fleet-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(tmp, path.Base(repo.Repo))
+	if err := gitops.Clone(repo.cloneURL(), dir, repo.Branch, auth); err != nil {
+		return fmt.Errorf("failed to clone: %w", err)
+	}
+	return op(repo, dir, token, res)
+}
+
+// FleetVerify verifies each clone against its manifest, as project verify does.
+func FleetVerify(repo ListedRepo, dir, token string, res *FleetResult) error {
+	report, err := Verify(dir)
+	if err != nil {
+		return err
+	}
+	report.ProjectDir = ""
+	res.Verify = report
+	return nil
+}
+
+// FleetScore grades each clone against rubric, as project score does.
+func FleetScore(rubric *Rubric) FleetOp {
+	return func(repo ListedRepo, dir, token string, res *FleetResult) error {
+		card, err := Score(dir, rubric)
+		if err != nil {
+			return err
+		}
+		card.ProjectDir = ""
+		res.Score = card
+		return nil
+	}
+}
+
+// FleetUpdate updates each clone with a generator from newGen and opens a
+// pull request for it, as project update --pr does. Clones are worked on
+// in parallel, so each gets a generator of its own. A repository generated
+// with another template pack fails rather than being regenerated with this one.
+func FleetUpdate(newGen func() (*Generator, error), branch string) FleetOp {
+	return func(repo ListedRepo, dir, token string, res *FleetResult) error {
+		g, err := newGen()
+		if err != nil {
+			return err
+		}
+		// Update would regenerate another pack's project with this one
+		m, err := loadManifest(g.fsys(), dir)
+		if err != nil {
+			return err
+		}
+		if p := g.packStatus(m); p.Error != "" {
+			return errors.New(p.Error)
+		}
+		url, applied, err := g.UpdatePullRequest(dir, PullRequestOptions{Token: token, Branch: branch})
+		for _, m := range applied {
+			res.Migrations = append(res.Migrations, m.Version+" "+m.Name)
+		}
+		res.PullRequest = url
+		return err
+	}
+}
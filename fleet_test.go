@@ -0,0 +1,72 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/fileutils"
+	"github.com/robbyriverside/project/internal/gitops"
+)
+
+func TestRunFleet(t *testing.T) {
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	if err := g.GenerateAll("github.com/example/demo", t.TempDir()); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	clean := g.Config.ProjectPath()
+	edited := filepath.Join(t.TempDir(), "edited")
+	if err := fileutils.CopyTree(clean, edited); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(edited, "README.md"), []byte("mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Clones copy the projects above; anything else isn't found
+	sources := map[string]string{
+		"https://github.com/acme/api.git": clean,
+		"https://github.com/acme/cli.git": edited,
+	}
+	defer func(r execx.Runner) { gitops.Runner = r }(gitops.Runner)
+	gitops.Runner = &execx.Recorder{Respond: func(c execx.Call) (execx.Result, error) {
+		url, dir := c.Args[len(c.Args)-2], c.Args[len(c.Args)-1]
+		src, ok := sources[url]
+		if c.Args[0] != "clone" || !ok {
+			return execx.Result{Stderr: []byte("repository not found")}, errors.New("exit status 128")
+		}
+		return execx.Result{}, fileutils.CopyTree(src, dir)
+	}}
+
+	repos := []ListedRepo{{Repo: "github.com/acme/api"}, {Repo: "github.com/acme/cli"}, {Repo: "github.com/acme/gone"}}
+	var done atomic.Int32
+	results := RunFleet(context.Background(), repos, FleetOptions{Parallel: 2, Progress: func(FleetResult) { done.Add(1) }}, FleetVerify)
+	if len(results) != 3 || done.Load() != 3 {
+		t.Fatalf("got %d results with %d reported, want 3", len(results), done.Load())
+	}
+	if r := results[0]; r.Repo != "github.com/acme/api" || r.Error != "" || !r.Verify.Clean() {
+		t.Errorf("api = %+v, want clean", r)
+	}
+	if r := results[1]; r.Error != "" || r.Verify.Clean() {
+		t.Errorf("cli = %+v, want README.md modified", r)
+	}
+	if r := results[2]; r.Error == "" || r.Verify != nil {
+		t.Errorf("gone = %+v, want a clone error", r)
+	}
+
+	results = RunFleet(context.Background(), repos[:2], FleetOptions{}, FleetScore(nil))
+	if results[0].Score == nil || results[0].Score.Score == 0 {
+		t.Errorf("api = %+v, want a score", results[0])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results = RunFleet(ctx, repos, FleetOptions{}, FleetVerify)
+	if results[0].Error == "" || results[0].Verify != nil {
+		t.Errorf("after cancel api = %+v, want it not started", results[0])
+	}
+}
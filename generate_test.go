@@ -0,0 +1,306 @@
+package project
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robbyriverside/project/internal/archive"
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/fileutils"
+	"github.com/robbyriverside/project/internal/retry"
+)
+
+// fakeGo answers go commands as the toolchain would, without running it:
+// go mod init writes a go.mod and go mod edit -json reports no requires.
+func fakeGo(c execx.Call) (execx.Result, error) {
+	switch {
+	case strings.HasPrefix(c.String(), "go mod init "):
+		return execx.Result{}, os.WriteFile(filepath.Join(c.Dir, "go.mod"), []byte("module "+c.Args[2]+"\n"), 0o644)
+	case c.String() == "go mod edit -json":
+		return execx.Result{Stdout: []byte("{}")}, nil
+	}
+	return execx.Result{}, nil
+}
+
+func TestGenerateAllRunner(t *testing.T) {
+	rec := &execx.Recorder{Respond: fakeGo}
+	g := &Generator{Runner: rec, GoEnv: GoEnv{Proxy: "off"}}
+	dir := t.TempDir()
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+
+	var got []string
+	for _, c := range rec.Calls() {
+		got = append(got, c.Name+" "+strings.Join(c.Args[:2], " "))
+		if c.Dir != g.Config.ProjectPath() {
+			t.Errorf("%s ran in %s, want %s", c, c.Dir, g.Config.ProjectPath())
+		}
+		if len(c.Env) != 1 || c.Env[0] != "GOPROXY=off" {
+			t.Errorf("%s env = %q, want GOPROXY=off", c, c.Env)
+		}
+	}
+	if want := "go mod init|go mod edit|go mod tidy"; strings.Join(got, "|") != want {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+	if len(g.Commands) != len(got) {
+		t.Errorf("Commands has %d entries, want %d", len(g.Commands), len(got))
+	}
+
+	mod, err := os.ReadFile(filepath.Join(g.Config.ProjectPath(), "go.mod"))
+	if err != nil || !strings.Contains(string(mod), "github.com/example/demo => .") {
+		t.Errorf("go.mod = %q, %v; want the replace directives", mod, err)
+	}
+}
+
+func TestGenerateAllRetry(t *testing.T) {
+	tidies := 0
+	rec := &execx.Recorder{Respond: func(c execx.Call) (execx.Result, error) {
+		if c.String() == "go mod tidy" {
+			if tidies++; tidies == 1 {
+				return execx.Result{Stderr: []byte("dial tcp: lookup proxy.golang.org: i/o timeout\n")}, errors.New("exit status 1")
+			}
+		}
+		return fakeGo(c)
+	}}
+	g := &Generator{Runner: rec, Retry: retry.Policy{Attempts: 2, Delay: time.Millisecond}}
+	if err := g.GenerateAll("github.com/example/demo", t.TempDir()); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	if tidies != 2 {
+		t.Errorf("go mod tidy ran %d times, want 2", tidies)
+	}
+
+	// Failures that aren't the network's are not retried
+	tidies = 0
+	rec.Respond = func(c execx.Call) (execx.Result, error) {
+		if c.String() == "go mod tidy" {
+			tidies++
+			return execx.Result{Stderr: []byte("go: finding module for package example.com/nope\n")}, errors.New("exit status 1")
+		}
+		return fakeGo(c)
+	}
+	if err := g.GenerateAll("github.com/example/demo", t.TempDir()); err == nil || tidies != 1 {
+		t.Errorf("GenerateAll() = %v with %d tidies, want one failed tidy", err, tidies)
+	}
+}
+
+// GenerateAll on a MemFS writes the project in memory, runs nothing and
+// leaves the host untouched.
+func TestGenerateAllInMemory(t *testing.T) {
+	mem := &fileutils.MemFS{}
+	rec := &execx.Recorder{Respond: fakeGo}
+	dir := filepath.Join(t.TempDir(), "out")
+	g := &Generator{FS: mem, Runner: rec}
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("GenerateAll() touched the host: %v", err)
+	}
+	if calls := rec.Calls(); len(calls) != 0 {
+		t.Errorf("GenerateAll() ran %v, want nothing off the host", calls)
+	}
+
+	pp := g.Config.ProjectPath()
+	gomod, err := mem.ReadFile(filepath.Join(pp, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(gomod), "module github.com/example/demo\n") || !strings.Contains(string(gomod), "github.com/example/demo/config => ./config") {
+		t.Errorf("go.mod = %q, want the module and its replaces", gomod)
+	}
+	for _, r := range g.Results {
+		if _, err := mem.Stat(filepath.Join(pp, filepath.FromSlash(r.Path))); err != nil {
+			t.Errorf("%s not written: %v", r.Path, err)
+		}
+	}
+
+	// A second run reads back what the first wrote
+	diffs, err := (&Generator{FS: mem}).Diff(pp)
+	if err != nil {
+		t.Fatalf("Diff() error: %v", err)
+	}
+	for _, d := range diffs {
+		if d.State != DiffClean {
+			t.Errorf("%s is %s in memory", d.Path, d.State)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := archive.WriteFS(&buf, mem.DirFS(pp), archive.Zip); err != nil {
+		t.Fatalf("WriteFS() error: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if !slices.Contains(names, "cmd/demo/main.go") {
+		t.Errorf("archive holds %v, want cmd/demo/main.go", names)
+	}
+}
+
+func TestGenerateAllFileModes(t *testing.T) {
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}, Features: []string{"coverage"}}
+	if err := g.GenerateAll("github.com/example/demo", t.TempDir()); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	pp := g.Config.ProjectPath()
+	for path, want := range map[string]os.FileMode{
+		"scripts/coverage-check.sh": 0o755,
+		"cmd/demo/main.go":          fileutils.DefaultMode,
+	} {
+		info, err := os.Stat(filepath.Join(pp, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("%s mode = %v, want %v", path, info.Mode().Perm(), want)
+		}
+	}
+}
+
+func TestGenerateAllEvents(t *testing.T) {
+	var events []Event
+	rec := &execx.Recorder{Respond: fakeGo}
+	g := &Generator{Runner: rec, Events: func(e Event) { events = append(events, e) }}
+	if err := g.GenerateAll("github.com/example/demo", filepath.Join(t.TempDir(), "demo")); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+
+	var steps []string
+	files, commands := 0, 0
+	for _, e := range events {
+		switch e.Kind {
+		case EventStepStart:
+			steps = append(steps, e.Step)
+			if e.Index != len(steps) || e.Total != len(StepNames()) {
+				t.Errorf("step %s is %d of %d, want %d of %d", e.Step, e.Index, e.Total, len(steps), len(StepNames()))
+			}
+		case EventStepDone:
+			if e.Step != steps[len(steps)-1] || e.Err != "" {
+				t.Errorf("step-done %+v doesn't end step %s", e, steps[len(steps)-1])
+			}
+		case EventFile:
+			if e.Path != g.Results[files].Path || e.Status != g.Results[files].Status {
+				t.Errorf("file event %+v, want result %+v", e, g.Results[files])
+			}
+			files++
+		case EventCommand:
+			commands++
+		}
+	}
+	if !slices.Equal(steps, StepNames()) {
+		t.Errorf("steps = %v, want %v", steps, StepNames())
+	}
+	if files != len(g.Results) {
+		t.Errorf("%d file events, want one per result (%d)", files, len(g.Results))
+	}
+	if commands != len(g.Commands) {
+		t.Errorf("%d command events, want one per command (%d)", commands, len(g.Commands))
+	}
+}
+
+func TestPreview(t *testing.T) {
+	rec := &execx.Recorder{Respond: fakeGo}
+	g := &Generator{Runner: rec, Features: []string{"bench"}, Binaries: []string{"demod"}, Vars: map[string]string{"team": "core"}}
+	files, err := g.Preview("github.com/example/demo")
+	if err != nil {
+		t.Fatalf("Preview() error: %v", err)
+	}
+	if len(rec.Calls()) != 0 {
+		t.Errorf("Preview() ran %v, want no commands", rec.Calls())
+	}
+	if _, err := os.Stat("demo"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Preview() wrote the project directory: %v", err)
+	}
+	if g.Config.Vars["team"] != "core" {
+		t.Errorf("Config.Vars = %v, want team=core", g.Config.Vars)
+	}
+
+	g = &Generator{Runner: &execx.Recorder{Respond: fakeGo}, Features: []string{"bench"}, Binaries: []string{"demod"}, Vars: map[string]string{"team": "core"}}
+	if err := g.GenerateAll("github.com/example/demo", t.TempDir()); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	written := make(map[string]string)
+	for _, r := range g.Results {
+		written[r.Path] = r.SHA256
+	}
+	for _, f := range files {
+		if sum, ok := written[f.Path]; !ok || sum != f.Checksum() {
+			t.Errorf("previewed %s differs from the generated one", f.Path)
+		}
+		delete(written, f.Path)
+	}
+	if len(written) > 0 {
+		t.Errorf("Preview() is missing %v", written)
+	}
+}
+
+func TestPreviewTUI(t *testing.T) {
+	g := &Generator{Kind: "tui", Subcommands: []Subcommand{{Name: "sync", Short: "Sync the data"}}}
+	files, err := g.Preview("github.com/example/demo")
+	if err != nil {
+		t.Fatalf("Preview() error: %v", err)
+	}
+	got := make(map[string]string)
+	fset := token.NewFileSet()
+	for _, f := range files {
+		got[f.Path] = string(f.Data)
+		if strings.HasSuffix(f.Path, ".go") {
+			if _, err := parser.ParseFile(fset, f.Path, f.Data, parser.AllErrors); err != nil {
+				t.Errorf("%s does not parse: %v", f.Path, err)
+			}
+		}
+	}
+	for _, path := range []string{"ui/ui.go", "ui/keys.go", "ui/styles.go", "ui/home.go", "cmd/demo/ui.go", "cmd/demo/sync_cmd.go", "config/config.go"} {
+		if _, ok := got[path]; !ok {
+			t.Errorf("Preview() is missing %s", path)
+		}
+	}
+	if !strings.Contains(got["cmd/demo/main.go"], `args = []string{"ui"}`) {
+		t.Errorf("main.go does not default to the ui command:\n%s", got["cmd/demo/main.go"])
+	}
+	if !strings.Contains(got["logs/logs.go"], "File string") {
+		t.Errorf("logs.go has no log file option")
+	}
+}
+
+func TestPreviewComponentTaskfiles(t *testing.T) {
+	g := &Generator{Features: []string{"app", "bench"}}
+	files, err := g.Preview("github.com/example/demo")
+	if err != nil {
+		t.Fatalf("Preview() error: %v", err)
+	}
+	got := make(map[string]string)
+	for _, f := range files {
+		got[f.Path] = string(f.Data)
+	}
+	for _, name := range []string{"app", "bench"} {
+		if !strings.Contains(got["Taskfile.yaml"], "  "+name+":\n    taskfile: ./taskfiles/"+name+".yaml\n") {
+			t.Errorf("Taskfile.yaml does not include taskfiles/%s.yaml", name)
+		}
+	}
+	if app := got["taskfiles/app.yaml"]; !strings.Contains(app, "go run {{.MAIN}} serve") {
+		t.Errorf("taskfiles/app.yaml = %q, want the serve task with task variables", app)
+	}
+	if _, ok := got["taskfiles/bench.yaml"]; !ok {
+		t.Errorf("Preview() is missing taskfiles/bench.yaml")
+	}
+	if strings.Contains(got["Taskfile.yaml"], "  bench:\n    desc:") {
+		t.Errorf("Taskfile.yaml still defines the bench task itself")
+	}
+}
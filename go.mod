@@ -4,11 +4,12 @@ go 1.24.0
 
 require (
 	github.com/jessevdk/go-flags v1.6.1
+	go.starlark.net v0.0.0-20250623223156-8bf495bf4e9a
 	go.uber.org/zap v1.27.0
+	golang.org/x/mod v0.33.0
+	golang.org/x/sys v0.41.0
+	golang.org/x/text v0.34.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	go.uber.org/multierr v1.10.0 // indirect
-	golang.org/x/sys v0.21.0 // indirect
-)
+require go.uber.org/multierr v1.10.0 // indirect
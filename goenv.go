@@ -0,0 +1,120 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/retry"
+)
+
+// GoEnv is the module environment of the go commands a run starts (go mod
+// init, edit and tidy). Empty fields leave the caller's environment, and
+// whatever go env -w set, in place.
+type GoEnv struct {
+	Proxy   string // GOPROXY, e.g. https://proxy.example.com,direct
+	Private string // GOPRIVATE, e.g. github.com/acme/*
+	NoSumDB string // GONOSUMDB; the go command defaults it to GOPRIVATE
+
+	// ModCache is GOMODCACHE. Runs that generate many projects share one,
+	// warmed with WarmModCache, so each go mod tidy finds the scaffold's
+	// modules there instead of downloading them again.
+	ModCache string
+}
+
+// environ lists the variables e sets, as KEY=value.
+func (e GoEnv) environ() []string {
+	var env []string
+	for _, kv := range [][2]string{{"GOPROXY", e.Proxy}, {"GOPRIVATE", e.Private}, {"GONOSUMDB", e.NoSumDB}, {"GOMODCACHE", e.ModCache}} {
+		if kv[1] != "" {
+			env = append(env, kv[0]+"="+kv[1])
+		}
+	}
+	return env
+}
+
+// CommandResult is one go command a run started, with its captured output.
+type CommandResult struct {
+	Args     []string // e.g. [go mod tidy]
+	Duration time.Duration
+	Output   []byte // stderr, where the go command reports progress and errors
+	Err      error
+}
+
+// stderrTail is how many lines of stderr a failed command's error quotes.
+const stderrTail = 10
+
+// runGo runs a go subcommand in the project folder with g.GoEnv, cancelled
+// with g.Context or after g.GoTimeout, and records it in g.Commands. It
+// returns stdout; a failure's error ends with the tail of stderr.
+func (g *Generator) runGo(args ...string) ([]byte, error) {
+	ctx := g.ctx()
+	if g.GoTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.GoTimeout)
+		defer cancel()
+	}
+	if env := g.GoEnv.environ(); len(env) > 0 {
+		ctx = execx.WithEnv(ctx, env...)
+	}
+
+	res, err := g.run(ctx, g.Config.ProjectPath(), "go", args...)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && g.ctx().Err() == nil {
+		err = fmt.Errorf("timed out after %s", g.GoTimeout)
+	}
+	argv := append([]string{"go"}, args...)
+	g.Commands = append(g.Commands, CommandResult{Args: argv, Duration: res.Duration, Output: res.Stderr, Err: err})
+	g.event(Event{Kind: EventCommand, Command: argv, Duration: res.Duration, Err: errString(err)})
+	if err != nil {
+		name := strings.Join(argv, " ")
+		if tail := lastLines(string(res.Stderr), stderrTail); tail != "" {
+			return nil, fmt.Errorf("%s: %w\n%s", name, err, tail)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return res.Stdout, nil
+}
+
+// netErrors are what go commands print when the module proxy or a VCS
+// host can't be reached; they are worth another try.
+var netErrors = []string{
+	"dial tcp", "i/o timeout", "TLS handshake timeout", "connection reset",
+	"connection refused", "unexpected EOF", "temporary failure in name resolution",
+	"429 Too Many Requests", "502 Bad Gateway", "503 Service Unavailable", "504 Gateway Timeout",
+}
+
+// goNet runs a go subcommand that downloads modules, repeating it per
+// g.Retry while it fails for network reasons.
+func (g *Generator) goNet(args ...string) error {
+	policy := g.Retry
+	policy.OnRetry = func(attempt int, err error, wait time.Duration) {
+		g.log().Debugw("retry", "args", append([]string{"go"}, args...), "attempt", attempt, "wait", wait, "error", err)
+		if g.Retry.OnRetry != nil {
+			g.Retry.OnRetry(attempt, err, wait)
+		}
+	}
+	return policy.Do(g.ctx(), func() error {
+		err := g.goCmd(args...)
+		if err == nil || g.ctx().Err() != nil {
+			return retry.Permanent(err)
+		}
+		for _, s := range netErrors {
+			if strings.Contains(err.Error(), s) {
+				return err
+			}
+		}
+		return retry.Permanent(err)
+	})
+}
+
+// lastLines returns the last n lines of s, without the trailing newline.
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,29 @@
+package project
+
+import (
+	"path"
+
+	"github.com/robbyriverside/project/internal/codemod"
+)
+
+// mergeGo returns what to write for a Go file edited since the last run:
+// the file as the user left it, plus the imports, struct fields and switch
+// cases f adds, with conflict markers around what can't be added safely;
+// conflicts counts them. ok is false when the file wasn't edited, isn't
+// Go, is marked DO NOT EDIT or can't be parsed, and the usual rules apply.
+func (g *Generator) mergeGo(f RenderedFile, destPath string) (merged, current []byte, conflicts int, ok bool) {
+	prev := g.prev.File(f.Path)
+	if prev == nil || f.Streamed() || path.Ext(f.Path) != ".go" || fileMarker(g.fsys(), destPath) == HeaderGenerated {
+		return nil, nil, 0, false
+	}
+	current, err := g.fsys().ReadFile(destPath)
+	if err != nil || checksum(current) == prev.SHA256 {
+		return nil, nil, 0, false
+	}
+	merged, conflicts, err = codemod.MergeGo(f.Path, current, f.Data)
+	if err != nil {
+		g.log().Debugw("gomerge", "path", f.Path, "error", err)
+		return nil, nil, 0, false
+	}
+	return merged, current, conflicts, true
+}
@@ -0,0 +1,171 @@
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+// Header markers a pack's headers rules give files.
+const (
+	HeaderEditable  = "editable"  // safe to edit; update keeps local edits
+	HeaderGenerated = "generated" // DO NOT EDIT; update rewrites local edits
+)
+
+// HeaderRule gives the files matching Path a provenance header with the
+// rule's marker; the first matching rule wins. See Generator.Headers.
+type HeaderRule struct {
+	// Path is a glob against the slash-separated project path; one without
+	// a slash matches the file name in any directory, e.g. *.go.
+	Path   string `yaml:"path"`
+	Marker string `yaml:"marker"`
+}
+
+// headerMarker returns the marker of the first rule matching rel, if any.
+func (p *Pack) headerMarker(rel string) string {
+	for _, r := range p.Headers {
+		name := rel
+		if !strings.Contains(r.Path, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(r.Path, name); ok {
+			return r.Marker
+		}
+	}
+	return ""
+}
+
+// checkHeaders rejects rules with unknown markers or bad globs.
+func (p *Pack) checkHeaders() error {
+	for _, r := range p.Headers {
+		if r.Marker != HeaderEditable && r.Marker != HeaderGenerated {
+			return fmt.Errorf("header rule %q: marker must be %s or %s, not %q", r.Path, HeaderEditable, HeaderGenerated, r.Marker)
+		}
+		if _, err := path.Match(r.Path, ""); err != nil {
+			return fmt.Errorf("header rule %q: %w", r.Path, err)
+		}
+	}
+	return nil
+}
+
+// commentStyles are the line comment delimiters of the files that can
+// carry a header, by extension or, for dotfiles and the like, by name.
+var commentStyles = map[string][2]string{
+	".go":   {"// ", ""},
+	".yaml": {"# ", ""},
+	".yml":  {"# ", ""},
+	".toml": {"# ", ""},
+	".sh":   {"# ", ""},
+	".md":   {"<!-- ", " -->"},
+
+	".gitignore":   {"# ", ""},
+	".env.example": {"# ", ""},
+	"Makefile":     {"# ", ""},
+	"Dockerfile":   {"# ", ""},
+}
+
+// commentStyle returns how to write a comment line in rel.
+func commentStyle(rel string) ([2]string, bool) {
+	if style, ok := commentStyles[path.Base(rel)]; ok {
+		return style, true
+	}
+	style, ok := commentStyles[path.Ext(rel)]
+	return style, ok
+}
+
+// headerLine is the provenance comment for a file with marker. Generated
+// Go files follow the "Code generated ... DO NOT EDIT." convention that go
+// vet and editors recognize.
+func headerLine(style [2]string, marker string, pack *Pack) string {
+	note := "safe to edit."
+	if marker == HeaderGenerated {
+		note = "DO NOT EDIT."
+	}
+	return fmt.Sprintf("%sCode generated by project %s from pack %s %s; %s%s\n", style[0], Version, pack.Name, pack.Version, note, style[1])
+}
+
+// addHeaders puts the pack's provenance header at the top of each file a
+// rule matches, after any #! line.
+func (g *Generator) addHeaders(files []RenderedFile) error {
+	if !g.Headers {
+		return nil
+	}
+	pack, err := g.PackInfo()
+	if err != nil {
+		return err
+	}
+	for i := range files {
+		f := &files[i]
+		marker := pack.headerMarker(f.Path)
+		style, ok := commentStyle(f.Path)
+		if marker == "" || !ok {
+			continue
+		}
+		header := []byte(headerLine(style, marker, pack) + "\n")
+		if !f.Streamed() {
+			f.Data = insertHeader(f.Data, header)
+			continue
+		}
+		open := f.Open
+		f.Open = func() (io.ReadCloser, error) {
+			r, err := open()
+			if err != nil {
+				return nil, err
+			}
+			return struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(header), r), r}, nil
+		}
+		if f.Size, f.SHA256, err = streamChecksum(f.Open); err != nil {
+			return fmt.Errorf("failed to render %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// insertHeader returns data with header at the top, or below a #! line.
+func insertHeader(data, header []byte) []byte {
+	var shebang []byte
+	if bytes.HasPrefix(data, []byte("#!")) {
+		end := bytes.IndexByte(data, '\n') + 1
+		if end == 0 {
+			end = len(data)
+		}
+		shebang, data = data[:end], data[end:]
+	}
+	out := make([]byte, 0, len(shebang)+len(header)+len(data))
+	return append(append(append(out, shebang...), header...), data...)
+}
+
+// headerLines is how far into a file fileMarker looks for a header.
+const headerLines = 5
+
+// fileMarker reads the marker of the provenance header at the top of the
+// file at path on fsys, or "" when it has none.
+func fileMarker(fsys fileutils.FS, path string) string {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for i := 0; i < headerLines && sc.Scan(); i++ {
+		line := sc.Text()
+		if !strings.Contains(line, "Code generated by project ") {
+			continue
+		}
+		switch {
+		case strings.Contains(line, "DO NOT EDIT."):
+			return HeaderGenerated
+		case strings.Contains(line, "safe to edit."):
+			return HeaderEditable
+		}
+	}
+	return ""
+}
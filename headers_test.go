@@ -0,0 +1,73 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+func TestInsertHeader(t *testing.T) {
+	header := []byte("# Code generated by project dev from pack p 1.0.0; safe to edit.\n\n")
+	tests := []struct{ in, want string }{
+		{"x: 1\n", string(header) + "x: 1\n"},
+		{"#!/bin/sh\necho hi\n", "#!/bin/sh\n" + string(header) + "echo hi\n"},
+		{"#!/bin/sh", "#!/bin/sh" + string(header)},
+	}
+	for _, tt := range tests {
+		if got := string(insertHeader([]byte(tt.in), header)); got != tt.want {
+			t.Errorf("insertHeader(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}, Headers: true}
+	dir := t.TempDir()
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	root := g.Config.ProjectPath()
+	configGo := filepath.Join(root, "config", "config.go")
+	logsGo := filepath.Join(root, "logs", "logs.go")
+	if got := fileMarker(fileutils.OS, configGo); got != HeaderEditable {
+		t.Errorf("config.go marker = %q, want %q", got, HeaderEditable)
+	}
+	if got := fileMarker(fileutils.OS, logsGo); got != HeaderGenerated {
+		t.Errorf("logs.go marker = %q, want %q", got, HeaderGenerated)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "README.md")); strings.Contains(string(data), "Code generated") {
+		t.Error("README.md has a header though no rule matches it")
+	}
+
+	// Local edits survive in editable files only; headers stay on without the flag
+	for _, path := range []string{configGo, logsGo} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, append(data, "// local edit\n"...), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g = &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("second GenerateAll() error: %v", err)
+	}
+	status := map[string]FileStatus{}
+	for _, r := range g.Results {
+		status[r.Path] = r.Status
+	}
+	if status["config/config.go"] != StatusKept || status["logs/logs.go"] != StatusUpdated {
+		t.Errorf("statuses = config.go %s, logs.go %s; want kept, updated", status["config/config.go"], status["logs/logs.go"])
+	}
+	if data, _ := os.ReadFile(configGo); !strings.HasSuffix(string(data), "// local edit\n") {
+		t.Error("config.go lost its local edit")
+	}
+	if data, _ := os.ReadFile(logsGo); strings.Contains(string(data), "// local edit") {
+		t.Error("logs.go kept its local edit")
+	}
+}
@@ -0,0 +1,104 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robbyriverside/project/internal/sandbox"
+)
+
+// Hook is a command a pack declares in pack.yaml, e.g.
+//
+//	hooks:
+//	  - name: format
+//	    run: [gofmt, -w, .]
+//
+// Hooks never go through a shell; run is the argv as-is.
+type Hook struct {
+	Name string   `yaml:"name"`
+	Run  []string `yaml:"run"`
+}
+
+// HookStatus describes what a run did with one hook.
+type HookStatus string
+
+const (
+	HookRan      HookStatus = "ran"
+	HookFailed   HookStatus = "failed"
+	HookDenied   HookStatus = "denied"   // command not on the allowlist
+	HookDisabled HookStatus = "disabled" // hooks are off by policy
+)
+
+// HookResult is the outcome of one hook, with its captured output.
+type HookResult struct {
+	Name     string
+	Command  []string
+	Status   HookStatus
+	Output   string
+	Isolated bool // ran without network
+	Fallback bool // ran with network because isolation is unavailable
+	Err      error
+}
+
+// HookPolicy controls pack hooks. A nil policy on the Generator, or one
+// that isn't Enabled, reports every hook as disabled without running it.
+type HookPolicy struct {
+	Enabled bool
+	sandbox.Policy
+}
+
+// runHooks runs the pack's hooks in the project directory under
+// g.HookPolicy, recording each in g.HookResults. A failing hook doesn't
+// stop the rest; the error returned names the first one that failed.
+func (g *Generator) runHooks() error {
+	pack, err := g.PackInfo()
+	if err != nil {
+		return err
+	}
+
+	var first error
+	for _, h := range pack.Hooks {
+		r := HookResult{Name: h.Name, Command: h.Run}
+		var took time.Duration
+		switch {
+		case g.HookPolicy == nil || !g.HookPolicy.Enabled:
+			r.Status = HookDisabled
+		case !g.HookPolicy.Allowed(h.Run):
+			r.Status = HookDenied
+		default:
+			policy := g.HookPolicy.Policy
+			if policy.Runner == nil {
+				policy.Runner = g.Runner
+			}
+			res, err := sandbox.Run(g.ctx(), &policy, g.Config.ProjectPath(), h.Run)
+			if res != nil {
+				r.Output = string(res.Output)
+				r.Isolated = res.Isolated
+				r.Fallback = res.Fallback
+				took = res.Duration
+			}
+			r.Status = HookRan
+			if err != nil {
+				r.Status, r.Err = HookFailed, err
+				if errors.Is(err, sandbox.ErrNotAllowed) {
+					r.Status = HookDenied
+				}
+			}
+		}
+		kv := []any{"name", h.Name, "args", h.Run, "dir", g.Config.ProjectPath(), "status", r.Status, "duration", took}
+		if r.Fallback {
+			kv = append(kv, "fallback", true)
+		}
+		if r.Err != nil {
+			kv = append(kv, "error", r.Err)
+		}
+		g.log().Debugw("hook", kv...)
+		if r.Status == HookFailed && first == nil {
+			first = fmt.Errorf("hook %s failed: %w", h.Name, r.Err)
+		}
+		g.HookResults = append(g.HookResults, r)
+		g.event(Event{Kind: EventHook, Hook: h.Name, HookStatus: r.Status, Command: h.Run, Duration: took, Err: errString(r.Err)})
+	}
+	return first
+}
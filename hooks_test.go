@@ -0,0 +1,94 @@
+package project
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/sandbox"
+)
+
+const hooksPack = `name: hooks
+version: 1.0.0
+hooks:
+  - name: format
+    run: [gofmt, -w, .]
+  - name: fetch
+    run: [curl, https://example.com/install.sh]
+  - name: vet
+    run: [go, vet, ./...]
+  - name: tidy
+    run: [go, mod, tidy]
+`
+
+func TestRunHooks(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *HookPolicy
+		want   []HookStatus
+		ran    []string
+		err    string
+	}{
+		{
+			name: "no policy",
+			want: []HookStatus{HookDisabled, HookDisabled, HookDisabled, HookDisabled},
+		},
+		{
+			name:   "disabled",
+			policy: &HookPolicy{Policy: sandbox.Policy{Allow: []string{"go", "gofmt"}}},
+			want:   []HookStatus{HookDisabled, HookDisabled, HookDisabled, HookDisabled},
+		},
+		{
+			// vet fails; tidy still runs, and the error names vet
+			name:   "enabled",
+			policy: &HookPolicy{Enabled: true, Policy: sandbox.Policy{Allow: []string{"go", "gofmt"}}},
+			want:   []HookStatus{HookRan, HookDenied, HookFailed, HookRan},
+			ran:    []string{"gofmt -w .", "go vet ./...", "go mod tidy"},
+			err:    "hook vet failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &execx.Recorder{Respond: func(c execx.Call) (execx.Result, error) {
+				if c.String() == "go vet ./..." {
+					return execx.Result{Stderr: []byte("main.go:3: unreachable code\n")}, errors.New("exit status 1")
+				}
+				return execx.Result{Stdout: []byte("ok\n")}, nil
+			}}
+			g := &Generator{
+				Runner:     rec,
+				HookPolicy: tt.policy,
+				Templates:  fstest.MapFS{PackManifestName: {Data: []byte(hooksPack)}},
+				Config:     NewGenConfig("github.com/example/demo", t.TempDir()),
+			}
+
+			err := g.runHooks()
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("runHooks() error = %v, want %q", err, tt.err)
+			}
+			var got []HookStatus
+			for _, r := range g.HookResults {
+				got = append(got, r.Status)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("hook statuses = %v, want %v", got, tt.want)
+			}
+			var ran []string
+			for _, c := range rec.Calls() {
+				ran = append(ran, c.String())
+				if c.Dir != g.Config.ProjectPath() {
+					t.Errorf("%s ran in %s, want the project dir", c, c.Dir)
+				}
+			}
+			if !slices.Equal(ran, tt.ran) {
+				t.Errorf("ran %q, want %q", ran, tt.ran)
+			}
+			if len(g.HookResults) == 4 && g.HookResults[2].Status == HookFailed && !strings.Contains(g.HookResults[2].Output, "unreachable code") {
+				t.Errorf("failed hook output = %q, want its stderr", g.HookResults[2].Output)
+			}
+		})
+	}
+}
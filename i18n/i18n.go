@@ -0,0 +1,173 @@
+// Package i18n translates user-facing messages with golang.org/x/text.
+// Messages are keyed by their English text, a fmt format, and translated
+// in YAML files named after the language, e.g. messages/ja.yaml:
+//
+//	"Removed %s\n": "%s を削除しました\n"
+//
+// Usage:
+//
+//	c := i18n.New()
+//	_ = c.Load(fsys, "messages")    // ja.yaml, de.yaml, ...
+//	p := c.Printer(i18n.Locale())   // closest language, else English
+//	p.Printf("Removed %s\n", dir)
+//
+// The project tool ships its own catalog; template packs can ship one in
+// their messages/ directory for strings their templates print with T.
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+	"gopkg.in/yaml.v3"
+)
+
+// Catalog holds translations for any number of languages. English is
+// always available: an untranslated message prints as its key.
+type Catalog struct {
+	builder *catalog.Builder
+	formats map[language.Tag]map[string]string
+	langs   []language.Tag // English first
+}
+
+// New returns an empty catalog.
+func New() *Catalog {
+	return &Catalog{
+		builder: catalog.NewBuilder(catalog.Fallback(language.English)),
+		formats: make(map[language.Tag]map[string]string),
+		langs:   []language.Tag{language.English},
+	}
+}
+
+// Load adds the translations in dir of fsys, one <lang>.yaml file per
+// language mapping English messages to their translations. A missing dir
+// is not an error.
+func (c *Catalog) Load(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read messages: %w", err)
+	}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".yaml")
+		if e.IsDir() || !ok {
+			continue
+		}
+		tag, err := language.Parse(name)
+		if err != nil {
+			return fmt.Errorf("invalid messages file %s: %w", e.Name(), err)
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read messages: %w", err)
+		}
+		var msgs map[string]string
+		if err := yaml.Unmarshal(data, &msgs); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", e.Name(), err)
+		}
+		for key, msg := range msgs {
+			if err := c.Set(tag, key, msg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Set adds one translation.
+func (c *Catalog) Set(tag language.Tag, key, msg string) error {
+	if err := c.builder.SetString(tag, key, msg); err != nil {
+		return fmt.Errorf("invalid message %q: %w", key, err)
+	}
+	if c.formats[tag] == nil {
+		c.formats[tag] = make(map[string]string)
+		if tag != language.English {
+			c.langs = append(c.langs, tag)
+		}
+	}
+	c.formats[tag][key] = msg
+	return nil
+}
+
+// Printer returns a printer for the catalog language closest to lang,
+// a BCP 47 tag or POSIX locale such as ja_JP.UTF-8; English if none is close.
+func (c *Catalog) Printer(lang string) *Printer {
+	tag := language.English
+	if t, err := language.Parse(normalize(lang)); err == nil {
+		if _, i, conf := language.NewMatcher(c.langs).Match(t); conf != language.No {
+			tag = c.langs[i]
+		}
+	}
+	return &Printer{
+		p:       message.NewPrinter(tag, message.Catalog(c.builder)),
+		tag:     tag,
+		formats: c.formats[tag],
+	}
+}
+
+// Printer prints messages in one language.
+type Printer struct {
+	p       *message.Printer
+	tag     language.Tag
+	formats map[string]string
+}
+
+// Language returns the printer's language, e.g. "ja".
+func (p *Printer) Language() string {
+	return p.tag.String()
+}
+
+// Sprintf formats the translation of key.
+func (p *Printer) Sprintf(key string, args ...any) string {
+	return p.p.Sprintf(key, args...)
+}
+
+// Printf prints the translation of key to stdout.
+func (p *Printer) Printf(key string, args ...any) {
+	p.p.Printf(key, args...)
+}
+
+// Fprintf prints the translation of key to w.
+func (p *Printer) Fprintf(w io.Writer, key string, args ...any) {
+	p.p.Fprintf(w, key, args...)
+}
+
+// Errorf is fmt.Errorf with the translation of key, so %w still wraps.
+func (p *Printer) Errorf(key string, args ...any) error {
+	format, ok := p.formats[key]
+	if !ok {
+		format = key
+	}
+	return fmt.Errorf(format, args...)
+}
+
+// Locale returns the user's message locale from the environment, in the
+// POSIX order LC_ALL, LC_MESSAGES, LANG; "" when unset or C/POSIX.
+func Locale() string {
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if l := os.Getenv(v); l != "" {
+			if l == "C" || l == "POSIX" || strings.HasPrefix(l, "C.") {
+				return ""
+			}
+			return l
+		}
+	}
+	return ""
+}
+
+// normalize turns a POSIX locale such as ja_JP.UTF-8@euro into a BCP 47 tag.
+func normalize(locale string) string {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	return strings.ReplaceAll(locale, "_", "-")
+}
@@ -0,0 +1,65 @@
+package i18n
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func testCatalog(t *testing.T) *Catalog {
+	t.Helper()
+	c := New()
+	fsys := fstest.MapFS{
+		"messages/ja.yaml": {Data: []byte("\"Removed %s\\n\": \"%s を削除しました\\n\"\n\"failed to fetch: %w\": \"取得に失敗しました: %w\"\n")},
+		"messages/README":  {Data: []byte("not a catalog")},
+	}
+	if err := c.Load(fsys, "messages"); err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	return c
+}
+
+func TestPrinter(t *testing.T) {
+	c := testCatalog(t)
+	tests := []struct {
+		lang     string
+		wantLang string
+		want     string
+	}{
+		{"ja", "ja", "x を削除しました\n"},
+		{"ja_JP.UTF-8", "ja", "x を削除しました\n"},
+		{"de", "en", "Removed x\n"},
+		{"", "en", "Removed x\n"},
+		{"not a locale!", "en", "Removed x\n"},
+	}
+	for _, tt := range tests {
+		p := c.Printer(tt.lang)
+		if got := p.Language(); got != tt.wantLang {
+			t.Errorf("Printer(%q).Language() = %q, want %q", tt.lang, got, tt.wantLang)
+		}
+		if got := p.Sprintf("Removed %s\n", "x"); got != tt.want {
+			t.Errorf("Printer(%q).Sprintf() = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestErrorf(t *testing.T) {
+	base := errors.New("boom")
+	err := testCatalog(t).Printer("ja").Errorf("failed to fetch: %w", base)
+	if !errors.Is(err, base) || err.Error() != "取得に失敗しました: boom" {
+		t.Errorf("Errorf() = %v, want translated and wrapping %v", err, base)
+	}
+}
+
+func TestLocale(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "ja_JP.UTF-8")
+	t.Setenv("LANG", "en_US.UTF-8")
+	if got := Locale(); got != "ja_JP.UTF-8" {
+		t.Errorf("Locale() = %q, want LC_MESSAGES", got)
+	}
+	t.Setenv("LC_ALL", "C")
+	if got := Locale(); got != "" {
+		t.Errorf("Locale() with LC_ALL=C = %q, want \"\"", got)
+	}
+}
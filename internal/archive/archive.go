@@ -0,0 +1,254 @@
+// Package archive packs a directory tree into a gzipped tar or a zip, for
+// projects handed out as a download instead of written in place.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+// Format is how an archive is packed.
+type Format string
+
+const (
+	TarGz Format = "tar.gz"
+	Zip   Format = "zip"
+)
+
+// FormatOf picks the format a file name's extension asks for: .tar.gz,
+// .tgz or .zip.
+func FormatOf(name string) (Format, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return TarGz, nil
+	case strings.HasSuffix(lower, ".zip"):
+		return Zip, nil
+	}
+	return "", fmt.Errorf("%s is not a .tar.gz, .tgz or .zip file", filepath.Base(name))
+}
+
+// Write packs dir into w in format.
+func Write(w io.Writer, dir string, format Format) error {
+	if format == Zip {
+		return WriteZip(w, dir)
+	}
+	return WriteTarGz(w, dir)
+}
+
+// WriteFS is Write for the tree of fsys, such as a project generated in
+// memory.
+func WriteFS(w io.Writer, fsys fs.FS, format Format) error {
+	if format == Zip {
+		return writeZip(w, fsys, ".")
+	}
+	return writeTarGz(w, fsys, ".")
+}
+
+// walk calls fn for everything in fsys, with its slash-separated path.
+// dir names the tree in errors.
+func walk(fsys fs.FS, dir string, fn func(name string, info fs.FileInfo) error) error {
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || name == "." {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(name, info)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", dir, err)
+	}
+	return nil
+}
+
+// WriteTarGz writes every file and directory under dir to w as a .tar.gz,
+// named by their slash-separated paths relative to dir, with their modes
+// and modification times. Symlinks are stored as links.
+func WriteTarGz(w io.Writer, dir string) error {
+	return writeTarGz(w, dirFS(dir), dir)
+}
+
+func writeTarGz(w io.Writer, fsys fs.FS, dir string) error {
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	if err := walk(fsys, dir, func(name string, info fs.FileInfo) error {
+		return addTar(tw, fsys, name, info)
+	}); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// addTar writes the header of the file name, and its content when it is a
+// regular file.
+func addTar(tw *tar.Writer, fsys fs.FS, name string, info fs.FileInfo) error {
+	link, err := linkTarget(fsys, name, info)
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	// Owners of the machine that generated the project mean nothing to
+	// whoever unpacks it
+	hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	return copyFile(tw, fsys, name)
+}
+
+// WriteZip is WriteTarGz for a .zip. Symlinks are stored the way Info-ZIP
+// does, as entries holding the link target.
+func WriteZip(w io.Writer, dir string) error {
+	return writeZip(w, dirFS(dir), dir)
+}
+
+func writeZip(w io.Writer, fsys fs.FS, dir string) error {
+	zw := zip.NewWriter(w)
+	if err := walk(fsys, dir, func(name string, info fs.FileInfo) error {
+		return addZip(zw, fsys, name, info)
+	}); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func addZip(zw *zip.Writer, fsys fs.FS, name string, info fs.FileInfo) error {
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	} else {
+		hdr.Method = zip.Deflate
+	}
+	fw, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		link, err := linkTarget(fsys, name, info)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(fw, link)
+		return err
+	case info.Mode().IsRegular():
+		return copyFile(fw, fsys, name)
+	}
+	return nil
+}
+
+// readLinkFS is a filesystem that can read symlinks.
+type readLinkFS interface {
+	fs.FS
+	ReadLink(name string) (string, error)
+}
+
+// linkFS is os.DirFS(dir) that also reads symlinks, which os.DirFS
+// itself only does from Go 1.25.
+type linkFS struct {
+	fs.FS
+	dir string
+}
+
+func dirFS(dir string) linkFS {
+	return linkFS{FS: os.DirFS(dir), dir: dir}
+}
+
+func (l linkFS) ReadLink(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return os.Readlink(filepath.Join(l.dir, filepath.FromSlash(name)))
+}
+
+// linkTarget is where the symlink name points, or "" for other files.
+func linkTarget(fsys fs.FS, name string, info fs.FileInfo) (string, error) {
+	if info.Mode()&fs.ModeSymlink == 0 {
+		return "", nil
+	}
+	rl, ok := fsys.(readLinkFS)
+	if !ok {
+		return "", fmt.Errorf("can't read symlink %s", name)
+	}
+	return rl.ReadLink(name)
+}
+
+func copyFile(w io.Writer, fsys fs.FS, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// ExtractTarGz unpacks the .tar.gz in r into dir: its directories and
+// regular files, with modes made writable by the owner. Links and other
+// special entries are skipped, and an entry naming a path outside dir
+// fails the whole extraction.
+func ExtractTarGz(r io.Reader, dir string) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("unsafe path %q", hdr.Name)
+		}
+		dst := filepath.Join(dir, filepath.FromSlash(name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dst, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+			if err := fileutils.WriteFile(dst, data, fileutils.NormalizeMode(fs.FileMode(hdr.Mode))); err != nil {
+				return err
+			}
+		}
+	}
+}
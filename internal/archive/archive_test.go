@@ -0,0 +1,136 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testTree writes a small project and returns its parent directory.
+func testTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "demo", "cmd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "demo", "go.mod"), []byte("module demo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "demo", "cmd", "run.sh"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// wantTree is testTree as each entry's mode and content.
+var wantTree = map[string]string{
+	"demo/":           "-rwxr-xr-x ",
+	"demo/cmd/":       "-rwxr-xr-x ",
+	"demo/go.mod":     "-rw-r--r-- module demo\n",
+	"demo/cmd/run.sh": "-rwxr-xr-x #!/bin/sh\n",
+}
+
+func TestWriteTarGz(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testTree(t), TarGz); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(zr)
+	got := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		got[hdr.Name] = hdr.FileInfo().Mode().Perm().String() + " " + string(data)
+	}
+	checkTree(t, got)
+}
+
+func TestWriteZip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testTree(t), Zip); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		got[f.Name] = f.Mode().Perm().String() + " " + string(data)
+	}
+	checkTree(t, got)
+}
+
+func checkTree(t *testing.T, got map[string]string) {
+	t.Helper()
+	if len(got) != len(wantTree) {
+		t.Errorf("archive has %q, want %q", got, wantTree)
+	}
+	for name, want := range wantTree {
+		if got[name] != want {
+			t.Errorf("%s = %q, want %q", name, got[name], want)
+		}
+	}
+}
+
+func TestExtractTarGz(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTarGz(&buf, testTree(t)); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := ExtractTarGz(&buf, dir); err != nil {
+		t.Fatalf("ExtractTarGz() error: %v", err)
+	}
+	got := make(map[string]string)
+	for name := range wantTree {
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		got[name] = info.Mode().Perm().String() + " " + string(data)
+	}
+	checkTree(t, got)
+
+	// An entry climbing out of dir is refused
+	buf.Reset()
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	tw.WriteHeader(&tar.Header{Name: "../escape", Mode: 0o644, Typeflag: tar.TypeReg})
+	tw.Close()
+	zw.Close()
+	if err := ExtractTarGz(&buf, dir); err == nil {
+		t.Error("ExtractTarGz() of ../escape succeeded, want an error")
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	for name, want := range map[string]Format{"out.tar.gz": TarGz, "OUT.TGZ": TarGz, "dist/demo.zip": Zip, "demo.tar": ""} {
+		got, err := FormatOf(name)
+		if got != want || (err != nil) != (want == "") {
+			t.Errorf("FormatOf(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+}
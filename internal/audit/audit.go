@@ -0,0 +1,130 @@
+// Package audit keeps a log of generation runs, so generated code can be
+// traced to who made it, when, and from which template pack: JSON objects,
+// one per line, appended to a local file and optionally posted to a
+// webhook.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robbyriverside/project/internal/retry"
+)
+
+// Results of a run.
+const (
+	OK     = "ok"
+	Failed = "failed"
+)
+
+// Entry is one run of a command that writes projects.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	Host     string    `json:"host,omitempty"`
+	Command  string    `json:"command"`        // e.g. gen or add config-field
+	Args     []string  `json:"args,omitempty"` // the command line, less the program
+	Version  string    `json:"version"`        // of the project tool
+	Projects []Project `json:"projects,omitempty"`
+	Result   string    `json:"result"` // OK or Failed
+	Error    string    `json:"error,omitempty"`
+}
+
+// Project is a project a run wrote, or tried to.
+type Project struct {
+	Module      string `json:"module,omitempty"`
+	Dir         string `json:"dir"`
+	Pack        string `json:"pack,omitempty"`
+	PackVersion string `json:"pack_version,omitempty"`
+}
+
+// Append adds e to the log at path, creating it, readable only by its
+// owner, if need be. Each entry is a single write, so concurrent runs
+// don't interleave their lines.
+func Append(path string, e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Read returns the entries of the log at path, oldest first; none if it
+// doesn't exist yet.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// Post sends e as JSON to a webhook. Like any POST, it is repeated only
+// when the server says it didn't handle it: 429 or 503.
+func Post(ctx context.Context, url string, e Entry, policy retry.Policy) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	return policy.Do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return retry.Permanent(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return retry.Permanent(fmt.Errorf("POST %s failed: %w", url, err))
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode/100 == 2 {
+			return nil
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		err = fmt.Errorf("POST %s: %d %s", url, resp.StatusCode, strings.TrimSpace(string(msg)))
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+}
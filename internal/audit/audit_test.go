@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robbyriverside/project/internal/retry"
+)
+
+func testEntry(module string) Entry {
+	return Entry{
+		Time:     time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC),
+		User:     "ada",
+		Command:  "gen",
+		Args:     []string{"gen", module},
+		Version:  "v1.2.3",
+		Projects: []Project{{Module: module, Dir: "~/src/demo", Pack: "go-cli", PackVersion: "1.29.0"}},
+		Result:   OK,
+	}
+}
+
+func TestAppendRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	if entries, err := Read(path); err != nil || entries != nil {
+		t.Fatalf("Read(missing) = %v, %v; want none", entries, err)
+	}
+	for _, module := range []string{"github.com/acme/one", "github.com/acme/two"} {
+		if err := Append(path, testEntry(module)); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Projects[0].Module != "github.com/acme/two" || !entries[0].Time.Equal(testEntry("").Time) {
+		t.Errorf("Read() = %+v, want both entries in order", entries)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("log mode = %v, %v; want 0600", info.Mode(), err)
+	}
+
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString("{not json\n")
+	f.Close()
+	if _, err := Read(path); err == nil || !strings.Contains(err.Error(), ":3:") {
+		t.Errorf("Read() error = %v, want one naming line 3", err)
+	}
+}
+
+func TestPost(t *testing.T) {
+	var got []Entry
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls++; calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var e Entry
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		got = append(got, e)
+	}))
+	defer srv.Close()
+
+	policy := retry.Policy{Attempts: 2, Delay: time.Millisecond}
+	if err := Post(context.Background(), srv.URL, testEntry("github.com/acme/one"), policy); err != nil {
+		t.Fatalf("Post() error: %v", err)
+	}
+	if calls != 2 || len(got) != 1 || got[0].User != "ada" {
+		t.Errorf("webhook got %d calls, %+v; want a retry then the entry", calls, got)
+	}
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "nope", http.StatusForbidden)
+	})
+	calls = 0
+	if err := Post(context.Background(), srv.URL, testEntry("github.com/acme/one"), policy); err == nil || !strings.Contains(err.Error(), "403 nope") {
+		t.Errorf("Post() error = %v, want the 403", err)
+	}
+	if calls != 1 {
+		t.Errorf("a 403 was tried %d times, want 1", calls)
+	}
+}
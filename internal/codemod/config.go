@@ -0,0 +1,222 @@
+package codemod
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+// ConfigField is a key to add to the Config struct of a generated config
+// package. Values are kept as strings; Type says how they are checked.
+type ConfigField struct {
+	Name    string // Go field name, e.g. MaxRetries
+	Key     string // yaml key, e.g. max_retries
+	Type    string // string, int, bool, duration or list
+	Desc    string
+	Default string // empty for none
+}
+
+// Tag returns the struct tag of f, without the backquotes.
+func (f ConfigField) Tag() string {
+	parts := []string{"desc=" + f.Desc}
+	if f.Default != "" {
+		parts = append(parts, "default="+f.Default)
+	}
+	if f.Type != "" && f.Type != "string" {
+		parts = append(parts, "type="+f.Type)
+	}
+	return fmt.Sprintf("yaml:%q config:%q", f.Key, strings.Join(parts, ","))
+}
+
+// AddConfigField appends f to the Config struct in the Go file at path and,
+// when f has a default, sets it in the defaultConfig literal as well.
+func AddConfigField(path string, f ConfigField) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	st := findStruct(file, "Config")
+	if st == nil {
+		return fmt.Errorf("%s has no Config struct", path)
+	}
+	for _, field := range st.Fields.List {
+		for _, name := range field.Names {
+			if name.Name == f.Name {
+				return fmt.Errorf("Config already has a field %s", f.Name)
+			}
+		}
+		if field.Tag == nil {
+			continue
+		}
+		tag, _ := strconv.Unquote(field.Tag.Value)
+		if key, _, _ := strings.Cut(reflect.StructTag(tag).Get("yaml"), ","); key == f.Key {
+			return fmt.Errorf("Config already has a key %s", f.Key)
+		}
+	}
+	// Splice the new lines in before the closing braces, later one first,
+	// and let gofmt align them
+	edits := map[int]string{
+		fset.Position(st.Fields.Closing).Offset: fmt.Sprintf("%s string `%s`\n", f.Name, f.Tag()),
+	}
+	if f.Default != "" {
+		lit := findVarLiteral(file, "defaultConfig")
+		if lit == nil {
+			return fmt.Errorf("%s has no defaultConfig literal", path)
+		}
+		edits[fset.Position(lit.Rbrace).Offset] = fmt.Sprintf("%s: %s,\n", f.Name, strconv.Quote(f.Default))
+	}
+	offsets := make([]int, 0, len(edits))
+	for off := range edits {
+		offsets = append(offsets, off)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(offsets)))
+	for _, off := range offsets {
+		line := edits[off]
+		if before := bytes.TrimRight(src[:off], " \t"); !bytes.HasSuffix(before, []byte("\n")) {
+			line = "\n" + line // the brace closes a one-line literal
+		}
+		src = append(src[:off], append([]byte(line), src[off:]...)...)
+	}
+
+	out, err := format.Source(src)
+	if err != nil {
+		return fmt.Errorf("failed to format %s: %w", path, err)
+	}
+	return writeSource(fileutils.OS, path, out)
+}
+
+// FieldDoc is a key of a generated Config struct as its package's Fields
+// documents it.
+type FieldDoc struct {
+	Key        string
+	Type       string
+	Default    string
+	Desc       string
+	Env        string
+	Deprecated string // e.g. "use log_format"
+}
+
+// Description is Desc after the deprecation note, if any, as config docs
+// prints it.
+func (d FieldDoc) Description() string {
+	if d.Deprecated == "" {
+		return d.Desc
+	}
+	return strings.TrimSpace("Deprecated, " + d.Deprecated + ". " + d.Desc)
+}
+
+// ConfigFields reads the Config struct of a generated config package's
+// source as its Fields does at run time: each field's yaml key, the type,
+// default, desc and deprecated note of its config tag, and the variable
+// prefix_KEY that overrides it.
+func ConfigFields(src []byte, prefix string) ([]FieldDoc, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "config.go", src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the config package: %w", err)
+	}
+	st := findStruct(file, "Config")
+	if st == nil {
+		return nil, fmt.Errorf("the config package has no Config struct")
+	}
+	var out []FieldDoc
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			s, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(s)
+		}
+		key, _, _ := strings.Cut(tag.Get("yaml"), ",")
+		parts := parseTag(tag.Get("config"))
+		typ := parts["type"]
+		if typ == "" {
+			typ = types.ExprString(field.Type)
+		}
+		doc := FieldDoc{
+			Key:        key,
+			Type:       typ,
+			Default:    parts["default"],
+			Desc:       parts["desc"],
+			Env:        prefix + "_" + strings.ToUpper(key),
+			Deprecated: parts["deprecated"],
+		}
+		// Fields declared together share the tag, and reflection lists each
+		for range max(len(field.Names), 1) {
+			out = append(out, doc)
+		}
+	}
+	return out, nil
+}
+
+// parseTag splits a config tag, 'desc=...,default=...', as the generated
+// package does: a part without '=' continues the previous value, so
+// descriptions may contain commas.
+func parseTag(tag string) map[string]string {
+	out := make(map[string]string)
+	last := ""
+	for _, part := range strings.Split(tag, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) == 2 {
+			out[kv[0]] = kv[1]
+			last = kv[0]
+		} else if last != "" {
+			out[last] += "," + part
+		}
+	}
+	return out
+}
+
+// findStruct returns the struct type declared as name in file, if any.
+func findStruct(file *ast.File, name string) *ast.StructType {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if st, ok := ts.Type.(*ast.StructType); ok && ts.Name.Name == name {
+				return st
+			}
+		}
+	}
+	return nil
+}
+
+// findVarLiteral returns the composite literal that var name is set to.
+func findVarLiteral(file *ast.File, name string) *ast.CompositeLit {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, id := range vs.Names {
+				if id.Name != name || i >= len(vs.Values) {
+					continue
+				}
+				if lit, ok := vs.Values[i].(*ast.CompositeLit); ok {
+					return lit
+				}
+			}
+		}
+	}
+	return nil
+}
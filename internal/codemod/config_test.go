@@ -0,0 +1,86 @@
+package codemod
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const configSrc = `package config
+
+type Config struct {
+  // HomeDir is where data lives
+  HomeDir string ` + "`yaml:\"home\" config:\"desc=Base directory,default=~/dev\"`" + `
+}
+
+var defaultConfig = Config{
+  HomeDir: "~/dev",
+}
+`
+
+func TestAddConfigField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.go")
+	if err := os.WriteFile(path, []byte(configSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := ConfigField{Name: "MaxRetries", Key: "max_retries", Type: "int", Desc: "Retries, at most", Default: "3"}
+	if err := AddConfigField(path, f); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"\t// HomeDir is where data lives\n\tHomeDir    string `yaml:\"home\"",
+		"\tMaxRetries string `yaml:\"max_retries\" config:\"desc=Retries, at most,default=3,type=int\"`\n}",
+		"\tMaxRetries: \"3\",\n}",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+
+	if err := AddConfigField(path, ConfigField{Name: "Other", Key: "home"}); err == nil {
+		t.Error("added a second home key")
+	}
+	if err := AddConfigField(path, ConfigField{Name: "MaxRetries", Key: "retries"}); err == nil {
+		t.Error("added a second MaxRetries field")
+	}
+}
+
+func TestConfigFields(t *testing.T) {
+	src := `package config
+
+type Config struct {
+  HomeDir string ` + "`yaml:\"home\" config:\"desc=Base directory, data included,default=~/dev\"`" + `
+  LogFmt  string ` + "`yaml:\"log_fmt,omitempty\" config:\"deprecated=use log_format\"`" + `
+  Retries string ` + "`yaml:\"retries\" config:\"desc=Tries,type=int\"`" + `
+  Tags    []string
+}
+`
+	got, err := ConfigFields([]byte(src), "DEMO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FieldDoc{
+		{Key: "home", Type: "string", Default: "~/dev", Desc: "Base directory, data included", Env: "DEMO_HOME"},
+		{Key: "log_fmt", Type: "string", Env: "DEMO_LOG_FMT", Deprecated: "use log_format"},
+		{Key: "retries", Type: "int", Desc: "Tries", Env: "DEMO_RETRIES"},
+		{Key: "", Type: "[]string", Env: "DEMO_"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ConfigFields() =\n%+v\nwant\n%+v", got, want)
+	}
+	if d := got[1].Description(); d != "Deprecated, use log_format." {
+		t.Errorf("Description() = %q, want the deprecation note", d)
+	}
+
+	if _, err := ConfigFields([]byte("package config\n"), "DEMO"); err == nil {
+		t.Error("ConfigFields() of a package without Config succeeded")
+	}
+}
@@ -0,0 +1,87 @@
+// Package codemod rewrites Go source files in generated projects
+// using go/ast, so edits keep the code gofmt-formatted.
+package codemod
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+// RewriteImports changes imports of oldPath (and its sub-packages) to newPath
+// in every .go file under dir on fsys. Only the import paths change; the
+// rest of each file is left byte for byte. It returns the files that changed.
+func RewriteImports(fsys fileutils.FS, dir, oldPath, newPath string) ([]string, error) {
+	var changed []string
+	err := walkGoFiles(fsys, dir, func(path string) error {
+		ok, err := rewriteFileImports(fsys, path, oldPath, newPath)
+		if err != nil {
+			return err
+		}
+		if ok {
+			changed = append(changed, path)
+		}
+		return nil
+	})
+	return changed, err
+}
+
+// rewriteFileImports rewrites one file, reporting whether anything changed.
+func rewriteFileImports(fsys fileutils.FS, path, oldPath, newPath string) (bool, error) {
+	src, err := fsys.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ImportsOnly)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var edits []edit
+	for _, imp := range file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || (p != oldPath && !strings.HasPrefix(p, oldPath+"/")) {
+			continue
+		}
+		start, end := fset.Position(imp.Path.Pos()).Offset, fset.Position(imp.Path.End()).Offset
+		edits = append(edits, edit{start, end, strconv.Quote(newPath + strings.TrimPrefix(p, oldPath))})
+	}
+	out := splice(src, edits)
+	if bytes.Equal(out, src) {
+		return false, nil
+	}
+	return true, writeSource(fsys, path, out)
+}
+
+// walkGoFiles calls fn for each .go file under dir, skipping vendor and hidden dirs.
+func walkGoFiles(fsys fileutils.FS, dir string, fn func(path string) error) error {
+	return fileutils.Walk(fsys, dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (name == "vendor" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".go") {
+			return fn(path)
+		}
+		return nil
+	})
+}
+
+// writeSource atomically replaces a source file, keeping its mode.
+func writeSource(fsys fileutils.FS, path string, data []byte) error {
+	return fileutils.WriteFile(path, data, fileutils.DefaultMode, fileutils.WithFS(fsys))
+}
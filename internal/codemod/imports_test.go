@@ -0,0 +1,35 @@
+package codemod
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+func TestRewriteImports(t *testing.T) {
+	dir := t.TempDir()
+	// Two-space indents must survive: only the paths change
+	src := "package main\n\nimport (\n  \"fmt\"\n\n  \"example.com/app/config\"\n  lg \"example.com/app/logs\"\n  \"example.com/apple\"\n)\n\nfunc main() { fmt.Println(config.X, lg.Y, apple.Z) }\n"
+	want := "package main\n\nimport (\n  \"fmt\"\n\n  \"example.com/tool/config\"\n  lg \"example.com/tool/logs\"\n  \"example.com/apple\"\n)\n\nfunc main() { fmt.Println(config.X, lg.Y, apple.Z) }\n"
+	path := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "other.go")
+	if err := os.WriteFile(other, []byte("package main\n\nimport \"fmt\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := RewriteImports(fileutils.OS, dir, "example.com/app", "example.com/tool")
+	if err != nil {
+		t.Fatalf("RewriteImports() error: %v", err)
+	}
+	if len(changed) != 1 || changed[0] != path {
+		t.Errorf("RewriteImports() changed %v, want only main.go", changed)
+	}
+	if got, _ := os.ReadFile(path); string(got) != want {
+		t.Errorf("main.go =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,610 @@
+package codemod
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The lines MergeGo puts around what it can't merge, as git does: the
+// user's version, then the generated one.
+const (
+	markerCurrent   = "<<<<<<< current"
+	markerSeparator = "======="
+	markerGenerated = ">>>>>>> generated"
+)
+
+// MergeGo returns current, a Go file the user has edited, plus what
+// rendered, the file as the templates now generate it, adds: imports,
+// fields of the structs both declare, and cases of the switches in the
+// functions both declare. Everything else keeps current's version, and
+// the result is gofmt-formatted. Where adding is unsafe, a struct the
+// user made another type or a case whose value the user moved to another
+// case, both versions are written between conflict markers, which
+// conflicts counts. A current with markers still in it is returned as is;
+// one without any of rendered's declarations isn't an edit of it, and is
+// an error.
+func MergeGo(filename string, current, rendered []byte) (merged []byte, conflicts int, err error) {
+	if n := countMarkers(current); n > 0 {
+		return current, n, nil
+	}
+	ren, err := parseSource(filename, rendered)
+	if err != nil {
+		return nil, 0, fmt.Errorf("rendered: %w", err)
+	}
+	cur, err := parseSource(filename, current)
+	if err != nil {
+		return nil, 0, fmt.Errorf("current: %w", err)
+	}
+	if !shared(cur, ren) {
+		return nil, 0, fmt.Errorf("%s has none of the generated declarations", filename)
+	}
+
+	// Additions first, formatted while the file still parses; the markers
+	// go in last, where the formatted file puts them
+	merged = current
+	if adds, _ := plan(cur, ren); len(adds) > 0 {
+		if merged, err = format.Source(splice(current, adds)); err != nil {
+			return nil, 0, fmt.Errorf("failed to format %s: %w", filename, err)
+		}
+		if cur, err = parseSource(filename, merged); err != nil {
+			return nil, 0, fmt.Errorf("merged: %w", err)
+		}
+	}
+	_, marks := plan(cur, ren)
+	return splice(merged, marks), len(marks), nil
+}
+
+// countMarkers counts the conflicts marked in src.
+func countMarkers(src []byte) int {
+	n := 0
+	for _, line := range bytes.Split(src, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte(markerCurrent)) {
+			n++
+		}
+	}
+	return n
+}
+
+// shared reports whether cur declares a name ren does at top level, or
+// ren declares none.
+func shared(cur, ren *source) bool {
+	names := declNames(cur.file)
+	theirs := declNames(ren.file)
+	for name := range theirs {
+		if names[name] {
+			return true
+		}
+	}
+	return len(theirs) == 0
+}
+
+// declNames lists the names file declares at top level, those of its
+// methods included.
+func declNames(file *ast.File) map[string]bool {
+	names := make(map[string]bool)
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			names[d.Name.Name] = true
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					names[s.Name.Name] = true
+				case *ast.ValueSpec:
+					for _, id := range s.Names {
+						names[id.Name] = true
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// source is a parsed Go file.
+type source struct {
+	fset *token.FileSet
+	file *ast.File
+	src  []byte
+}
+
+func parseSource(filename string, src []byte) (*source, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	return &source{fset, file, src}, nil
+}
+
+func (s *source) offset(pos token.Pos) int {
+	return s.fset.Position(pos).Offset
+}
+
+func (s *source) text(n ast.Node) string {
+	return string(s.src[s.offset(n.Pos()):s.offset(n.End())])
+}
+
+// start is where the lines of the node at pos start: the start of its
+// line and of the comment lines right above it, or pos itself when
+// something else comes first on the line.
+func (s *source) start(pos token.Pos) int {
+	off := s.offset(pos)
+	line := bytes.LastIndexByte(s.src[:off], '\n') + 1
+	if len(bytes.TrimSpace(s.src[line:off])) > 0 {
+		return off
+	}
+	for line > 0 {
+		prev := bytes.LastIndexByte(s.src[:line-1], '\n') + 1
+		if !bytes.HasPrefix(bytes.TrimSpace(s.src[prev:line]), []byte("//")) {
+			break
+		}
+		line = prev
+	}
+	return line
+}
+
+// end is where the lines of the node ending at pos end: past the newline
+// of its line, a trailing comment included, or pos itself when code
+// follows on the line.
+func (s *source) end(pos token.Pos) int {
+	off := s.offset(pos)
+	eol := bytes.IndexByte(s.src[off:], '\n')
+	if eol < 0 {
+		return len(s.src)
+	}
+	if rest := bytes.TrimSpace(s.src[off : off+eol]); len(rest) > 0 && !bytes.HasPrefix(rest, []byte("//")) {
+		return off
+	}
+	return off + eol + 1
+}
+
+// lines returns the source of n with its comments, as start and end find it.
+func (s *source) lines(n ast.Node) string {
+	text := string(s.src[s.start(n.Pos()):s.end(n.End())])
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return text
+}
+
+// addition is text to insert at an offset of the current file, taken
+// from node of the rendered one.
+type addition struct {
+	at   int
+	text string
+	node ast.Node
+}
+
+// merger collects what a merge of ren into cur takes.
+type merger struct {
+	cur, ren  *source
+	adds      []addition
+	conflicts []edit
+}
+
+// plan compares cur with ren and returns the edits that add to cur what
+// ren has and those that mark conflicts. Additions inside a conflict are
+// dropped, the markers showing ren's version whole.
+func plan(cur, ren *source) (adds, marks []edit) {
+	m := &merger{cur: cur, ren: ren}
+	m.structs()
+	m.switches()
+
+	sort.SliceStable(m.conflicts, func(i, j int) bool { return m.conflicts[i].start < m.conflicts[j].start })
+	last := 0
+	for _, c := range m.conflicts {
+		if c.start >= last {
+			marks = append(marks, c)
+			last = c.end
+		}
+	}
+	kept := m.adds[:0]
+	for _, a := range m.adds {
+		if !inside(a.at, marks) {
+			kept = append(kept, a)
+		}
+	}
+	m.adds = kept
+	m.imports()
+
+	// Text added at one offset goes in the order it was found
+	var order []int
+	texts := make(map[int]string)
+	for _, a := range m.adds {
+		if _, ok := texts[a.at]; !ok {
+			order = append(order, a.at)
+		}
+		texts[a.at] += a.text
+	}
+	for _, at := range order {
+		adds = append(adds, edit{at, at, texts[at]})
+	}
+	return adds, marks
+}
+
+func inside(at int, marks []edit) bool {
+	for _, c := range marks {
+		if c.start < at && at < c.end {
+			return true
+		}
+	}
+	return false
+}
+
+// add inserts the lines of ren's node n at off in cur, before what's
+// there.
+func (m *merger) add(off int, n ast.Node) {
+	text := m.ren.lines(n)
+	if midLine(m.cur.src, off) {
+		text = "\n" + text
+	}
+	m.adds = append(m.adds, addition{at: off, text: text, node: n})
+}
+
+// conflict marks cur's node c, giving ren's node r as the alternative.
+func (m *merger) conflict(c, r ast.Node) {
+	start, end := m.cur.start(c.Pos()), m.cur.end(c.End())
+	text := markerCurrent + "\n" + m.cur.lines(c) + markerSeparator + "\n" + m.ren.lines(r) + markerGenerated + "\n"
+	if midLine(m.cur.src, start) {
+		text = "\n" + text
+	}
+	m.conflicts = append(m.conflicts, edit{start, end, text})
+}
+
+// midLine reports whether code comes before off on its line.
+func midLine(src []byte, off int) bool {
+	return len(bytes.TrimSpace(src[bytes.LastIndexByte(src[:off], '\n')+1:off])) > 0
+}
+
+// structs adds the fields of ren's structs that the same-named structs of
+// cur lack, after cur's last field. A field is added unless cur has one
+// of its names; one cur has keeps cur's type and tags.
+func (m *merger) structs() {
+	types := make(map[string]typeDecl)
+	for _, td := range typeDecls(m.cur.file) {
+		types[td.spec.Name.Name] = td
+	}
+	for _, rd := range typeDecls(m.ren.file) {
+		rst, ok := rd.spec.Type.(*ast.StructType)
+		cd, found := types[rd.spec.Name.Name]
+		if !ok || !found {
+			continue
+		}
+		cst, ok := cd.spec.Type.(*ast.StructType)
+		if !ok {
+			m.conflict(cd.node, rd.node)
+			continue
+		}
+		have := make(map[string]bool)
+		for _, f := range cst.Fields.List {
+			for _, name := range fieldNames(f) {
+				have[name] = true
+			}
+		}
+		for _, f := range rst.Fields.List {
+			names := fieldNames(f)
+			missing := true
+			for _, name := range names {
+				missing = missing && !have[name]
+			}
+			if missing {
+				m.add(m.cur.start(cst.Fields.Closing), f)
+			}
+		}
+	}
+}
+
+// typeDecl is a type declared at top level, and the node that declares
+// it alone: the spec in a type ( ... ) group, else the whole declaration.
+type typeDecl struct {
+	spec *ast.TypeSpec
+	node ast.Node
+}
+
+func typeDecls(file *ast.File) []typeDecl {
+	var decls []typeDecl
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			td := typeDecl{spec.(*ast.TypeSpec), gen}
+			if gen.Lparen.IsValid() {
+				td.node = spec
+			}
+			decls = append(decls, td)
+		}
+	}
+	return decls
+}
+
+// fieldNames names a struct field: its names, or its type's for an
+// embedded field.
+func fieldNames(f *ast.Field) []string {
+	var names []string
+	for _, id := range f.Names {
+		names = append(names, id.Name)
+	}
+	if len(names) > 0 {
+		return names
+	}
+	t := f.Type
+	for {
+		switch x := t.(type) {
+		case *ast.StarExpr:
+			t = x.X
+		case *ast.IndexExpr:
+			t = x.X
+		case *ast.IndexListExpr:
+			t = x.X
+		case *ast.SelectorExpr:
+			return []string{x.Sel.Name}
+		case *ast.Ident:
+			return []string{x.Name}
+		default:
+			return nil
+		}
+	}
+}
+
+// switches adds the cases of the switches in ren's functions that the
+// matching switches of cur's lack. Switches match by their tag, or type
+// switch assignment, and the order they come in within the function; a
+// case matches one with the same values. A new case goes before a final
+// default, or else last. One whose value cur has in another case is a
+// conflict, as the switch would have it twice.
+func (m *merger) switches() {
+	funcs := make(map[string]*ast.FuncDecl)
+	for _, decl := range m.cur.file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			funcs[funcKey(m.cur, fn)] = fn
+		}
+	}
+	for _, decl := range m.ren.file.Decls {
+		rf, ok := decl.(*ast.FuncDecl)
+		if !ok || rf.Body == nil {
+			continue
+		}
+		cf := funcs[funcKey(m.ren, rf)]
+		if cf == nil {
+			continue
+		}
+		cur := make(map[string]switchStmt)
+		for _, s := range switchStmts(m.cur, cf.Body) {
+			cur[s.key] = s
+		}
+		for _, rs := range switchStmts(m.ren, rf.Body) {
+			if cs, ok := cur[rs.key]; ok {
+				m.cases(cs, rs)
+			}
+		}
+	}
+}
+
+func (m *merger) cases(cs, rs switchStmt) {
+	have := make(map[string]bool)
+	values := make(map[string]bool)
+	var final *ast.CaseClause
+	for _, stmt := range cs.body.List {
+		cc := stmt.(*ast.CaseClause)
+		have[caseKey(m.cur, cc)] = true
+		for _, e := range cc.List {
+			values[m.cur.text(e)] = true
+		}
+		final = cc
+	}
+	at := m.cur.start(cs.body.Rbrace)
+	if final != nil && final.List == nil {
+		at = m.cur.start(final.Pos())
+	}
+	for _, stmt := range rs.body.List {
+		rc := stmt.(*ast.CaseClause)
+		if have[caseKey(m.ren, rc)] {
+			continue
+		}
+		for _, e := range rc.List {
+			if values[m.ren.text(e)] {
+				m.conflict(cs.stmt, rs.stmt)
+				return
+			}
+		}
+		m.add(at, rc)
+	}
+}
+
+// switchStmt is a switch or type switch and the key it's matched by.
+type switchStmt struct {
+	key  string
+	stmt ast.Stmt
+	body *ast.BlockStmt
+}
+
+// switchStmts lists the switches in body, nested ones included.
+func switchStmts(s *source, body *ast.BlockStmt) []switchStmt {
+	var list []switchStmt
+	seen := make(map[string]int)
+	ast.Inspect(body, func(n ast.Node) bool {
+		var key string
+		var block *ast.BlockStmt
+		switch x := n.(type) {
+		case *ast.SwitchStmt:
+			key, block = "switch "+nodeText(s, x.Init)+"; "+nodeText(s, x.Tag), x.Body
+		case *ast.TypeSwitchStmt:
+			key, block = "switch "+nodeText(s, x.Init)+"; "+nodeText(s, x.Assign), x.Body
+		default:
+			return true
+		}
+		seen[key]++
+		list = append(list, switchStmt{key: key + "#" + strconv.Itoa(seen[key]), stmt: n.(ast.Stmt), body: block})
+		return true
+	})
+	return list
+}
+
+// nodeText is the source of n, "" for none.
+func nodeText(s *source, n ast.Node) string {
+	if n == nil {
+		return ""
+	}
+	return s.text(n)
+}
+
+// caseKey is what a case matches by: its values, or "default".
+func caseKey(s *source, cc *ast.CaseClause) string {
+	if cc.List == nil {
+		return "default"
+	}
+	values := make([]string, len(cc.List))
+	for i, e := range cc.List {
+		values[i] = s.text(e)
+	}
+	return strings.Join(values, ", ")
+}
+
+// funcKey names a function or method, e.g. "(*home).Update".
+func funcKey(s *source, fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	return "(" + s.text(fn.Recv.List[0].Type) + ")." + fn.Name.Name
+}
+
+// imports adds the imports of ren that cur lacks and what's added uses;
+// blank and dot imports, used or not, are added too. Each goes after the
+// import it follows in ren, or before the one it precedes, so it lands in
+// the same group. A lone import "x" becomes a block, and a file without
+// imports gets one after its package clause.
+func (m *merger) imports() {
+	used := make(map[string]bool)
+	for _, a := range m.adds {
+		ast.Inspect(a.node, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if id, ok := sel.X.(*ast.Ident); ok {
+					used[id.Name] = true
+				}
+			}
+			return true
+		})
+	}
+	var block strings.Builder
+	var opened []*ast.ImportSpec
+	ren := m.ren.file.Imports
+	for i, imp := range ren {
+		if m.curImport(imp) != nil {
+			continue
+		}
+		if name := importName(imp); name != "_" && name != "." && !used[name] {
+			continue
+		}
+		spec := m.ren.text(imp)
+		if len(m.cur.file.Imports) == 0 {
+			block.WriteString("\t" + spec + "\n")
+			continue
+		}
+		near, after := m.near(ren, i)
+		start, end := m.cur.offset(near.Pos()), m.cur.offset(near.End())
+		gap := ""
+		if after && i > 0 && bytes.Count(m.ren.src[m.ren.offset(ren[i-1].End()):m.ren.offset(imp.Pos())], []byte("\n")) > 1 {
+			gap = "\n" // it starts a group in ren
+		}
+		if !m.grouped(near) {
+			if !slices.Contains(opened, near) {
+				opened = append(opened, near)
+				m.adds = append(m.adds, addition{at: start, text: "(\n\t"})
+			}
+			if after {
+				m.adds = append(m.adds, addition{at: end, text: gap + "\n\t" + spec})
+			} else {
+				m.adds = append(m.adds, addition{at: start, text: spec + "\n\t"})
+			}
+			continue
+		}
+		if after {
+			m.adds = append(m.adds, addition{at: m.cur.end(near.End()), text: gap + "\t" + spec + "\n"})
+		} else {
+			m.adds = append(m.adds, addition{at: m.cur.start(near.Pos()), text: "\t" + spec + "\n"})
+		}
+	}
+	for _, imp := range opened {
+		m.adds = append(m.adds, addition{at: m.cur.offset(imp.End()), text: "\n)"})
+	}
+	if block.Len() > 0 {
+		m.adds = append(m.adds, addition{at: m.cur.end(m.cur.file.Name.End()), text: "\nimport (\n" + block.String() + ")\n"})
+	}
+}
+
+// near returns the import of cur to put ren[i] next to and whether it goes
+// after it: the one it follows in ren, the one it precedes, or cur's last.
+func (m *merger) near(ren []*ast.ImportSpec, i int) (imp *ast.ImportSpec, after bool) {
+	for j := i - 1; j >= 0; j-- {
+		if imp := m.curImport(ren[j]); imp != nil {
+			return imp, true
+		}
+	}
+	for j := i + 1; j < len(ren); j++ {
+		if imp := m.curImport(ren[j]); imp != nil {
+			return imp, false
+		}
+	}
+	return m.cur.file.Imports[len(m.cur.file.Imports)-1], true
+}
+
+func (m *merger) curImport(imp *ast.ImportSpec) *ast.ImportSpec {
+	for _, c := range m.cur.file.Imports {
+		if importPath(c) == importPath(imp) {
+			return c
+		}
+	}
+	return nil
+}
+
+// grouped reports whether imp is in an import ( ... ) block.
+func (m *merger) grouped(imp *ast.ImportSpec) bool {
+	for _, decl := range m.cur.file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			for _, spec := range gen.Specs {
+				if spec == imp {
+					return gen.Lparen.IsValid()
+				}
+			}
+		}
+	}
+	return false
+}
+
+func importPath(imp *ast.ImportSpec) string {
+	p, err := strconv.Unquote(imp.Path.Value)
+	if err != nil {
+		return imp.Path.Value
+	}
+	return p
+}
+
+// majorVersion matches the /v2 of a module's major version and the .v3
+// of a gopkg.in path.
+var majorVersion = regexp.MustCompile(`[/.]v[0-9]+$`)
+
+// importName is the name the file refers to imp by: the one the import
+// gives, or the package name its path suggests, e.g. yaml for
+// gopkg.in/yaml.v3 and isatty for github.com/mattn/go-isatty.
+func importName(imp *ast.ImportSpec) string {
+	if imp.Name != nil {
+		return imp.Name.Name
+	}
+	p := majorVersion.ReplaceAllString(importPath(imp), "")
+	name := p[strings.LastIndex(p, "/")+1:]
+	name = strings.TrimPrefix(name, "go-")
+	return strings.NewReplacer("-", "", ".", "").Replace(name)
+}
@@ -0,0 +1,298 @@
+package codemod
+
+import "testing"
+
+func TestMergeGo(t *testing.T) {
+	tests := []struct {
+		name              string
+		current, rendered string
+		want              string
+		conflicts         int
+	}{
+		{
+			name: "import, field and case",
+			current: `package main
+
+import (
+	"fmt"
+)
+
+// Options are the user's, with a flag of their own.
+type Options struct {
+	Verbose bool // chatty
+	DryRun  bool
+}
+
+func run(cmd string) {
+	switch cmd {
+	case "version":
+		fmt.Println("v1") // mine
+	}
+}
+`,
+			rendered: `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type Options struct {
+	Verbose bool
+	// Timeout cancels the command.
+	Timeout int
+}
+
+func run(cmd string) {
+	switch cmd {
+	case "version":
+		fmt.Println("dev")
+	case "env":
+		fmt.Println(os.Environ())
+	}
+}
+`,
+			want: `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Options are the user's, with a flag of their own.
+type Options struct {
+	Verbose bool // chatty
+	DryRun  bool
+	// Timeout cancels the command.
+	Timeout int
+}
+
+func run(cmd string) {
+	switch cmd {
+	case "version":
+		fmt.Println("v1") // mine
+	case "env":
+		fmt.Println(os.Environ())
+	}
+}
+`,
+		},
+		{
+			name: "case before default, type switch in a method",
+			current: `package ui
+
+func (h *home) Update(msg any) {
+	switch msg := msg.(type) {
+	case string:
+		h.title = msg
+	default:
+		h.log(msg)
+	}
+}
+`,
+			rendered: `package ui
+
+func (h *home) Update(msg any) {
+	switch msg := msg.(type) {
+	case string:
+		h.title = msg
+	case error:
+		h.err = msg
+	}
+}
+`,
+			want: `package ui
+
+func (h *home) Update(msg any) {
+	switch msg := msg.(type) {
+	case string:
+		h.title = msg
+	case error:
+		h.err = msg
+	default:
+		h.log(msg)
+	}
+}
+`,
+		},
+		{
+			name: "imports only what added code uses",
+			current: `package main
+
+import "fmt"
+
+type Config struct {
+	Name string
+}
+`,
+			rendered: `package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	_ "example.com/demo/plugins/hello"
+)
+
+type Config struct {
+	Name    string
+	Timeout time.Duration
+}
+
+func title(s string) string { return strings.ToUpper(s) }
+`,
+			want: `package main
+
+import (
+	"fmt"
+	"time"
+
+	_ "example.com/demo/plugins/hello"
+)
+
+type Config struct {
+	Name    string
+	Timeout time.Duration
+}
+`,
+		},
+		{
+			name: "file without imports",
+			current: `package config
+
+type Config struct{}
+`,
+			rendered: `package config
+
+import "time"
+
+type Config struct {
+	Every time.Duration
+}
+`,
+			want: `package config
+
+import (
+	"time"
+)
+
+type Config struct {
+	Every time.Duration
+}
+`,
+		},
+		{
+			name: "case value moved to another case",
+			current: `package main
+
+func run(cmd string) {
+	switch cmd {
+	case "version", "env":
+		println(cmd)
+	}
+}
+`,
+			rendered: `package main
+
+func run(cmd string) {
+	switch cmd {
+	case "version":
+		println("dev")
+	case "env":
+		println("env")
+	}
+}
+`,
+			want: `package main
+
+func run(cmd string) {
+<<<<<<< current
+	switch cmd {
+	case "version", "env":
+		println(cmd)
+	}
+=======
+	switch cmd {
+	case "version":
+		println("dev")
+	case "env":
+		println("env")
+	}
+>>>>>>> generated
+}
+`,
+			conflicts: 1,
+		},
+		{
+			name: "struct made another type",
+			current: `package main
+
+// Options are flags.
+type Options map[string]string
+
+type Other struct {
+	A int
+}
+`,
+			rendered: `package main
+
+// Options are flags.
+type Options struct {
+	Verbose bool
+}
+
+type Other struct {
+	A int
+	B int
+}
+`,
+			want: `package main
+
+<<<<<<< current
+// Options are flags.
+type Options map[string]string
+=======
+// Options are flags.
+type Options struct {
+	Verbose bool
+}
+>>>>>>> generated
+
+type Other struct {
+	A int
+	B int
+}
+`,
+			conflicts: 1,
+		},
+		{
+			name:      "unresolved markers",
+			current:   "package main\n\n<<<<<<< current\ntype A int\n=======\ntype A string\n>>>>>>> generated\n",
+			rendered:  "package main\n\ntype A struct{ B int }\n",
+			want:      "package main\n\n<<<<<<< current\ntype A int\n=======\ntype A string\n>>>>>>> generated\n",
+			conflicts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts, err := MergeGo("x.go", []byte(tt.current), []byte(tt.rendered))
+			if err != nil {
+				t.Fatalf("MergeGo() error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MergeGo() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if conflicts != tt.conflicts {
+				t.Errorf("MergeGo() conflicts = %d, want %d", conflicts, tt.conflicts)
+			}
+		})
+	}
+
+	if _, _, err := MergeGo("x.go", []byte("package main\n\nfunc {\n"), []byte("package main\n")); err == nil {
+		t.Error("MergeGo() of a file that doesn't parse succeeded")
+	}
+	if _, _, err := MergeGo("x.go", []byte("package main\n"), []byte("package main\n\nfunc main() {}\n")); err == nil {
+		t.Error("MergeGo() of a file without the generated declarations succeeded")
+	}
+}
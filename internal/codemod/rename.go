@@ -0,0 +1,88 @@
+package codemod
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+// GoFiles lists the .go files under dir on fsys, skipping vendor and
+// hidden directories.
+func GoFiles(fsys fileutils.FS, dir string) ([]string, error) {
+	var files []string
+	err := walkGoFiles(fsys, dir, func(path string) error {
+		files = append(files, path)
+		return nil
+	})
+	return files, err
+}
+
+// RenamePackage returns src, the Go file filename, with the package
+// oldName renamed to newName: the package clause, an external test's
+// included, when inPackage says the file belongs to it, and the
+// identifiers qualifying its names when the file imports it by importPath
+// without naming the import. Nothing else in src changes.
+func RenamePackage(filename string, src []byte, importPath, oldName, newName string, inPackage bool) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	var edits []edit
+	if inPackage {
+		switch file.Name.Name {
+		case oldName:
+			edits = append(edits, edit{offset(file.Name.Pos()), offset(file.Name.End()), newName})
+		case oldName + "_test":
+			edits = append(edits, edit{offset(file.Name.Pos()), offset(file.Name.End()), newName + "_test"})
+		}
+	}
+
+	imported := false
+	for _, imp := range file.Imports {
+		if p, err := strconv.Unquote(imp.Path.Value); err == nil && p == importPath && imp.Name == nil {
+			imported = true
+		}
+	}
+	if imported {
+		// Package names are left unresolved by the parser, so an identifier
+		// that resolves is a local that shadows the package
+		ast.Inspect(file, func(n ast.Node) bool {
+			sel, ok := n.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if id, ok := sel.X.(*ast.Ident); ok && id.Name == oldName && id.Obj == nil {
+				edits = append(edits, edit{offset(id.Pos()), offset(id.End()), newName})
+			}
+			return true
+		})
+	}
+	return splice(src, edits), nil
+}
+
+// edit replaces src[start:end] with text.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// splice applies edits, which must not overlap, to a copy of src.
+func splice(src []byte, edits []edit) []byte {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	out := make([]byte, 0, len(src))
+	last := 0
+	for _, e := range edits {
+		out = append(out, src[last:e.start]...)
+		out = append(out, e.text...)
+		last = e.end
+	}
+	return append(out, src[last:]...)
+}
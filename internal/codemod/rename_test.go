@@ -0,0 +1,51 @@
+package codemod
+
+import "testing"
+
+func TestRenamePackage(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		inPackage bool
+		want      string
+	}{
+		{
+			name:      "package clause",
+			src:       "package demo\n\nfunc Run() {}\n",
+			inPackage: true,
+			want:      "package tool\n\nfunc Run() {}\n",
+		},
+		{
+			name:      "external test",
+			src:       "package demo_test\n",
+			inPackage: true,
+			want:      "package tool_test\n",
+		},
+		{
+			name: "importer",
+			src:  "package main\n\nimport (\n  \"example.com/demo\"\n)\n\nfunc main() {\n  demo.Run()\n  x := demo.Version\n  _ = x\n}\n",
+			want: "package main\n\nimport (\n  \"example.com/demo\"\n)\n\nfunc main() {\n  tool.Run()\n  x := tool.Version\n  _ = x\n}\n",
+		},
+		{
+			name: "named import and shadowing local",
+			src:  "package main\n\nimport d \"example.com/demo\"\n\nfunc main() {\n  demo := struct{ Run func() }{d.Run}\n  demo.Run()\n}\n",
+			want: "package main\n\nimport d \"example.com/demo\"\n\nfunc main() {\n  demo := struct{ Run func() }{d.Run}\n  demo.Run()\n}\n",
+		},
+		{
+			name: "other package",
+			src:  "package demo\n",
+			want: "package demo\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenamePackage("x.go", []byte(tt.src), "example.com/demo", "demo", "tool", tt.inPackage)
+			if err != nil {
+				t.Fatalf("RenamePackage() error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("RenamePackage() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+// Package diff produces unified diffs between two texts, line by line,
+// using the Myers O(ND) algorithm.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OpKind says whether a line is shared, removed from a, or added from b.
+type OpKind int
+
+const (
+	Equal OpKind = iota
+	Delete
+	Insert
+)
+
+// Op is one line of an edit script.
+type Op struct {
+	Kind OpKind
+	Line string
+}
+
+// Lines splits text into lines, keeping a missing final newline visible.
+func Lines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Edits returns the shortest edit script turning a into b.
+func Edits(a, b []string) []Op {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	// v[k+offset] is the furthest x reached on diagonal k; trace keeps each round for backtracking
+	offset := max
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset] // move down: insert from b
+			} else {
+				x = v[k-1+offset] + 1 // move right: delete from a
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+offset] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, d, offset)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack walks the saved rounds from the end to rebuild the edit script.
+func backtrack(a, b []string, trace [][]int, d, offset int) []Op {
+	x, y := len(a), len(b)
+	var ops []Op
+
+	for ; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+offset]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, Op{Equal, a[x]})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, Op{Insert, b[y]})
+		} else {
+			x--
+			ops = append(ops, Op{Delete, a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, Op{Equal, a[x]})
+	}
+
+	// Reverse into forward order
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// Unified returns a unified diff of a and b with the given lines of context,
+// or "" when the texts are equal.
+func Unified(aName, bName, a, b string, context int) string {
+	ops := Edits(Lines(a), Lines(b))
+
+	changed := false
+	for _, op := range ops {
+		if op.Kind != Equal {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for _, h := range hunks(ops, context) {
+		out.WriteString(h)
+	}
+	return out.String()
+}
+
+// hunks groups the edit script into @@ sections with surrounding context.
+func hunks(ops []Op, context int) []string {
+	var out []string
+	i := 0
+	aLine, bLine := 1, 1
+
+	for i < len(ops) {
+		// Skip ahead to the next change
+		for i < len(ops) && ops[i].Kind == Equal {
+			i++
+			aLine++
+			bLine++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		// Back up to include leading context
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		for j := start; j < i; j++ {
+			aLine--
+			bLine--
+		}
+
+		// Extend while changes are within 2*context of each other
+		end := i
+		for end < len(ops) {
+			if ops[end].Kind != Equal {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].Kind == Equal {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end += min(context, run-end)
+				break
+			}
+			end = run
+		}
+
+		var body strings.Builder
+		aStart, bStart := aLine, bLine
+		aCount, bCount := 0, 0
+		for _, op := range ops[start:end] {
+			line := op.Line
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n\\ No newline at end of file\n"
+			}
+			switch op.Kind {
+			case Equal:
+				body.WriteString(" " + line)
+				aCount++
+				bCount++
+			case Delete:
+				body.WriteString("-" + line)
+				aCount++
+			case Insert:
+				body.WriteString("+" + line)
+				bCount++
+			}
+		}
+		aLine += aCount
+		bLine += bCount
+
+		out = append(out, fmt.Sprintf("@@ -%s +%s @@\n%s", hunkRange(aStart, aCount), hunkRange(bStart, bCount), body.String()))
+		i = end
+	}
+	return out
+}
+
+// hunkRange formats "start,count", using the previous line when count is zero.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
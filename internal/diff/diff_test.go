@@ -0,0 +1,53 @@
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	b := "one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\n"
+
+	got := Unified("a", "b", a, b, 1)
+	want := `--- a
++++ b
+@@ -1,3 +1,3 @@
+ one
+-two
++2
+ three
+@@ -10 +10,2 @@
+ ten
++eleven
+`
+	if got != want {
+		t.Errorf("Unified() =\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := Unified("a", "b", a, a, 3); got != "" {
+		t.Errorf("Unified() of equal texts = %q, want empty", got)
+	}
+
+	got = Unified("a", "b", "", "x", 3)
+	want = "--- a\n+++ b\n@@ -0,0 +1 @@\n+x\n\\ No newline at end of file\n"
+	if got != want {
+		t.Errorf("Unified() from empty =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSideBySide(t *testing.T) {
+	u := Unified("a", "b", "one\ntwo\nthree\n", "one\n2\nthree\nfour\n", 3)
+	got := SideBySide(u, 23)
+	want := `a            b
+@@ -1,3 +1,4 @@
+one          one
+two        | 2
+three        three
+           > four
+`
+	if got != want {
+		t.Errorf("SideBySide() =\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := fit("abcdefghijkl", 10); got != "abcdefghi…" {
+		t.Errorf("fit() = %q, want the first 9 runes and …", got)
+	}
+}
@@ -0,0 +1,76 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SideBySide lays out a diff from Unified in two columns, a on the left and
+// b on the right, fitting rows into width. The gutter between the columns
+// marks changed rows with |, deleted ones with < and inserted ones with >.
+func SideBySide(unified string, width int) string {
+	col := ColumnWidth(width)
+	var out strings.Builder
+	row := func(left, mark, right string) {
+		left = fit(left, col)
+		line := fmt.Sprintf("%s%s %s %s", left, strings.Repeat(" ", col-utf8.RuneCountInString(left)), mark, fit(right, col))
+		out.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+
+	// Deleted and inserted lines pair up into rows once the run ends
+	var dels, ins []string
+	flush := func() {
+		for i := 0; i < len(dels) || i < len(ins); i++ {
+			switch {
+			case i >= len(ins):
+				row(dels[i], "<", "")
+			case i >= len(dels):
+				row("", ">", ins[i])
+			default:
+				row(dels[i], "|", ins[i])
+			}
+		}
+		dels, ins = nil, nil
+	}
+
+	var aName string
+	for _, line := range Lines(unified) {
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			flush()
+			aName = line[4:]
+		case strings.HasPrefix(line, "+++ "):
+			row(aName, " ", line[4:])
+		case strings.HasPrefix(line, "@@"):
+			flush()
+			out.WriteString(line + "\n")
+		case strings.HasPrefix(line, "-"):
+			dels = append(dels, line[1:])
+		case strings.HasPrefix(line, "+"):
+			ins = append(ins, line[1:])
+		case strings.HasPrefix(line, " "):
+			flush()
+			row(line[1:], " ", line[1:])
+		}
+		// "\ No newline at end of file" has no row of its own
+	}
+	flush()
+	return out.String()
+}
+
+// ColumnWidth is the width of each column of SideBySide, so the gutter
+// mark of a row is its rune at ColumnWidth(width)+1.
+func ColumnWidth(width int) int {
+	return max((width-3)/2, 10)
+}
+
+// fit expands tabs and cuts s to n runes, marking a cut with ….
+func fit(s string, n int) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
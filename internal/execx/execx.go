@@ -0,0 +1,76 @@
+// Package execx runs external commands through a Runner, so the
+// generator's go and git calls can be recorded instead of executed.
+package execx
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"slices"
+	"time"
+)
+
+// Result is the captured outcome of one command.
+type Result struct {
+	Stdout   []byte
+	Stderr   []byte
+	Duration time.Duration
+}
+
+// Runner runs name with args in dir. A command that starts but fails
+// returns its Result along with the error.
+type Runner interface {
+	Run(ctx context.Context, dir, name string, args ...string) (Result, error)
+}
+
+// Host runs commands on this machine with os/exec, killing them when ctx
+// is done.
+type Host struct {
+	// Env replaces the caller's environment when set; variables from
+	// WithEnv are added either way.
+	Env []string
+
+	// Prepare adjusts each command before it starts, e.g. to isolate it.
+	Prepare func(cmd *exec.Cmd)
+}
+
+// Run implements Runner.
+func (h Host) Run(ctx context.Context, dir, name string, args ...string) (Result, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	env := h.Env
+	if extra := Env(ctx); len(extra) > 0 {
+		if env == nil {
+			env = os.Environ()
+		}
+		cmd.Env = slices.Concat(env, extra)
+	} else if env != nil {
+		cmd.Env = env
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	// Don't wait on children that outlive a killed command and hold its output open
+	cmd.WaitDelay = time.Second
+	if h.Prepare != nil {
+		h.Prepare(cmd)
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+	return Result{Stdout: stdout.Bytes(), Stderr: stderr.Bytes(), Duration: time.Since(start)}, err
+}
+
+type envKey struct{}
+
+// WithEnv returns a copy of ctx under which commands also get env, a list
+// of KEY=value pairs, e.g. GOPROXY for go commands.
+func WithEnv(ctx context.Context, env ...string) context.Context {
+	return context.WithValue(ctx, envKey{}, slices.Concat(Env(ctx), env))
+}
+
+// Env returns the variables WithEnv added to ctx.
+func Env(ctx context.Context) []string {
+	env, _ := ctx.Value(envKey{}).([]string)
+	return env
+}
@@ -0,0 +1,57 @@
+package execx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHost(t *testing.T) {
+	ctx := WithEnv(context.Background(), "PROJECT_TEST_A=1")
+	ctx = WithEnv(ctx, "PROJECT_TEST_B=2")
+	res, err := Host{}.Run(ctx, t.TempDir(), "sh", "-c", "echo $PROJECT_TEST_A$PROJECT_TEST_B; echo err >&2")
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if got := string(res.Stdout); got != "12\n" {
+		t.Errorf("Run() stdout = %q", got)
+	}
+	if got := string(res.Stderr); got != "err\n" {
+		t.Errorf("Run() stderr = %q", got)
+	}
+
+	t.Setenv("PROJECT_TEST_SECRET", "hunter2")
+	res, err = Host{Env: []string{"PATH=/usr/bin:/bin"}}.Run(context.Background(), t.TempDir(), "sh", "-c", "echo secret=$PROJECT_TEST_SECRET; exit 3")
+	if err == nil || string(res.Stdout) != "secret=\n" {
+		t.Errorf("Run(Env, exit 3) = %q, %v; want scrubbed output and an error", res.Stdout, err)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r := &Recorder{Respond: func(c Call) (Result, error) {
+		if c.String() == "go mod tidy" {
+			return Result{Stderr: []byte("boom")}, errors.New("exit status 1")
+		}
+		return Result{Stdout: []byte("ok")}, nil
+	}}
+	ctx := WithEnv(context.Background(), "GOPROXY=off")
+	if res, err := r.Run(ctx, "/p", "go", "mod", "init", "example.com/m"); err != nil || string(res.Stdout) != "ok" {
+		t.Errorf("Run(init) = %q, %v", res.Stdout, err)
+	}
+	if _, err := r.Run(context.Background(), "/p", "go", "mod", "tidy"); err == nil {
+		t.Error("Run(tidy) error = nil, want the response's")
+	}
+
+	calls := r.Calls()
+	var got []string
+	for _, c := range calls {
+		got = append(got, c.String())
+	}
+	if want := "go mod init example.com/m|go mod tidy"; strings.Join(got, "|") != want {
+		t.Errorf("Calls() = %q, want %q", got, want)
+	}
+	if len(calls[0].Env) != 1 || calls[0].Env[0] != "GOPROXY=off" || calls[1].Env != nil {
+		t.Errorf("Calls() env = %q, %q", calls[0].Env, calls[1].Env)
+	}
+}
@@ -0,0 +1,51 @@
+package execx
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Call is one command a Recorder was asked to run.
+type Call struct {
+	Dir  string
+	Name string
+	Args []string
+	Env  []string // from WithEnv
+}
+
+// String returns the command line, e.g. "go mod tidy".
+func (c Call) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// Recorder is a fake Runner for tests: it runs nothing, records each call,
+// and answers with Respond, or an empty Result when Respond is nil.
+type Recorder struct {
+	Respond func(c Call) (Result, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Run implements Runner.
+func (r *Recorder) Run(ctx context.Context, dir, name string, args ...string) (Result, error) {
+	c := Call{Dir: dir, Name: name, Args: args, Env: Env(ctx)}
+	r.mu.Lock()
+	r.calls = append(r.calls, c)
+	r.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	if r.Respond == nil {
+		return Result{}, nil
+	}
+	return r.Respond(c)
+}
+
+// Calls returns the calls recorded so far, in order.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
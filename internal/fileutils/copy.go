@@ -0,0 +1,144 @@
+package fileutils
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// CopyFile copies src to dst verbatim, keeping the source file's mode.
+func CopyFile(src, dst string, opts ...Option) error {
+	o := newOptions(opts)
+	info, err := o.fs.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", src, err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file: %s", src)
+	}
+	data, err := o.fs.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", src, err)
+	}
+	if err := WriteFile(dst, data, info.Mode().Perm(), opts...); err != nil {
+		return err
+	}
+	// WriteFile keeps an existing destination's mode; a copy should match the source.
+	return o.fs.Chmod(dst, info.Mode().Perm())
+}
+
+// CopyTree recursively copies the directory srcDir into dstDir,
+// keeping file modes. Symlinks and other special files are skipped.
+func CopyTree(srcDir, dstDir string, opts ...Option) error {
+	o := newOptions(opts)
+	return Walk(o.fs, srcDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, p)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dstDir, rel)
+		if d.IsDir() {
+			return o.fs.MkdirAll(dst, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return CopyFile(p, dst, opts...)
+	})
+}
+
+// Walk is filepath.WalkDir on fsys.
+func Walk(fsys FS, root string, fn fs.WalkDirFunc) error {
+	if fsys == OS {
+		return filepath.WalkDir(root, fn)
+	}
+	info, err := fsys.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walk(fsys, root, fs.FileInfoToDirEntry(info), fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func walk(fsys FS, p string, d fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(p, d, nil); err != nil || !d.IsDir() {
+		if err == filepath.SkipDir && d.IsDir() {
+			err = nil
+		}
+		return err
+	}
+	entries, err := fsys.ReadDir(p)
+	if err != nil {
+		if err = fn(p, d, err); err != nil {
+			if err == filepath.SkipDir {
+				err = nil
+			}
+			return err
+		}
+	}
+	for _, e := range entries {
+		if err := walk(fsys, filepath.Join(p, e.Name()), e, fn); err != nil {
+			if err == filepath.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// CopyFS copies everything under root in fsys into dstDir and returns the
+// slash-separated paths written, relative to dstDir. Modes come from fsys
+// when it reports them (os.DirFS); read-only embedded files are normalized
+// to 0644, or 0755 when any execute bit is set.
+func CopyFS(fsys fs.FS, root, dstDir string, opts ...Option) ([]string, error) {
+	var written []string
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+
+		rel := p
+		if root != "." {
+			rel = path.Clean(p[len(root)+1:])
+		}
+		dst := filepath.Join(dstDir, filepath.FromSlash(rel))
+		if err := WriteFile(dst, data, NormalizeMode(info.Mode()), opts...); err != nil {
+			return err
+		}
+		written = append(written, rel)
+		return nil
+	})
+	return written, err
+}
+
+// NormalizeMode makes read-only embedded modes usable for generated files.
+func NormalizeMode(mode fs.FileMode) os.FileMode {
+	if mode.Perm()&0111 != 0 {
+		return 0755
+	}
+	if mode.Perm()&0200 == 0 {
+		return DefaultMode
+	}
+	return mode.Perm()
+}
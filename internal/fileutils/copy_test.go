@@ -0,0 +1,119 @@
+package fileutils
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "run.sh")
+	if err := os.WriteFile(src, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "out", "run.sh")
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// An existing destination takes the source's mode, unlike WriteFile
+	if err := os.WriteFile(dst, []byte("old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := CopyFile(src, dst); err != nil {
+		t.Fatalf("CopyFile() error: %v", err)
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o755 {
+		t.Errorf("copy mode = %v, want 0755", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "#!/bin/sh\n" {
+		t.Errorf("copy = %q, want the source", data)
+	}
+
+	if err := CopyFile(dir, filepath.Join(dir, "dir-copy")); err == nil {
+		t.Error("CopyFile() of a directory succeeded, want an error")
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]os.FileMode{
+		"README.md":          0o644,
+		"scripts/install.sh": 0o755,
+		"scripts/lib/env.sh": 0o600,
+	}
+	for name, mode := range files {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(name), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("README.md", filepath.Join(src, "link.md")); err != nil {
+		t.Skipf("no symlinks: %v", err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "copy")
+	if err := CopyTree(src, dst); err != nil {
+		t.Fatalf("CopyTree() error: %v", err)
+	}
+	for name, mode := range files {
+		p := filepath.Join(dst, filepath.FromSlash(name))
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Errorf("%s wasn't copied: %v", name, err)
+			continue
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("%s mode = %v, want %v", name, info.Mode().Perm(), mode)
+		}
+		if data, _ := os.ReadFile(p); string(data) != name {
+			t.Errorf("%s = %q, want %q", name, data, name)
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "link.md")); !os.IsNotExist(err) {
+		t.Errorf("symlink was copied: %v", err)
+	}
+}
+
+func TestCopyFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"static/.gitattributes":   {Data: []byte("* text=auto\n"), Mode: 0o444},
+		"static/scripts/setup.sh": {Data: []byte("#!/bin/sh\n"), Mode: 0o555},
+		"static/docs/notes.txt":   {Data: []byte("notes\n"), Mode: 0o600},
+		"main.tmpl":               {Data: []byte("package main\n")},
+	}
+	dst := t.TempDir()
+	written, err := CopyFS(fsys, "static", dst)
+	if err != nil {
+		t.Fatalf("CopyFS() error: %v", err)
+	}
+	slices.Sort(written)
+	want := []string{".gitattributes", "docs/notes.txt", "scripts/setup.sh"}
+	if !slices.Equal(written, want) {
+		t.Errorf("CopyFS() wrote %q, want %q", written, want)
+	}
+
+	// Read-only modes are made writable, executables stay executable
+	modes := map[string]fs.FileMode{".gitattributes": 0o644, "scripts/setup.sh": 0o755, "docs/notes.txt": 0o600}
+	for name, mode := range modes {
+		info, err := os.Stat(filepath.Join(dst, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("%s wasn't copied: %v", name, err)
+		} else if info.Mode().Perm() != mode {
+			t.Errorf("%s mode = %v, want %v", name, info.Mode().Perm(), mode)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "main.tmpl")); !os.IsNotExist(err) {
+		t.Errorf("CopyFS() copied a file outside its root: %v", err)
+	}
+}
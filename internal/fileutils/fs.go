@@ -0,0 +1,100 @@
+package fileutils
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FS is the filesystem projects are read from and written to. Names are
+// host paths, as the generator builds them, so the same code runs against
+// the host (OS) or a tree held in memory (MemFS).
+type FS interface {
+	Open(name string) (fs.File, error)
+	Stat(name string) (fs.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	MkdirAll(name string, perm fs.FileMode) error
+
+	// Replace sets name's content to what r yields, and its mode to perm,
+	// so readers see the old file or the new one and never part of it.
+	// With sync the new file survives a crash once Replace returns. The
+	// parent directory must exist.
+	Replace(name string, r io.Reader, perm fs.FileMode, sync bool) error
+
+	Chmod(name string, mode fs.FileMode) error
+	Chtimes(name string, atime, mtime time.Time) error
+	Rename(oldName, newName string) error
+	Remove(name string) error
+}
+
+// OS is the host filesystem.
+var OS FS = osFS{}
+
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error)          { return os.Open(name) }
+func (osFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (osFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+func (osFS) Chmod(name string, mode fs.FileMode) error { return os.Chmod(name, mode) }
+func (osFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+func (osFS) Rename(oldName, newName string) error { return os.Rename(oldName, newName) }
+func (osFS) Remove(name string) error             { return os.Remove(name) }
+
+// Replace writes a temp file in the same directory and renames it into
+// place.
+func (osFS) Replace(name string, r io.Reader, perm fs.FileMode, sync bool) error {
+	dir := filepath.Dir(name)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(name)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for %s: %w", name, err)
+	}
+	tmpName := tmp.Name()
+	// Clean up the temp file on any failure below
+	defer os.Remove(tmpName)
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if sync {
+		if err := tmp.Sync(); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to sync %s: %w", name, err)
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", name, err)
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return fmt.Errorf("failed to chmod %s: %w", name, err)
+	}
+	if err := os.Rename(tmpName, name); err != nil {
+		return fmt.Errorf("failed to rename into %s: %w", name, err)
+	}
+
+	if sync {
+		syncDir(dir)
+	}
+	return nil
+}
+
+// syncDir fsyncs a directory so a rename inside it is durable.
+// Errors are ignored since some platforms can't sync directories.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	_ = d.Sync()
+}
@@ -0,0 +1,298 @@
+package fileutils
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+)
+
+// MemFS is an FS held in memory, for generating projects that never touch
+// the host: tests, and output that is only served or archived. The zero
+// value is an empty filesystem, safe for concurrent use; the root and
+// "." are directories from the start.
+type MemFS struct {
+	mu    sync.RWMutex
+	nodes map[string]*memNode
+}
+
+type memNode struct {
+	data    []byte
+	mode    fs.FileMode // with fs.ModeDir for directories
+	modTime time.Time
+}
+
+// clean is the key of name.
+func clean(name string) string { return filepath.Clean(name) }
+
+// isRoot reports whether the cleaned name is a directory that always
+// exists.
+func isRoot(name string) bool { return name == "." || filepath.Dir(name) == name }
+
+func (m *MemFS) lookup(name string) (*memNode, bool) {
+	if isRoot(name) {
+		return &memNode{mode: fs.ModeDir | 0755}, true
+	}
+	n, ok := m.nodes[name]
+	return n, ok
+}
+
+func pathError(op, name string, err error) error {
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = clean(name)
+	n, ok := m.lookup(name)
+	if !ok {
+		return nil, pathError("stat", name, fs.ErrNotExist)
+	}
+	return memInfo{filepath.Base(name), n.size(), n.mode, n.modTime}, nil
+}
+
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = clean(name)
+	n, ok := m.lookup(name)
+	switch {
+	case !ok:
+		return nil, pathError("open", name, fs.ErrNotExist)
+	case n.mode.IsDir():
+		return nil, pathError("read", name, fs.ErrInvalid)
+	}
+	return slices.Clone(n.data), nil
+}
+
+func (m *MemFS) Open(name string) (fs.File, error) {
+	info, err := m.Stat(name)
+	if err != nil {
+		return nil, pathError("open", clean(name), fs.ErrNotExist)
+	}
+	if info.IsDir() {
+		entries, err := m.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		return &memDir{info: info, entries: entries}, nil
+	}
+	data, err := m.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &memFile{info: info, Reader: bytes.NewReader(data)}, nil
+}
+
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = clean(name)
+	n, ok := m.lookup(name)
+	switch {
+	case !ok:
+		return nil, pathError("readdir", name, fs.ErrNotExist)
+	case !n.mode.IsDir():
+		return nil, pathError("readdir", name, fs.ErrInvalid)
+	}
+	var out []fs.DirEntry
+	for p, child := range m.nodes {
+		if filepath.Dir(p) == name && p != name {
+			out = append(out, fs.FileInfoToDirEntry(memInfo{filepath.Base(p), child.size(), child.mode, child.modTime}))
+		}
+	}
+	slices.SortFunc(out, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return out, nil
+}
+
+func (m *MemFS) MkdirAll(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = clean(name)
+	var missing []string
+	for p := name; ; p = filepath.Dir(p) {
+		n, ok := m.lookup(p)
+		if ok {
+			if !n.mode.IsDir() {
+				return pathError("mkdir", p, fs.ErrExist)
+			}
+			break
+		}
+		missing = append(missing, p)
+	}
+	if m.nodes == nil {
+		m.nodes = make(map[string]*memNode)
+	}
+	for _, p := range missing {
+		m.nodes[p] = &memNode{mode: fs.ModeDir | perm.Perm(), modTime: time.Now()}
+	}
+	return nil
+}
+
+func (m *MemFS) Replace(name string, r io.Reader, perm fs.FileMode, sync bool) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return pathError("write", name, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = clean(name)
+	if dir, ok := m.lookup(filepath.Dir(name)); !ok || !dir.mode.IsDir() {
+		return pathError("write", name, fs.ErrNotExist)
+	}
+	if n, ok := m.lookup(name); ok && n.mode.IsDir() {
+		return pathError("write", name, fs.ErrExist)
+	}
+	if m.nodes == nil {
+		m.nodes = make(map[string]*memNode)
+	}
+	m.nodes[name] = &memNode{data: data, mode: perm.Perm(), modTime: time.Now()}
+	return nil
+}
+
+func (m *MemFS) Chmod(name string, mode fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = clean(name)
+	n, ok := m.nodes[name]
+	if !ok {
+		return pathError("chmod", name, fs.ErrNotExist)
+	}
+	n.mode = n.mode&fs.ModeDir | mode.Perm()
+	return nil
+}
+
+func (m *MemFS) Chtimes(name string, atime, mtime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = clean(name)
+	n, ok := m.nodes[name]
+	if !ok {
+		return pathError("chtimes", name, fs.ErrNotExist)
+	}
+	n.modTime = mtime
+	return nil
+}
+
+// Rename moves a file, or a directory and everything under it.
+func (m *MemFS) Rename(oldName, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	oldName, newName = clean(oldName), clean(newName)
+	n, ok := m.nodes[oldName]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: fs.ErrNotExist}
+	}
+	if dir, ok := m.lookup(filepath.Dir(newName)); !ok || !dir.mode.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: fs.ErrNotExist}
+	}
+	if to, ok := m.lookup(newName); ok && (to.mode.IsDir() || n.mode.IsDir()) {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: fs.ErrExist}
+	}
+	for p, child := range m.nodes {
+		if rel, err := filepath.Rel(oldName, p); err == nil && filepath.IsLocal(rel) && rel != "." {
+			delete(m.nodes, p)
+			m.nodes[filepath.Join(newName, rel)] = child
+		}
+	}
+	delete(m.nodes, oldName)
+	m.nodes[newName] = n
+	return nil
+}
+
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = clean(name)
+	n, ok := m.nodes[name]
+	if !ok {
+		return pathError("remove", name, fs.ErrNotExist)
+	}
+	if n.mode.IsDir() {
+		for p := range m.nodes {
+			if filepath.Dir(p) == name {
+				return pathError("remove", name, fs.ErrExist)
+			}
+		}
+	}
+	delete(m.nodes, name)
+	return nil
+}
+
+// DirFS returns a read-only snapshot of the tree under dir, named by
+// slash-separated paths relative to it as os.DirFS would, e.g. to archive
+// a project generated in memory.
+func (m *MemFS) DirFS(dir string) fs.FS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	dir = clean(dir)
+	out := fstest.MapFS{}
+	for p, n := range m.nodes {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." || !filepath.IsLocal(rel) {
+			continue
+		}
+		out[filepath.ToSlash(rel)] = &fstest.MapFile{Data: slices.Clone(n.data), Mode: n.mode, ModTime: n.modTime}
+	}
+	return out
+}
+
+func (n *memNode) size() int64 { return int64(len(n.data)) }
+
+// memInfo describes a node.
+type memInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) Mode() fs.FileMode  { return i.mode }
+func (i memInfo) ModTime() time.Time { return i.modTime }
+func (i memInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memInfo) Sys() any           { return nil }
+
+// memFile is an open file: a snapshot of its content when opened.
+type memFile struct {
+	info fs.FileInfo
+	*bytes.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Close() error               { return nil }
+
+// memDir is an open directory.
+type memDir struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *memDir) Close() error               { return nil }
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, pathError("read", d.info.Name(), fs.ErrInvalid)
+}
+
+func (d *memDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	if count <= 0 {
+		out := d.entries
+		d.entries = nil
+		return out, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n := min(count, len(d.entries))
+	out := d.entries[:n]
+	d.entries = d.entries[n:]
+	return out, nil
+}
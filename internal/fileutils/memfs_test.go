@@ -0,0 +1,44 @@
+package fileutils
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMemFS(t *testing.T) {
+	m := &MemFS{}
+	root := filepath.Join(string(filepath.Separator), "src", "demo")
+	if err := WriteFile(filepath.Join(root, "cmd", "demo", "main.go"), []byte("package main\n"), 0, WithFS(m)); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	if err := WriteFile(filepath.Join(root, "run.sh"), []byte("#!/bin/sh\n"), 0o755, WithFS(m)); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	// An existing file keeps its mode
+	if err := WriteFile(filepath.Join(root, "run.sh"), []byte("#!/bin/bash\n"), 0o644, WithFS(m)); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	if info, err := m.Stat(filepath.Join(root, "run.sh")); err != nil || info.Mode() != 0o755 {
+		t.Errorf("run.sh mode = %v (%v), want 0755", info.Mode(), err)
+	}
+
+	if err := m.Rename(filepath.Join(root, "cmd", "demo"), filepath.Join(root, "cmd", "tool")); err != nil {
+		t.Fatalf("Rename() error: %v", err)
+	}
+	if data, err := m.ReadFile(filepath.Join(root, "cmd", "tool", "main.go")); err != nil || string(data) != "package main\n" {
+		t.Errorf("cmd/tool/main.go = %q (%v) after the rename", data, err)
+	}
+	if err := m.Remove(filepath.Join(root, "cmd")); err == nil {
+		t.Error("Remove() removed a directory that isn't empty")
+	}
+	if _, err := m.Stat(filepath.Join(root, "cmd", "demo")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat(cmd/demo) error = %v, want not exist", err)
+	}
+
+	if err := fstest.TestFS(m.DirFS(root), "run.sh", "cmd/tool/main.go"); err != nil {
+		t.Error(err)
+	}
+}
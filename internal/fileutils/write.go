@@ -1,15 +1,71 @@
 package fileutils
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 )
 
-func WriteFile(path string, data []byte) error {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+// DefaultMode is used for newly created files when no mode is given.
+const DefaultMode os.FileMode = 0644
+
+// Option adjusts how WriteFile behaves.
+type Option func(*writeOptions)
+
+type writeOptions struct {
+	sync bool
+	fs   FS
+}
+
+func newOptions(opts []Option) writeOptions {
+	o := writeOptions{fs: OS}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithSync fsyncs the file (and its directory) before returning,
+// so the write survives a crash right after generation.
+func WithSync(sync bool) Option {
+	return func(o *writeOptions) {
+		o.sync = sync
+	}
+}
+
+// WithFS reads and writes files on fsys instead of the host; a nil fsys
+// is the host.
+func WithFS(fsys FS) Option {
+	return func(o *writeOptions) {
+		if fsys != nil {
+			o.fs = fsys
+		}
+	}
+}
+
+// WriteFile atomically writes data to path: it writes a temp file in the
+// same directory and renames it into place, so readers never see a partial file.
+// New files get mode (DefaultMode if zero); existing files keep their current mode.
+func WriteFile(path string, data []byte, mode os.FileMode, opts ...Option) error {
+	return WriteFrom(path, bytes.NewReader(data), mode, opts...)
+}
+
+// WriteFrom is WriteFile for content read from r, which is streamed into
+// the temp file rather than held in memory.
+func WriteFrom(path string, r io.Reader, mode os.FileMode, opts ...Option) error {
+	o := newOptions(opts)
+
+	if err := o.fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return fmt.Errorf("mkdir failed: %w", err)
 	}
-	return os.WriteFile(path, data, 0644)
+
+	if mode == 0 {
+		mode = DefaultMode
+	}
+	if info, err := o.fs.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return o.fs.Replace(path, r, mode, o.sync)
 }
@@ -0,0 +1,84 @@
+package fileutils
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "config.yaml")
+
+	// New files get the default mode, and missing parents are made
+	if err := WriteFile(path, []byte("a: 1\n"), 0); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.Mode().Perm() != DefaultMode {
+		t.Errorf("new file mode = %v, want %v", before.Mode().Perm(), DefaultMode)
+	}
+
+	// An existing file keeps its mode, and is replaced by a rename rather
+	// than rewritten in place
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(path, []byte("a: 2\n"), 0o755, WithSync(true)); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Mode().Perm() != 0o600 {
+		t.Errorf("overwritten file mode = %v, want 0600", after.Mode().Perm())
+	}
+	if os.SameFile(before, after) {
+		t.Error("WriteFile() rewrote the file in place, want a new file renamed over it")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "a: 2\n" {
+		t.Errorf("content = %q (%v), want a: 2", data, err)
+	}
+	assertNoTemps(t, filepath.Dir(path))
+}
+
+func TestWriteFromFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(path, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A reader failing part way leaves the old file and no temp file
+	boom := errors.New("boom")
+	r := io.MultiReader(strings.NewReader("package broken"), iotest.ErrReader(boom))
+	if err := WriteFrom(path, r, 0); !errors.Is(err, boom) {
+		t.Fatalf("WriteFrom() error = %v, want %v", err, boom)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "package main\n" {
+		t.Errorf("content = %q (%v) after a failed write, want the old file", data, err)
+	}
+	assertNoTemps(t, dir)
+}
+
+// assertNoTemps fails if WriteFile left a temp file in dir.
+func assertNoTemps(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			t.Errorf("temp file %s left behind", e.Name())
+		}
+	}
+}
@@ -1,29 +1,31 @@
 package finalize
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
+
+	"github.com/robbyriverside/project/internal/execx"
 )
 
+// Runner runs the go commands; tests swap in an execx.Recorder.
+var Runner execx.Runner = execx.Host{}
+
 func InitMod(dir, module string) error {
-	cmd := exec.Command("go", "mod", "init", module)
-	cmd.Dir = dir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("go mod init failed: %w", err)
-	}
-	return nil
+	return goCmd(dir, "mod", "init", module)
 }
 
 func Tidy(dir string) error {
-	cmd := exec.Command("go", "mod", "tidy")
-	cmd.Dir = dir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("go mod tidy failed: %w", err)
+	return goCmd(dir, "mod", "tidy")
+}
+
+// goCmd runs a go subcommand in dir, passing its output through.
+func goCmd(dir string, args ...string) error {
+	res, err := Runner.Run(context.Background(), dir, "go", args...)
+	os.Stdout.Write(res.Stdout)
+	os.Stderr.Write(res.Stderr)
+	if err != nil {
+		return fmt.Errorf("go %s %s failed: %w", args[0], args[1], err)
 	}
 	return nil
 }
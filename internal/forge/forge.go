@@ -0,0 +1,180 @@
+// Package forge talks to code hosting APIs (GitHub, GitLab) to create
+// repositories and configure them for freshly generated projects.
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/robbyriverside/project/internal/retry"
+)
+
+// Repo describes a repository to create.
+type Repo struct {
+	Owner       string // user, org or (GitLab) group path
+	Name        string
+	Description string
+	Private     bool
+}
+
+// Protection are branch protection rules, usually loaded from a pack's protection.yaml.
+type Protection struct {
+	Branch              string   `yaml:"branch"`
+	RequiredReviews     int      `yaml:"required_reviews"`
+	RequireStatusChecks bool     `yaml:"require_status_checks"`
+	StatusChecks        []string `yaml:"status_checks"`
+	EnforceAdmins       bool     `yaml:"enforce_admins"`
+	AllowForcePushes    bool     `yaml:"allow_force_pushes"`
+	DismissStaleReviews bool     `yaml:"dismiss_stale_reviews"`
+}
+
+// Issue is an issue to file, e.g. to report a repository's drift from its templates.
+type Issue struct {
+	Title  string
+	Body   string
+	Labels []string
+}
+
+// PullRequest asks to merge the pushed branch Head into Base.
+type PullRequest struct {
+	Title string
+	Body  string
+	Head  string
+	Base  string
+}
+
+// Forge is a code host the generator can create repositories on.
+type Forge interface {
+	// CreateRepo creates the repository and returns its https clone URL.
+	CreateRepo(r Repo) (string, error)
+	// ListRepos lists the owner/name paths of an org's or user's
+	// repositories, or a GitLab group's including subgroups, leaving out
+	// archived ones.
+	ListRepos(owner string) ([]string, error)
+	// Protect applies branch protection to an existing repository.
+	Protect(owner, name string, p Protection) error
+	// OpenIssue files an issue on the repository and returns its web URL.
+	// An open issue with the same title is returned instead of filing a
+	// second one.
+	OpenIssue(owner, name string, i Issue) (string, error)
+	// OpenPullRequest opens a pull (GitLab: merge) request and returns its web URL.
+	OpenPullRequest(owner, name string, pr PullRequest) (string, error)
+	// PushAuth returns the git Authorization header value for https pushes.
+	PushAuth() string
+}
+
+// For picks the API client for a module host. github.com and gitlab.com are
+// recognized by name; other hosts containing "gitlab" are treated as
+// self-managed GitLab, anything else as GitHub Enterprise. Requests that
+// fail on the network or with a server error are repeated per policy, and
+// all of them, retries included, stop when ctx is done.
+func For(ctx context.Context, host, token string, policy retry.Policy) Forge {
+	client := &apiClient{ctx: ctx, http: &http.Client{Timeout: 30 * time.Second}, token: token, retry: policy}
+	switch {
+	case host == "github.com":
+		client.base = "https://api.github.com"
+		return &gitHub{client}
+	case strings.Contains(host, "gitlab"):
+		client.base = "https://" + host + "/api/v4"
+		return &gitLab{client}
+	default:
+		client.base = "https://" + host + "/api/v3"
+		return &gitHub{client}
+	}
+}
+
+// apiClient sends JSON requests with bearer-token auth.
+type apiClient struct {
+	ctx   context.Context
+	http  *http.Client
+	base  string
+	token string
+	retry retry.Policy
+}
+
+// APIError is a non-2xx response from a forge API.
+type APIError struct {
+	Method string
+	URL    string
+	Status int
+	Body   string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.Status, strings.TrimSpace(e.Body))
+}
+
+// do sends body (if non-nil) as JSON and decodes a JSON response into out (if non-nil).
+// A POST, which may create something twice, is repeated only when the server
+// says it didn't handle it: 429 or 503.
+func (c *apiClient) do(method, path string, body, out interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	return c.retry.Do(c.ctx, func() error {
+		err := c.send(method, path, data, out)
+		var apiErr *APIError
+		switch {
+		case err == nil:
+			return nil
+		case errors.As(err, &apiErr):
+			if !retry.TransientStatus(apiErr.Status) ||
+				(method == "POST" && apiErr.Status != http.StatusTooManyRequests && apiErr.Status != http.StatusServiceUnavailable) {
+				return retry.Permanent(err)
+			}
+		case method == "POST":
+			return retry.Permanent(err)
+		}
+		return err
+	})
+}
+
+// send makes one request.
+func (c *apiClient) send(method, path string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	url := c.base + path
+	req, err := http.NewRequestWithContext(c.ctx, method, url, reader)
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return &APIError{Method: method, URL: url, Status: resp.StatusCode, Body: string(data)}
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return retry.Permanent(fmt.Errorf("failed to decode response: %w", err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package forge
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/robbyriverside/project/internal/retry"
+)
+
+func TestParsePush(t *testing.T) {
+	const secret = "s3cret"
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	github := `{"ref": "refs/heads/main", "after": "abc123", "repository": {"full_name": "acme/api", "default_branch": "main"}}`
+	gitlab := `{"ref": "refs/tags/v1.0.0", "after": "def456", "project": {"path_with_namespace": "acme/tools/cli"}}`
+
+	tests := []struct {
+		name    string
+		body    string
+		headers map[string]string
+		want    *PushEvent
+		err     error
+	}{
+		{"github push", github, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": sign(github)},
+			&PushEvent{Repo: "acme/api", Branch: "main", DefaultBranch: "main", After: "abc123"}, nil},
+		{"github wrong signature", github, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": sign("{}")}, nil, ErrSignature},
+		{"github unsigned", github, map[string]string{"X-GitHub-Event": "push"}, nil, ErrSignature},
+		{"github ping", `{}`, map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": sign(`{}`)}, nil, ErrNotPush},
+		{"gitlab tag push", gitlab, map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": secret},
+			&PushEvent{Repo: "acme/tools/cli", After: "def456"}, nil},
+		{"gitlab wrong token", gitlab, map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "guess"}, nil, ErrSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/webhook", strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			got, err := ParsePush(r, secret)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("ParsePush() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePush() error: %v", err)
+			}
+			if *got != *tt.want {
+				t.Errorf("ParsePush() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenIssue(t *testing.T) {
+	var posted int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/acme/api/issues":
+			if r.URL.Query().Get("labels") != "project-drift" {
+				t.Errorf("labels = %q, want project-drift", r.URL.Query().Get("labels"))
+			}
+			w.Write([]byte(`[{"title": "Already filed", "html_url": "https://github.com/acme/api/issues/1"}]`))
+		case r.Method == "POST" && r.URL.Path == "/repos/acme/api/issues":
+			posted++
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["title"] != "Drifted" {
+				t.Errorf("posted title = %v, want Drifted", body["title"])
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"html_url": "https://github.com/acme/api/issues/2"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	f := &gitHub{&apiClient{ctx: context.Background(), http: srv.Client(), base: srv.URL, token: "t"}}
+
+	url, err := f.OpenIssue("acme", "api", Issue{Title: "Already filed", Labels: []string{"project-drift"}})
+	if err != nil || url != "https://github.com/acme/api/issues/1" || posted != 0 {
+		t.Errorf("OpenIssue() of a filed issue = %q, %v with %d posted; want issue 1 reused", url, err, posted)
+	}
+	url, err = f.OpenIssue("acme", "api", Issue{Title: "Drifted", Body: "details", Labels: []string{"project-drift"}})
+	if err != nil || url != "https://github.com/acme/api/issues/2" || posted != 1 {
+		t.Errorf("OpenIssue() = %q, %v with %d posted; want issue 2 filed", url, err, posted)
+	}
+}
+
+func TestOpenPullRequest(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.EscapedPath() != "/projects/acme%2Ftools%2Fcli/merge_requests" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"web_url": "https://gitlab.com/acme/tools/cli/-/merge_requests/7"}`))
+	}))
+	defer srv.Close()
+	f := &gitLab{&apiClient{ctx: context.Background(), http: srv.Client(), base: srv.URL, token: "t"}}
+
+	url, err := f.OpenPullRequest("acme/tools", "cli", PullRequest{Title: "Update", Body: "changes", Head: "project-update-1.33.0", Base: "main"})
+	if err != nil || url != "https://gitlab.com/acme/tools/cli/-/merge_requests/7" {
+		t.Fatalf("OpenPullRequest() = %q, %v; want merge request 7", url, err)
+	}
+	if got["source_branch"] != "project-update-1.33.0" || got["target_branch"] != "main" || got["description"] != "changes" {
+		t.Errorf("posted %v, want the branches and changelog", got)
+	}
+}
+
+func TestListRepos(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/octo/repos":
+			if r.URL.Query().Get("page") != "1" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"full_name": "octo/api"}, {"full_name": "octo/old", "archived": true}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	f := &gitHub{&apiClient{ctx: context.Background(), http: srv.Client(), base: srv.URL, token: "t"}}
+
+	// octo isn't an org, so its repos are listed as a user's
+	got, err := f.ListRepos("octo")
+	if err != nil || len(got) != 1 || got[0] != "octo/api" {
+		t.Errorf("ListRepos() = %v, %v; want octo/api without the archived repo", got, err)
+	}
+}
+
+func TestCanceled(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := retry.Policy{Attempts: 5, Delay: time.Hour, OnRetry: func(int, error, time.Duration) { cancel() }}
+	f := &gitHub{&apiClient{ctx: ctx, http: srv.Client(), base: srv.URL, token: "t", retry: policy}}
+
+	// The first failure cancels the run, which ends the hour's wait for the
+	// next try, and a canceled run sends no more requests
+	if _, err := f.ListRepos("octo"); err == nil {
+		t.Error("ListRepos() succeeded, want the API's error")
+	}
+	if _, err := f.CreateRepo(Repo{Owner: "octo", Name: "api"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("CreateRepo() after cancel = %v, want context.Canceled", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("the API was called %d times, want once", n)
+	}
+}
@@ -0,0 +1,147 @@
+package forge
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// gitHub implements Forge for github.com and GitHub Enterprise.
+type gitHub struct {
+	*apiClient
+}
+
+func (g *gitHub) CreateRepo(r Repo) (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := g.do("GET", "/user", nil, &user); err != nil {
+		return "", err
+	}
+
+	// Repos for the token's own user go to /user/repos, anything else is an org
+	path := "/user/repos"
+	if r.Owner != "" && !strings.EqualFold(r.Owner, user.Login) {
+		path = "/orgs/" + r.Owner + "/repos"
+	}
+
+	var created struct {
+		CloneURL string `json:"clone_url"`
+	}
+	body := map[string]interface{}{
+		"name":        r.Name,
+		"description": r.Description,
+		"private":     r.Private,
+	}
+	if err := g.do("POST", path, body, &created); err != nil {
+		return "", err
+	}
+	return created.CloneURL, nil
+}
+
+func (g *gitHub) ListRepos(owner string) ([]string, error) {
+	path := "/orgs/" + owner + "/repos"
+	var paths []string
+	for page := 1; ; page++ {
+		var repos []struct {
+			FullName string `json:"full_name"`
+			Archived bool   `json:"archived"`
+		}
+		err := g.do("GET", fmt.Sprintf("%s?per_page=100&page=%d", path, page), nil, &repos)
+		var apiErr *APIError
+		if page == 1 && errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			// Not an org, so a user
+			path = "/users/" + owner + "/repos"
+			err = g.do("GET", fmt.Sprintf("%s?per_page=100&page=%d", path, page), nil, &repos)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range repos {
+			if !r.Archived {
+				paths = append(paths, r.FullName)
+			}
+		}
+		if len(repos) < 100 {
+			return paths, nil
+		}
+	}
+}
+
+func (g *gitHub) Protect(owner, name string, p Protection) error {
+	var checks interface{}
+	if p.RequireStatusChecks {
+		contexts := p.StatusChecks
+		if contexts == nil {
+			contexts = []string{}
+		}
+		checks = map[string]interface{}{"strict": true, "contexts": contexts}
+	}
+	var reviews interface{}
+	if p.RequiredReviews > 0 {
+		reviews = map[string]interface{}{
+			"required_approving_review_count": p.RequiredReviews,
+			"dismiss_stale_reviews":           p.DismissStaleReviews,
+		}
+	}
+
+	body := map[string]interface{}{
+		"required_status_checks":        checks,
+		"enforce_admins":                p.EnforceAdmins,
+		"required_pull_request_reviews": reviews,
+		"restrictions":                  nil,
+		"allow_force_pushes":            p.AllowForcePushes,
+	}
+	path := fmt.Sprintf("/repos/%s/%s/branches/%s/protection", owner, name, p.Branch)
+	return g.do("PUT", path, body, nil)
+}
+
+func (g *gitHub) OpenIssue(owner, name string, i Issue) (string, error) {
+	q := url.Values{"state": {"open"}, "per_page": {"100"}}
+	if len(i.Labels) > 0 {
+		q.Set("labels", strings.Join(i.Labels, ","))
+	}
+	var open []struct {
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+	}
+	path := fmt.Sprintf("/repos/%s/%s/issues", owner, name)
+	if err := g.do("GET", path+"?"+q.Encode(), nil, &open); err != nil {
+		return "", err
+	}
+	for _, o := range open {
+		if o.Title == i.Title {
+			return o.HTMLURL, nil
+		}
+	}
+
+	var created struct {
+		HTMLURL string `json:"html_url"`
+	}
+	body := map[string]interface{}{"title": i.Title, "body": i.Body}
+	if len(i.Labels) > 0 {
+		body["labels"] = i.Labels
+	}
+	if err := g.do("POST", path, body, &created); err != nil {
+		return "", err
+	}
+	return created.HTMLURL, nil
+}
+
+func (g *gitHub) OpenPullRequest(owner, name string, pr PullRequest) (string, error) {
+	var created struct {
+		HTMLURL string `json:"html_url"`
+	}
+	body := map[string]interface{}{"title": pr.Title, "body": pr.Body, "head": pr.Head, "base": pr.Base}
+	if err := g.do("POST", fmt.Sprintf("/repos/%s/%s/pulls", owner, name), body, &created); err != nil {
+		return "", err
+	}
+	return created.HTMLURL, nil
+}
+
+func (g *gitHub) PushAuth() string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte("x-access-token:"+g.token))
+}
@@ -0,0 +1,145 @@
+package forge
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// gitLab implements Forge for gitlab.com and self-managed GitLab.
+type gitLab struct {
+	*apiClient
+}
+
+func (g *gitLab) CreateRepo(r Repo) (string, error) {
+	body := map[string]interface{}{
+		"name":        r.Name,
+		"path":        r.Name,
+		"description": r.Description,
+		"visibility":  "public",
+	}
+	if r.Private {
+		body["visibility"] = "private"
+	}
+
+	// Projects under a group need the group's namespace id
+	if r.Owner != "" {
+		var ns struct {
+			ID   int    `json:"id"`
+			Kind string `json:"kind"`
+		}
+		if err := g.do("GET", "/namespaces/"+url.PathEscape(r.Owner), nil, &ns); err != nil {
+			return "", fmt.Errorf("failed to find namespace %s: %w", r.Owner, err)
+		}
+		if ns.Kind == "group" {
+			body["namespace_id"] = ns.ID
+		}
+	}
+
+	var created struct {
+		HTTPURL string `json:"http_url_to_repo"`
+	}
+	if err := g.do("POST", "/projects", body, &created); err != nil {
+		return "", err
+	}
+	return created.HTTPURL, nil
+}
+
+func (g *gitLab) ListRepos(owner string) ([]string, error) {
+	var paths []string
+	for page := 1; ; page++ {
+		var projects []struct {
+			Path     string `json:"path_with_namespace"`
+			Archived bool   `json:"archived"`
+		}
+		path := fmt.Sprintf("/groups/%s/projects?include_subgroups=true&archived=false&per_page=100&page=%d", url.PathEscape(owner), page)
+		if err := g.do("GET", path, nil, &projects); err != nil {
+			return nil, err
+		}
+		for _, p := range projects {
+			if !p.Archived {
+				paths = append(paths, p.Path)
+			}
+		}
+		if len(projects) < 100 {
+			return paths, nil
+		}
+	}
+}
+
+func (g *gitLab) Protect(owner, name string, p Protection) error {
+	id := url.PathEscape(owner + "/" + name)
+
+	// Re-protecting an already protected branch fails, so clear it first
+	_ = g.do("DELETE", "/projects/"+id+"/protected_branches/"+url.PathEscape(p.Branch), nil, nil)
+
+	const maintainer = 40
+	body := map[string]interface{}{
+		"name":               p.Branch,
+		"push_access_level":  maintainer,
+		"merge_access_level": maintainer,
+		"allow_force_push":   p.AllowForcePushes,
+	}
+	if err := g.do("POST", "/projects/"+id+"/protected_branches", body, nil); err != nil {
+		return err
+	}
+
+	if p.RequiredReviews > 0 {
+		rule := map[string]interface{}{"approvals_before_merge": p.RequiredReviews}
+		if err := g.do("PUT", "/projects/"+id, rule, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *gitLab) OpenIssue(owner, name string, i Issue) (string, error) {
+	id := url.PathEscape(owner + "/" + name)
+	q := url.Values{"state": {"opened"}, "search": {i.Title}, "in": {"title"}}
+	var open []struct {
+		Title  string `json:"title"`
+		WebURL string `json:"web_url"`
+	}
+	if err := g.do("GET", "/projects/"+id+"/issues?"+q.Encode(), nil, &open); err != nil {
+		return "", err
+	}
+	for _, o := range open {
+		if o.Title == i.Title {
+			return o.WebURL, nil
+		}
+	}
+
+	var created struct {
+		WebURL string `json:"web_url"`
+	}
+	body := map[string]interface{}{"title": i.Title, "description": i.Body}
+	if len(i.Labels) > 0 {
+		body["labels"] = strings.Join(i.Labels, ",")
+	}
+	if err := g.do("POST", "/projects/"+id+"/issues", body, &created); err != nil {
+		return "", err
+	}
+	return created.WebURL, nil
+}
+
+func (g *gitLab) OpenPullRequest(owner, name string, pr PullRequest) (string, error) {
+	var created struct {
+		WebURL string `json:"web_url"`
+	}
+	body := map[string]interface{}{
+		"title":                pr.Title,
+		"description":          pr.Body,
+		"source_branch":        pr.Head,
+		"target_branch":        pr.Base,
+		"remove_source_branch": true,
+	}
+	if err := g.do("POST", "/projects/"+url.PathEscape(owner+"/"+name)+"/merge_requests", body, &created); err != nil {
+		return "", err
+	}
+	return created.WebURL, nil
+}
+
+func (g *gitLab) PushAuth() string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte("oauth2:"+g.token))
+}
@@ -0,0 +1,108 @@
+package forge
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxPayloadSize bounds the webhook payload ParsePush reads.
+const maxPayloadSize = 5 << 20
+
+// ErrNotPush is returned by ParsePush for a genuine webhook that isn't a
+// push, such as GitHub's ping, which the receiver just acknowledges.
+var ErrNotPush = errors.New("not a push event")
+
+// ErrSignature is returned by ParsePush when the webhook isn't signed with
+// the shared secret.
+var ErrSignature = errors.New("missing or wrong webhook signature")
+
+// PushEvent is the part of a push webhook the drift watcher needs.
+type PushEvent struct {
+	Repo          string // owner/name, or the GitLab project path
+	Branch        string // "" for a tag push
+	DefaultBranch string // the repository's
+	After         string // the commit pushed
+}
+
+// ParsePush reads a push webhook from GitHub or GitLab. GitHub signs the
+// payload with secret in X-Hub-Signature-256; GitLab sends the secret
+// itself as X-Gitlab-Token. An empty secret skips the check.
+func ParsePush(r *http.Request, secret string) (*PushEvent, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload: %w", err)
+	}
+
+	var payload struct {
+		Ref        string `json:"ref"`
+		After      string `json:"after"`
+		Repository struct {
+			FullName      string `json:"full_name"`
+			DefaultBranch string `json:"default_branch"`
+		} `json:"repository"`
+		Project struct {
+			Path          string `json:"path_with_namespace"`
+			DefaultBranch string `json:"default_branch"`
+		} `json:"project"`
+	}
+	ev := &PushEvent{}
+	switch {
+	case r.Header.Get("X-GitHub-Event") != "":
+		if secret != "" && !validSignature(body, secret, r.Header.Get("X-Hub-Signature-256")) {
+			return nil, ErrSignature
+		}
+		if r.Header.Get("X-GitHub-Event") != "push" {
+			return nil, ErrNotPush
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("invalid push payload: %w", err)
+		}
+		ev.Repo, ev.DefaultBranch = payload.Repository.FullName, payload.Repository.DefaultBranch
+	case r.Header.Get("X-Gitlab-Event") != "":
+		if secret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), []byte(secret)) != 1 {
+			return nil, ErrSignature
+		}
+		if r.Header.Get("X-Gitlab-Event") != "Push Hook" {
+			return nil, ErrNotPush
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("invalid push payload: %w", err)
+		}
+		ev.Repo, ev.DefaultBranch = payload.Project.Path, payload.Project.DefaultBranch
+	default:
+		return nil, errors.New("not a GitHub or GitLab webhook")
+	}
+
+	if ev.Repo == "" {
+		return nil, errors.New("invalid push payload: no repository")
+	}
+	ev.Branch, _ = strings.CutPrefix(payload.Ref, "refs/heads/")
+	if ev.Branch == payload.Ref {
+		ev.Branch = ""
+	}
+	ev.After = payload.After
+	return ev, nil
+}
+
+// validSignature checks a GitHub "sha256=<hex>" signature of body.
+func validSignature(body []byte, secret, signature string) bool {
+	got, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	sum, err := hex.DecodeString(got)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(sum, mac.Sum(nil))
+}
@@ -0,0 +1,213 @@
+// Package gitops wraps the git commands the generator needs.
+package gitops
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+// Runner runs the git commands; tests swap in an execx.Recorder.
+var Runner execx.Runner = execx.Host{}
+
+// Root returns the top-level directory of the git repo containing dir,
+// or "" if dir is not inside a repo (or git is not installed).
+func Root(dir string) string {
+	out, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// Dirty lists paths with uncommitted changes (including untracked files)
+// in the repo containing dir, relative to the repo root.
+func Dirty(dir string) ([]string, error) {
+	out, err := git(dir, "status", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) > 3 {
+			paths = append(paths, strings.TrimSpace(line[3:]))
+		}
+	}
+	return paths, nil
+}
+
+// git runs a git subcommand in dir and returns its stdout.
+func git(dir string, args ...string) (string, error) {
+	return gitEnv(dir, nil, args...)
+}
+
+// gitEnv is git with env, KEY=value pairs, added to git's environment.
+func gitEnv(dir string, env []string, args ...string) (string, error) {
+	ctx := context.Background()
+	if len(env) > 0 {
+		ctx = execx.WithEnv(ctx, env...)
+	}
+	res, err := Runner.Run(ctx, dir, "git", args...)
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(string(res.Stderr)))
+	}
+	return string(res.Stdout), nil
+}
+
+// Config returns the value of a git config key as seen from dir, or ""
+// when it is unset.
+func Config(dir, key string) string {
+	out, err := git(dir, "config", "--get", key)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// Init creates a repo in dir with the given initial branch.
+func Init(dir, branch string) error {
+	_, err := git(dir, "init", "-b", branch)
+	return err
+}
+
+// CommitAll stages everything in dir and commits it.
+func CommitAll(dir, message string) error {
+	if _, err := git(dir, "add", "-A"); err != nil {
+		return err
+	}
+	_, err := git(dir, "commit", "-m", message)
+	return err
+}
+
+// Branch returns the branch checked out in dir, or an error when HEAD is detached.
+func Branch(dir string) (string, error) {
+	out, err := git(dir, "symbolic-ref", "-q", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("no branch is checked out: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Checkout switches dir to branch, creating it from HEAD when create is set.
+func Checkout(dir, branch string, create bool) error {
+	args := []string{"checkout", "-q"}
+	if create {
+		args = append(args, "-b")
+	}
+	_, err := git(dir, append(args, branch)...)
+	return err
+}
+
+// DeleteBranch deletes a local branch, merged or not.
+func DeleteBranch(dir, branch string) error {
+	_, err := git(dir, "branch", "-q", "-D", branch)
+	return err
+}
+
+// SetRemote points the named remote at url, adding it if needed.
+func SetRemote(dir, name, url string) error {
+	if _, err := git(dir, "remote", "set-url", name, url); err == nil {
+		return nil
+	}
+	_, err := git(dir, "remote", "add", name, url)
+	return err
+}
+
+// RemoteURL returns the URL of the named remote of the repo containing dir.
+func RemoteURL(dir, name string) (string, error) {
+	out, err := git(dir, "remote", "get-url", name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Push pushes branch to remote and sets it as upstream. auth, if set, is
+// sent as an HTTP Authorization header; see authEnv.
+func Push(dir, remote, branch, auth string) error {
+	_, err := gitEnv(dir, authEnv(auth), "push", "-u", remote, branch)
+	return err
+}
+
+// Clone makes a shallow clone of url's branch, "" for its default one, in
+// dir. auth, if set, is sent as an HTTP Authorization header, as with Push.
+func Clone(url, dir, branch, auth string) error {
+	args := []string{"clone", "-q", "--depth", "1"}
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	args = append(args, url, dir)
+	_, err := gitEnv(filepath.Dir(dir), authEnv(auth), args...)
+	return err
+}
+
+// authEnv configures http.extraHeader with auth through git's environment,
+// so the token lands neither in .git/config nor on the command line, where
+// other users can read it.
+func authEnv(auth string) []string {
+	if auth == "" {
+		return nil
+	}
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: " + auth,
+	}
+}
+
+// Pull fast-forwards the clone in dir to its remote branch.
+func Pull(dir string) error {
+	_, err := git(dir, "pull", "-q", "--ff-only")
+	return err
+}
+
+// unreachable are what git prints when it can't get through to a remote,
+// as opposed to one that answered with an error.
+var unreachable = []string{
+	"Could not resolve host", "Could not resolve hostname", "Temporary failure in name resolution",
+	"Failed to connect", "Connection refused", "Connection timed out", "Operation timed out",
+	"Connection reset", "Network is unreachable", "The remote end hung up unexpectedly",
+	"returned error: 429", "returned error: 50",
+}
+
+// Unreachable reports whether err, from a git command, says the remote
+// couldn't be reached, so a cached copy may stand in for it.
+func Unreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, s := range unreachable {
+		if strings.Contains(err.Error(), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Files lists the tracked and untracked-but-not-ignored files under dir,
+// relative to dir, slash-separated. Tracked files deleted from the working
+// tree are left out.
+func Files(dir string) ([]string, error) {
+	out, err := git(dir, "ls-files", "--cached", "--others", "--exclude-standard", "-z", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := git(dir, "ls-files", "--deleted", "-z", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	gone := make(map[string]bool)
+	for _, f := range strings.Split(deleted, "\x00") {
+		gone[f] = true
+	}
+	var files []string
+	for _, f := range strings.Split(out, "\x00") {
+		if f != "" && !gone[f] {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
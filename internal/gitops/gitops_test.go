@@ -0,0 +1,110 @@
+package gitops
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+func TestPushAuth(t *testing.T) {
+	defer func(r execx.Runner) { Runner = r }(Runner)
+	rec := &execx.Recorder{}
+	Runner = rec
+
+	if err := Push("/repo", "origin", "main", "Bearer s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Clone("https://example.com/demo.git", "/work/demo", "", "Bearer s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range rec.Calls() {
+		if strings.Contains(strings.Join(c.Args, " "), "s3cret") {
+			t.Errorf("%s has the token on its command line", c)
+		}
+		if !slices.Contains(c.Env, "GIT_CONFIG_VALUE_0=Authorization: Bearer s3cret") {
+			t.Errorf("%s env = %q, want the Authorization header", c, c.Env)
+		}
+	}
+
+	rec = &execx.Recorder{}
+	Runner = rec
+	if err := Push("/repo", "origin", "main", ""); err != nil {
+		t.Fatal(err)
+	}
+	if c := rec.Calls()[0]; c.Env != nil {
+		t.Errorf("%s without auth env = %q, want none", c, c.Env)
+	}
+}
+
+func TestAuthEnvConfiguresGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	out, err := gitEnv(t.TempDir(), authEnv("Bearer s3cret"), "config", "--get", "http.extraHeader")
+	if err != nil || strings.TrimSpace(out) != "Authorization: Bearer s3cret" {
+		t.Errorf("http.extraHeader = %q, %v; want the Authorization header", out, err)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for name, data := range map[string]string{".gitignore": "*.log\n", "a.go": "", "b.go": "", "sub/c.go": ""} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Init(dir, "main"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := git(dir, "add", "-A"); err != nil {
+		t.Fatal(err)
+	}
+	// One untracked file, one ignored and one tracked but deleted
+	for _, name := range []string{"new.go", "build.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Remove(filepath.Join(dir, "b.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := Files(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(files)
+	if want := []string{".gitignore", "a.go", "new.go", "sub/c.go"}; !slices.Equal(files, want) {
+		t.Errorf("Files() = %q, want %q", files, want)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	for msg, want := range map[string]bool{
+		"git pull failed: exit status 128: fatal: unable to access 'https://github.com/acme/x/': Could not resolve host: github.com":    true,
+		"git pull failed: exit status 128: ssh: connect to host github.com port 22: Connection timed out":                               true,
+		"git pull failed: exit status 128: fatal: unable to access 'https://github.com/acme/x/': The requested URL returned error: 503": true,
+		"git pull failed: exit status 128: fatal: Not possible to fast-forward, aborting.":                                              false,
+		"git pull failed: exit status 128: fatal: unable to access 'https://github.com/acme/x/': The requested URL returned error: 403": false,
+		"git pull failed: exit status 1: error: Your local changes to the following files would be overwritten by merge: presets.yaml":  false,
+	} {
+		if got := Unreachable(errors.New(msg)); got != want {
+			t.Errorf("Unreachable(%q) = %v, want %v", msg, got, want)
+		}
+	}
+	if Unreachable(nil) {
+		t.Error("Unreachable(nil) = true")
+	}
+}
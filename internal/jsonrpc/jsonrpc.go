@@ -0,0 +1,294 @@
+// Package jsonrpc serves methods over JSON-RPC 2.0 on a stream, by default
+// framed as in the Language Server Protocol: each message is a JSON body
+// preceded by a Content-Length header and a blank line. Editors' language
+// client libraries speak it as is, so an extension can drive the server
+// over the stdin and stdout of a child process. LineFraming puts one
+// message on each line instead, as the Model Context Protocol does.
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxMessage bounds one message's body.
+const maxMessage = 16 << 20
+
+// ExitMethod is the notification that ends Serve, as in LSP.
+const ExitMethod = "exit"
+
+// ProgressMethod is the notification Call.Progress sends. Its params are
+// {"token": <request id>, "value": ...}, as LSP's $/progress.
+const ProgressMethod = "$/progress"
+
+// Handler answers one request. Its result is encoded as the response's
+// result; its error as the response's error, with the code of an *Error
+// or CodeInvalidParams.
+type Handler func(ctx context.Context, call *Call) (any, error)
+
+// Server answers the requests of one client, in turn.
+type Server struct {
+	Methods map[string]Handler
+
+	// Framing splits the stream into messages; nil means HeaderFraming.
+	Framing Framing
+}
+
+// Framing reads and writes whole message bodies on a stream.
+type Framing interface {
+	// Read returns the next body, or io.EOF at a clean end of input.
+	Read(r *bufio.Reader) ([]byte, error)
+	Write(w io.Writer, body []byte) error
+}
+
+var (
+	// HeaderFraming precedes each body with a Content-Length header, as LSP does.
+	HeaderFraming Framing = headerFraming{}
+
+	// LineFraming puts each body on a line of its own, as the stdio
+	// transport of MCP does; blank lines are skipped.
+	LineFraming Framing = lineFraming{}
+)
+
+// Call is a request being handled.
+type Call struct {
+	Method string
+	Params json.RawMessage
+
+	id   json.RawMessage
+	conn *conn
+}
+
+// Progress sends a progress notification for the call, e.g. a step done.
+func (c *Call) Progress(value any) error {
+	return c.conn.write(message{JSONRPC: "2.0", Method: ProgressMethod, Params: progress{c.id, value}})
+}
+
+type progress struct {
+	Token json.RawMessage `json:"token"`
+	Value any             `json:"value"`
+}
+
+// Error is a JSON-RPC error a Handler can return to pick the code.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// JSON-RPC error codes.
+const (
+	CodeParse          = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+)
+
+// message is a request, notification or response.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// request is a message as read.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// conn writes whole messages, one at a time.
+type conn struct {
+	mu      sync.Mutex
+	w       io.Writer
+	framing Framing
+}
+
+func (c *conn) write(m message) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.framing.Write(c.w, body)
+}
+
+// Serve answers requests read from r on w until r ends, the exit
+// notification arrives or ctx is done. Other notifications, which have no
+// id, get no answer.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	framing := s.Framing
+	if framing == nil {
+		framing = HeaderFraming
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	bodies := make(chan []byte)
+	errc := make(chan error, 1)
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			body, err := framing.Read(br)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				errc <- err
+				return
+			}
+			select {
+			case bodies <- body:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	c := &conn{w: w, framing: framing}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errc:
+			return err
+		case body := <-bodies:
+			resp, exit := s.handle(ctx, c, body)
+			if exit {
+				return nil
+			}
+			if resp != nil {
+				if err := c.write(*resp); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// handle answers one message, or returns nil for a notification, with
+// exit set for the exit notification.
+func (s *Server) handle(ctx context.Context, c *conn, body []byte) (resp *message, exit bool) {
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return &message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{CodeParse, err.Error()}}, false
+	}
+	if req.ID == nil {
+		return nil, req.Method == ExitMethod
+	}
+	resp = &message{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &Error{CodeInvalidRequest, "not a JSON-RPC 2.0 request"}
+		return resp, false
+	}
+	h, ok := s.Methods[req.Method]
+	if !ok {
+		resp.Error = &Error{CodeMethodNotFound, fmt.Sprintf("unknown method %q", req.Method)}
+		return resp, false
+	}
+	if len(req.Params) == 0 || string(req.Params) == "null" {
+		req.Params = json.RawMessage("{}")
+	}
+
+	result, err := h(ctx, &Call{Method: req.Method, Params: req.Params, id: req.ID, conn: c})
+	var rerr *Error
+	switch {
+	case errors.As(err, &rerr):
+		resp.Error = rerr
+	case err != nil:
+		resp.Error = &Error{CodeInvalidParams, err.Error()}
+	case result == nil:
+		resp.Result = struct{}{}
+	default:
+		resp.Result = result
+	}
+	return resp, false
+}
+
+type headerFraming struct{}
+
+func (headerFraming) Read(r *bufio.Reader) ([]byte, error) { return Read(r) }
+func (headerFraming) Write(w io.Writer, body []byte) error { return Write(w, body) }
+
+type lineFraming struct{}
+
+func (lineFraming) Read(r *bufio.Reader) ([]byte, error) {
+	for {
+		var line []byte
+		for {
+			chunk, err := r.ReadSlice('\n')
+			line = append(line, chunk...)
+			if len(line) > maxMessage {
+				return nil, fmt.Errorf("message is over the limit of %d bytes", maxMessage)
+			}
+			if errors.Is(err, bufio.ErrBufferFull) {
+				continue
+			}
+			if errors.Is(err, io.EOF) && len(bytes.TrimSpace(line)) > 0 {
+				break // a last line without a newline
+			}
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line, nil
+		}
+	}
+}
+
+// Write relies on JSON encoding newlines inside strings as \n.
+func (lineFraming) Write(w io.Writer, body []byte) error {
+	_, err := w.Write(append(bytes.TrimSpace(body), '\n'))
+	return err
+}
+
+// Read reads one message's body from r, skipping headers other than
+// Content-Length. It returns io.EOF at a clean end of input.
+func Read(r *bufio.Reader) ([]byte, error) {
+	tp := textproto.NewReader(r)
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("bad header: %w", err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("bad Content-Length %q", header.Get("Content-Length"))
+	}
+	if n > maxMessage {
+		return nil, fmt.Errorf("message of %d bytes is over the limit of %d", n, maxMessage)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// Write writes body to w as one message.
+func Write(w io.Writer, body []byte) error {
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err := w.Write(body)
+	return err
+}
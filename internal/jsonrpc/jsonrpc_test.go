@@ -0,0 +1,124 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	s := &Server{Methods: map[string]Handler{
+		"greet": func(ctx context.Context, call *Call) (any, error) {
+			var in struct{ Name string }
+			if err := json.Unmarshal(call.Params, &in); err != nil {
+				return nil, err
+			}
+			if in.Name == "" {
+				return nil, errors.New("name is required")
+			}
+			if err := call.Progress(map[string]string{"step": "greeting"}); err != nil {
+				return nil, err
+			}
+			return map[string]string{"greeting": "hello " + in.Name}, nil
+		},
+		"fail": func(ctx context.Context, call *Call) (any, error) {
+			return nil, &Error{Code: -32001, Message: "busy"}
+		},
+	}}
+	var in bytes.Buffer
+	for _, m := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"greet","params":{"name":"ada"}}`,
+		`{"jsonrpc":"2.0","method":"initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"greet"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"fail"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"wave"}`,
+		`not json`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"greet","params":{"name":"never"}}`,
+	} {
+		if err := Write(&in, []byte(m)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var out bytes.Buffer
+	if err := s.Serve(context.Background(), &in, &out); err != nil {
+		t.Fatalf("Serve() error: %v", err)
+	}
+
+	var got []string
+	r := bufio.NewReader(&out)
+	for {
+		body, err := Read(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(body))
+	}
+	want := []string{
+		`{"jsonrpc":"2.0","method":"$/progress","params":{"token":1,"value":{"step":"greeting"}}}`,
+		`{"jsonrpc":"2.0","id":1,"result":{"greeting":"hello ada"}}`,
+		`{"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"name is required"}}`,
+		`{"jsonrpc":"2.0","id":3,"error":{"code":-32001,"message":"busy"}}`,
+		`{"jsonrpc":"2.0","id":4,"error":{"code":-32601,"message":"unknown method \"wave\""}}`,
+		`"code":-32700`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d messages, want %d (none after exit):\n%s", len(got), len(want), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if !strings.Contains(got[i], want[i]) {
+			t.Errorf("message %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("Content-Type: application/vscode-jsonrpc; charset=utf-8\r\nContent-Length: 2\r\n\r\n{}"))
+	body, err := Read(r)
+	if err != nil || string(body) != "{}" {
+		t.Errorf("Read() = %q, %v; want {}", body, err)
+	}
+	if _, err := Read(r); !errors.Is(err, io.EOF) {
+		t.Errorf("Read() at the end = %v, want io.EOF", err)
+	}
+	if _, err := Read(bufio.NewReader(strings.NewReader("Content-Length: x\r\n\r\n"))); err == nil {
+		t.Error("Read() accepted a bad Content-Length")
+	}
+}
+
+func TestLineFraming(t *testing.T) {
+	long := `{"pad":"` + strings.Repeat("x", 8<<10) + `"}`
+	r := bufio.NewReader(strings.NewReader("{\"a\":1}\r\n\n  \n" + long + "\n{}"))
+	for _, want := range []string{`{"a":1}`, long, `{}`} {
+		body, err := LineFraming.Read(r)
+		if err != nil || string(body) != want {
+			t.Fatalf("Read() = %.40q, %v; want %.40q", body, err, want)
+		}
+	}
+	if _, err := LineFraming.Read(r); !errors.Is(err, io.EOF) {
+		t.Errorf("Read() at the end = %v, want io.EOF", err)
+	}
+
+	s := &Server{Framing: LineFraming, Methods: map[string]Handler{
+		"echo": func(ctx context.Context, call *Call) (any, error) { return call.Params, nil },
+	}}
+	in := `{"jsonrpc":"2.0","id":1,"method":"echo","params":{"text":"two\nlines"}}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"wave"}` + "\n"
+	var out bytes.Buffer
+	if err := s.Serve(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatalf("Serve() error: %v", err)
+	}
+	want := `{"jsonrpc":"2.0","id":1,"result":{"text":"two\nlines"}}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"unknown method \"wave\""}}` + "\n"
+	if out.String() != want {
+		t.Errorf("Serve() wrote:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
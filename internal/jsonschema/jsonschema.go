@@ -0,0 +1,330 @@
+// Package jsonschema checks YAML documents against the subset of JSON
+// Schema the project's own schemas use, reporting the line and column of
+// each problem. Keywords it doesn't know, like description, are ignored.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is one (sub)schema. The boolean schemas true and false decode as
+// an empty Schema and one with False set.
+type Schema struct {
+	Type                 Types              `json:"type"`
+	Enum                 []string           `json:"enum"`
+	Not                  *Schema            `json:"not"`
+	Pattern              string             `json:"pattern"`
+	MinLength            int                `json:"minLength"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *Schema            `json:"additionalProperties"`
+	PropertyNames        *Schema            `json:"propertyNames"`
+	Items                *Schema            `json:"items"`
+	MinItems             int                `json:"minItems"`
+	UniqueItems          bool               `json:"uniqueItems"`
+
+	// If picks Then or Else by whether the value matches it.
+	If   *Schema `json:"if"`
+	Then *Schema `json:"then"`
+	Else *Schema `json:"else"`
+
+	// Ref names a schema of the root's Defs, as "#/$defs/name".
+	Ref  string             `json:"$ref"`
+	Defs map[string]*Schema `json:"$defs"`
+
+	False bool `json:"-"`
+
+	pattern *regexp.Regexp
+	ref     *Schema
+}
+
+// Parse decodes a schema and compiles its patterns.
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := s.compile(&s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &s, nil
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{False: true}
+		return nil
+	}
+	type plain Schema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+func (s *Schema) compile(root *Schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/$defs/")
+		if s.ref = root.Defs[name]; !ok || s.ref == nil {
+			return fmt.Errorf("unknown $ref %q", s.Ref)
+		}
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+		s.pattern = re
+	}
+	for _, subs := range []map[string]*Schema{s.Properties, s.Defs} {
+		for _, sub := range subs {
+			if err := sub.compile(root); err != nil {
+				return err
+			}
+		}
+	}
+	for _, sub := range []*Schema{s.Not, s.AdditionalProperties, s.PropertyNames, s.Items, s.If, s.Then, s.Else} {
+		if err := sub.compile(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Types is the type keyword: one type name or a list of them.
+type Types []string
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = Types{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// Error is one place a document breaks the schema. Path locates the value
+// in the document, e.g. commands[0].name, and is empty for the top level.
+type Error struct {
+	Line, Column int
+	Path         string
+	Message      string
+}
+
+func (e Error) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// Validate checks doc, a parsed YAML document, against s and returns every
+// problem in the order they appear in the file.
+func (s *Schema) Validate(doc *yaml.Node) []Error {
+	v := &validator{}
+	switch {
+	case doc.Kind == yaml.DocumentNode && len(doc.Content) > 0:
+		doc = doc.Content[0]
+	case doc.Kind == 0 || doc.Kind == yaml.DocumentNode: // an empty file
+		doc = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Line: 1, Column: 1}
+	}
+	v.check(s, doc, "")
+	sort.SliceStable(v.errs, func(i, j int) bool {
+		a, b := v.errs[i], v.errs[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return v.errs
+}
+
+type validator struct {
+	errs []Error
+}
+
+func (v *validator) fail(n *yaml.Node, path, format string, args ...any) {
+	v.errs = append(v.errs, Error{Line: n.Line, Column: n.Column, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) check(s *Schema, n *yaml.Node, path string) {
+	if s == nil {
+		return
+	}
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if s.False {
+		v.fail(n, path, "not allowed here")
+		return
+	}
+	typ := nodeType(n)
+	if len(s.Type) > 0 && !matchesType(s.Type, typ) {
+		v.fail(n, path, "got %s, want %s", typ, strings.Join(s.Type, " or "))
+		return
+	}
+	if len(s.Enum) > 0 && !(n.Kind == yaml.ScalarNode && slices.Contains(s.Enum, n.Value)) {
+		v.fail(n, path, "%s is not one of %s", describe(n), strings.Join(s.Enum, ", "))
+	}
+	if s.Not != nil && v.matches(s.Not, n, path) {
+		v.fail(n, path, "%s is not allowed", describe(n))
+	}
+	if s.If != nil {
+		if v.matches(s.If, n, path) {
+			v.check(s.Then, n, path)
+		} else {
+			v.check(s.Else, n, path)
+		}
+	}
+	v.check(s.ref, n, path)
+
+	switch n.Kind {
+	case yaml.ScalarNode:
+		if s.pattern != nil && typ == "string" && !s.pattern.MatchString(n.Value) {
+			v.fail(n, path, "%q doesn't match %s", n.Value, s.Pattern)
+		}
+		if s.MinLength > 0 && typ == "string" && utf8.RuneCountInString(n.Value) < s.MinLength {
+			v.fail(n, path, "must not be empty")
+		}
+	case yaml.MappingNode:
+		v.checkObject(s, n, path)
+	case yaml.SequenceNode:
+		if len(n.Content) < s.MinItems {
+			v.fail(n, path, "got %d items, want at least %d", len(n.Content), s.MinItems)
+		}
+		seen := make(map[string]bool)
+		for i, item := range n.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			v.check(s.Items, item, itemPath)
+			if s.UniqueItems && item.Kind == yaml.ScalarNode {
+				if seen[item.Value] {
+					v.fail(item, itemPath, "%s is listed twice", describe(item))
+				}
+				seen[item.Value] = true
+			}
+		}
+	}
+}
+
+// matches reports whether n is valid against s, without failing v.
+func (v *validator) matches(s *Schema, n *yaml.Node, path string) bool {
+	sub := &validator{}
+	sub.check(s, n, path)
+	return len(sub.errs) == 0
+}
+
+func (v *validator) checkObject(s *Schema, n *yaml.Node, path string) {
+	present := make(map[string]bool)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		present[key.Value] = true
+		keyPath := key.Value
+		if path != "" {
+			keyPath = path + "." + key.Value
+		}
+		v.check(s.PropertyNames, key, keyPath)
+		if sub, ok := s.Properties[key.Value]; ok {
+			v.check(sub, value, keyPath)
+			continue
+		}
+		if s.AdditionalProperties != nil && s.AdditionalProperties.False {
+			v.fail(key, path, "unknown key %q%s", key.Value, suggest(key.Value, s.Properties))
+			continue
+		}
+		v.check(s.AdditionalProperties, value, keyPath)
+	}
+	for _, name := range s.Required {
+		if !present[name] {
+			v.fail(n, path, "missing %s", name)
+		}
+	}
+}
+
+// suggest names a known key the unknown one is probably a typo of.
+func suggest(key string, known map[string]*Schema) string {
+	names := make([]string, 0, len(known))
+	for name := range known {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	best, dist := "", 3
+	for _, name := range names {
+		if d := distance(key, name); d < dist {
+			best, dist = name, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %s?)", best)
+}
+
+// distance is the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// nodeType is the JSON type of a YAML node.
+func nodeType(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch n.ShortTag() {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	}
+	return "string"
+}
+
+func matchesType(want Types, got string) bool {
+	for _, t := range want {
+		if t == got || (t == "number" && got == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(n *yaml.Node) string {
+	if n.Kind == yaml.ScalarNode {
+		return fmt.Sprintf("%q", n.Value)
+	}
+	return "the " + nodeType(n)
+}
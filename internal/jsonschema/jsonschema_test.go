@@ -0,0 +1,110 @@
+package jsonschema
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testSchema = `{
+  "type": "object",
+  "required": ["name"],
+  "additionalProperties": false,
+  "properties": {
+    "name": {"type": "string", "pattern": "^[a-z]+$", "not": {"enum": ["main"]}},
+    "size": {"type": ["integer", "string"]},
+    "tags": {"type": "array", "uniqueItems": true, "items": {"enum": ["a", "b"]}},
+    "env": {"type": "object", "propertyNames": {"pattern": "^[A-Z]+$"}, "additionalProperties": {"type": "string"}},
+    "gone": false
+  }
+}`
+
+func TestValidate(t *testing.T) {
+	schema, err := Parse([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	tests := []struct {
+		name, doc string
+		want      []string
+	}{
+		{"valid", "name: abc\nsize: 3\ntags: [a, b]\nenv: {HOME: x}\n", nil},
+		{"empty", "", []string{"1:1: got null, want object"}},
+		{"missing", "size: big\n", []string{"1:1: missing name"}},
+		{"unknown key", "name: abc\nnmae: x\n", []string{`2:1: unknown key "nmae" (did you mean name?)`}},
+		{"type", "name: abc\nsize: [1]\n", []string{"2:7: size: got array, want integer or string"}},
+		{"pattern and not", "name: Main\n---\n", []string{`1:7: name: "Main" doesn't match ^[a-z]+$`}},
+		{"not", "name: main\n", []string{`1:7: name: "main" is not allowed`}},
+		{"items", "name: abc\ntags: [a, c, a]\n", []string{`2:11: tags[1]: "c" is not one of a, b`, `2:14: tags[2]: "a" is listed twice`}},
+		{"property names", "name: abc\nenv:\n  home: x\n  PATH: 1\n", []string{`3:3: env.home: "home" doesn't match ^[A-Z]+$`, "4:9: env.PATH: got integer, want string"}},
+		{"false", "name: abc\ngone: 1\n", []string{"2:7: gone: not allowed here"}},
+		{"alias", "name: &n abc\nenv: {A: *n}\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range schema.Validate(&doc) {
+				got = append(got, e.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBadPattern(t *testing.T) {
+	if _, err := Parse([]byte(`{"properties": {"a": {"pattern": "("}}}`)); err == nil {
+		t.Error("Parse() accepted an invalid pattern")
+	}
+	if _, err := Parse([]byte(`{"items": {"$ref": "#/$defs/gone"}}`)); err == nil {
+		t.Error("Parse() accepted an unknown $ref")
+	}
+}
+
+// A document is either one item or a list of them, told apart by if.
+const refSchema = `{
+  "if": {"required": ["items"]},
+  "then": {"properties": {"items": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/item"}}}},
+  "else": {"$ref": "#/$defs/item"},
+  "$defs": {
+    "item": {"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}
+  }
+}`
+
+func TestValidateRefs(t *testing.T) {
+	schema, err := Parse([]byte(refSchema))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	tests := []struct {
+		name, doc string
+		want      []string
+	}{
+		{"one", "id: 1\n", nil},
+		{"one wrong", "id: x\n", []string{"1:5: id: got string, want integer"}},
+		{"list", "items: [{id: 1}, {id: 2}]\n", nil},
+		{"list wrong", "items: [{id: 1}, {name: b}]\n", []string{"1:18: items[1]: missing id"}},
+		{"empty list", "items: []\n", []string{"1:8: items: got 0 items, want at least 1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range schema.Validate(&doc) {
+				got = append(got, e.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
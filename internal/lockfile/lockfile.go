@@ -0,0 +1,200 @@
+// Package lockfile keeps two runs from writing into the same directory at
+// once. A lock is a file created exclusively, recording who holds it so a
+// lock left behind by a crashed run can be recognized and taken over.
+package lockfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StaleAfter is how old a lock from another host must be before it is
+// considered abandoned; on the same host the holder's PID is checked instead.
+const StaleAfter = time.Hour
+
+// judged runs between judging a lock stale and breaking it; tests widen
+// the window there.
+var judged = func() {}
+
+// Info is the content of a lock file.
+type Info struct {
+	PID     int       `yaml:"pid"`
+	Host    string    `yaml:"host"`
+	Started time.Time `yaml:"started"`
+	Command string    `yaml:"command"`
+}
+
+// HeldError reports a lock held by a live run.
+type HeldError struct {
+	Path string
+	Info Info
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("%s is held by pid %d on %s since %s (%s)",
+		e.Path, e.Info.PID, e.Info.Host, e.Info.Started.Local().Format(time.DateTime), e.Info.Command)
+}
+
+// Lock is a held lock file.
+type Lock struct {
+	Path string
+
+	// Broke is the stale or forcibly broken lock this one replaced, if any.
+	Broke *Info
+
+	data []byte // what this run wrote to Path
+}
+
+// Acquire creates the lock file at path. An existing lock is replaced when
+// it is stale, or unconditionally with force; otherwise a *HeldError is returned.
+func Acquire(path string, force bool) (*Lock, error) {
+	l := &Lock{Path: path}
+	for attempt := 0; attempt < 2; attempt++ {
+		data, err := create(path)
+		if err == nil {
+			l.data = data
+			return l, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock: %w", err)
+		}
+
+		held, data, err := read(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // released meanwhile
+		}
+		if err == nil && !force && !held.stale() {
+			return nil, &HeldError{Path: path, Info: *held}
+		}
+		// Unreadable locks are either being written right now or debris
+		// from a crash mid-write; only the latter is old
+		if err != nil && !force && recent(path) {
+			return nil, fmt.Errorf("%s is being taken by another run", path)
+		}
+		judged()
+		broke, err := breakLock(path, data)
+		if err != nil {
+			return nil, err
+		}
+		if broke {
+			if held == nil {
+				held = &Info{}
+			}
+			l.Broke = held
+		}
+	}
+	return nil, fmt.Errorf("failed to acquire %s: lock keeps reappearing", path)
+}
+
+// Release removes the lock file, unless another run has broken it and
+// taken the lock since.
+func (l *Lock) Release() error {
+	if data, err := os.ReadFile(l.Path); err != nil || !bytes.Equal(data, l.data) {
+		return nil
+	}
+	if err := os.Remove(l.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
+
+// breakLock removes the lock at path if it still holds data, the content
+// judged stale. It first renames the lock to a name of its own, which only
+// one of several runs breaking it at once can do, then checks it took the
+// lock it judged: a fresh one another run took meanwhile is put back. It
+// reports whether it removed the lock.
+func breakLock(path string, data []byte) (bool, error) {
+	aside := fmt.Sprintf("%s.%d-%d", path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(path, aside); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil // released or broken by another run
+		}
+		return false, fmt.Errorf("failed to break lock: %w", err)
+	}
+	defer os.Remove(aside)
+	if got, err := os.ReadFile(aside); err == nil && !bytes.Equal(got, data) {
+		if err := os.Link(aside, path); err != nil {
+			return false, fmt.Errorf("failed to break lock: %s changed hands meanwhile", path)
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// create takes the lock at path and returns what it wrote there, which the
+// start time to the nanosecond makes unique to this run.
+func create(path string) ([]byte, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	data, err := yaml.Marshal(Info{
+		PID:     os.Getpid(),
+		Host:    host,
+		Started: time.Now().UTC(),
+		Command: strings.Join(os.Args, " "),
+	})
+	if err == nil {
+		_, err = f.Write(data)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return data, nil
+}
+
+// read returns the lock at path and its raw content; the content comes
+// back even when it can't be parsed.
+func read(path string) (*Info, []byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var info Info
+	if err := yaml.Unmarshal(data, &info); err != nil || info.PID == 0 {
+		return nil, data, fmt.Errorf("unreadable lock %s", path)
+	}
+	return &info, data, nil
+}
+
+// stale reports whether the run that took the lock is gone.
+func (i *Info) stale() bool {
+	if host, _ := os.Hostname(); host == i.Host {
+		return !processAlive(i.PID)
+	}
+	return time.Since(i.Started) > StaleAfter
+}
+
+// recent reports whether the file at path was modified in the last few seconds.
+func recent(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && time.Since(fi.ModTime()) < 5*time.Second
+}
+
+// processAlive probes pid with signal 0. Windows has no such probe;
+// finding the process is the best available check there.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
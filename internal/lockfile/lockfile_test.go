@@ -0,0 +1,149 @@
+package lockfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func writeInfo(t *testing.T, path string, info Info) {
+	t.Helper()
+	data, err := yaml.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".project.lock")
+
+	l, err := Acquire(path, false)
+	if err != nil {
+		t.Fatalf("Acquire() error: %v", err)
+	}
+	var held *HeldError
+	if _, err := Acquire(path, false); !errors.As(err, &held) || held.Info.PID != os.Getpid() {
+		t.Errorf("second Acquire() error = %v, want HeldError for pid %d", err, os.Getpid())
+	}
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if l, err = Acquire(path, false); err != nil {
+		t.Fatalf("Acquire() after Release error: %v", err)
+	}
+	l.Release()
+}
+
+func TestStale(t *testing.T) {
+	host, _ := os.Hostname()
+	tests := []struct {
+		name  string
+		info  Info
+		force bool
+		taken bool
+	}{
+		{"live pid", Info{PID: os.Getpid(), Host: host, Started: time.Now()}, false, false},
+		{"live pid, forced", Info{PID: os.Getpid(), Host: host, Started: time.Now()}, true, true},
+		{"dead pid", Info{PID: 1 << 30, Host: host, Started: time.Now()}, false, true},
+		{"other host, recent", Info{PID: 1, Host: "elsewhere", Started: time.Now()}, false, false},
+		{"other host, old", Info{PID: 1, Host: "elsewhere", Started: time.Now().Add(-2 * StaleAfter)}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".project.lock")
+			writeInfo(t, path, tt.info)
+
+			l, err := Acquire(path, tt.force)
+			if got := err == nil; got != tt.taken {
+				t.Fatalf("Acquire() error = %v, want taken=%v", err, tt.taken)
+			}
+			if l != nil {
+				if l.Broke == nil || l.Broke.PID != tt.info.PID {
+					t.Errorf("Broke = %+v, want pid %d", l.Broke, tt.info.PID)
+				}
+				l.Release()
+			}
+		})
+	}
+}
+
+func TestBreakRace(t *testing.T) {
+	defer func(f func()) { judged = f }(judged)
+	host, _ := os.Hostname()
+	for i := 0; i < 20; i++ {
+		path := filepath.Join(t.TempDir(), ".project.lock")
+		writeInfo(t, path, Info{PID: 1 << 30, Host: host, Started: time.Now()})
+
+		// Runs that all judged the same lock stale break it at once:
+		// exactly one takes it
+		const runs = 8
+		var arrived atomic.Int32
+		all := make(chan struct{})
+		judged = func() {
+			if arrived.Add(1) == runs {
+				close(all)
+			}
+			<-all
+		}
+		locks := make(chan *Lock, runs)
+		var wg sync.WaitGroup
+		for range runs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				l, err := Acquire(path, false)
+				var held *HeldError
+				if err != nil && !errors.As(err, &held) {
+					t.Errorf("Acquire() error = %v, want a HeldError", err)
+				}
+				if l != nil {
+					locks <- l
+				}
+			}()
+		}
+		wg.Wait()
+		close(locks)
+		if len(locks) != 1 {
+			t.Fatalf("%d runs took the lock, want 1", len(locks))
+		}
+		l := <-locks
+		if _, _, err := read(path); err != nil {
+			t.Fatalf("lock after the race: %v", err)
+		}
+		if err := l.Release(); err != nil {
+			t.Fatal(err)
+		}
+		if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 0 {
+			t.Fatalf("left behind %v", entries)
+		}
+	}
+}
+
+func TestReleaseBroken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".project.lock")
+	l, err := Acquire(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another run breaks the lock and takes it
+	other, err := Acquire(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Release() removed the lock another run took: %v", err)
+	}
+	other.Release()
+}
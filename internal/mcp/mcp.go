@@ -0,0 +1,110 @@
+// Package mcp serves tools over the Model Context Protocol, so AI
+// assistants can call them with typed arguments: JSON-RPC 2.0 messages, one
+// per line, read from r and answered on w, usually stdin and stdout. The
+// JSON-RPC side is internal/jsonrpc's, with line framing.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/robbyriverside/project/internal/jsonrpc"
+)
+
+// ProtocolVersion is the protocol revision the server speaks.
+const ProtocolVersion = "2025-06-18"
+
+// Tool is one operation the server offers. InputSchema is the JSON Schema
+// of its arguments, which Call gets as sent. Call's result, which must
+// encode as a JSON object, is returned as structured content and as JSON
+// text for clients that only read text; its error is reported as a failed
+// call rather than a protocol error, so the assistant can read it and try
+// again.
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+
+	Call func(ctx context.Context, args json.RawMessage) (any, error) `json:"-"`
+}
+
+// Server answers the requests of one client.
+type Server struct {
+	Name    string
+	Version string
+	Tools   []Tool
+}
+
+// Serve answers requests until r ends or ctx is done. Requests are handled
+// in turn; notifications, which have no id, get no answer.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	rpc := &jsonrpc.Server{Framing: jsonrpc.LineFraming, Methods: map[string]jsonrpc.Handler{
+		"initialize": s.initialize,
+		"ping":       func(context.Context, *jsonrpc.Call) (any, error) { return struct{}{}, nil },
+		"tools/list": s.listTools,
+		"tools/call": s.callTool,
+	}}
+	return rpc.Serve(ctx, r, w)
+}
+
+func (s *Server) initialize(context.Context, *jsonrpc.Call) (any, error) {
+	return map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{"tools": map[string]any{}},
+		"serverInfo":      map[string]string{"name": s.Name, "version": s.Version},
+	}, nil
+}
+
+func (s *Server) listTools(context.Context, *jsonrpc.Call) (any, error) {
+	tools := s.Tools
+	if tools == nil {
+		tools = []Tool{}
+	}
+	return map[string]any{"tools": tools}, nil
+}
+
+// toolResult is the answer to tools/call.
+type toolResult struct {
+	Content           []content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+}
+
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func (s *Server) callTool(ctx context.Context, call *jsonrpc.Call) (any, error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(call.Params, &p); err != nil {
+		return nil, err
+	}
+	var tool *Tool
+	for i := range s.Tools {
+		if s.Tools[i].Name == p.Name {
+			tool = &s.Tools[i]
+		}
+	}
+	if tool == nil {
+		return nil, fmt.Errorf("unknown tool %q", p.Name)
+	}
+	if len(p.Arguments) == 0 || string(p.Arguments) == "null" {
+		p.Arguments = json.RawMessage("{}")
+	}
+
+	out, err := tool.Call(ctx, p.Arguments)
+	if err != nil {
+		return toolResult{Content: []content{{"text", err.Error()}}, IsError: true}, nil
+	}
+	text, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return toolResult{Content: []content{{"text", string(text)}}, StructuredContent: out}, nil
+}
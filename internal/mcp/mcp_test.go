@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	s := &Server{Name: "test", Version: "1.0.0", Tools: []Tool{{
+		Name:        "greet",
+		Description: "Greet someone",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"name":{"type":"string"}}}`),
+		Call: func(ctx context.Context, args json.RawMessage) (any, error) {
+			var in struct{ Name string }
+			if err := json.Unmarshal(args, &in); err != nil {
+				return nil, err
+			}
+			if in.Name == "" {
+				return nil, errors.New("name is required")
+			}
+			return map[string]string{"greeting": "hello " + in.Name}, nil
+		},
+	}}}
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"greet","arguments":{"name":"ada"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"greet"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"wave"}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"resources/list"}`,
+		`not json`,
+	}, "\n")
+	var out strings.Builder
+	if err := s.Serve(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatalf("Serve() error: %v", err)
+	}
+
+	var got []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("bad response %q: %v", line, err)
+		}
+		got = append(got, m)
+	}
+	if len(got) != 7 {
+		t.Fatalf("got %d responses, want 7 (none for the notification):\n%s", len(got), out.String())
+	}
+	check := func(i int, path, want string) {
+		t.Helper()
+		data, _ := json.Marshal(got[i])
+		if !strings.Contains(string(data), want) {
+			t.Errorf("response %d %s = %s, want it to contain %s", i, path, data, want)
+		}
+	}
+	check(0, "serverInfo", `"serverInfo":{"name":"test","version":"1.0.0"}`)
+	check(1, "tools", `"name":"greet"`)
+	check(1, "inputSchema", `"inputSchema":{"properties"`)
+	check(2, "structuredContent", `"structuredContent":{"greeting":"hello ada"}`)
+	check(3, "isError", `"isError":true`)
+	check(3, "content", `name is required`)
+	check(4, "error", `"code":-32602`)
+	check(5, "error", `"code":-32601`)
+	check(6, "error", `"code":-32700`)
+}
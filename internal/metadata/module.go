@@ -1,13 +1,99 @@
 package metadata
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 )
 
-// DeriveModuleName splits the last path segment as project name
+// Module is a Go module path split into the parts templates care about.
+// For gitlab.com/org/group/subgroup/tool/v2:
+//
+//	Path  = "gitlab.com/org/group/subgroup/tool/v2"
+//	Host  = "gitlab.com"
+//	Owner = "org/group/subgroup"
+//	Repo  = "tool"
+//	Major = "v2"
+type Module struct {
+	Path  string
+	Host  string
+	Owner string
+	Repo  string
+	Major string
+}
+
+// RepoURL returns the browsable https URL of the repository.
+func (m *Module) RepoURL() string {
+	return "https://" + strings.Join([]string{m.Host, m.Owner, m.Repo}, "/")
+}
+
+var majorSuffix = regexp.MustCompile(`^v[2-9][0-9]*$`)
+
+// Parse accepts a module path or a git clone URL of any depth, e.g.
+//
+//	github.com/rrs/shoes
+//	https://gitlab.com/org/group/subgroup/tool.git
+//	git@gitlab.com:org/group/tool.git
+//	ssh://git@host.example.com:2222/team/tool
+//
+// and returns the module path with its host, owner path and repo name.
+func Parse(raw string) (*Module, error) {
+	s := strings.TrimSpace(raw)
+
+	// Strip scheme and credentials: https://, ssh://git@host:port/, git@host:
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+		if at := strings.Index(s, "@"); at >= 0 && at < strings.Index(s+"/", "/") {
+			s = s[at+1:]
+		}
+		if host, rest, ok := strings.Cut(s, "/"); ok {
+			host, _, _ = strings.Cut(host, ":") // drop port
+			s = host + "/" + rest
+		}
+	} else if at := strings.Index(s, "@"); at >= 0 && strings.Contains(s[at:], ":") {
+		s = strings.Replace(s[at+1:], ":", "/", 1)
+	}
+
+	s = strings.Trim(s, "/")
+	s = strings.TrimSuffix(s, ".git")
+	// GitLab web URLs put /-/tree/... after the project path
+	if i := strings.Index(s, "/-/"); i >= 0 {
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, "/")
+	for _, p := range parts {
+		if p == "" || p == "." || p == ".." {
+			return nil, fmt.Errorf("invalid module path %q", raw)
+		}
+	}
+
+	m := &Module{Path: s}
+	if len(parts) > 1 && majorSuffix.MatchString(parts[len(parts)-1]) {
+		m.Major = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("module path %q needs a host, owner and repository", raw)
+	}
+	if !strings.Contains(parts[0], ".") {
+		return nil, fmt.Errorf("module path %q must start with a host name", raw)
+	}
+
+	m.Host = parts[0]
+	m.Owner = strings.Join(parts[1:len(parts)-1], "/")
+	m.Repo = parts[len(parts)-1]
+	return m, nil
+}
+
+// DeriveModuleName returns the repository name and module path,
+// falling back to the last path segment when moduleURL can't be parsed.
 func DeriveModuleName(moduleURL string) (string, string) {
 	// e.g., 'github.com/rrs/shoes' -> name='shoes', package='github.com/rrs/shoes'
-	parts := strings.Split(moduleURL, "/")
+	if m, err := Parse(moduleURL); err == nil {
+		return m.Repo, m.Path
+	}
+	parts := strings.Split(strings.Trim(moduleURL, "/"), "/")
 	name := parts[len(parts)-1]
 	return name, moduleURL
 }
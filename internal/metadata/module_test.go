@@ -0,0 +1,36 @@
+package metadata
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		in                      string
+		path, host, owner, repo string
+		major                   string
+	}{
+		{"github.com/rrs/shoes", "github.com/rrs/shoes", "github.com", "rrs", "shoes", ""},
+		{"https://github.com/rrs/shoes.git", "github.com/rrs/shoes", "github.com", "rrs", "shoes", ""},
+		{"git@github.com:rrs/shoes.git", "github.com/rrs/shoes", "github.com", "rrs", "shoes", ""},
+		{"https://gitlab.com/org/group/subgroup/tool", "gitlab.com/org/group/subgroup/tool", "gitlab.com", "org/group/subgroup", "tool", ""},
+		{"gitlab.com/org/group/tool/v2", "gitlab.com/org/group/tool/v2", "gitlab.com", "org/group", "tool", "v2"},
+		{"ssh://git@git.example.com:2222/team/tool.git", "git.example.com/team/tool", "git.example.com", "team", "tool", ""},
+		{"https://gitlab.com/org/tool/-/tree/main", "gitlab.com/org/tool", "gitlab.com", "org", "tool", ""},
+	}
+
+	for _, tc := range testCases {
+		m, err := Parse(tc.in)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tc.in, err)
+			continue
+		}
+		if m.Path != tc.path || m.Host != tc.host || m.Owner != tc.owner || m.Repo != tc.repo || m.Major != tc.major {
+			t.Errorf("Parse(%q) = %+v", tc.in, *m)
+		}
+	}
+
+	for _, bad := range []string{"shoes", "github.com/shoes", "rrs/shoes/x", "github.com//shoes", "github.com/acme/..", "github.com/acme/.", "github.com/../shoes"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Package naming turns a project name derived from a module path into
+// names that are safe for Go packages and binaries.
+package naming
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// reserved are names that are legal identifiers but break or confuse a
+// generated project: special directories, the go tool's own words, and the
+// scaffold's sub-packages (the root package would shadow them in main.go).
+var reserved = map[string]string{
+	"main":     "is reserved for executables",
+	"test":     "collides with `go test` conventions",
+	"testing":  "shadows the standard library testing package",
+	"internal": "has special import rules in Go",
+	"vendor":   "is treated as a vendor directory",
+	"testdata": "is ignored by the go tool",
+	"cmd":      "collides with the generated cmd/ directory",
+	"config":   "collides with the generated config package",
+	"logs":     "collides with the generated logs package",
+	"pathutil": "collides with the generated pathutil package",
+}
+
+// PackageName returns a valid, idiomatic Go package name for name:
+// lowercase letters and digits only, not starting with a digit, and not a keyword.
+func PackageName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+	pkg := b.String()
+	switch {
+	case pkg == "":
+		return "app"
+	case unicode.IsDigit(rune(pkg[0])):
+		pkg = "x" + pkg
+	}
+	if token.IsKeyword(pkg) {
+		pkg += "pkg"
+	}
+	return pkg
+}
+
+// BinaryName returns a command name for name: lowercase, keeping dashes,
+// dots and underscores but replacing anything else (spaces, slashes) with dashes.
+func BinaryName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+		case r == '-' || r == '.' || r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	bin := strings.Trim(b.String(), "-._")
+	if bin == "" {
+		return "app"
+	}
+	return bin
+}
+
+// EnvPrefix returns the environment variable prefix for name: uppercase
+// letters, digits and underscores, e.g. "GO_SHOES" for "go-shoes".
+func EnvPrefix(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(strings.TrimSpace(name)) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	prefix := strings.Trim(b.String(), "_")
+	switch {
+	case prefix == "":
+		return "APP"
+	case unicode.IsDigit(rune(prefix[0])):
+		prefix = "_" + prefix
+	}
+	return prefix
+}
+
+// SnakeCase returns the snake_case form of a Go identifier, for config
+// keys: "MaxRetries" becomes "max_retries" and "HTTPPort" "http_port".
+func SnakeCase(ident string) string {
+	runes := []rune(ident)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// CamelCase returns the exported Go identifier for a command name:
+// "sync-users" becomes "SyncUsers" and "import_v2" "ImportV2".
+func CamelCase(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		switch {
+		case r == '-' || r == '_' || r == '.':
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Warnings explains anything problematic about name and how it was adjusted.
+func Warnings(name string) []string {
+	var out []string
+	pkg := PackageName(name)
+	if pkg != name {
+		out = append(out, fmt.Sprintf("project name %q is not a valid Go package name; using package %q", name, pkg))
+	}
+	if token.IsKeyword(strings.ToLower(name)) {
+		out = append(out, fmt.Sprintf("project name %q is a Go keyword", name))
+	}
+	if why, ok := reserved[pkg]; ok {
+		out = append(out, fmt.Sprintf("project name %q %s; consider renaming the repository", pkg, why))
+	}
+	if bin := BinaryName(name); bin != name {
+		out = append(out, fmt.Sprintf("project name %q is not a clean command name; using binary %q", name, bin))
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package naming
+
+import "testing"
+
+func TestNames(t *testing.T) {
+	testCases := []struct {
+		in, pkg, bin, env string
+		warn              bool
+	}{
+		{"shoes", "shoes", "shoes", "SHOES", false},
+		{"go-shoes", "goshoes", "go-shoes", "GO_SHOES", true},
+		{"my.tool", "mytool", "my.tool", "MY_TOOL", true},
+		{"Big_App", "bigapp", "big_app", "BIG_APP", true},
+		{"go", "gopkg", "go", "GO", true},
+		{"3d", "x3d", "3d", "_3D", true},
+		{"logs", "logs", "logs", "LOGS", true},
+		{"my tool", "mytool", "my-tool", "MY_TOOL", true},
+	}
+
+	for _, tc := range testCases {
+		if got := PackageName(tc.in); got != tc.pkg {
+			t.Errorf("PackageName(%q) = %q, want %q", tc.in, got, tc.pkg)
+		}
+		if got := BinaryName(tc.in); got != tc.bin {
+			t.Errorf("BinaryName(%q) = %q, want %q", tc.in, got, tc.bin)
+		}
+		if got := EnvPrefix(tc.in); got != tc.env {
+			t.Errorf("EnvPrefix(%q) = %q, want %q", tc.in, got, tc.env)
+		}
+		if got := len(Warnings(tc.in)) > 0; got != tc.warn {
+			t.Errorf("Warnings(%q) = %v, want warnings=%v", tc.in, Warnings(tc.in), tc.warn)
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	testCases := map[string]string{
+		"HomeDir":    "home_dir",
+		"MaxRetries": "max_retries",
+		"HTTPPort":   "http_port",
+		"APIKey":     "api_key",
+		"Retries2":   "retries2",
+		"Timeout":    "timeout",
+		"URL":        "url",
+	}
+	for in, want := range testCases {
+		if got := SnakeCase(in); got != want {
+			t.Errorf("SnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	testCases := map[string]string{
+		"sync":       "Sync",
+		"sync-users": "SyncUsers",
+		"import_v2":  "ImportV2",
+		"db.migrate": "DbMigrate",
+	}
+	for in, want := range testCases {
+		if got := CamelCase(in); got != want {
+			t.Errorf("CamelCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
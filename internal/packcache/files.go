@@ -0,0 +1,98 @@
+package packcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+	"github.com/robbyriverside/project/internal/gitops"
+	"github.com/robbyriverside/project/internal/retry"
+)
+
+// maxFileSize bounds what Get will download; it serves small config files.
+const maxFileSize = 1 << 20
+
+// Get returns the content at url, keeping a copy under files/ so the last
+// download is used when the network is not available. offline reports that.
+func (c *Cache) Get(url string) (data []byte, offline bool, err error) {
+	cached := filepath.Join(c.Dir, "files", key(url))
+	err = c.Retry.Do(context.Background(), func() (err error) {
+		data, err = c.get(url)
+		return err
+	})
+	if err != nil {
+		if old, rerr := os.ReadFile(cached); rerr == nil {
+			return old, true, nil
+		}
+		return nil, false, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if err := fileutils.WriteFile(cached, data, fileutils.DefaultMode); err != nil {
+		return nil, false, fmt.Errorf("failed to cache %s: %w", url, err)
+	}
+	return data, false, nil
+}
+
+func (c *Cache) get(url string) ([]byte, error) {
+	resp, err := c.Client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxFileSize {
+		return nil, retry.Permanent(fmt.Errorf("larger than %d bytes", maxFileSize))
+	}
+	return data, nil
+}
+
+// Repo returns a shallow clone of the git repository at url under repos/,
+// cloning it on first use and fast-forwarding it after. A bare host/path
+// such as github.com/acme/golden-paths is cloned over https. When git
+// can't reach the remote, an existing clone is returned with offline set;
+// any other failure to update it, such as a rewritten history, is an error.
+func (c *Cache) Repo(url string) (dir string, offline bool, err error) {
+	if !IsURL(url) && !strings.Contains(url, "@") && !strings.HasPrefix(url, "file://") {
+		url = "https://" + url
+	}
+	dir = filepath.Join(c.Dir, "repos", key(url))
+	if _, err := os.Stat(dir); err == nil {
+		if err := gitops.Pull(dir); err != nil {
+			if gitops.Unreachable(err) {
+				return dir, true, nil
+			}
+			return "", false, fmt.Errorf("failed to update %s: %w (project cache clean clones it afresh)", url, err)
+		}
+		return dir, false, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", false, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", false, fmt.Errorf("failed to create cache: %w", err)
+	}
+	if err := gitops.Clone(url, dir, "", ""); err != nil {
+		os.RemoveAll(dir)
+		return "", false, fmt.Errorf("failed to clone %s: %w", url, err)
+	}
+	return dir, false, nil
+}
+
+// key names the cache entry of a URL.
+func key(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,302 @@
+// Package packcache downloads template packs (.tar.gz archives over http/https)
+// and keeps them by the sha256 of the archive, so a pack is fetched once,
+// shared by every project, and still usable when the network is not.
+//
+// A URL may pin the expected content with a fragment, e.g.
+// https://example.com/pack.tar.gz#sha256=<hex>; pinned packs already in
+// the cache are used without any network access.
+package packcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robbyriverside/project/internal/archive"
+	"github.com/robbyriverside/project/internal/fileutils"
+	"github.com/robbyriverside/project/internal/packsign"
+	"github.com/robbyriverside/project/internal/retry"
+	"github.com/robbyriverside/project/pathutil"
+)
+
+// Cache is a directory of downloaded packs:
+//
+//	blobs/<sha256>.tar.gz  the archives, as downloaded
+//	blobs/<sha256>.tar.gz.minisig, .sig  their signatures, when published
+//	packs/<sha256>/        their extracted contents
+//	index.json             the last sha256 seen for each URL
+//	files/<key>            other downloads (see Get), by hashed URL
+//	repos/<key>/           shallow git clones (see Repo), by hashed URL
+type Cache struct {
+	Dir    string
+	Client *http.Client
+
+	// Retry repeats downloads that fail on the network or with a server
+	// error before falling back to a cached copy; the zero Policy tries once.
+	Retry retry.Policy
+}
+
+// Entry is a pack resolved through the cache.
+type Entry struct {
+	URL     string
+	SHA256  string
+	Archive string // the cached .tar.gz, with any signatures beside it
+	Dir     string // extracted pack root
+	Offline bool   // the download failed and a cached copy was used
+}
+
+type indexEntry struct {
+	SHA256  string    `json:"sha256"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// DefaultDir is the cache location: $PROJECT_CACHE, else ~/.project/cache.
+func DefaultDir() string {
+	if dir := os.Getenv("PROJECT_CACHE"); dir != "" {
+		return pathutil.MustExpand(dir)
+	}
+	return pathutil.MustExpand("~/.project/cache")
+}
+
+// New returns a cache in dir using a client with a sensible timeout.
+func New(dir string) *Cache {
+	return &Cache{Dir: dir, Client: &http.Client{Timeout: 60 * time.Second}}
+}
+
+// IsURL reports whether a --templates value names a remote pack.
+func IsURL(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// Fetch returns the pack at rawURL, downloading it unless a pinned copy is
+// cached. When the download fails, the last cached copy for the URL is used.
+func (c *Cache) Fetch(rawURL string) (*Entry, error) {
+	url, want := splitPin(rawURL)
+	if want != "" && c.verify(want) == nil {
+		return c.entry(url, want, false)
+	}
+
+	var sum string
+	err := c.Retry.Do(context.Background(), func() (err error) {
+		sum, err = c.download(url)
+		return err
+	})
+	if err != nil {
+		fallback := want
+		if fallback == "" {
+			fallback = c.lookup(url)
+		}
+		if fallback == "" || c.verify(fallback) != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", url, err)
+		}
+		return c.entry(url, fallback, true)
+	}
+	if want != "" && sum != want {
+		return nil, fmt.Errorf("pack %s has sha256 %s, want %s", url, sum, want)
+	}
+	if err := c.record(url, sum); err != nil {
+		return nil, err
+	}
+	c.downloadSignatures(url, sum)
+	return c.entry(url, sum, false)
+}
+
+// Clean removes the whole cache.
+func (c *Cache) Clean() error {
+	if err := os.RemoveAll(c.Dir); err != nil {
+		return fmt.Errorf("failed to clean cache: %w", err)
+	}
+	return nil
+}
+
+// splitPin separates a "#sha256=<hex>" fragment from the URL.
+func splitPin(rawURL string) (string, string) {
+	url, frag, _ := strings.Cut(rawURL, "#")
+	sum, ok := strings.CutPrefix(frag, "sha256=")
+	if !ok {
+		return url, ""
+	}
+	return url, strings.ToLower(sum)
+}
+
+func (c *Cache) blobPath(sum string) string {
+	return filepath.Join(c.Dir, "blobs", sum+".tar.gz")
+}
+
+func (c *Cache) packPath(sum string) string {
+	return filepath.Join(c.Dir, "packs", sum)
+}
+
+// download streams url into the blob store and returns its sha256. Errors
+// that repeating the download won't fix are marked retry.Permanent.
+func (c *Cache) download(url string) (string, error) {
+	resp, err := c.Client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Join(c.Dir, "blobs"), 0755); err != nil {
+		return "", retry.Permanent(fmt.Errorf("failed to create cache: %w", err))
+	}
+	tmp, err := os.CreateTemp(filepath.Join(c.Dir, "blobs"), ".download-*")
+	if err != nil {
+		return "", retry.Permanent(fmt.Errorf("failed to create cache file: %w", err))
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), resp.Body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", retry.Permanent(err)
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if err := os.Rename(tmp.Name(), c.blobPath(sum)); err != nil {
+		return "", retry.Permanent(fmt.Errorf("failed to store pack: %w", err))
+	}
+	return sum, nil
+}
+
+// checkStatus turns a response other than 200 into an error, permanent
+// unless the server may answer differently next time.
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	err := fmt.Errorf("unexpected status %s", resp.Status)
+	if !retry.TransientStatus(resp.StatusCode) {
+		return retry.Permanent(err)
+	}
+	return err
+}
+
+// downloadSignatures stores whichever signatures are published beside the
+// pack, as <url>.minisig or <url>.sig. Missing ones are not an error here;
+// the trust policy decides whether the pack may be used unsigned.
+func (c *Cache) downloadSignatures(url, sum string) {
+	for suffix := range packsign.Suffixes {
+		resp, err := c.Client.Get(url + suffix)
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			continue
+		}
+		_ = fileutils.WriteFile(c.blobPath(sum)+suffix, data, fileutils.DefaultMode)
+	}
+}
+
+// verify checks that the cached blob still hashes to sum.
+func (c *Cache) verify(sum string) error {
+	f, err := os.Open(c.blobPath(sum))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != sum {
+		return fmt.Errorf("cached pack %s is corrupt (sha256 %s)", sum, got)
+	}
+	return nil
+}
+
+// entry extracts the blob if needed and describes it.
+func (c *Cache) entry(url, sum string, offline bool) (*Entry, error) {
+	dir := c.packPath(sum)
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		if err := c.extract(sum, dir); err != nil {
+			return nil, err
+		}
+	}
+	root, err := packRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{URL: url, SHA256: sum, Archive: c.blobPath(sum), Dir: root, Offline: offline}, nil
+}
+
+// extract unpacks the blob into a temp directory, then moves it into place.
+func (c *Cache) extract(sum, dir string) error {
+	f, err := os.Open(c.blobPath(sum))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".extract-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := archive.ExtractTarGz(f, tmp); err != nil {
+		return fmt.Errorf("failed to read pack %s: %w", sum, err)
+	}
+	return os.Rename(tmp, dir)
+}
+
+// packRoot descends into a single top-level directory, as in GitHub
+// release tarballs, so the pack's files sit at the root.
+func packRoot(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(dir, entries[0].Name()), nil
+	}
+	return dir, nil
+}
+
+func (c *Cache) indexPath() string {
+	return filepath.Join(c.Dir, "index.json")
+}
+
+func (c *Cache) readIndex() map[string]indexEntry {
+	index := make(map[string]indexEntry)
+	data, err := os.ReadFile(c.indexPath())
+	if err == nil {
+		_ = json.Unmarshal(data, &index)
+	}
+	return index
+}
+
+// lookup returns the last sha256 downloaded for url, or "".
+func (c *Cache) lookup(url string) string {
+	return c.readIndex()[url].SHA256
+}
+
+// record remembers sum as the latest content of url.
+func (c *Cache) record(url, sum string) error {
+	index := c.readIndex()
+	index[url] = indexEntry{SHA256: sum, Fetched: time.Now().UTC()}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutils.WriteFile(c.indexPath(), data, fileutils.DefaultMode)
+}
@@ -0,0 +1,235 @@
+package packcache
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/gitops"
+	"github.com/robbyriverside/project/internal/retry"
+)
+
+// tarball builds a .tar.gz with files under a single top-level directory.
+func tarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		hdr := &tar.Header{Name: "pack-1.0/" + name, Mode: 0644, Size: int64(len(body)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFetch(t *testing.T) {
+	archive := tarball(t, map[string]string{"main.tmpl": "package main\n"})
+	h := sha256.Sum256(archive)
+	sum := hex.EncodeToString(h[:])
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	}))
+	url := srv.URL + "/pack.tar.gz"
+	c := New(t.TempDir())
+
+	entry, err := c.Fetch(url)
+	if err != nil {
+		t.Fatalf("Fetch() error: %v", err)
+	}
+	if entry.SHA256 != sum || entry.Offline {
+		t.Errorf("Fetch() = %+v, want sha256 %s online", entry, sum)
+	}
+	if data, err := os.ReadFile(filepath.Join(entry.Dir, "main.tmpl")); err != nil || string(data) != "package main\n" {
+		t.Errorf("main.tmpl = %q, %v", data, err)
+	}
+
+	if _, err := c.Fetch(url + "#sha256=" + sum); err != nil {
+		t.Errorf("Fetch() with matching pin: %v", err)
+	}
+	if _, err := c.Fetch(url + "#sha256=" + sum[:63] + "x"); err == nil {
+		t.Error("Fetch() with wrong pin succeeded")
+	}
+
+	// With the server gone, the cached copy is used
+	srv.Close()
+	entry, err = c.Fetch(url)
+	if err != nil {
+		t.Fatalf("offline Fetch() error: %v", err)
+	}
+	if !entry.Offline || entry.SHA256 != sum {
+		t.Errorf("offline Fetch() = %+v, want cached %s", entry, sum)
+	}
+
+	// A corrupted blob is not trusted
+	if err := os.WriteFile(c.blobPath(sum), []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Fetch(url); err == nil {
+		t.Error("offline Fetch() with corrupt blob succeeded")
+	}
+
+	if err := c.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(c.Dir); !os.IsNotExist(err) {
+		t.Errorf("Clean() left %s", c.Dir)
+	}
+}
+
+func TestUnsafePath(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "../evil", Mode: 0644, Typeflag: tar.TypeReg})
+	tw.Close()
+	gz.Close()
+	archive := buf.Bytes()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	}))
+	defer srv.Close()
+
+	if _, err := New(t.TempDir()).Fetch(srv.URL); err == nil {
+		t.Error("Fetch() extracted a path outside the pack")
+	}
+}
+
+func TestGet(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("service:\n  kind: cli\n"))
+	}))
+	url := srv.URL + "/presets.yaml"
+	c := New(t.TempDir())
+
+	data, offline, err := c.Get(url)
+	if err != nil || offline || string(data) != "service:\n  kind: cli\n" {
+		t.Fatalf("Get() = %q, %v, %v", data, offline, err)
+	}
+
+	srv.Close()
+	data, offline, err = c.Get(url)
+	if err != nil || !offline || string(data) != "service:\n  kind: cli\n" {
+		t.Errorf("Get() offline = %q, %v, %v; want cached copy", data, offline, err)
+	}
+	if _, _, err := c.Get(srv.URL + "/other.yaml"); err == nil {
+		t.Error("Get() of an uncached URL offline succeeded")
+	}
+}
+
+func TestGetRetry(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case r.URL.Path == "/missing.yaml":
+			http.NotFound(w, r)
+		case requests == 1:
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("ok\n"))
+		}
+	}))
+	defer srv.Close()
+	c := New(t.TempDir())
+	c.Retry = retry.Policy{Attempts: 3, Delay: time.Millisecond}
+
+	if data, _, err := c.Get(srv.URL + "/presets.yaml"); err != nil || string(data) != "ok\n" || requests != 2 {
+		t.Errorf("Get() = %q, %v after %d requests; want ok after a retry", data, err, requests)
+	}
+	requests = 0
+	if _, _, err := c.Get(srv.URL + "/missing.yaml"); err == nil || requests != 1 {
+		t.Errorf("Get() of a missing file = %v after %d requests; want one failed request", err, requests)
+	}
+}
+
+// commit writes files into the repo at dir and commits them, amending the
+// last commit with amend.
+func commit(t *testing.T, dir string, files map[string]string, amend bool) {
+	t.Helper()
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	args := []string{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "presets"}
+	if amend {
+		args = append(args, "--amend")
+	}
+	for _, argv := range [][]string{{"add", "-A"}, args} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, argv...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", argv[0], err, out)
+		}
+	}
+}
+
+func TestRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	origin := t.TempDir()
+	if err := gitops.Init(origin, "main"); err != nil {
+		t.Fatal(err)
+	}
+	commit(t, origin, map[string]string{"presets.yaml": "v1\n"}, false)
+	url := "file://" + origin
+	c := New(t.TempDir())
+
+	read := func() string {
+		t.Helper()
+		dir, offline, err := c.Repo(url)
+		if err != nil || offline {
+			t.Fatalf("Repo() = %v, %v; want a clone online", offline, err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "presets.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if got := read(); got != "v1\n" {
+		t.Errorf("first Repo() has %q, want v1", got)
+	}
+	commit(t, origin, map[string]string{"presets.yaml": "v2\n"}, false)
+	if got := read(); got != "v2\n" {
+		t.Errorf("Repo() after a push has %q, want v2", got)
+	}
+
+	// A rewritten history is an error, not a reason to go offline
+	commit(t, origin, map[string]string{"presets.yaml": "v3\n"}, true)
+	if dir, offline, err := c.Repo(url); err == nil || offline {
+		t.Errorf("Repo() after a force push = %s, %v, %v; want an error", dir, offline, err)
+	}
+
+	// An unreachable remote falls back to the clone
+	defer func(r execx.Runner) { gitops.Runner = r }(gitops.Runner)
+	gitops.Runner = &execx.Recorder{Respond: func(execx.Call) (execx.Result, error) {
+		return execx.Result{Stderr: []byte("fatal: unable to access 'https://example.com/': Could not resolve host: example.com\n")}, errors.New("exit status 128")
+	}}
+	if dir, offline, err := c.Repo(url); err != nil || !offline || dir == "" {
+		t.Errorf("Repo() unreachable = %s, %v, %v; want the clone offline", dir, offline, err)
+	}
+}
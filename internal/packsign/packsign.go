@@ -0,0 +1,173 @@
+// Package packsign checks template pack signatures against a trust store:
+// a directory of public keys. Signatures sit next to the pack archive,
+// <archive>.minisig for minisign and <archive>.sig for cosign, and are
+// verified with the minisign or cosign binary.
+package packsign
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Tool names a signing tool.
+type Tool string
+
+const (
+	Minisign Tool = "minisign"
+	Cosign   Tool = "cosign"
+)
+
+// Suffixes maps each signature file suffix to the tool that checks it.
+var Suffixes = map[string]Tool{
+	".minisig": Minisign,
+	".sig":     Cosign,
+}
+
+// Key is a trusted public key. Minisign keys are *.pub files; cosign keys
+// are PEM files (*.pem, or a *.pub starting with a PEM header).
+type Key struct {
+	Path string
+	Tool Tool
+}
+
+// Result reports which signature and key verified a pack.
+type Result struct {
+	Signature string
+	Key       Key
+}
+
+// TrustStore lists the keys in dir. A missing directory is an empty store.
+func TrustStore(dir string) ([]Key, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust store: %w", err)
+	}
+
+	var keys []Key
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		switch filepath.Ext(e.Name()) {
+		case ".pem":
+			keys = append(keys, Key{Path: path, Tool: Cosign})
+		case ".pub":
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read key: %w", err)
+			}
+			tool := Minisign
+			if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN")) {
+				tool = Cosign
+			}
+			keys = append(keys, Key{Path: path, Tool: tool})
+		}
+	}
+	return keys, nil
+}
+
+// Signatures returns the signature files present next to archive.
+func Signatures(archive string) map[string]Tool {
+	found := make(map[string]Tool)
+	for suffix, tool := range Suffixes {
+		if _, err := os.Stat(archive + suffix); err == nil {
+			found[archive+suffix] = tool
+		}
+	}
+	return found
+}
+
+// Verify checks that archive carries a signature made by one of the keys
+// in trustDir, trying every signature against every key of its tool.
+func Verify(archive, trustDir string) (*Result, error) {
+	sigs := Signatures(archive)
+	if len(sigs) == 0 {
+		return nil, fmt.Errorf("%s is not signed (no %s)", filepath.Base(archive), suffixList())
+	}
+	keys, err := TrustStore(trustDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no trusted keys in %s", trustDir)
+	}
+
+	var failures []string
+	for _, sig := range sortedKeys(sigs) {
+		tool := sigs[sig]
+		for _, key := range keys {
+			if key.Tool != tool {
+				continue
+			}
+			if err := run(tool, archive, sig, key.Path); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", filepath.Base(key.Path), err))
+				continue
+			}
+			return &Result{Signature: sig, Key: key}, nil
+		}
+	}
+	if len(failures) == 0 {
+		return nil, fmt.Errorf("no trusted %s keys in %s", toolList(sigs), trustDir)
+	}
+	return nil, fmt.Errorf("no trusted key verifies %s:\n  %s", filepath.Base(archive), strings.Join(failures, "\n  "))
+}
+
+// run verifies one signature with one key using the tool's binary.
+func run(tool Tool, archive, sig, key string) error {
+	var args []string
+	switch tool {
+	case Minisign:
+		args = []string{"-V", "-q", "-p", key, "-m", archive, "-x", sig}
+	case Cosign:
+		args = []string{"verify-blob", "--key", key, "--signature", sig, archive}
+	}
+	if _, err := exec.LookPath(string(tool)); err != nil {
+		return fmt.Errorf("%s is not installed", tool)
+	}
+	cmd := exec.Command(string(tool), args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", tool, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func suffixList() string {
+	var out []string
+	for suffix := range Suffixes {
+		out = append(out, "*"+suffix)
+	}
+	sort.Strings(out)
+	return strings.Join(out, " or ")
+}
+
+func toolList(sigs map[string]Tool) string {
+	seen := make(map[Tool]bool)
+	var out []string
+	for _, tool := range sigs {
+		if !seen[tool] {
+			seen[tool] = true
+			out = append(out, string(tool))
+		}
+	}
+	sort.Strings(out)
+	return strings.Join(out, "/")
+}
+
+func sortedKeys(m map[string]Tool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
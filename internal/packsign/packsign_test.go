@@ -0,0 +1,81 @@
+package packsign
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTrustStore(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "alice.pub"), "untrusted comment: minisign public key\nRWQ...\n")
+	write(t, filepath.Join(dir, "cosign.pub"), "-----BEGIN PUBLIC KEY-----\n...\n")
+	write(t, filepath.Join(dir, "bob.pem"), "-----BEGIN PUBLIC KEY-----\n...\n")
+	write(t, filepath.Join(dir, "README"), "not a key")
+
+	keys, err := TrustStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]Tool)
+	for _, k := range keys {
+		got[filepath.Base(k.Path)] = k.Tool
+	}
+	want := map[string]Tool{"alice.pub": Minisign, "cosign.pub": Cosign, "bob.pem": Cosign}
+	if len(got) != len(want) {
+		t.Fatalf("TrustStore() = %v, want %v", got, want)
+	}
+	for name, tool := range want {
+		if got[name] != tool {
+			t.Errorf("TrustStore()[%s] = %q, want %q", name, got[name], tool)
+		}
+	}
+
+	if keys, err := TrustStore(filepath.Join(dir, "missing")); err != nil || len(keys) != 0 {
+		t.Errorf("TrustStore(missing) = %v, %v; want empty", keys, err)
+	}
+}
+
+// TestVerify runs against a stand-in minisign that accepts only the key
+// named good.pub, so no real signing tool is needed.
+func TestVerify(t *testing.T) {
+	bin := t.TempDir()
+	write(t, filepath.Join(bin, "minisign"), "#!/bin/sh\ncase \"$*\" in *good.pub*) exit 0;; esac\necho 'Signature verification failed' >&2\nexit 1\n")
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "pack.tar.gz")
+	write(t, archive, "pack")
+
+	trust := t.TempDir()
+	if _, err := Verify(archive, trust); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Errorf("Verify() of unsigned pack = %v, want not signed", err)
+	}
+
+	write(t, archive+".minisig", "sig")
+	if _, err := Verify(archive, trust); err == nil || !strings.Contains(err.Error(), "no trusted keys") {
+		t.Errorf("Verify() with empty trust store = %v, want no trusted keys", err)
+	}
+
+	write(t, filepath.Join(trust, "bad.pub"), "untrusted comment: key\n")
+	if _, err := Verify(archive, trust); err == nil || !strings.Contains(err.Error(), "bad.pub") {
+		t.Errorf("Verify() with wrong key = %v, want failure naming bad.pub", err)
+	}
+
+	write(t, filepath.Join(trust, "good.pub"), "untrusted comment: key\n")
+	res, err := Verify(archive, trust)
+	if err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+	if filepath.Base(res.Key.Path) != "good.pub" || res.Key.Tool != Minisign {
+		t.Errorf("Verify() = %+v, want good.pub via minisign", res)
+	}
+}
@@ -0,0 +1,117 @@
+// Package regions finds managed regions in generated files: blocks between
+// a "project:begin <name>" line and a "project:end <name>" line, written in
+// whatever comment syntax the file uses. Update rewrites only their bodies,
+// keeping the user's code around them.
+package regions
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	beginMark = "project:begin "
+	endMark   = "project:end "
+)
+
+// Region is one managed region; Start and End delimit its body, the lines
+// between the marker lines.
+type Region struct {
+	Name       string
+	Start, End int
+}
+
+// Find returns the regions of data in order. Regions may not nest, and
+// each name may be used once.
+func Find(data []byte) ([]Region, error) {
+	var (
+		out  []Region
+		open *Region
+		seen = map[string]bool{}
+	)
+	for off, lineNo := 0, 1; off < len(data); lineNo++ {
+		end := bytes.IndexByte(data[off:], '\n')
+		next := len(data)
+		if end >= 0 {
+			next = off + end + 1
+		}
+		line := data[off:next]
+		if name, ok := marker(line, beginMark); ok {
+			if open != nil {
+				return nil, fmt.Errorf("line %d: region %q begins inside region %q", lineNo, name, open.Name)
+			}
+			if seen[name] {
+				return nil, fmt.Errorf("line %d: region %q appears twice", lineNo, name)
+			}
+			seen[name] = true
+			open = &Region{Name: name, Start: next}
+		} else if name, ok := marker(line, endMark); ok {
+			if open == nil || open.Name != name {
+				return nil, fmt.Errorf("line %d: end of region %q that is not open", lineNo, name)
+			}
+			open.End = off
+			out = append(out, *open)
+			open = nil
+		}
+		off = next
+	}
+	if open != nil {
+		return nil, fmt.Errorf("region %q has no end", open.Name)
+	}
+	return out, nil
+}
+
+// marker returns the region name after mark on line, if it has one.
+func marker(line []byte, mark string) (string, bool) {
+	i := bytes.Index(line, []byte(mark))
+	if i < 0 {
+		return "", false
+	}
+	fields := bytes.Fields(line[i+len(mark):])
+	if len(fields) == 0 {
+		return "", false
+	}
+	return string(fields[0]), true
+}
+
+// Merge returns current with the body of each region replaced by the body
+// of the same region in rendered. Everything outside the regions is kept.
+// Every region of rendered must be in current; regions only in current
+// are left alone.
+func Merge(current, rendered []byte) ([]byte, error) {
+	want, err := Find(rendered)
+	if err != nil {
+		return nil, fmt.Errorf("templates: %w", err)
+	}
+	have, err := Find(current)
+	if err != nil {
+		return nil, err
+	}
+	bodies := make(map[string][]byte, len(want))
+	for _, r := range want {
+		bodies[r.Name] = rendered[r.Start:r.End]
+	}
+	found := make(map[string]bool, len(have))
+	for _, r := range have {
+		found[r.Name] = true
+	}
+	for _, r := range want {
+		if !found[r.Name] {
+			return nil, fmt.Errorf("region %q is missing", r.Name)
+		}
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for _, r := range have {
+		body, ok := bodies[r.Name]
+		if !ok {
+			continue
+		}
+		out.Write(current[last:r.Start])
+		out.Write(body)
+		last = r.End
+	}
+	out.Write(current[last:])
+	return out.Bytes(), nil
+}
@@ -0,0 +1,60 @@
+package regions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []string
+		wantErr string
+	}{
+		{"none", "a\nb\n", nil, ""},
+		{"two", "// project:begin a\nx\n// project:end a\n# project:begin b\n# project:end b\n", []string{"a:x\n", "b:"}, ""},
+		{"nested", "// project:begin a\n// project:begin b\n", nil, `begins inside region "a"`},
+		{"unopened", "// project:end a\n", nil, `not open`},
+		{"unclosed", "// project:begin a\n", nil, `has no end`},
+		{"twice", "// project:begin a\n// project:end a\n// project:begin a\n// project:end a\n", nil, `appears twice`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Find([]byte(tt.in))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Find() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Find() error: %v", err)
+			}
+			var names []string
+			for _, r := range got {
+				names = append(names, r.Name+":"+tt.in[r.Start:r.End])
+			}
+			if strings.Join(names, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Find() = %q, want %q", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	current := "package main\n\n// mine\n  // project:begin commands\n  old()\n  // project:end commands\n  mine()\n"
+	rendered := "package main\n\n  // project:begin commands\n  old()\n  added()\n  // project:end commands\n"
+	got, err := Merge([]byte(current), []byte(rendered))
+	if err != nil {
+		t.Fatalf("Merge() error: %v", err)
+	}
+	want := "package main\n\n// mine\n  // project:begin commands\n  old()\n  added()\n  // project:end commands\n  mine()\n"
+	if string(got) != want {
+		t.Errorf("Merge() = %q, want %q", got, want)
+	}
+
+	if _, err := Merge([]byte("package main\n"), []byte(rendered)); err == nil || !strings.Contains(err.Error(), `region "commands" is missing`) {
+		t.Errorf("Merge() without the region = %v, want missing region", err)
+	}
+}
@@ -0,0 +1,75 @@
+// Package retry repeats operations that fail for transient reasons, such as
+// a flaky proxy or a rate-limited API, waiting longer after each failure.
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Policy says how often and how patiently to retry. The zero Policy tries
+// once.
+type Policy struct {
+	Attempts int           // tries in all, including the first
+	Delay    time.Duration // wait after the first failure, doubled after each
+	MaxDelay time.Duration // cap on the wait; zero means no cap
+
+	// OnRetry, when set, is told of each failure about to be retried.
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+// permanent marks an error not worth retrying.
+type permanent struct{ err error }
+
+func (p *permanent) Error() string { return p.err.Error() }
+func (p *permanent) Unwrap() error { return p.err }
+
+// Permanent wraps err so Do returns it at once instead of retrying.
+func Permanent(err error) error {
+	var perm *permanent
+	if err == nil || errors.As(err, &perm) {
+		return err
+	}
+	return &permanent{err}
+}
+
+// Do calls op until it succeeds, returns a Permanent error, ctx is done,
+// or p.Attempts tries have failed, and returns op's last error.
+func (p Policy) Do(ctx context.Context, op func() error) error {
+	wait := p.Delay
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil {
+			return nil
+		}
+		var perm *permanent
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if attempt >= p.Attempts {
+			return err
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, wait)
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		wait *= 2
+		if p.MaxDelay > 0 && wait > p.MaxDelay {
+			wait = p.MaxDelay
+		}
+	}
+}
+
+// TransientStatus reports whether an HTTP response status may succeed when
+// the request is repeated: rate limits and server-side failures.
+func TransientStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusRequestTimeout || code >= 500
+}
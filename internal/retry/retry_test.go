@@ -0,0 +1,64 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	flaky := errors.New("connection reset")
+	tests := []struct {
+		name     string
+		attempts int
+		fails    int
+		err      error
+		wantErr  bool
+		wantRuns int
+	}{
+		{"zero policy tries once", 0, 1, flaky, true, 1},
+		{"succeeds after retries", 3, 2, flaky, false, 3},
+		{"gives up", 3, 5, flaky, true, 3},
+		{"permanent stops", 3, 5, Permanent(flaky), true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var waits []time.Duration
+			p := Policy{Attempts: tt.attempts, Delay: time.Millisecond, MaxDelay: 3 * time.Millisecond,
+				OnRetry: func(_ int, _ error, wait time.Duration) { waits = append(waits, wait) }}
+			runs := 0
+			err := p.Do(context.Background(), func() error {
+				runs++
+				if runs <= tt.fails {
+					return tt.err
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr || runs != tt.wantRuns {
+				t.Errorf("Do() = %v after %d runs, want error %v after %d", err, runs, tt.wantErr, tt.wantRuns)
+			}
+			if err != nil && !errors.Is(err, flaky) {
+				t.Errorf("Do() = %v, want %v", err, flaky)
+			}
+			for i, w := range waits {
+				if want := min(time.Millisecond<<i, 3*time.Millisecond); w != want {
+					t.Errorf("wait %d = %s, want %s", i, w, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	runs := 0
+	err := Policy{Attempts: 5, Delay: time.Hour}.Do(ctx, func() error {
+		runs++
+		return errors.New("timeout")
+	})
+	if err == nil || runs != 1 {
+		t.Errorf("Do() = %v after %d runs, want the first error", err, runs)
+	}
+}
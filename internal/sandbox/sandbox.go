@@ -0,0 +1,191 @@
+// Package sandbox runs untrusted commands, such as template pack hooks,
+// with an allowlist of programs, a scrubbed environment, a timeout,
+// captured output and, where the OS supports it, no network.
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+// maxOutput caps the captured output of one command.
+const maxOutput = 64 << 10
+
+// Policy limits what a command may do.
+type Policy struct {
+	Allow   []string      // program names that may run, e.g. "go"
+	Network bool          // leave network access in place
+	Timeout time.Duration // zero means no limit
+
+	// Fallback runs commands with the network where it can't be cut off;
+	// without it they fail with ErrIsolation there.
+	Fallback bool
+
+	// Runner, when set, runs the command instead of the host; the
+	// environment and the network are then up to it.
+	Runner execx.Runner
+}
+
+// Result is the outcome of one command.
+type Result struct {
+	Output   []byte // combined stdout and stderr, truncated to 64KiB
+	Isolated bool   // the network was actually cut off
+	Fallback bool   // ran with the network because it couldn't be cut off
+	Duration time.Duration
+}
+
+// ErrNotAllowed reports a program missing from the allowlist.
+var ErrNotAllowed = errors.New("command not allowed")
+
+// Allowed reports whether argv's program is on the allowlist. Only the
+// bare name is matched, so "/tmp/go" doesn't pass for "go".
+func (p *Policy) Allowed(argv []string) bool {
+	if len(argv) == 0 {
+		return false
+	}
+	for _, name := range p.Allow {
+		if argv[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Run executes argv in dir under the policy.
+func Run(ctx context.Context, p *Policy, dir string, argv []string) (*Result, error) {
+	if !p.Allowed(argv) {
+		if len(argv) == 0 {
+			return nil, fmt.Errorf("%w: empty command", ErrNotAllowed)
+		}
+		return nil, fmt.Errorf("%w: %s (allowed: %v)", ErrNotAllowed, argv[0], p.Allow)
+	}
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+
+	if p.Runner != nil {
+		return runWith(ctx, p, dir, argv)
+	}
+	if p.Network {
+		return run(ctx, p, dir, argv, false)
+	}
+	var res *Result
+	err := fmt.Errorf("%w on %s", ErrIsolation, runtime.GOOS)
+	if canIsolate {
+		res, err = run(ctx, p, dir, argv, true)
+	}
+	if errors.Is(err, ErrIsolation) && p.Fallback {
+		res, err = run(ctx, p, dir, argv, false)
+		if res != nil {
+			res.Fallback = true
+		}
+	}
+	return res, err
+}
+
+func run(ctx context.Context, p *Policy, dir string, argv []string, isolate bool) (*Result, error) {
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = dir
+	cmd.Env = environ(p.Network)
+	out := &limitedBuffer{max: maxOutput}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// Don't wait on children that outlive a killed command and hold its output open
+	cmd.WaitDelay = time.Second
+	if isolate {
+		isolateNetwork(cmd)
+	}
+
+	start := time.Now()
+	if cmd.Err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", argv[0], cmd.Err)
+	}
+	err := cmd.Start()
+	if err != nil {
+		if isolate {
+			return nil, fmt.Errorf("%w: %v", ErrIsolation, err)
+		}
+		return nil, fmt.Errorf("failed to start %s: %w", argv[0], err)
+	}
+	err = cmd.Wait()
+	res := &Result{Output: out.Bytes(), Isolated: isolate, Duration: time.Since(start)}
+	return res, result(ctx, p, argv, err)
+}
+
+// runWith runs argv through p.Runner, its stdout then its stderr standing
+// in for the combined output.
+func runWith(ctx context.Context, p *Policy, dir string, argv []string) (*Result, error) {
+	r, err := p.Runner.Run(ctx, dir, argv[0], argv[1:]...)
+	out := &limitedBuffer{max: maxOutput}
+	out.Write(r.Stdout)
+	out.Write(r.Stderr)
+	res := &Result{Output: out.Bytes(), Duration: r.Duration}
+	return res, result(ctx, p, argv, err)
+}
+
+// result words the error of a finished command.
+func result(ctx context.Context, p *Policy, argv []string, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s timed out after %s", argv[0], p.Timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", argv[0], err)
+	}
+	return nil
+}
+
+// ErrIsolation reports that the network couldn't be cut off for a command
+// (user namespaces are disabled, or the OS isn't Linux) and the policy
+// has no Fallback.
+var ErrIsolation = errors.New("network isolation unavailable")
+
+// environ keeps only what tools need to find themselves and their caches;
+// credentials and tokens in the caller's environment are left out.
+// Without network, Go module downloads are switched off as well.
+func environ(network bool) []string {
+	var env []string
+	for _, key := range []string{"PATH", "HOME", "TMPDIR", "LANG", "GOPATH", "GOCACHE", "GOMODCACHE", "GOROOT"} {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	if !network {
+		env = append(env, "GOPROXY=off")
+	}
+	return env
+}
+
+// limitedBuffer keeps the first max bytes written and drops the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+func (b *limitedBuffer) Bytes() []byte {
+	if b.truncated {
+		return append(b.Buffer.Bytes(), "\n... output truncated\n"...)
+	}
+	return b.Buffer.Bytes()
+}
@@ -0,0 +1,23 @@
+package sandbox
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// canIsolate: Linux cuts the network with a new user and network namespace.
+const canIsolate = true
+
+// isolateNetwork starts cmd in its own network namespace, which has only a
+// loopback device. The user namespace lets an unprivileged user create it;
+// the caller's uid and gid are mapped to themselves.
+func isolateNetwork(cmd *exec.Cmd) {
+	uid, gid := os.Getuid(), os.Getgid()
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:                 syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET,
+		UidMappings:                []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}},
+		GidMappings:                []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}},
+		GidMappingsEnableSetgroups: false,
+	}
+}
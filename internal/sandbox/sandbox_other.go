@@ -0,0 +1,11 @@
+//go:build !linux
+
+package sandbox
+
+import "os/exec"
+
+// canIsolate: no portable way to cut the network elsewhere, so commands
+// fail with ErrIsolation unless the policy allows Fallback.
+const canIsolate = false
+
+func isolateNetwork(cmd *exec.Cmd) {}
@@ -0,0 +1,87 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+func TestRun(t *testing.T) {
+	p := &Policy{Allow: []string{"sh"}, Timeout: 5 * time.Second}
+
+	if _, err := Run(context.Background(), p, t.TempDir(), []string{"echo", "hi"}); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Run(echo) error = %v, want ErrNotAllowed", err)
+	}
+	if _, err := Run(context.Background(), p, t.TempDir(), []string{"/bin/sh", "-c", "true"}); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Run(/bin/sh) error = %v, want ErrNotAllowed", err)
+	}
+
+	t.Setenv("PROJECT_TEST_SECRET", "hunter2")
+	res, err := Run(context.Background(), p, t.TempDir(), []string{"sh", "-c", "echo out; echo err >&2; echo secret=$PROJECT_TEST_SECRET"})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if got := string(res.Output); got != "out\nerr\nsecret=\n" {
+		t.Errorf("Run() output = %q", got)
+	}
+
+	res, err = Run(context.Background(), p, t.TempDir(), []string{"sh", "-c", "echo partial; exit 3"})
+	if err == nil || !strings.Contains(string(res.Output), "partial") {
+		t.Errorf("Run(exit 3) = %q, %v; want output and an error", res.Output, err)
+	}
+
+	p.Timeout = 100 * time.Millisecond
+	if _, err := Run(context.Background(), p, t.TempDir(), []string{"sh", "-c", "sleep 5"}); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Run(sleep) error = %v, want timeout", err)
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{max: 4}
+	b.Write([]byte("abc"))
+	b.Write([]byte("def"))
+	if got := string(b.Bytes()); got != "abcd\n... output truncated\n" {
+		t.Errorf("Bytes() = %q", got)
+	}
+}
+
+func TestRunWithRunner(t *testing.T) {
+	rec := &execx.Recorder{Respond: func(c execx.Call) (execx.Result, error) {
+		return execx.Result{Stdout: []byte("out\n"), Stderr: []byte("err\n")}, nil
+	}}
+	p := &Policy{Allow: []string{"gofmt"}, Runner: rec}
+	res, err := Run(context.Background(), p, "/p", []string{"gofmt", "-w", "."})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if got := string(res.Output); got != "out\nerr\n" {
+		t.Errorf("Run() output = %q", got)
+	}
+	if calls := rec.Calls(); len(calls) != 1 || calls[0].String() != "gofmt -w ." || calls[0].Dir != "/p" {
+		t.Errorf("Calls() = %+v", calls)
+	}
+}
+
+func TestRunIsolation(t *testing.T) {
+	p := &Policy{Allow: []string{"sh"}}
+	res, err := Run(context.Background(), p, t.TempDir(), []string{"sh", "-c", "true"})
+	if err == nil {
+		if !res.Isolated || res.Fallback {
+			t.Errorf("Run() = %+v, want it isolated", res)
+		}
+		t.Skip("isolation works here, so there is nothing to fall back from")
+	}
+	// Without isolation a command only runs when the policy allows falling back
+	if !errors.Is(err, ErrIsolation) {
+		t.Fatalf("Run() error = %v, want ErrIsolation", err)
+	}
+	p.Fallback = true
+	res, err = Run(context.Background(), p, t.TempDir(), []string{"sh", "-c", "true"})
+	if err != nil || res.Isolated || !res.Fallback {
+		t.Errorf("Run() with Fallback = %+v, %v; want it run with the network", res, err)
+	}
+}
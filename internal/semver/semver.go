@@ -0,0 +1,62 @@
+// Package semver compares "MAJOR.MINOR.PATCH" versions, with or without a leading v.
+package semver
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Compare returns -1, 0 or 1 as a is less than, equal to, or greater than b.
+// Missing parts count as zero and a pre-release ("1.2.0-rc1") sorts before its release.
+func Compare(a, b string) int {
+	aCore, aPre := split(a)
+	bCore, bPre := split(b)
+	for i := 0; i < 3; i++ {
+		if aCore[i] != bCore[i] {
+			if aCore[i] < bCore[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	case aPre < bPre:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// Valid reports whether v looks like a semantic version.
+func Valid(v string) bool {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	core, _, _ := strings.Cut(v, "-")
+	parts := strings.Split(core, ".")
+	if v == "" || len(parts) > 3 {
+		return false
+	}
+	for _, p := range parts {
+		if _, err := strconv.Atoi(p); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// split parses v into its numeric core and pre-release suffix.
+func split(v string) ([3]int, string) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	core, pre, _ := strings.Cut(v, "-")
+	var out [3]int
+	for i, p := range strings.SplitN(core, ".", 3) {
+		n, _ := strconv.Atoi(p)
+		out[i] = n
+	}
+	return out, pre
+}
@@ -0,0 +1,76 @@
+// Package term reports what the terminal the tool writes to can do, so
+// output stays plain text when it is piped or captured in CI logs.
+package term
+
+import (
+	"os"
+	"strconv"
+)
+
+// NoColor turns color off for every file, as --no-color does.
+var NoColor bool
+
+// Style is an ANSI SGR code.
+type Style string
+
+const (
+	Bold   Style = "1"
+	Dim    Style = "2"
+	Red    Style = "31"
+	Green  Style = "32"
+	Yellow Style = "33"
+	Cyan   Style = "36"
+)
+
+// Color reports whether output written to f may be colored: on a terminal
+// unless NoColor, NO_COLOR (https://no-color.org) or TERM=dumb say not;
+// FORCE_COLOR or CLICOLOR_FORCE turn it on anywhere, e.g. in CI.
+func Color(f *os.File) bool {
+	switch {
+	case NoColor, os.Getenv("NO_COLOR") != "":
+		return false
+	case forced("FORCE_COLOR"), forced("CLICOLOR_FORCE"):
+		return true
+	case os.Getenv("TERM") == "dumb":
+		return false
+	}
+	return IsTerminal(f)
+}
+
+// forced reports whether the variable is set to something other than 0.
+func forced(name string) bool {
+	v := os.Getenv(name)
+	return v != "" && v != "0"
+}
+
+// Wrap returns s in style, whether or not anything is colored.
+func (style Style) Wrap(s string) string {
+	if s == "" {
+		return s
+	}
+	return "\x1b[" + string(style) + "m" + s + "\x1b[0m"
+}
+
+// Paint returns s in style when output to f is colored, else s.
+func Paint(f *os.File, style Style, s string) string {
+	if !Color(f) {
+		return s
+	}
+	return style.Wrap(s)
+}
+
+// Size returns the columns and rows of the terminal f is on, else COLUMNS
+// and LINES, else 80x24.
+func Size(f *os.File) (width, height int) {
+	if w, h, ok := windowSize(f); ok {
+		return w, h
+	}
+	width, height = 80, 24
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		width = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LINES")); err == nil && n > 0 {
+		height = n
+	}
+	return width, height
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package term
+
+import "os"
+
+// IsTerminal reports whether f is a terminal rather than a pipe or file.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// windowSize: no portable way to ask elsewhere, so Size falls back to
+// COLUMNS and LINES.
+func windowSize(f *os.File) (width, height int, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,50 @@
+package term
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestColor(t *testing.T) {
+	// A plain file is never a terminal
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		noColor bool
+		want    bool
+	}{
+		{"not a terminal", nil, false, false},
+		{"FORCE_COLOR", map[string]string{"FORCE_COLOR": "1"}, false, true},
+		{"FORCE_COLOR=0", map[string]string{"FORCE_COLOR": "0"}, false, false},
+		{"CLICOLOR_FORCE", map[string]string{"CLICOLOR_FORCE": "1"}, false, true},
+		{"NO_COLOR wins", map[string]string{"FORCE_COLOR": "1", "NO_COLOR": "1"}, false, false},
+		{"--no-color wins", map[string]string{"FORCE_COLOR": "1"}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"NO_COLOR", "FORCE_COLOR", "CLICOLOR_FORCE", "TERM"} {
+				t.Setenv(name, tt.env[name])
+			}
+			defer func(v bool) { NoColor = v }(NoColor)
+			NoColor = tt.noColor
+
+			if got := Color(f); got != tt.want {
+				t.Errorf("Color() = %v, want %v", got, tt.want)
+			}
+			want := "x"
+			if tt.want {
+				want = "\x1b[31mx\x1b[0m"
+			}
+			if got := Paint(f, Red, "x"); got != want {
+				t.Errorf("Paint() = %q, want %q", got, want)
+			}
+		})
+	}
+}
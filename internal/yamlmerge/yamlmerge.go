@@ -0,0 +1,273 @@
+// Package yamlmerge adds to YAML files without rewriting them: the keys a
+// newly generated file has and the user's copy lacks, or one key an add
+// command inserts, are spliced into the user's text. yaml.Node finds where
+// each mapping starts and ends; every line of the user's file, comments
+// and blank lines included, is kept as it was.
+package yamlmerge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrExists is returned by Insert when the key is already there.
+var ErrExists = errors.New("key already exists")
+
+// Merge returns current plus every mapping key of rendered that current
+// lacks, at any depth: a task the templates added to Taskfile.yaml, a
+// service of docker-compose.yaml, a job of a CI workflow. Added keys go
+// after the last key of their mapping, with the comments above them in
+// rendered. Keys current has keep current's value; sequences and scalars
+// are never merged. Flow-style mappings such as {a: 1} can't take keys
+// and are an error.
+func Merge(current, rendered []byte) ([]byte, error) {
+	cur, err := parse(current)
+	if err != nil {
+		return nil, fmt.Errorf("current: %w", err)
+	}
+	ren, err := parse(rendered)
+	if err != nil {
+		return nil, fmt.Errorf("rendered: %w", err)
+	}
+	if cur.root == nil {
+		return rendered, nil
+	}
+	if ren.root == nil {
+		return current, nil
+	}
+	var ins []insertion
+	if err := collect(cur, ren, cur.root, ren.root, len(cur.lines)+1, len(ren.lines)+1, &ins); err != nil {
+		return nil, err
+	}
+	return cur.apply(ins), nil
+}
+
+// Insert returns doc with key set to value, a YAML document, in the
+// mapping at path, e.g. path ["tasks"] and key "docker:build" for a new
+// task. Mappings missing along path are added. It fails with ErrExists if
+// the mapping already has key.
+func Insert(doc []byte, path []string, key string, value []byte) ([]byte, error) {
+	d, err := parse(doc)
+	if err != nil {
+		return nil, err
+	}
+	node := d.root
+	for i, p := range path {
+		if node == nil {
+			break
+		}
+		if node = lookup(node, p); node != nil && node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s is not a mapping", strings.Join(path[:i+1], "."))
+		}
+	}
+	if node != nil && lookup(node, key) != nil {
+		return nil, fmt.Errorf("%s: %w", strings.Join(append(slices.Clone(path), key), "."), ErrExists)
+	}
+
+	var b strings.Builder
+	indent := ""
+	for _, p := range path {
+		fmt.Fprintf(&b, "%s%s:\n", indent, quoteKey(p))
+		indent += "  "
+	}
+	fmt.Fprintf(&b, "%s%s:\n", indent, quoteKey(key))
+	for _, line := range strings.Split(strings.TrimRight(string(value), "\n"), "\n") {
+		if line == "" {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(indent + "  " + line + "\n")
+	}
+	return Merge(doc, []byte(b.String()))
+}
+
+// quoteKey writes key as YAML would need it.
+func quoteKey(key string) string {
+	out, err := yaml.Marshal(key)
+	if err != nil {
+		return key
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+// document is a parsed YAML file and its lines, 1-based as yaml.Node
+// numbers them: lines[0] is line 1.
+type document struct {
+	root  *yaml.Node // the top-level mapping, nil for an empty document
+	lines []string
+	eol   bool // the text ends in a newline
+}
+
+func parse(data []byte) (*document, error) {
+	var n yaml.Node
+	if err := yaml.Unmarshal(data, &n); err != nil {
+		return nil, err
+	}
+	d := &document{eol: len(data) == 0 || bytes.HasSuffix(data, []byte("\n"))}
+	text := strings.TrimSuffix(string(data), "\n")
+	if text != "" {
+		d.lines = strings.Split(text, "\n")
+	}
+	if n.Kind == 0 || len(n.Content) == 0 {
+		return d, nil
+	}
+	root := n.Content[0]
+	if root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+		return d, nil
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("document is not a mapping")
+	}
+	d.root = root
+	return d, nil
+}
+
+// line returns line n, or "" past the end.
+func (d *document) line(n int) string {
+	if n < 1 || n > len(d.lines) {
+		return ""
+	}
+	return d.lines[n-1]
+}
+
+// start is the first line of the mapping entry whose key is on line n:
+// the key, or the comment lines right above it.
+func (d *document) start(n int) int {
+	for n > 1 && strings.HasPrefix(strings.TrimSpace(d.line(n-1)), "#") {
+		n--
+	}
+	return n
+}
+
+// last is the last line that isn't blank before limit.
+func (d *document) last(from, limit int) int {
+	end := limit - 1
+	for end > from && strings.TrimSpace(d.line(end)) == "" {
+		end--
+	}
+	return end
+}
+
+// insertion adds text after line at of the current document.
+type insertion struct {
+	at    int
+	lines []string
+}
+
+// collect finds the keys of ren that cur lacks. curLimit and renLimit are
+// the lines where whatever follows each mapping starts.
+func collect(cur, ren *document, c, r *yaml.Node, curLimit, renLimit int, ins *[]insertion) error {
+	var added []string
+	for i := 0; i+1 < len(r.Content); i += 2 {
+		rk, rv := r.Content[i], r.Content[i+1]
+		rEnd := renLimit
+		if i+2 < len(r.Content) {
+			rEnd = ren.start(r.Content[i+2].Line)
+		}
+		ci := index(c, rk.Value)
+		if ci < 0 {
+			if c.Style&yaml.FlowStyle != 0 {
+				return fmt.Errorf("line %d: can't add %s to a flow mapping", c.Line, rk.Value)
+			}
+			from := ren.start(rk.Line)
+			block := slices.Clone(ren.lines[from-1 : ren.last(from, rEnd)])
+			if from > 1 && strings.TrimSpace(ren.line(from-1)) == "" && len(c.Content) > 0 {
+				block = append([]string{""}, block...)
+			}
+			added = append(added, reindent(block, rk.Column-1, c.Content[0].Column-1, ren.unit(), cur.unit())...)
+			continue
+		}
+		cv := c.Content[ci+1]
+		if cv.Kind != yaml.MappingNode || rv.Kind != yaml.MappingNode || len(cv.Content) == 0 {
+			continue
+		}
+		cEnd := curLimit
+		if ci+2 < len(c.Content) {
+			cEnd = cur.start(c.Content[ci+2].Line)
+		}
+		if err := collect(cur, ren, cv, rv, cEnd, rEnd, ins); err != nil {
+			return err
+		}
+	}
+	if len(added) > 0 {
+		at := 0
+		if n := len(c.Content); n > 0 {
+			at = cur.last(c.Content[n-2].Line, curLimit)
+		}
+		*ins = append(*ins, insertion{at: at, lines: added})
+	}
+	return nil
+}
+
+// index returns the position of key in mapping m, or -1.
+func index(m *yaml.Node, key string) int {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// lookup returns the value of key in mapping m, or nil.
+func lookup(m *yaml.Node, key string) *yaml.Node {
+	if i := index(m, key); i >= 0 {
+		return m.Content[i+1]
+	}
+	return nil
+}
+
+// unit is the document's indentation step: the smallest indentation of
+// any line, 2 if none is indented.
+func (d *document) unit() int {
+	unit := 0
+	for _, line := range d.lines {
+		n := len(line) - len(strings.TrimLeft(line, " "))
+		if n > 0 && n < len(line) && (unit == 0 || n < unit) {
+			unit = n
+		}
+	}
+	if unit == 0 {
+		return 2
+	}
+	return unit
+}
+
+// reindent moves lines indented from column from to column to, scaling
+// the indentation past from by the ratio of the documents' steps, so a
+// block from a file indented by 2 fits one indented by 4.
+func reindent(lines []string, from, to, fromUnit, toUnit int) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		body := strings.TrimLeft(line, " ")
+		if body == "" {
+			continue
+		}
+		extra := max(len(line)-len(body)-from, 0)
+		out[i] = strings.Repeat(" ", to+extra*toUnit/fromUnit) + body
+	}
+	return out
+}
+
+// apply returns the document with the insertions made, the last lines
+// first so earlier line numbers stay valid. A mapping and the one nested
+// at its end insert after the same line; collect lists the nested one
+// first, and it must end up first, so ties go in reverse.
+func (d *document) apply(ins []insertion) []byte {
+	lines := slices.Clone(d.lines)
+	slices.Reverse(ins)
+	slices.SortStableFunc(ins, func(a, b insertion) int { return b.at - a.at })
+	for _, in := range ins {
+		lines = slices.Insert(lines, in.at, in.lines...)
+	}
+	out := strings.Join(lines, "\n")
+	if len(lines) > 0 && (d.eol || len(ins) > 0) {
+		out += "\n"
+	}
+	return []byte(out)
+}
@@ -0,0 +1,179 @@
+package yamlmerge
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name              string
+		current, rendered string
+		want              string
+		wantErr           bool
+	}{
+		{
+			name: "new task after the user's",
+			current: `version: '3'
+
+tasks:
+  # the user's build
+  build:
+    cmds:
+      - go build -tags prod ./...
+
+  deploy:   # added by hand
+    cmds:
+      - ./deploy.sh
+`,
+			rendered: `version: '3'
+
+tasks:
+  build:
+    cmds:
+      - go build ./...
+
+  # Lint with golangci-lint
+  lint:
+    cmds:
+      - golangci-lint run
+`,
+			want: `version: '3'
+
+tasks:
+  # the user's build
+  build:
+    cmds:
+      - go build -tags prod ./...
+
+  deploy:   # added by hand
+    cmds:
+      - ./deploy.sh
+
+  # Lint with golangci-lint
+  lint:
+    cmds:
+      - golangci-lint run
+`,
+		},
+		{
+			name: "service in a nested mapping, reindented",
+			current: `services:
+    db:
+        image: postgres:16
+volumes:
+    data: {}
+`,
+			rendered: `services:
+  db:
+    image: postgres:15
+  cache:
+    image: redis:7
+volumes:
+  data: {}
+`,
+			want: `services:
+    db:
+        image: postgres:16
+    cache:
+        image: redis:7
+volumes:
+    data: {}
+`,
+		},
+		{
+			name: "job and top-level key at the end",
+			current: `name: CI
+jobs:
+  test:
+    runs-on: ubuntu-latest
+`,
+			rendered: `name: CI
+jobs:
+  test:
+    runs-on: ubuntu-latest
+  wasm:
+    runs-on: ubuntu-latest
+permissions:
+  contents: read
+`,
+			want: `name: CI
+jobs:
+  test:
+    runs-on: ubuntu-latest
+  wasm:
+    runs-on: ubuntu-latest
+permissions:
+  contents: read
+`,
+		},
+		{
+			name:     "nothing to add",
+			current:  "a: 1 # mine\nb:\n  - x\n",
+			rendered: "a: 2\nb:\n  - y\n",
+			want:     "a: 1 # mine\nb:\n  - x\n",
+		},
+		{
+			name:     "empty current",
+			current:  "",
+			rendered: "a: 1\n",
+			want:     "a: 1\n",
+		},
+		{
+			name:     "flow mapping",
+			current:  "tasks: {build: {}}\n",
+			rendered: "tasks:\n  build: {}\n  lint: {}\n",
+			wantErr:  true,
+		},
+		{
+			name:     "not a mapping",
+			current:  "- a\n",
+			rendered: "a: 1\n",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Merge([]byte(tt.current), []byte(tt.rendered))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Merge() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Merge() error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Merge() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInsert(t *testing.T) {
+	doc := []byte("version: '3'\n\ntasks:\n  build:\n    cmds: [go build]\n")
+	got, err := Insert(doc, []string{"tasks"}, "docker:build", []byte("cmds:\n  - docker build .\n"))
+	if err != nil {
+		t.Fatalf("Insert() error: %v", err)
+	}
+	want := "version: '3'\n\ntasks:\n  build:\n    cmds: [go build]\n  docker:build:\n    cmds:\n      - docker build .\n"
+	if string(got) != want {
+		t.Errorf("Insert() =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := Insert(got, []string{"tasks"}, "docker:build", []byte("cmds: []\n")); !errors.Is(err, ErrExists) {
+		t.Errorf("Insert() of an existing key = %v, want ErrExists", err)
+	}
+	if _, err := Insert(doc, []string{"version"}, "x", []byte("1\n")); err == nil {
+		t.Errorf("Insert() under a scalar succeeded")
+	}
+
+	got, err = Insert([]byte("name: demo\n"), []string{"services", "web"}, "image", []byte("nginx\n"))
+	if err != nil {
+		t.Fatalf("Insert() error: %v", err)
+	}
+	if want := "name: demo\nservices:\n  web:\n    image:\n      nginx\n"; string(got) != want {
+		t.Errorf("Insert() =\n%s\nwant\n%s", got, want)
+	}
+}
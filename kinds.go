@@ -0,0 +1,94 @@
+package project
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultKind is the kind of project generated when none is given.
+const DefaultKind = "cli"
+
+// projectKind is one kind of project: the templates it renders, in order,
+// and the optional features that make sense for it (nil means all). cli
+// kinds have the CLI's commands and config package.
+type projectKind struct {
+	files    []string
+	features []string
+	cli      bool
+}
+
+// kinds lists the supported project kinds. Templates can tell them apart
+// with {{if eq .Kind "library"}}.
+var kinds = map[string]projectKind{
+	"cli": {
+		cli:   true,
+		files: []string{"main", "context", "config", "logs", "pathutil", "project", "taskfile", "readme", "contributing", "configdoc", "envexample", "docs", "about", "setup", "env", "scaffold", "smoke", "main_test", "goreleaser", "gitignore"},
+	},
+	"library": {
+		files:    []string{"doc", "project", "example", "taskfile", "readme", "contributing", "gitignore"},
+		features: []string{"bench", "catalog", "coverage", "itest", "private", "wasm"},
+	},
+	// tui is a cli whose binary opens a Bubble Tea UI, in package ui, when
+	// run with no arguments or as <binary> ui
+	"tui": {
+		cli:   true,
+		files: []string{"main", "context", "config", "logs", "pathutil", "project", "tui", "tui-keys", "tui-styles", "tui-home", "tui-command", "taskfile", "readme", "contributing", "configdoc", "envexample", "docs", "about", "setup", "env", "scaffold", "smoke", "main_test", "goreleaser", "gitignore"},
+	},
+}
+
+// KnownKinds returns the names of all project kinds, sorted.
+func KnownKinds() []string {
+	names := make([]string, 0, len(kinds))
+	for name := range kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// cliKinds lists the kinds with the CLI's commands and config package,
+// for errors about the others, e.g. "cli and tui".
+func cliKinds() string {
+	var names []string
+	for _, name := range KnownKinds() {
+		if kinds[name].cli {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, " and ")
+}
+
+// applyKind settles the project kind: g.Kind, else the previous manifest's,
+// else DefaultKind. A project can't change kind once generated.
+func (g *Generator) applyKind(prev *Manifest) error {
+	kind := strings.ToLower(strings.TrimSpace(g.Kind))
+	if prev != nil && prev.Kind != "" {
+		if kind != "" && kind != prev.Kind {
+			return fmt.Errorf("project is a %s, can't change it to %s", prev.Kind, kind)
+		}
+		kind = prev.Kind
+	}
+	if kind == "" {
+		kind = DefaultKind
+	}
+	if _, ok := kinds[kind]; !ok {
+		return fmt.Errorf("unknown kind %q (known: %s)", kind, strings.Join(KnownKinds(), ", "))
+	}
+	g.Kind = kind
+	g.Config.Kind = kind
+	return nil
+}
+
+// supports reports whether the project kind offers the named feature.
+func (k projectKind) supports(feature string) bool {
+	if k.features == nil {
+		return true
+	}
+	for _, f := range k.features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/robbyriverside/project/internal/lockfile"
+)
+
+// LockName is the file held in the project directory while a run writes
+// to it, so concurrent runs (e.g. CI retries) can't interleave writes.
+const LockName = ".project.lock"
+
+// lock takes projectDir's lock for the rest of the run, breaking a stale
+// one, or any one with g.BreakLock. Nested calls (Update runs GenerateAll)
+// reuse the lock already held. The returned func releases it.
+func (g *Generator) lock(projectDir string) (func(), error) {
+	if g.held != nil {
+		return func() {}, nil
+	}
+	if err := g.fsys().MkdirAll(projectDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", projectDir, err)
+	}
+	// No other process sees a project off the host
+	if !g.onHost() {
+		return func() {}, nil
+	}
+
+	l, err := lockfile.Acquire(filepath.Join(projectDir, LockName), g.BreakLock)
+	var held *lockfile.HeldError
+	if errors.As(err, &held) {
+		return nil, fmt.Errorf("another run is writing this project: %w (use --break-lock if it is gone)", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	g.held = l
+	return func() {
+		_ = l.Release()
+		g.held = nil
+		// Removing the lock touched the directory after stampFiles ran
+		if g.Reproducible {
+			t := g.now()
+			_ = os.Chtimes(projectDir, t, t)
+		}
+	}, nil
+}
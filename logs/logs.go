@@ -8,6 +8,8 @@ import (
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"github.com/robbyriverside/project/internal/term"
 )
 
 // Global logging state
@@ -60,6 +62,9 @@ func InitLogger(env string) {
 		if format == "text" {
 			cfg = zap.NewDevelopmentConfig()
 			cfg.Encoding = "console"
+			if term.Color(os.Stderr) {
+				cfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+			}
 		} else {
 			// 'json' or 'formatted' => base is ProductionConfig
 			cfg = zap.NewProductionConfig()
@@ -72,8 +77,8 @@ func InitLogger(env string) {
 			}
 		}
 
-		// Common settings
-		cfg.OutputPaths = []string{"stdout"}
+		// Logs go to stderr, so commands that print to stdout (render) stay pipeable
+		cfg.OutputPaths = []string{"stderr"}
 		cfg.ErrorOutputPaths = []string{"stderr"}
 
 		if Options.Verbose {
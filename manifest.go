@@ -0,0 +1,151 @@
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestName is the file written at the project root recording what was generated.
+const ManifestName = ".project.yaml"
+
+// Manifest records the inputs and outputs of a generation run, so later
+// runs (project update) can tell which files changed.
+type Manifest struct {
+	GeneratorVersion string `yaml:"generator_version"`
+	TemplatePack     string `yaml:"template_pack"`
+	TemplateVersion  string `yaml:"template_version"`
+	ModuleURL        string `yaml:"module"`
+	ProjectName      string `yaml:"project_name"`
+
+	// ProjectDir is the absolute directory the project was last written to.
+	ProjectDir string `yaml:"project_dir,omitempty"`
+
+	BinaryName string `yaml:"binary_name,omitempty"`
+
+	// Binaries lists every cmd/<name>, binary_name first, when there are several.
+	Binaries []string `yaml:"binaries,omitempty"`
+
+	// Targets are the GOOS/GOARCH pairs build:all builds for.
+	Targets []string `yaml:"targets,omitempty"`
+
+	HomeDir string `yaml:"home_dir"`
+	Lang    string `yaml:"lang,omitempty"`
+	Kind    string `yaml:"kind,omitempty"`
+
+	// Features are the optional features enabled at generation, e.g. bench.
+	Features []string `yaml:"features,omitempty"`
+
+	// Adopted is set for existing repos brought in with gen --from-existing;
+	// updates then only touch the files listed here.
+	Adopted bool `yaml:"adopted,omitempty"`
+
+	// Vendor keeps vendor/ in step with go.mod on update and upgrade-deps.
+	Vendor bool `yaml:"vendor,omitempty"`
+
+	// Attest keeps the SBOM and provenance in .project/ current on update.
+	Attest bool `yaml:"attest,omitempty"`
+
+	// Headers keeps provenance headers on generated files on update.
+	Headers bool `yaml:"headers,omitempty"`
+
+	// Subcommands, ConfigFields and EnvTargets are what a blueprint added
+	// to the CLI, regenerated on update.
+	Subcommands  []Subcommand  `yaml:"commands,omitempty"`
+	ConfigFields []ConfigField `yaml:"config_fields,omitempty"`
+	EnvTargets   []EnvTarget   `yaml:"env_targets,omitempty"`
+
+	// Vars are the template variables the project was generated with, and
+	// Uses the sibling modules its go.mod replaces.
+	Vars map[string]string `yaml:"vars,omitempty"`
+	Uses []ModuleUse       `yaml:"uses,omitempty"`
+
+	// Catalog is the service catalog entry the blueprint gave.
+	Catalog Catalog `yaml:"catalog,omitempty"`
+
+	Files []ManifestFile `yaml:"files"`
+}
+
+// ManifestFile is one generated file: its slash-separated path relative
+// to the project root, the template (or static asset) it came from,
+// and the sha256 of the content that was written.
+type ManifestFile struct {
+	Path     string `yaml:"path"`
+	Template string `yaml:"template"`
+	SHA256   string `yaml:"sha256"`
+}
+
+// ManifestPath returns the manifest location inside a project directory.
+func ManifestPath(projectDir string) string {
+	return filepath.Join(projectDir, ManifestName)
+}
+
+// LoadManifest reads the manifest from a project directory.
+func LoadManifest(projectDir string) (*Manifest, error) {
+	return loadManifest(fileutils.OS, projectDir)
+}
+
+// loadManifest is LoadManifest on fsys.
+func loadManifest(fsys fileutils.FS, projectDir string) (*Manifest, error) {
+	data, err := fsys.ReadFile(ManifestPath(projectDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// loadManifestIfExists is LoadManifest, but a missing manifest is not an error.
+func loadManifestIfExists(fsys fileutils.FS, projectDir string) (*Manifest, error) {
+	m, err := loadManifest(fsys, projectDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return m, err
+}
+
+// File returns the manifest entry for a slash-separated path, or nil.
+func (m *Manifest) File(path string) *ManifestFile {
+	if m == nil {
+		return nil
+	}
+	for i := range m.Files {
+		if m.Files[i].Path == path {
+			return &m.Files[i]
+		}
+	}
+	return nil
+}
+
+// Marshal renders the manifest as YAML with files in a stable order.
+func (m *Manifest) Marshal() ([]byte, error) {
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	out, err := yaml.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	return out, nil
+}
+
+// checksum returns the hex sha256 of data.
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fileChecksum returns the hex sha256 of the file at path on fsys.
+func fileChecksum(fsys fileutils.FS, path string) (string, error) {
+	_, sum, err := streamChecksum(func() (io.ReadCloser, error) { return fsys.Open(path) })
+	return sum, err
+}
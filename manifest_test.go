@@ -0,0 +1,62 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+func TestGenerateAllSkipsUnchanged(t *testing.T) {
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	dir := t.TempDir()
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	pp := g.Config.ProjectPath()
+
+	m, err := LoadManifest(pp)
+	if err != nil {
+		t.Fatalf("LoadManifest() error: %v", err)
+	}
+	if len(m.Files) != len(g.Results) {
+		t.Errorf("manifest lists %d files, the run wrote %d", len(m.Files), len(g.Results))
+	}
+	if m.ProjectDir != pp || !filepath.IsAbs(m.ProjectDir) {
+		t.Errorf("manifest project_dir = %q, want %q", m.ProjectDir, pp)
+	}
+	for _, f := range m.Files {
+		sum, err := fileChecksum(fileutils.OS, filepath.Join(pp, filepath.FromSlash(f.Path)))
+		if err != nil || sum != f.SHA256 {
+			t.Errorf("manifest sha256 of %s = %s, file has %s (%v)", f.Path, f.SHA256, sum, err)
+		}
+	}
+
+	// A second run leaves every file alone but the deleted one
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	readme := filepath.Join(pp, "README.md")
+	if err := os.Chtimes(readme, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(pp, "config", "config.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("second GenerateAll() error: %v", err)
+	}
+	for _, r := range g.Results {
+		want := StatusUnchanged
+		if r.Path == "config/config.go" {
+			want = StatusCreated
+		}
+		if r.Status != want {
+			t.Errorf("second run: %s is %s, want %s", r.Path, r.Status, want)
+		}
+	}
+	if info, err := os.Stat(readme); err != nil || !info.ModTime().Equal(old) {
+		t.Errorf("second run rewrote README.md: %v", err)
+	}
+}
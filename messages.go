@@ -0,0 +1,36 @@
+package project
+
+import (
+	"github.com/robbyriverside/project/i18n"
+)
+
+// MessagesDir is the directory a template pack may ship translations in,
+// one <lang>.yaml per language as read by i18n.Catalog.Load. Templates
+// print them with the T func: {{T "Run %s to start" .BinaryName}}.
+const MessagesDir = "messages"
+
+// messages returns the printer for the pack's translations in g.Lang, or
+// the pack's default language; it's loaded once per generator.
+func (g *Generator) messages() (*i18n.Printer, error) {
+	if g.printer != nil {
+		return g.printer, nil
+	}
+	fsys, err := g.pack()
+	if err != nil {
+		return nil, err
+	}
+	c := i18n.New()
+	if err := c.Load(fsys, MessagesDir); err != nil {
+		return nil, err
+	}
+	lang := g.Lang
+	if lang == "" {
+		pack, err := g.PackInfo()
+		if err != nil {
+			return nil, err
+		}
+		lang = pack.DefaultLanguage()
+	}
+	g.printer = c.Printer(lang)
+	return g.printer, nil
+}
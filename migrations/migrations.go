@@ -0,0 +1,116 @@
+// Package migrations upgrades projects scaffolded from older versions of the
+// embedded template pack when templates change incompatibly.
+//
+// Each migration lives in its own file named after the template version it
+// upgrades to, e.g. migrations/0002_rename_logs_pkg.go, and registers itself:
+//
+//	func init() {
+//		register(Migration{
+//			Version: "1.1.0",
+//			Name:    "rename_logs_pkg",
+//			Apply: func(ctx *Context) error {
+//				if err := ctx.Rename("logs", "logging"); err != nil {
+//					return err
+//				}
+//				return ctx.RewriteImports(ctx.ModuleURL+"/logs", ctx.ModuleURL+"/logging")
+//			},
+//		})
+//	}
+//
+// `project update` runs every migration newer than the version recorded in
+// the project's manifest, in version order, before re-rendering templates.
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+
+	"github.com/robbyriverside/project/internal/codemod"
+	"github.com/robbyriverside/project/internal/fileutils"
+	"github.com/robbyriverside/project/internal/semver"
+)
+
+// Migration transforms a project from the previous template version to Version.
+type Migration struct {
+	Version string // template pack version this migration upgrades to
+	Name    string
+	Apply   func(ctx *Context) error
+}
+
+// Context is the project a migration runs against.
+type Context struct {
+	Dir       string // project root
+	ModuleURL string
+	FS        fileutils.FS // the project's filesystem; nil is the host
+}
+
+// registry holds all migrations registered by init functions.
+var registry []Migration
+
+// register adds a migration; called from each migration file's init.
+func register(m Migration) {
+	registry = append(registry, m)
+}
+
+// Pending returns the migrations needed to go from template version from to to,
+// oldest first.
+func Pending(from, to string) []Migration {
+	return pending(registry, from, to)
+}
+
+func pending(all []Migration, from, to string) []Migration {
+	var out []Migration
+	for _, m := range all {
+		if semver.Compare(m.Version, from) > 0 && semver.Compare(m.Version, to) <= 0 {
+			out = append(out, m)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return semver.Compare(out[i].Version, out[j].Version) < 0
+	})
+	return out
+}
+
+// Run applies the migrations in order, stopping at the first failure.
+func Run(ctx *Context, ms []Migration) error {
+	for _, m := range ms {
+		if err := m.Apply(ctx); err != nil {
+			return fmt.Errorf("migration %s (%s) failed: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// Rename moves a file or directory within the project, creating parent dirs.
+// A missing source is not an error, so migrations can be re-run safely.
+func (ctx *Context) Rename(oldRel, newRel string) error {
+	fsys := ctx.fs()
+	oldPath := filepath.Join(ctx.Dir, filepath.FromSlash(oldRel))
+	newPath := filepath.Join(ctx.Dir, filepath.FromSlash(newRel))
+	if _, err := fsys.Stat(oldPath); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err := fsys.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return fmt.Errorf("failed to mkdir for %s: %w", newRel, err)
+	}
+	if err := fsys.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", oldRel, newRel, err)
+	}
+	return nil
+}
+
+// RewriteImports changes imports of oldPath to newPath across the project's Go files.
+func (ctx *Context) RewriteImports(oldPath, newPath string) error {
+	_, err := codemod.RewriteImports(ctx.fs(), ctx.Dir, oldPath, newPath)
+	return err
+}
+
+func (ctx *Context) fs() fileutils.FS {
+	if ctx.FS == nil {
+		return fileutils.OS
+	}
+	return ctx.FS
+}
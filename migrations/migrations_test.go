@@ -0,0 +1,69 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPending(t *testing.T) {
+	all := []Migration{
+		{Version: "1.3.0", Name: "c"},
+		{Version: "1.1.0", Name: "a"},
+		{Version: "2.0.0", Name: "d"},
+		{Version: "1.2.0", Name: "b"},
+	}
+
+	got := pending(all, "1.1.0", "1.3.0")
+	var names []string
+	for _, m := range got {
+		names = append(names, m.Name)
+	}
+	if strings.Join(names, ",") != "b,c" {
+		t.Errorf("pending(1.1.0, 1.3.0) = %v, want [b c]", names)
+	}
+	if got := pending(all, "2.0.0", "2.0.0"); len(got) != 0 {
+		t.Errorf("pending(2.0.0, 2.0.0) = %v, want none", got)
+	}
+}
+
+func TestRenameAndRewriteImports(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "logs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "logs", "logs.go"), []byte("package logs\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	main := "package main\n\nimport (\n\t\"example.com/app/logs\"\n)\n\nvar _ = logs.X\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &Context{Dir: dir, ModuleURL: "example.com/app"}
+	err := Run(ctx, []Migration{{
+		Version: "1.1.0",
+		Name:    "rename_logs_pkg",
+		Apply: func(ctx *Context) error {
+			if err := ctx.Rename("logs", "logging"); err != nil {
+				return err
+			}
+			return ctx.RewriteImports(ctx.ModuleURL+"/logs", ctx.ModuleURL+"/logging")
+		},
+	}})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "logging", "logs.go")); err != nil {
+		t.Errorf("logs dir not renamed: %v", err)
+	}
+	out, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `"example.com/app/logging"`) {
+		t.Errorf("import not rewritten:\n%s", out)
+	}
+}
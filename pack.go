@@ -0,0 +1,183 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/robbyriverside/project/internal/semver"
+)
+
+// PackManifestName is the optional file at a template pack's root describing it.
+const PackManifestName = "pack.yaml"
+
+// baselineTemplateVersion is assumed for manifests written before
+// template versions were recorded.
+const baselineTemplateVersion = "1.0.0"
+
+// Pack describes a template pack. Version follows semver and is bumped
+// whenever the templates change; incompatible changes ship a migration.
+type Pack struct {
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version"`
+	Description string `yaml:"description"`
+
+	// Languages lists the languages with localized <name>.<lang>.tmpl
+	// templates; the first is the default used by plain <name>.tmpl.
+	// Fallbacks maps a requested language to others to try before the default.
+	Languages []string            `yaml:"languages"`
+	Fallbacks map[string][]string `yaml:"fallbacks"`
+
+	// Hooks run in the project directory after generation; see runHooks.
+	Hooks []Hook `yaml:"hooks"`
+
+	// Steps, when set, limits generation to these steps; see StepNames.
+	Steps []string `yaml:"steps"`
+
+	// Headers pick the files that get a provenance header with --headers.
+	Headers []HeaderRule `yaml:"headers"`
+
+	// Variables are the pack's own template variables; see PackVariable.
+	Variables []PackVariable `yaml:"variables"`
+}
+
+// DefaultLanguage is the language of the pack's plain templates.
+func (p *Pack) DefaultLanguage() string {
+	if len(p.Languages) > 0 {
+		return p.Languages[0]
+	}
+	return "en"
+}
+
+// Supports reports whether the pack declares lang or its base language.
+func (p *Pack) Supports(lang string) bool {
+	lang = normalizeLang(lang)
+	for _, l := range append([]string{p.DefaultLanguage()}, p.Languages...) {
+		if l == lang || l == baseLang(lang) {
+			return true
+		}
+	}
+	return false
+}
+
+// Negotiate returns the languages to try for a request like "pt_BR.UTF-8",
+// most specific first: the exact tag, its base language, the pack's
+// fallbacks for either, and finally the default. Languages the pack
+// doesn't declare are dropped when it declares any.
+func (p *Pack) Negotiate(requested string) []string {
+	lang := normalizeLang(requested)
+	candidates := []string{lang, baseLang(lang)}
+	candidates = append(candidates, p.Fallbacks[lang]...)
+	candidates = append(candidates, p.Fallbacks[baseLang(lang)]...)
+
+	declared := make(map[string]bool)
+	for _, l := range p.Languages {
+		declared[l] = true
+	}
+
+	var out []string
+	seen := make(map[string]bool)
+	for _, c := range append(candidates, p.DefaultLanguage()) {
+		if c == "" || seen[c] || (len(declared) > 0 && !declared[c]) {
+			continue
+		}
+		seen[c] = true
+		out = append(out, c)
+	}
+	if !seen[p.DefaultLanguage()] {
+		out = append(out, p.DefaultLanguage())
+	}
+	return out
+}
+
+// normalizeLang turns locale strings like "ja_JP.UTF-8" into "ja-JP".
+func normalizeLang(lang string) string {
+	lang, _, _ = strings.Cut(strings.TrimSpace(lang), ".")
+	lang = strings.ReplaceAll(lang, "_", "-")
+	base, region, ok := strings.Cut(lang, "-")
+	if !ok {
+		return strings.ToLower(base)
+	}
+	return strings.ToLower(base) + "-" + strings.ToUpper(region)
+}
+
+// baseLang strips the region from a language tag: "pt-BR" => "pt".
+func baseLang(lang string) string {
+	base, _, _ := strings.Cut(lang, "-")
+	return base
+}
+
+// templateName picks <fileType>.<lang>.tmpl for the negotiated language
+// when the pack has it, else the default <fileType>.tmpl.
+func (g *Generator) templateName(fileType string) (string, error) {
+	name := fileType + ".tmpl"
+	if g.Lang == "" {
+		return name, nil
+	}
+	set, err := g.parsedTemplates()
+	if err != nil {
+		return "", err
+	}
+	for _, lang := range set.pack.Negotiate(g.Lang) {
+		if lang == set.pack.DefaultLanguage() {
+			break
+		}
+		localized := fileType + "." + lang + ".tmpl"
+		if _, ok := set.parsed[localized]; ok {
+			return localized, nil
+		}
+	}
+	return name, nil
+}
+
+// TemplateNames lists the templates at the root of the pack in use,
+// localized ones included, e.g. readme.tmpl and readme.ja.tmpl.
+func (g *Generator) TemplateNames() ([]string, error) {
+	fsys, err := g.pack()
+	if err != nil {
+		return nil, err
+	}
+	return fs.Glob(fsys, "*.tmpl")
+}
+
+// PackInfo reads pack.yaml from the template pack in use.
+// Packs without one are reported as "custom" at version 0.0.0.
+func (g *Generator) PackInfo() (*Pack, error) {
+	set, err := g.parsedTemplates()
+	if err != nil {
+		return nil, err
+	}
+	return set.pack, nil
+}
+
+// readPackInfo reads the pack.yaml of fsys.
+func readPackInfo(fsys fs.FS) (*Pack, error) {
+	data, err := fs.ReadFile(fsys, PackManifestName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Pack{Name: "custom", Version: "0.0.0"}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", PackManifestName, err)
+	}
+
+	var p Pack
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", PackManifestName, err)
+	}
+	if p.Version == "" {
+		p.Version = "0.0.0"
+	}
+	if !semver.Valid(p.Version) {
+		return nil, fmt.Errorf("invalid version %q in %s", p.Version, PackManifestName)
+	}
+	if err := p.checkHeaders(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", PackManifestName, err)
+	}
+	if err := p.checkVariables(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", PackManifestName, err)
+	}
+	return &p, nil
+}
@@ -0,0 +1,215 @@
+package project
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// PackVariable is a template variable a pack declares in pack.yaml, read
+// by its templates as {{.Vars.<name>}} and set like any other var, with gen
+// --var or a blueprint's vars. gen asks for those left unset, e.g.
+//
+//	variables:
+//	  - name: team
+//	    prompt: Team that owns the service
+//	    required: true
+//	    pattern: '[a-z][a-z0-9-]*'
+//	  - name: port
+//	    type: int
+//	    default: "8080"
+//	  - name: database
+//	    type: choice
+//	    choices: [postgres, mysql]
+//	    default: postgres
+type PackVariable struct {
+	Name     string   `yaml:"name" json:"name"`
+	Type     string   `yaml:"type,omitempty" json:"type,omitempty"`         // string (default), int, bool or choice
+	Prompt   string   `yaml:"prompt,omitempty" json:"prompt,omitempty"`     // the question gen asks; defaults to the name
+	Default  string   `yaml:"default,omitempty" json:"default,omitempty"`   // may use config fields, e.g. {{.ProjectName}}-db
+	Required bool     `yaml:"required,omitempty" json:"required,omitempty"` // must not be left empty
+	Pattern  string   `yaml:"pattern,omitempty" json:"pattern,omitempty"`   // regexp the whole value must match
+	Choices  []string `yaml:"choices,omitempty" json:"choices,omitempty"`   // the values a choice may take
+}
+
+// Variable types.
+const (
+	VarString = "string"
+	VarInt    = "int"
+	VarBool   = "bool"
+	VarChoice = "choice"
+)
+
+// varName is what {{.Vars.<name>}} can reach.
+var varName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// checkVariables rejects variables templates couldn't use or values
+// couldn't satisfy.
+func (p *Pack) checkVariables() error {
+	seen := make(map[string]bool)
+	for _, v := range p.Variables {
+		if !varName.MatchString(v.Name) {
+			return fmt.Errorf("variable %q: name must be a letter or _ followed by letters, digits or _", v.Name)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("variable %s is declared twice", v.Name)
+		}
+		seen[v.Name] = true
+		switch v.Type {
+		case "", VarString, VarInt, VarBool:
+		case VarChoice:
+			if len(v.Choices) == 0 {
+				return fmt.Errorf("variable %s: a choice needs choices", v.Name)
+			}
+		default:
+			return fmt.Errorf("variable %s: type must be %s, %s, %s or %s, not %q", v.Name, VarString, VarInt, VarBool, VarChoice, v.Type)
+		}
+		if _, err := v.pattern(); err != nil {
+			return fmt.Errorf("variable %s: %w", v.Name, err)
+		}
+		if v.Default != "" && !strings.Contains(v.Default, "{{") {
+			if _, err := v.Parse(v.Default); err != nil {
+				return fmt.Errorf("variable %s: default: %w", v.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// pattern compiles Pattern to match whole values, or returns nil without one.
+func (v PackVariable) pattern() (*regexp.Regexp, error) {
+	if v.Pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(`^(?:` + v.Pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re, nil
+}
+
+// Parse checks value against the variable's type, choices and pattern and
+// returns it as templates see it: ints without leading zeros or sign, and
+// bools, which also take yes and no, as true or false. An empty value
+// passes unless the variable is required.
+func (v PackVariable) Parse(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if v.Required {
+			return "", fmt.Errorf("a value is required")
+		}
+		return "", nil
+	}
+	switch v.Type {
+	case VarInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a whole number", value)
+		}
+		value = strconv.Itoa(n)
+	case VarBool:
+		switch strings.ToLower(value) {
+		case "y", "yes":
+			value = "true"
+		case "n", "no":
+			value = "false"
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%q is not true or false", value)
+		}
+		value = strconv.FormatBool(b)
+	case VarChoice:
+		if !slices.Contains(v.Choices, value) {
+			return "", fmt.Errorf("%q is not one of %s", value, strings.Join(v.Choices, ", "))
+		}
+	}
+	re, err := v.pattern()
+	if err != nil {
+		return "", err
+	}
+	if re != nil && !re.MatchString(value) {
+		return "", fmt.Errorf("%q doesn't match %s", value, v.Pattern)
+	}
+	return value, nil
+}
+
+// defaultValue renders Default over gc.
+func (v PackVariable) defaultValue(gc *GenConfig) (string, error) {
+	if !strings.Contains(v.Default, "{{") {
+		return v.Default, nil
+	}
+	tmpl, err := template.New(v.Name).Option("missingkey=error").Parse(v.Default)
+	if err != nil {
+		return "", fmt.Errorf("invalid default of variable %s: %w", v.Name, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, gc); err != nil {
+		return "", fmt.Errorf("invalid default of variable %s: %w", v.Name, err)
+	}
+	return b.String(), nil
+}
+
+// VarPrompt asks for the value of a pack variable, offering def, and
+// returns the answer; "" takes def.
+type VarPrompt func(v PackVariable, def string) (string, error)
+
+// ResolveVars gives each variable the pack declares a checked value in
+// g.Vars, and g.Config.Vars when there is a config. A value already set,
+// by --var, a blueprint or the manifest, is kept; an unset one is asked
+// for with ask, or takes its default when ask is nil. Required variables
+// still empty fail, named together.
+func (g *Generator) ResolveVars(ask VarPrompt) error {
+	pack, err := g.PackInfo()
+	if err != nil || len(pack.Variables) == 0 {
+		return err
+	}
+	gc := g.Config
+	if gc == nil {
+		gc = NewGenConfig("", "")
+	}
+
+	vars := maps.Clone(g.Vars)
+	if vars == nil {
+		vars = make(map[string]string)
+	}
+	var missing []string
+	for _, v := range pack.Variables {
+		value, ok := vars[v.Name]
+		if !ok {
+			def, err := v.defaultValue(gc)
+			if err != nil {
+				return err
+			}
+			value = def
+			if ask != nil {
+				answer, err := ask(v, def)
+				if err != nil {
+					return err
+				}
+				if answer != "" {
+					value = answer
+				}
+			}
+		}
+		if strings.TrimSpace(value) == "" && v.Required {
+			missing = append(missing, v.Name)
+			continue
+		}
+		if vars[v.Name], err = v.Parse(value); err != nil {
+			return fmt.Errorf("invalid variable %s: %w", v.Name, err)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("template pack %s requires %s; set them with --var name=value or a blueprint's vars", pack.Name, strings.Join(missing, ", "))
+	}
+	g.Vars = vars
+	if g.Config != nil {
+		g.Config.Vars = maps.Clone(vars)
+	}
+	return nil
+}
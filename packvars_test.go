@@ -0,0 +1,110 @@
+package project
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPackVariableParse(t *testing.T) {
+	tests := []struct {
+		v     PackVariable
+		value string
+		want  string
+		ok    bool
+	}{
+		{PackVariable{Name: "team"}, " core ", "core", true},
+		{PackVariable{Name: "team"}, "", "", true},
+		{PackVariable{Name: "team", Required: true}, "  ", "", false},
+		{PackVariable{Name: "team", Pattern: "[a-z]+"}, "core", "core", true},
+		{PackVariable{Name: "team", Pattern: "[a-z]+"}, "core-1", "", false},
+		{PackVariable{Name: "port", Type: VarInt}, "0080", "80", true},
+		{PackVariable{Name: "port", Type: VarInt}, "http", "", false},
+		{PackVariable{Name: "tls", Type: VarBool}, "yes", "true", true},
+		{PackVariable{Name: "tls", Type: VarBool}, "F", "false", true},
+		{PackVariable{Name: "tls", Type: VarBool}, "maybe", "", false},
+		{PackVariable{Name: "db", Type: VarChoice, Choices: []string{"postgres", "mysql"}}, "mysql", "mysql", true},
+		{PackVariable{Name: "db", Type: VarChoice, Choices: []string{"postgres", "mysql"}}, "oracle", "", false},
+	}
+	for _, tt := range tests {
+		got, err := tt.v.Parse(tt.value)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("%+v Parse(%q) = %q, %v; want %q, ok %v", tt.v, tt.value, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPackVariablesInvalid(t *testing.T) {
+	for _, vars := range []string{
+		"- name: team-name",
+		"- name: team\n- name: team",
+		"- name: port\n  type: float",
+		"- name: db\n  type: choice",
+		"- name: team\n  pattern: '[a-z'",
+		"- name: port\n  type: int\n  default: http",
+	} {
+		pack := fstest.MapFS{PackManifestName: {Data: []byte("name: p\nversion: 1.0.0\nvariables:\n" + yamlIndent(vars))}}
+		if _, err := readPackInfo(pack); err == nil || !strings.Contains(err.Error(), "invalid pack.yaml") {
+			t.Errorf("readPackInfo() of variables\n%s\nerror = %v, want invalid pack.yaml", vars, err)
+		}
+	}
+}
+
+func yamlIndent(s string) string {
+	return "  " + strings.ReplaceAll(s, "\n", "\n  ") + "\n"
+}
+
+func TestResolveVars(t *testing.T) {
+	pack := fstest.MapFS{PackManifestName: {Data: []byte(`name: p
+version: 1.0.0
+variables:
+  - name: team
+    required: true
+  - name: port
+    type: int
+    default: "8080"
+  - name: svc
+    default: "{{.ProjectName}}-svc"
+`)}}
+	newGen := func(vars map[string]string) *Generator {
+		return &Generator{Templates: pack, Config: NewGenConfig("github.com/example/demo", ""), Vars: vars}
+	}
+
+	g := newGen(nil)
+	if err := g.ResolveVars(nil); err == nil || !strings.Contains(err.Error(), "requires team") {
+		t.Errorf("ResolveVars() without team error = %v, want it required", err)
+	}
+
+	g = newGen(map[string]string{"team": "core", "port": "9090"})
+	if err := g.ResolveVars(nil); err != nil {
+		t.Fatalf("ResolveVars() error: %v", err)
+	}
+	want := map[string]string{"team": "core", "port": "9090", "svc": "demo-svc"}
+	if !maps.Equal(g.Vars, want) || !maps.Equal(g.Config.Vars, want) {
+		t.Errorf("ResolveVars() vars = %v, config vars = %v; want %v", g.Vars, g.Config.Vars, want)
+	}
+
+	// Only unset variables are asked for; no answer takes the default
+	var asked []string
+	g = newGen(map[string]string{"port": "9090"})
+	err := g.ResolveVars(func(v PackVariable, def string) (string, error) {
+		asked = append(asked, v.Name+"="+def)
+		if v.Name == "team" {
+			return "core", nil
+		}
+		return "", nil
+	})
+	if err != nil {
+		t.Fatalf("ResolveVars() error: %v", err)
+	}
+	if !slices.Equal(asked, []string{"team=", "svc=demo-svc"}) || !maps.Equal(g.Vars, want) {
+		t.Errorf("ResolveVars() asked %v and set %v; want team and svc asked, vars %v", asked, g.Vars, want)
+	}
+
+	g = newGen(map[string]string{"team": "core", "port": "http"})
+	if err := g.ResolveVars(nil); err == nil || !strings.Contains(err.Error(), "invalid variable port") {
+		t.Errorf("ResolveVars() with port=http error = %v, want it invalid", err)
+	}
+}
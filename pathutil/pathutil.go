@@ -0,0 +1,121 @@
+// Package pathutil expands and contracts user-facing paths such as
+// "~/shoes", "~alice/src" or "$HOME/dev" in a cross-platform way.
+//
+// Usage:
+//
+//	abs, _ := pathutil.Expand("~/shoes")  // /home/me/shoes
+//	short := pathutil.Contract(abs)        // ~/shoes
+package pathutil
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// Expand replaces a leading ~ or ~user with the matching home directory
+// and expands $VAR / ${VAR} environment references (and %VAR% on Windows).
+// The result is cleaned but not made absolute.
+func Expand(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", nil
+	}
+
+	path = expandEnv(path)
+
+	if !strings.HasPrefix(path, "~") {
+		return filepath.Clean(path), nil
+	}
+
+	// Split "~user/rest" into "user" and "rest"
+	rest := path[1:]
+	name := rest
+	if i := strings.IndexAny(rest, `/\`); i >= 0 {
+		name, rest = rest[:i], rest[i+1:]
+	} else {
+		rest = ""
+	}
+
+	home, err := homeFor(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, rest), nil
+}
+
+// MustExpand is like Expand but returns the input unchanged on error.
+func MustExpand(path string) string {
+	expanded, err := Expand(path)
+	if err != nil {
+		return path
+	}
+	return expanded
+}
+
+// Contract replaces the current user's home directory prefix with ~,
+// so paths are stored in a portable form, e.g. "/home/me/shoes" => "~/shoes".
+func Contract(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	clean := filepath.Clean(path)
+	if clean == home {
+		return "~"
+	}
+	prefix := home + string(filepath.Separator)
+	if strings.HasPrefix(clean, prefix) {
+		return "~/" + filepath.ToSlash(strings.TrimPrefix(clean, prefix))
+	}
+	return path
+}
+
+// homeFor returns the home directory of the named user, or the current user if empty.
+func homeFor(name string) (string, error) {
+	if name == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find home directory: %w", err)
+		}
+		return home, nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory for %s: %w", name, err)
+	}
+	return u.HomeDir, nil
+}
+
+// expandEnv expands $VAR and ${VAR}, plus %VAR% on Windows.
+func expandEnv(path string) string {
+	path = os.ExpandEnv(path)
+	if filepath.Separator != '\\' {
+		return path
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.Index(path, "%")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(path[start+1:], "%")
+		if end < 0 {
+			break
+		}
+		end += start + 1
+		name := path[start+1 : end]
+		if val, ok := os.LookupEnv(name); ok && name != "" {
+			b.WriteString(path[:start])
+			b.WriteString(val)
+		} else {
+			b.WriteString(path[:end+1])
+		}
+		path = path[end+1:]
+	}
+	b.WriteString(path)
+	return b.String()
+}
@@ -0,0 +1,44 @@
+package pathutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandContract(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("no home directory: %v", err)
+	}
+	t.Setenv("PATHUTIL_TEST_DIR", "data")
+
+	testCases := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"~", home},
+		{"~/shoes", filepath.Join(home, "shoes")},
+		{"$HOME/dev", filepath.Join(home, "dev")},
+		{"~/${PATHUTIL_TEST_DIR}/x", filepath.Join(home, "data", "x")},
+		{"relative/dir", filepath.Join("relative", "dir")},
+	}
+
+	for _, tc := range testCases {
+		got, err := Expand(tc.in)
+		if err != nil {
+			t.Fatalf("Expand(%q) failed: %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Errorf("Expand(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+
+	if got := Contract(filepath.Join(home, "shoes")); got != "~/shoes" {
+		t.Errorf("Contract(home/shoes) = %q, want ~/shoes", got)
+	}
+	if got := Contract("/elsewhere"); got != "/elsewhere" {
+		t.Errorf("Contract(/elsewhere) = %q, want unchanged", got)
+	}
+}
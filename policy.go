@@ -0,0 +1,191 @@
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy is an organization's rules for the projects it generates, which a
+// platform team publishes and points the policy config key at, e.g.
+//
+//	modules: ['^github\.com/acme/[a-z][a-z0-9-]*$']
+//	ci: github
+//	licenses: [MIT, Apache-2.0]
+//	features: {require: [coverage], forbid: [plugins]}
+//	forbidden_imports: [github.com/pkg/errors]
+//
+// GenerateAll checks it once the files are rendered, before writing any.
+type Policy struct {
+	// Modules are patterns the module path must match one of.
+	Modules []string `yaml:"modules,omitempty"`
+
+	// CI is the CI provider projects must use, github or gitlab; the
+	// project's host picks it.
+	CI string `yaml:"ci,omitempty"`
+
+	// Licenses are the SPDX ids a project may be licensed under: its
+	// license var, MIT unless a blueprint sets another.
+	Licenses []string `yaml:"licenses,omitempty"`
+
+	// Features every project must enable, and those none may.
+	Features struct {
+		Require []string `yaml:"require,omitempty"`
+		Forbid  []string `yaml:"forbid,omitempty"`
+	} `yaml:"features,omitempty"`
+
+	// ForbiddenImports are packages the generated code may not import,
+	// with any packages below them.
+	ForbiddenImports []string `yaml:"forbidden_imports,omitempty"`
+
+	// Source is the file or URL the policy came from.
+	Source string `yaml:"-"`
+
+	modules []*regexp.Regexp
+}
+
+// DefaultLicense is the license of projects that don't set a license var.
+const DefaultLicense = "MIT"
+
+// ParsePolicy decodes a policy file, rejecting keys it doesn't know so a
+// typo can't quietly switch a rule off.
+func ParsePolicy(data []byte, source string) (*Policy, error) {
+	p := &Policy{Source: source}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid policy %s: %w", source, err)
+	}
+	for _, pattern := range p.Modules {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy %s: modules: %w", source, err)
+		}
+		p.modules = append(p.modules, re)
+	}
+	if p.CI != "" && p.CI != "github" && p.CI != "gitlab" {
+		return nil, fmt.Errorf("invalid policy %s: ci is %q, want github or gitlab", source, p.CI)
+	}
+	for _, name := range append(slices.Clone(p.Features.Require), p.Features.Forbid...) {
+		if _, ok := featureFiles[name]; !ok {
+			return nil, fmt.Errorf("invalid policy %s: unknown feature %q", source, name)
+		}
+	}
+	return p, nil
+}
+
+// PolicyViolation is one rule a project breaks.
+type PolicyViolation struct {
+	Rule    string // the policy key, e.g. modules
+	Message string
+}
+
+// PolicyError lists every rule a project breaks.
+type PolicyError struct {
+	Source     string
+	Violations []PolicyViolation
+}
+
+func (e *PolicyError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "project breaks the policy of %s:", e.Source)
+	for _, v := range e.Violations {
+		fmt.Fprintf(&b, "\n  %s: %s", v.Rule, v.Message)
+	}
+	return b.String()
+}
+
+// Check reports, as a *PolicyError, every rule the project g.Config
+// describes breaks, given the files it renders to.
+func (p *Policy) Check(g *Generator, files []RenderedFile) error {
+	var out []PolicyViolation
+	fail := func(rule, format string, args ...any) {
+		out = append(out, PolicyViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(p.modules) > 0 && !slices.ContainsFunc(p.modules, func(re *regexp.Regexp) bool { return re.MatchString(g.Config.ModuleURL) }) {
+		fail("modules", "%s doesn't match %s", g.Config.ModuleURL, strings.Join(p.Modules, " or "))
+	}
+	if ci := strings.TrimSuffix(g.ciFileType(), "-ci"); p.CI != "" && ci != p.CI {
+		fail("ci", "projects on %s get %s CI, but %s is required", g.Config.Host, ci, p.CI)
+	}
+	license := g.Config.Vars["license"]
+	if license == "" {
+		license = DefaultLicense
+	}
+	if len(p.Licenses) > 0 && !slices.Contains(p.Licenses, license) {
+		fail("licenses", "%s is not approved; use one of %s (the license var)", license, strings.Join(p.Licenses, ", "))
+	}
+	for _, name := range p.Features.Require {
+		if !g.Config.Features[name] {
+			fail("features", "%s is required", name)
+		}
+	}
+	for _, name := range p.Features.Forbid {
+		if g.Config.Features[name] {
+			fail("features", "%s is not allowed", name)
+		}
+	}
+	if len(p.ForbiddenImports) > 0 {
+		for _, f := range files {
+			imports, err := goImports(f)
+			if err != nil {
+				return err
+			}
+			for _, imp := range imports {
+				if p.forbids(imp) {
+					fail("forbidden_imports", "%s imports %s", f.Path, imp)
+				}
+			}
+		}
+	}
+
+	if len(out) > 0 {
+		return &PolicyError{Source: p.Source, Violations: out}
+	}
+	return nil
+}
+
+// forbids reports whether imp is a forbidden import or below one.
+func (p *Policy) forbids(imp string) bool {
+	return slices.ContainsFunc(p.ForbiddenImports, func(banned string) bool {
+		return imp == banned || strings.HasPrefix(imp, banned+"/")
+	})
+}
+
+// goImports lists the packages a rendered Go file imports; other files
+// import nothing.
+func goImports(f RenderedFile) ([]string, error) {
+	if path.Ext(f.Path) != ".go" {
+		return nil, nil
+	}
+	r, err := f.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), f.Path, src, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the imports of %s: %w", f.Path, err)
+	}
+	var out []string
+	for _, spec := range file.Imports {
+		if imp, err := strconv.Unquote(spec.Path.Value); err == nil {
+			out = append(out, imp)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,91 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+func TestParsePolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name, data, want string
+	}{
+		{"unknown key", "licences: [MIT]\n", "field licences not found"},
+		{"bad pattern", "modules: ['(']\n", "modules:"},
+		{"bad ci", "ci: jenkins\n", `ci is "jenkins"`},
+		{"unknown feature", "features: {require: [nope]}\n", `unknown feature "nope"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(tc.data), "policy.yaml")
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("ParsePolicy() error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+	if p, err := ParsePolicy(nil, "empty.yaml"); err != nil || p.Source != "empty.yaml" {
+		t.Errorf("ParsePolicy(empty) = %+v, %v", p, err)
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	p, err := ParsePolicy([]byte(`
+modules: ['^github\.com/acme/']
+ci: gitlab
+licenses: [Apache-2.0]
+features: {require: [coverage], forbid: [bench]}
+forbidden_imports: [fmt]
+`), "acme.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &execx.Recorder{Respond: fakeGo}
+	g := &Generator{Runner: rec, Features: []string{"bench"}, Policy: p}
+	dir := t.TempDir()
+	err = g.GenerateAll("github.com/example/demo", dir)
+	var perr *PolicyError
+	if !errors.As(err, &perr) {
+		t.Fatalf("GenerateAll() error = %v, want a PolicyError", err)
+	}
+	rules := make(map[string]bool)
+	for _, v := range perr.Violations {
+		rules[v.Rule] = true
+	}
+	for _, rule := range []string{"modules", "ci", "licenses", "features", "forbidden_imports"} {
+		if !rules[rule] {
+			t.Errorf("no %s violation in %v", rule, perr)
+		}
+	}
+	if entries, _ := os.ReadDir(g.Config.ProjectPath()); len(entries) > 0 {
+		t.Errorf("GenerateAll() wrote %d files despite the policy", len(entries))
+	}
+
+	// A project that keeps to the policy passes, previewed or generated
+	p, err = ParsePolicy([]byte(`
+modules: ['^gitlab\.com/acme/']
+ci: gitlab
+licenses: [Apache-2.0]
+features: {require: [coverage]}
+forbidden_imports: [github.com/pkg/errors]
+`), "acme.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g = &Generator{
+		Runner:   &execx.Recorder{Respond: fakeGo},
+		Features: []string{"coverage"},
+		Vars:     map[string]string{"license": "Apache-2.0"},
+		Policy:   p,
+	}
+	if _, err := g.Preview("gitlab.com/acme/demo"); err != nil {
+		t.Errorf("Preview() error = %v, want none", err)
+	}
+	if err := g.GenerateAll("gitlab.com/acme/demo", t.TempDir()); err != nil {
+		t.Errorf("GenerateAll() error = %v, want none", err)
+	}
+	if _, err := g.Preview("github.com/acme/demo"); err == nil {
+		t.Errorf("Preview() passed github.com/acme/demo, want a modules violation")
+	}
+}
@@ -0,0 +1,192 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+	"github.com/robbyriverside/project/internal/gitops"
+)
+
+// PreflightIssue is one reason generation would be unsafe, with the paths involved.
+type PreflightIssue struct {
+	Reason string
+	Paths  []string
+}
+
+// PreflightError is returned when the output directory fails safety checks.
+type PreflightError struct {
+	Dir    string
+	Issues []PreflightIssue
+}
+
+func (e *PreflightError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "refusing to generate into %s (use --force to override):", e.Dir)
+	for _, issue := range e.Issues {
+		fmt.Fprintf(&b, "\n  %s", issue.Reason)
+		for _, p := range issue.Paths {
+			fmt.Fprintf(&b, "\n    %s", p)
+		}
+	}
+	return b.String()
+}
+
+// Preflight checks that generating g.Config won't silently merge into
+// existing work: the output directory must be empty, outside any other
+// Go module, and not inside a git repo with uncommitted changes.
+func (g *Generator) Preflight() error {
+	dir := g.Config.ProjectPath()
+	var issues []PreflightIssue
+
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		reason := "directory is not empty; these files would be overwritten or mixed in:"
+		if _, err := os.Stat(ManifestPath(dir)); err == nil {
+			reason = "directory already holds a generated project (use project update instead):"
+		}
+		issues = append(issues, PreflightIssue{
+			Reason: reason,
+			Paths:  g.conflictingPaths(dir, entries),
+		})
+	}
+
+	if mod := enclosingModule(filepath.Dir(dir)); mod != "" {
+		issues = append(issues, PreflightIssue{
+			Reason: "directory is inside an existing Go module:",
+			Paths:  []string{mod},
+		})
+	}
+
+	if root := gitops.Root(existingParent(dir)); root != "" {
+		if dirty, err := gitops.Dirty(root); err == nil && len(dirty) > 0 {
+			issues = append(issues, PreflightIssue{
+				Reason: fmt.Sprintf("git repo %s has uncommitted changes:", root),
+				Paths:  dirty,
+			})
+		}
+	}
+
+	if len(issues) > 0 {
+		return &PreflightError{Dir: dir, Issues: issues}
+	}
+	return nil
+}
+
+// PreflightInit is the check for project init, which generates into a
+// directory that may already hold work, such as a fresh clone with a
+// README and LICENSE. Only real conflicts count: a generated project
+// already there, files that would be overwritten with other content, a
+// go.mod for another module, or an enclosing Go module.
+func (g *Generator) PreflightInit() error {
+	dir := g.Config.ProjectPath()
+	var issues []PreflightIssue
+
+	if _, err := os.Stat(ManifestPath(dir)); err == nil {
+		issues = append(issues, PreflightIssue{
+			Reason: "directory already holds a generated project (use project update instead):",
+			Paths:  []string{ManifestName},
+		})
+	}
+
+	// Render the project as GenerateAll will, kind, features and all
+	if err := g.applyNew(); err != nil {
+		return err
+	}
+	files, err := g.RenderAll()
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for _, f := range files {
+		sum, err := fileChecksum(fileutils.OS, filepath.Join(dir, filepath.FromSlash(f.Path)))
+		if err == nil && sum != f.Checksum() {
+			paths = append(paths, f.Path)
+		}
+	}
+	if len(paths) > 0 {
+		sort.Strings(paths)
+		issues = append(issues, PreflightIssue{
+			Reason: "these files exist and would be overwritten:",
+			Paths:  paths,
+		})
+	}
+
+	if mod, err := readModulePath(dir); err == nil && mod != g.Config.ModuleURL {
+		issues = append(issues, PreflightIssue{
+			Reason: fmt.Sprintf("go.mod declares module %s, not %s:", mod, g.Config.ModuleURL),
+			Paths:  []string{"go.mod"},
+		})
+	}
+
+	if mod := enclosingModule(filepath.Dir(dir)); mod != "" {
+		issues = append(issues, PreflightIssue{
+			Reason: "directory is inside an existing Go module:",
+			Paths:  []string{mod},
+		})
+	}
+
+	if len(issues) > 0 {
+		return &PreflightError{Dir: dir, Issues: issues}
+	}
+	return nil
+}
+
+// conflictingPaths lists files the generator would overwrite, followed by
+// the other top-level entries already in dir.
+func (g *Generator) conflictingPaths(dir string, entries []os.DirEntry) []string {
+	var paths []string
+	covered := make(map[string]bool)
+	if files, err := g.RenderAll(); err == nil {
+		for _, f := range files {
+			if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(f.Path))); err == nil {
+				paths = append(paths, f.Path+" (overwritten)")
+				top, _, _ := strings.Cut(f.Path, "/")
+				covered[top] = true
+			}
+		}
+	}
+	sort.Strings(paths)
+	for _, e := range entries {
+		if covered[e.Name()] {
+			continue
+		}
+		name := e.Name()
+		if e.IsDir() {
+			name += "/"
+		}
+		paths = append(paths, name)
+	}
+	return paths
+}
+
+// enclosingModule returns the go.mod found in dir or any parent, or "".
+func enclosingModule(dir string) string {
+	for {
+		mod := filepath.Join(dir, "go.mod")
+		if _, err := os.Stat(mod); err == nil {
+			return mod
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// existingParent returns dir, or its closest ancestor that exists.
+func existingParent(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,179 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/gitops"
+)
+
+// fakeGit answers git as if repo were a repo with dirty uncommitted
+// paths, and nothing else were.
+func fakeGit(repo string, dirty ...string) execx.Runner {
+	return &execx.Recorder{Respond: func(c execx.Call) (execx.Result, error) {
+		if repo == "" || (c.Dir != repo && !strings.HasPrefix(c.Dir, repo+string(filepath.Separator))) {
+			return execx.Result{Stderr: []byte("fatal: not a git repository\n")}, errors.New("exit status 128")
+		}
+		switch c.Args[0] {
+		case "rev-parse":
+			return execx.Result{Stdout: []byte(repo + "\n")}, nil
+		case "status":
+			var out strings.Builder
+			for _, p := range dirty {
+				out.WriteString("?? " + p + "\n")
+			}
+			return execx.Result{Stdout: []byte(out.String())}, nil
+		}
+		return execx.Result{}, nil
+	}}
+}
+
+func TestPreflight(t *testing.T) {
+	defer func(r execx.Runner) { gitops.Runner = r }(gitops.Runner)
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		out     string // output directory inside the tree
+		repo    string // git repo root inside the tree, if any
+		dirty   []string
+		reasons []string
+		paths   []string
+	}{
+		{name: "missing directory", out: "demo"},
+		{name: "empty directory", files: map[string]string{"demo/.keep": ""}, out: "demo/sub"},
+		{
+			name:    "not empty",
+			files:   map[string]string{"demo/README.md": "mine", "demo/notes/todo.txt": "x"},
+			out:     "demo",
+			reasons: []string{"directory is not empty"},
+			paths:   []string{"README.md (overwritten)", "notes/"},
+		},
+		{
+			name:    "generated before",
+			files:   map[string]string{"demo/" + ManifestName: "module: github.com/example/demo\n"},
+			out:     "demo",
+			reasons: []string{"already holds a generated project"},
+		},
+		{
+			name:    "inside a module",
+			files:   map[string]string{"go.mod": "module github.com/example/mono\n"},
+			out:     "services/demo",
+			reasons: []string{"inside an existing Go module"},
+			paths:   []string{"go.mod"},
+		},
+		{
+			name:    "dirty repo",
+			files:   map[string]string{"work/.git/HEAD": ""},
+			out:     "work/demo",
+			repo:    "work",
+			dirty:   []string{"draft.txt"},
+			reasons: []string{"has uncommitted changes"},
+			paths:   []string{"draft.txt"},
+		},
+		{name: "clean repo", files: map[string]string{"work/.git/HEAD": ""}, out: "work/demo", repo: "work"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := writeTree(t, tt.files)
+			repo := ""
+			if tt.repo != "" {
+				repo = filepath.Join(root, tt.repo)
+			}
+			gitops.Runner = fakeGit(repo, tt.dirty...)
+
+			g := &Generator{Config: NewGenConfig("github.com/example/demo", "")}
+			g.Config.OutputDir = filepath.Join(root, filepath.FromSlash(tt.out))
+			err := g.Preflight()
+			if len(tt.reasons) == 0 {
+				if err != nil {
+					t.Errorf("Preflight() error: %v", err)
+				}
+				return
+			}
+			var pe *PreflightError
+			if !errors.As(err, &pe) {
+				t.Fatalf("Preflight() = %v, want a PreflightError", err)
+			}
+			var reasons, paths []string
+			for _, issue := range pe.Issues {
+				reasons = append(reasons, issue.Reason)
+				for _, p := range issue.Paths {
+					paths = append(paths, filepath.ToSlash(strings.TrimPrefix(p, root+string(filepath.Separator))))
+				}
+			}
+			for _, want := range tt.reasons {
+				if !slices.ContainsFunc(reasons, func(r string) bool { return strings.Contains(r, want) }) {
+					t.Errorf("Preflight() reasons %q, want one about %q", reasons, want)
+				}
+			}
+			for _, want := range tt.paths {
+				if !slices.Contains(paths, want) {
+					t.Errorf("Preflight() paths %q, want %q", paths, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPreflightInit(t *testing.T) {
+	defer func(r execx.Runner) { gitops.Runner = r }(gitops.Runner)
+	gitops.Runner = fakeGit("")
+
+	newGen := func(dir string) *Generator {
+		g := &Generator{Features: []string{"bench"}, Config: NewGenConfig("github.com/example/demo", "")}
+		g.Config.OutputDir = dir
+		return g
+	}
+
+	// A fresh clone's own files don't conflict; ones the project renders,
+	// including those of its features, do
+	dir := writeTree(t, map[string]string{
+		"NOTES.md":      "mine",
+		"README.md":     "# demo\n",
+		"bench_test.go": "package demo\n",
+	})
+	var pe *PreflightError
+	if err := newGen(dir).PreflightInit(); !errors.As(err, &pe) {
+		t.Fatalf("PreflightInit() = %v, want a PreflightError", err)
+	}
+	if len(pe.Issues) != 1 || !slices.Equal(pe.Issues[0].Paths, []string{"README.md", "bench_test.go"}) {
+		t.Errorf("PreflightInit() issues = %+v, want README.md and bench_test.go overwritten", pe.Issues)
+	}
+
+	// Files that already hold what would be rendered are fine
+	files, err := newGen(dir).Preview("github.com/example/demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if f.Path == "README.md" || f.Path == "bench_test.go" {
+			if err := os.WriteFile(filepath.Join(dir, f.Path), f.Data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := newGen(dir).PreflightInit(); err != nil {
+		t.Errorf("PreflightInit() over rendered files error: %v", err)
+	}
+
+	g := newGen(t.TempDir())
+	g.Features = []string{"nosuch"}
+	if err := g.PreflightInit(); err == nil {
+		t.Error("PreflightInit() with an unknown feature succeeded, want an error")
+	}
+
+	// A pack that fails to render fails the check
+	g = newGen(t.TempDir())
+	g.Features = nil
+	g.Templates = fstest.MapFS{PackManifestName: {Data: []byte("name: empty\nversion: 1.0.0\n")}}
+	if err := g.PreflightInit(); err == nil || !strings.Contains(err.Error(), "main.tmpl") {
+		t.Errorf("PreflightInit() with a pack missing main.tmpl = %v, want its render error", err)
+	}
+}
@@ -0,0 +1,150 @@
+package project
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed presets.yaml
+var shippedPresets []byte
+
+// Preset is a named bundle of a project kind and features.
+type Preset struct {
+	Name        string   `yaml:"-"`
+	Description string   `yaml:"description"`
+	Kind        string   `yaml:"kind,omitempty"`
+	Features    []string `yaml:"features,omitempty"`
+
+	// Templates is the template pack the preset generates from, as for
+	// --templates; empty means whichever pack is otherwise selected.
+	Templates string `yaml:"templates,omitempty"`
+
+	// Source is "shipped" or the file the preset was defined in.
+	Source string `yaml:"-"`
+}
+
+// PresetsName is the file a template pack may ship presets in, beside pack.yaml.
+const PresetsName = "presets.yaml"
+
+// Presets returns the shipped presets, then those of the template pack,
+// then those of g.Bundle, then those in userFile, later ones overriding
+// earlier ones by name, sorted by name. Missing pack, bundle or user
+// presets are not an error.
+func (g *Generator) Presets(userFile string) ([]Preset, error) {
+	shipped, err := ParsePresets(shippedPresets, "shipped")
+	if err != nil {
+		return nil, err
+	}
+	layers := [][]Preset{shipped}
+
+	fsys, err := g.pack()
+	if err != nil {
+		return nil, err
+	}
+	data, err := fs.ReadFile(fsys, PresetsName)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read pack presets: %w", err)
+	}
+	if err == nil {
+		pack, err := g.PackInfo()
+		if err != nil {
+			return nil, err
+		}
+		presets, err := ParsePresets(data, "pack "+pack.Name)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, presets)
+	}
+
+	var bundled string
+	if g.Bundle != "" {
+		bundled = filepath.Join(g.Bundle, PresetsName)
+	}
+	for _, file := range []string{bundled, userFile} {
+		if file == "" {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read presets: %w", err)
+		}
+		if err == nil {
+			presets, err := ParsePresets(data, file)
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, presets)
+		}
+	}
+	return mergePresets(layers...), nil
+}
+
+// ParsePresets reads a presets file: a map of preset names to presets.
+// source records where they came from, for display.
+func ParsePresets(data []byte, source string) ([]Preset, error) {
+	var m map[string]Preset
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse presets from %s: %w", source, err)
+	}
+	out := make([]Preset, 0, len(m))
+	for name, p := range m {
+		p.Name = strings.ToLower(strings.TrimSpace(name))
+		p.Source = source
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// mergePresets combines layers of presets, later layers winning by name.
+func mergePresets(layers ...[]Preset) []Preset {
+	set := make(map[string]Preset)
+	for _, layer := range layers {
+		for _, p := range layer {
+			set[p.Name] = p
+		}
+	}
+	out := make([]Preset, 0, len(set))
+	for _, p := range set {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// FindPreset returns the preset called name from presets.
+func FindPreset(presets []Preset, name string) (*Preset, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	names := make([]string, 0, len(presets))
+	for i := range presets {
+		if presets[i].Name == name {
+			return &presets[i], nil
+		}
+		names = append(names, presets[i].Name)
+	}
+	return nil, fmt.Errorf("unknown preset %q (known: %s)", name, strings.Join(names, ", "))
+}
+
+// Apply sets g's kind from the preset, unless g already names another one,
+// and adds the preset's features to those g requests. Unknown features
+// and ones the kind lacks are reported when g generates.
+func (p *Preset) Apply(g *Generator) error {
+	if p.Kind != "" {
+		kind := strings.ToLower(strings.TrimSpace(g.Kind))
+		if kind != "" && kind != p.Kind {
+			return fmt.Errorf("preset %s is for %s projects, not %s", p.Name, p.Kind, kind)
+		}
+		g.Kind = p.Kind
+	}
+	g.Features = append(append([]string(nil), p.Features...), g.Features...)
+	return nil
+}
@@ -2,13 +2,28 @@ package project
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io/fs"
+	"maps"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 	"text/template"
+	"time"
+
+	"github.com/robbyriverside/project/i18n"
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/fileutils"
+	"github.com/robbyriverside/project/internal/lockfile"
+	"github.com/robbyriverside/project/internal/metadata"
+	"github.com/robbyriverside/project/internal/naming"
+	"github.com/robbyriverside/project/internal/retry"
+	"github.com/robbyriverside/project/pathutil"
+	"go.uber.org/zap"
 )
 
 type GenConfig struct {
@@ -16,53 +31,508 @@ type GenConfig struct {
 	ProjectName string
 	OutputDir   string
 
+	// PackageName is ProjectName sanitized into a Go package identifier,
+	// e.g. "goshoes" for "go-shoes"; BinaryName names cmd/<BinaryName>.
+	PackageName string
+	BinaryName  string
+
+	// Binaries names every cmd/<name> of the project, BinaryName first.
+	// Per-binary templates such as main.tmpl render once for each, with
+	// BinaryName set to that binary.
+	Binaries []string
+
+	// Targets are the GOOS/GOARCH pairs build:all cross-compiles every
+	// binary for, into dist/.
+	Targets []Target
+
+	// EnvPrefix starts the environment variables that override config keys,
+	// e.g. "GO_SHOES" gives GO_SHOES_HOME for the home key.
+	EnvPrefix string
+
+	// Warnings explains any adjustments made to ProjectName.
+	Warnings []string
+
+	// Host and Owner come from the module path, e.g. "gitlab.com" and
+	// "org/group/subgroup" for gitlab.com/org/group/subgroup/tool.
+	// RepoURL is the https address of the repository, for docs and CI.
+	Host    string
+	Owner   string
+	RepoURL string
+
+	// Vars holds extra template variables, available as {{.Vars.name}}.
+	Vars map[string]string
+
+	// Kind is the kind of project, "cli", "library" or "tui"; see KnownKinds.
+	Kind string
+
+	// Features holds the enabled optional features, tested in templates
+	// with {{if .Features.bench}}.
+	Features map[string]bool
+
+	// Taskfiles names the component taskfiles, taskfiles/<name>.yaml,
+	// that Taskfile.yaml includes.
+	Taskfiles []string
+
+	// Vendor is set when the project commits its dependencies in vendor/.
+	Vendor bool
+
+	// GeneratorVersion, TemplatePack and TemplateVersion say what rendered
+	// the project, as recorded in the manifest; set when templates render.
+	GeneratorVersion string
+	TemplatePack     string
+	TemplateVersion  string
+
+	// Subcommands, ConfigFields and EnvTargets are what a blueprint adds to
+	// a CLI; see Generator.Subcommands. Subcommand and EnvTarget are the
+	// one being rendered by subcommand.tmpl and envtarget.tmpl.
+	Subcommands  []Subcommand
+	ConfigFields []ConfigField
+	EnvTargets   []EnvTarget
+	Subcommand   Subcommand
+	EnvTarget    EnvTarget
+
+	// Uses are the modules of sibling projects the project imports, each
+	// replaced by its local directory in go.mod.
+	Uses []ModuleUse
+
+	// Catalog is the project's service catalog entry, defaults filled in.
+	Catalog Catalog
+
 	// HomeDir is a default value referencing the project name,
-	// e.g. "~/shoes" if ProjectName="shoes"
+	// e.g. "~/shoes" if ProjectName="shoes". It stays in ~ form for templates;
+	// use HomeDirPath for the expanded location.
 	HomeDir string
 }
 
-// NewGenConfig derives ProjectName from the module URL, sets outDir to "." if empty,
-// and defaults HomeDir to "~/{ProjectName}"
+// NewGenConfig derives ProjectName (plus PackageName and BinaryName) from the module URL,
+// sets outDir to "." if empty, and defaults HomeDir to "~/{ProjectName}".
+// Module paths of any depth work: the repo is the last segment (ignoring a /vN
+// suffix) and everything between the host and the repo is the Owner.
 func NewGenConfig(moduleURL, outDir string) *GenConfig {
 	if outDir == "" {
 		outDir = "."
 	}
-	parts := strings.Split(strings.TrimSpace(moduleURL), "/")
-	name := parts[len(parts)-1]
+	name, moduleURL := metadata.DeriveModuleName(strings.TrimSpace(moduleURL))
 
-	return &GenConfig{
+	gc := &GenConfig{
 		ModuleURL:   moduleURL,
 		ProjectName: name,
 		OutputDir:   outDir,
+		PackageName: naming.PackageName(name),
+		BinaryName:  naming.BinaryName(name),
+		Binaries:    []string{naming.BinaryName(name)},
+		EnvPrefix:   naming.EnvPrefix(name),
+		Warnings:    naming.Warnings(name),
 		HomeDir:     fmt.Sprintf("~/%s", name),
+		Kind:        DefaultKind,
+	}
+	if m, err := metadata.Parse(moduleURL); err == nil {
+		gc.Host = m.Host
+		gc.Owner = m.Owner
+		gc.RepoURL = m.RepoURL()
 	}
+	return gc
+}
+
+// setName renames the project, and the package, binary, environment
+// prefix and home directory named after it.
+func (gc *GenConfig) setName(name string) {
+	gc.ProjectName = name
+	gc.PackageName = naming.PackageName(name)
+	gc.BinaryName = naming.BinaryName(name)
+	gc.Binaries = []string{gc.BinaryName}
+	gc.EnvPrefix = naming.EnvPrefix(name)
+	gc.Warnings = naming.Warnings(name)
+	gc.HomeDir = fmt.Sprintf("~/%s", name)
+}
+
+// ResolveOutputDir settles OutputDir as an absolute path. OutputDir may be
+// a template over the config, e.g. "~/src/{{.Owner}}/{{.ProjectName}}";
+// a leading ~ and env vars are expanded after it renders. Missing parent
+// directories are created when the project is written.
+func (gc *GenConfig) ResolveOutputDir() error {
+	dir := gc.OutputDir
+	if strings.Contains(dir, "{{") {
+		tmpl, err := template.New("dir").Option("missingkey=error").Parse(dir)
+		if err != nil {
+			return fmt.Errorf("invalid output directory %q: %w", dir, err)
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, gc); err != nil {
+			return fmt.Errorf("invalid output directory %q: %w", dir, err)
+		}
+		dir = b.String()
+	}
+	expanded, err := pathutil.Expand(dir)
+	if err != nil {
+		return fmt.Errorf("invalid output directory %q: %w", gc.OutputDir, err)
+	}
+	abs, err := filepath.Abs(expanded)
+	if err != nil {
+		return fmt.Errorf("invalid output directory %q: %w", gc.OutputDir, err)
+	}
+	gc.OutputDir = abs
+	return nil
 }
 
 // ProjectPath returns the absolute path where the new project folder goes.
+// A leading ~ and any env vars in OutputDir are expanded first.
 func (gc *GenConfig) ProjectPath() string {
-	abs, err := filepath.Abs(gc.OutputDir)
+	dir := pathutil.MustExpand(gc.OutputDir)
+	abs, err := filepath.Abs(dir)
 	if err != nil {
-		abs = gc.OutputDir // fallback
+		abs = dir // fallback
 	}
 	return abs
 }
 
+// SetVar overrides a string field by name (case-insensitive, e.g. "ProjectName"),
+// or sets an entry in Vars when no field matches.
+func (gc *GenConfig) SetVar(key, value string) {
+	rv := reflect.ValueOf(gc).Elem()
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		if strings.EqualFold(rt.Field(i).Name, key) && rv.Field(i).Kind() == reflect.String {
+			rv.Field(i).SetString(value)
+			return
+		}
+	}
+	if gc.Vars == nil {
+		gc.Vars = make(map[string]string)
+	}
+	gc.Vars[key] = value
+}
+
+// HomeDirPath returns HomeDir with ~ and env vars expanded.
+func (gc *GenConfig) HomeDirPath() string {
+	return pathutil.MustExpand(gc.HomeDir)
+}
+
 // Generator coordinates the template lookups and file generation.
 type Generator struct {
 	Config *GenConfig
+
+	// Templates is the template pack: *.tmpl files at its root are rendered,
+	// and anything under static/ is copied verbatim. Defaults to the embedded pack.
+	// Every template, and the partials under partials/, is parsed into one
+	// set the first time the generator needs it, so templates can use each
+	// other's definitions with {{template "name" .}}. A pack other than
+	// the embedded one is parsed again when its files change, for packs
+	// being edited while a long-lived generator uses them.
+	Templates fs.FS
+
+	// Bundle is the unpacked air-gapped bundle UseBundle set Templates
+	// from; its presets come between the pack's and the user's.
+	Bundle string
+
+	// Fsync forces every written file to be synced to disk.
+	Fsync bool
+
+	// FS holds the projects a run reads and writes; nil is the host. Tests
+	// and output that is only served or archived can set a
+	// fileutils.MemFS. go and the pack's hooks need the project on the
+	// host, so elsewhere go.mod is written directly and the steps that
+	// run them are skipped; see onHost.
+	FS fileutils.FS
+
+	// Lang selects localized templates (e.g. "ja") when the pack has them.
+	Lang string
+
+	// Kind picks the kind of project for a first generation, e.g. "library";
+	// later runs keep the kind recorded in the manifest.
+	Kind string
+
+	// Features enables optional components, e.g. "bench"; see KnownFeatures.
+	// They add to whatever the project's manifest already records.
+	Features []string
+
+	// Binaries names the cmd/<name> entry points, e.g. "server" and "ctl".
+	// On a first generation the first replaces the default binary; later
+	// ones, and any on update, add to those the manifest records.
+	Binaries []string
+
+	// Targets are GOOS/GOARCH pairs, e.g. "linux/arm64", for a build:all
+	// task that cross-compiles every binary into dist/ with checksums.
+	// They add to those the manifest records.
+	Targets []string
+
+	// Subcommands, ConfigFields and EnvTargets extend a CLI past the
+	// scaffold, usually from a Blueprint: a stub per command, registered in
+	// main.go, keys in the config package, and env/<name>.env plus a
+	// run:<name> task per target. They add to those the manifest records,
+	// replacing any of the same name.
+	Subcommands  []Subcommand
+	ConfigFields []ConfigField
+	EnvTargets   []EnvTarget
+
+	// Vars are template variables, {{.Vars.name}}, e.g. from a blueprint.
+	// Uses are the sibling projects of a BlueprintSet this one imports,
+	// replaced by their local directories in go.mod. Both add to those
+	// the manifest records.
+	Vars map[string]string
+	Uses []ModuleUse
+
+	// Catalog is the project's service catalog entry; its fields replace
+	// those the manifest records.
+	Catalog Catalog
+
+	// Results lists each file of the last run and whether it was written.
+	Results []FileResult
+
+	// HookPolicy permits and limits the pack's hooks; nil disables them.
+	HookPolicy *HookPolicy
+
+	// Policy, when set, holds the project to an organization's rules
+	// before any file is written.
+	Policy *Policy
+
+	// HookResults lists each pack hook of the last run and its output.
+	HookResults []HookResult
+
+	// BreakLock takes the project's lock even when another run holds it.
+	BreakLock bool
+
+	// StreamThreshold is the size in bytes above which rendered files are
+	// streamed to disk rather than buffered; zero means DefaultStreamThreshold.
+	StreamThreshold int64
+
+	// Clock is the time templates see through the year and now funcs;
+	// nil means time.Now.
+	Clock func() time.Time
+
+	// Vendor runs go mod vendor after tidy; the project then builds from
+	// VendorDir, which it commits. Updates keep a vendored project vendored.
+	Vendor bool
+
+	// Attest writes an SPDX SBOM and in-toto provenance into AttestDir.
+	// Once set, later runs on the project keep doing so.
+	Attest bool
+
+	// Headers puts a provenance header, marked safe to edit or DO NOT EDIT,
+	// at the top of the files the pack's headers rules match. Once set,
+	// later runs on the project keep doing so.
+	Headers bool
+
+	// Reproducible fixes Clock at SourceDateEpoch and stamps every written
+	// file with that time, so the same inputs give identical output.
+	Reproducible bool
+
+	// SkipSteps leaves out steps of GenerateAll and OnlySteps, when set,
+	// runs just those, e.g. to debug one stage; see StepNames.
+	SkipSteps []string
+	OnlySteps []string
+
+	// Context cancels a run: the go commands and hooks it starts are killed
+	// and no further files are written. Nil means context.Background.
+	Context context.Context
+
+	// GoEnv sets GOPROXY, GOPRIVATE and GONOSUMDB for the go commands the
+	// run starts, e.g. when the module lives on a private host.
+	GoEnv GoEnv
+
+	// Runner runs the go commands and pack hooks of a run; nil runs them on
+	// the host. Tests set an execx.Recorder to generate without a toolchain.
+	Runner execx.Runner
+
+	// GoTimeout limits each go command the run starts; zero means no limit.
+	// Commands records them, with their output, as they finish.
+	GoTimeout time.Duration
+	Commands  []CommandResult
+
+	// Retry repeats go mod tidy and vendor, and the forge API calls of
+	// CreateRemote, when they fail for network reasons; the zero Policy
+	// tries once.
+	Retry retry.Policy
+
+	// Events, when set, is called as the run goes with each step begun and
+	// ended, file written or left alone, and command and hook run, so a
+	// front end can show progress. It is called on the run's goroutine.
+	Events func(Event)
+
+	// Log receives a debug trace of the run: each template looked up and
+	// rendered, each file written, and each command started. Nil drops it.
+	Log *zap.SugaredLogger
+
+	prev      *Manifest
+	held      *lockfile.Lock
+	printer   *i18n.Printer
+	templates *templateSet // the pack, parsed; see parsedTemplates
+	rendering bool         // templates is in use and not to be reloaded
 }
 
-func (g *Generator) readTemplate(name string) (*template.Template, error) {
+// FileStatus describes what a run did to one file.
+type FileStatus string
+
+const (
+	StatusCreated   FileStatus = "created"
+	StatusUpdated   FileStatus = "updated"
+	StatusUnchanged FileStatus = "unchanged"
+	StatusSkipped   FileStatus = "skipped"
+	StatusKept      FileStatus = "kept"     // edited locally and marked safe to edit
+	StatusMerged    FileStatus = "merged"   // managed regions rewritten or YAML keys or Go code added, local edits kept
+	StatusConflict  FileStatus = "conflict" // Go code added with conflict markers to resolve by hand
+)
+
+// FileResult is the outcome for one generated file.
+type FileResult struct {
+	Path     string // slash-separated, relative to the project root
+	Template string
+	SHA256   string
+	Status   FileStatus
+}
+
+// ctx returns g.Context, or context.Background if unset.
+func (g *Generator) ctx() context.Context {
+	if g.Context != nil {
+		return g.Context
+	}
+	return context.Background()
+}
+
+// fsys is the filesystem of the run.
+func (g *Generator) fsys() fileutils.FS {
+	if g.FS != nil {
+		return g.FS
+	}
+	return fileutils.OS
+}
+
+// onHost reports whether the run writes to the host, where go and hooks
+// can run in the project.
+func (g *Generator) onHost() bool {
+	return g.fsys() == fileutils.OS
+}
+
+// pack returns the template pack in use, falling back to the embedded templates.
+func (g *Generator) pack() (fs.FS, error) {
+	if g.Templates != nil {
+		return g.Templates, nil
+	}
 	fsys, err := fs.Sub(templateFS, "templates")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+		return nil, fmt.Errorf("failed to open embedded templates: %w", err)
 	}
-	content, err := fs.ReadFile(fsys, name)
+	return fsys, nil
+}
+
+// templateSet is the pack a generator parsed: its manifest, and its
+// templates and partials parsed into one tree. A template that fails to
+// parse only fails the run if it is used.
+type templateSet struct {
+	fsys   fs.FS
+	pack   *Pack
+	root   *template.Template
+	parsed map[string]parsedTemplate
+	stamp  map[string]fileStamp // nil for the embedded pack, which can't change
+}
+
+type parsedTemplate struct {
+	tmpl *template.Template
+	err  error
+}
+
+// fileStamp is what tells that a pack file changed.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// partialsDir is the pack folder of templates that are only used by
+// others, through {{template}}, and never rendered as files themselves.
+const partialsDir = "partials"
+
+// packStamp returns the stamp of every file a templateSet is parsed from.
+func packStamp(fsys fs.FS) (map[string]fileStamp, error) {
+	names, err := templateFiles(fsys)
+	if err != nil {
+		return nil, err
+	}
+	stamp := make(map[string]fileStamp, len(names)+1)
+	for _, name := range append(names, PackManifestName) {
+		info, err := fs.Stat(fsys, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		stamp[name] = fileStamp{info.Size(), info.ModTime()}
+	}
+	return stamp, nil
+}
+
+// templateFiles lists the templates and partials of a pack.
+func templateFiles(fsys fs.FS) ([]string, error) {
+	names, err := fs.Glob(fsys, "*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	partials, err := fs.Glob(fsys, partialsDir+"/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	return append(names, partials...), nil
+}
+
+// stale reports whether the pack's files changed since it was parsed.
+func (s *templateSet) stale() bool {
+	if s.stamp == nil {
+		return false
+	}
+	stamp, err := packStamp(s.fsys)
+	return err != nil || !maps.Equal(stamp, s.stamp)
+}
+
+// parsedTemplates returns the pack in use, parsing it on first use and
+// again when it changed since.
+func (g *Generator) parsedTemplates() (*templateSet, error) {
+	if g.templates != nil && (g.rendering || !g.templates.stale()) {
+		return g.templates, nil
+	}
+	set, err := g.loadTemplates()
+	if err != nil {
+		return nil, err
+	}
+	g.templates = set
+	return set, nil
+}
+
+// loadTemplates reads and parses the pack.
+func (g *Generator) loadTemplates() (*templateSet, error) {
+	fsys, err := g.pack()
+	if err != nil {
+		return nil, err
+	}
+	set := &templateSet{fsys: fsys, root: template.New("").Funcs(g.funcs())}
+	if g.Templates != nil {
+		// Stamp before reading, so a change made while parsing shows next time
+		if set.stamp, err = packStamp(fsys); err != nil {
+			return nil, err
+		}
+	}
+	if set.pack, err = readPackInfo(fsys); err != nil {
+		return nil, err
+	}
+	names, err := templateFiles(fsys)
+	if err != nil {
+		return nil, err
+	}
+	set.parsed = make(map[string]parsedTemplate, len(names))
+	for _, name := range names {
+		tmpl, err := set.parse(name)
+		set.parsed[name] = parsedTemplate{tmpl, err}
+	}
+	return set, nil
+}
+
+// parse reads the template name of the pack into the set's tree.
+func (s *templateSet) parse(name string) (*template.Template, error) {
+	content, err := fs.ReadFile(s.fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read template %s: %w", name, err)
 	}
 
-	tmpl, err := template.New(name).Parse(string(content))
+	tmpl, err := s.root.New(name).Parse(string(content))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
 	}
@@ -70,95 +540,403 @@ func (g *Generator) readTemplate(name string) (*template.Template, error) {
 	return tmpl, nil
 }
 
+// withTemplates runs fn with the pack parsed and held for all of it, so
+// it is checked for changes once rather than for each file rendered.
+func (g *Generator) withTemplates(fn func() error) error {
+	if g.rendering {
+		return fn()
+	}
+	if _, err := g.parsedTemplates(); err != nil {
+		return err
+	}
+	g.rendering = true
+	defer func() { g.rendering = false }()
+	return fn()
+}
+
+// readTemplate returns the template name of the pack. Templates elsewhere
+// than the root and partials/, such as those a pack script names, are
+// parsed into the set on first use.
+func (g *Generator) readTemplate(name string) (*template.Template, error) {
+	set, err := g.parsedTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+	}
+	p, ok := set.parsed[name]
+	if !ok {
+		p.tmpl, p.err = set.parse(name)
+		set.parsed[name] = p
+	}
+	return p.tmpl, p.err
+}
+
 // GenerateAll creates the config and runs each file generation plus go mod steps.
 func (g *Generator) GenerateAll(moduleURL, outDir string) error {
 	// Build or update the config
 	g.Config = NewGenConfig(moduleURL, outDir)
-
-	// Add any file types you want to generate:
-	fileTypes := []string{"main", "config", "logs", "project", "taskfile"}
-
-	for _, ft := range fileTypes {
-		if err := g.GenerateFile(ft); err != nil {
-			return fmt.Errorf("failed to generate %s: %w", ft, err)
-		}
+	if err := g.Config.ResolveOutputDir(); err != nil {
+		return err
 	}
+	g.Results = nil
+	g.HookResults = nil
+	g.Commands = nil
 
-	// Post-process Taskfile.yaml
-	if err := g.postProcessTaskfile(); err != nil {
-		return fmt.Errorf("failed to post-process Taskfile.yaml: %w", err)
+	release, err := g.lock(g.Config.ProjectPath())
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := g.applyReproducible(); err != nil {
+		return err
 	}
 
-	// Finally do go mod init + tidy
-	if err := g.InitMod(); err != nil {
-		return fmt.Errorf("go mod init failed: %w", err)
+	// Remember what the last run wrote, so unchanged files can be skipped
+	prev, err := loadManifestIfExists(g.fsys(), g.Config.ProjectPath())
+	if err != nil {
+		return err
+	}
+	g.prev = prev
+	g.applyName(prev)
+	if prev != nil && prev.BinaryName != "" {
+		g.Config.BinaryName = prev.BinaryName
 	}
-	if err := g.addReplaceDirectives(); err != nil {
-		return fmt.Errorf("failed to add replace directives: %w", err)
+	if prev != nil && g.Lang == "" {
+		g.Lang = prev.Lang
 	}
-	if err := g.ModTidy(); err != nil {
-		return fmt.Errorf("go mod tidy failed: %w", err)
+	if prev != nil && prev.Attest {
+		g.Attest = true
+	}
+	if prev != nil && prev.Headers {
+		g.Headers = true
+	}
+	if err := g.applyKind(prev); err != nil {
+		return err
 	}
+	if err := g.applyFeatures(prev); err != nil {
+		return err
+	}
+	if err := g.applyBinaries(prev); err != nil {
+		return err
+	}
+	if err := g.applyTargets(prev); err != nil {
+		return err
+	}
+	if err := g.applyExtras(prev); err != nil {
+		return err
+	}
+	g.applyShared(prev)
+	if err := g.ResolveVars(nil); err != nil {
+		return err
+	}
+	g.applyCatalog(prev)
+	g.applyVendor(prev)
 
-	return nil
+	return g.runSteps()
 }
 
 // GenerateFile reads <fileType>.tmpl, executes it with g.Config, writes the result.
 func (g *Generator) GenerateFile(fileType string) error {
-	tplName := fileType + ".tmpl"
-	tpl, err := g.readTemplate(tplName)
+	f, err := g.Render(fileType)
+	if err != nil {
+		return err
+	}
+	if err := g.emit(*f); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", f.Path, err)
+	}
+	return nil
+}
+
+// emit writes one rendered file unless its content is already on disk,
+// and records the outcome in g.Results.
+func (g *Generator) emit(f RenderedFile) error {
+	destPath := filepath.Join(g.Config.ProjectPath(), filepath.FromSlash(f.Path))
+	sum := f.Checksum()
+
+	status := StatusCreated
+	if g.unchanged(f.Path, destPath, sum) {
+		status = StatusUnchanged
+	} else if merged, current, ok := g.mergeRegions(f, destPath); ok {
+		status = StatusUnchanged
+		if !bytes.Equal(merged, current) {
+			status = StatusMerged
+			if err := g.writeFile(destPath, merged, f.Mode); err != nil {
+				return err
+			}
+		}
+	} else if prev := g.keptEdits(f.Path, destPath); prev != nil {
+		status, sum = StatusKept, prev.SHA256
+	} else if merged, current, ok := g.mergeYAML(f, destPath); ok {
+		status = StatusUnchanged
+		if !bytes.Equal(merged, current) {
+			status = StatusMerged
+			if err := g.writeFile(destPath, merged, f.Mode); err != nil {
+				return err
+			}
+		}
+	} else if merged, current, conflicts, ok := g.mergeGo(f, destPath); ok {
+		status = StatusUnchanged
+		if !bytes.Equal(merged, current) {
+			status = StatusMerged
+			if err := g.writeFile(destPath, merged, f.Mode); err != nil {
+				return err
+			}
+		}
+		if conflicts > 0 {
+			status = StatusConflict
+		}
+	} else {
+		if _, err := g.fsys().Stat(destPath); err == nil {
+			status = StatusUpdated
+		}
+		if err := g.writeRendered(destPath, f); err != nil {
+			return err
+		}
+	}
+
+	g.log().Debugw("write", "path", f.Path, "status", status)
+	g.Results = append(g.Results, FileResult{Path: f.Path, Template: f.Template, SHA256: sum, Status: status})
+	g.event(Event{Kind: EventFile, Path: f.Path, Status: status})
+	return nil
+}
+
+// keptEdits returns the manifest entry of a file to leave alone: one
+// edited since the last run whose header marks it safe to edit.
+func (g *Generator) keptEdits(rel, destPath string) *ManifestFile {
+	prev := g.prev.File(rel)
+	if prev == nil || fileMarker(g.fsys(), destPath) != HeaderEditable {
+		return nil
+	}
+	if onDisk, err := fileChecksum(g.fsys(), destPath); err != nil || onDisk == prev.SHA256 {
+		return nil
+	}
+	return prev
+}
+
+// unchanged reports whether destPath already holds content with the given checksum.
+// If the previous manifest recorded the same hash and the file hasn't been touched
+// since the manifest was written, the file isn't read at all.
+func (g *Generator) unchanged(rel, destPath, sum string) bool {
+	info, err := g.fsys().Stat(destPath)
+	if err != nil {
+		return false
+	}
+	if prev := g.prev.File(rel); prev != nil && prev.SHA256 == sum {
+		mInfo, err := g.fsys().Stat(ManifestPath(g.Config.ProjectPath()))
+		if err == nil && !info.ModTime().After(mInfo.ModTime()) {
+			return true
+		}
+	}
+	existing, err := fileChecksum(g.fsys(), destPath)
+	return err == nil && existing == sum
+}
+
+// WriteManifest records the files of the current run in .project.yaml.
+func (g *Generator) WriteManifest() error {
+	pack, err := g.PackInfo()
+	if err != nil {
+		return err
+	}
+	m := &Manifest{
+		GeneratorVersion: Version,
+		TemplatePack:     pack.Name,
+		TemplateVersion:  pack.Version,
+		ModuleURL:        g.Config.ModuleURL,
+		ProjectName:      g.Config.ProjectName,
+		ProjectDir:       g.Config.ProjectPath(),
+		BinaryName:       g.Config.BinaryName,
+		Binaries:         g.recordedBinaries(),
+		Targets:          g.Targets,
+		HomeDir:          g.Config.HomeDir,
+		Lang:             g.Lang,
+		Kind:             g.Kind,
+		Features:         g.Features,
+		Adopted:          g.prev != nil && g.prev.Adopted,
+		Vendor:           g.Vendor,
+		Attest:           g.Attest,
+		Headers:          g.Headers,
+		Subcommands:      g.Subcommands,
+		ConfigFields:     g.ConfigFields,
+		EnvTargets:       g.EnvTargets,
+		Vars:             g.Vars,
+		Uses:             g.Uses,
+		Catalog:          g.Catalog,
+	}
+	for _, r := range g.Results {
+		if r.Status == StatusSkipped {
+			continue
+		}
+		m.Files = append(m.Files, ManifestFile{Path: r.Path, Template: r.Template, SHA256: r.SHA256})
+	}
+	out, err := m.Marshal()
 	if err != nil {
 		return err
 	}
 
-	// Execute
-	var buf bytes.Buffer
-	if err := tpl.Execute(&buf, g.Config); err != nil {
-		return fmt.Errorf("failed to execute template %s: %w", fileType, err)
+	path := ManifestPath(g.Config.ProjectPath())
+	if existing, err := g.fsys().ReadFile(path); err == nil && bytes.Equal(existing, out) {
+		return nil
 	}
+	return g.writeFile(path, out, fileutils.DefaultMode)
+}
+
+// writeFile routes every generated write through fileutils for atomic replacement.
+func (g *Generator) writeFile(path string, data []byte, mode os.FileMode) error {
+	return fileutils.WriteFile(path, data, mode, fileutils.WithFS(g.FS), fileutils.WithSync(g.Fsync))
+}
 
-	// Determine final output path
-	destPath := g.filePath(fileType)
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return fmt.Errorf("failed to mkdir for %s: %w", destPath, err)
+// writeRendered writes f to path, streaming large files through the same
+// temp-file-and-rename path instead of holding them in memory.
+func (g *Generator) writeRendered(path string, f RenderedFile) error {
+	if !f.Streamed() {
+		return g.writeFile(path, f.Data, f.Mode)
 	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return fileutils.WriteFrom(path, r, f.Mode, fileutils.WithFS(g.FS), fileutils.WithSync(g.Fsync))
+}
 
-	// Write result
-	if err := os.WriteFile(destPath, buf.Bytes(), 0644); err != nil {
-		return fmt.Errorf("failed to write file %s: %w", destPath, err)
+// fileMode chooses the permissions for newly created files of each type.
+// Existing files keep their mode when overwritten.
+func (g *Generator) fileMode(fileType string) os.FileMode {
+	switch fileType {
+	case "coverage":
+		return 0755
+	default:
+		return fileutils.DefaultMode
 	}
+}
 
+// CopyStatic copies the pack's static/ tree into the project, keeping relative paths.
+// Packs without a static/ folder are skipped.
+func (g *Generator) CopyStatic() error {
+	files, err := g.RenderStatic()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := g.emit(f); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", f.Template, err)
+		}
+	}
 	return nil
 }
 
 // filePath chooses the output location for each type of file.
 func (g *Generator) filePath(fileType string) string {
 	projPath := g.Config.ProjectPath()
+	if name, ok := strings.CutPrefix(fileType, taskfilePrefix); ok {
+		return filepath.Join(projPath, TaskfilesDir, name+".yaml")
+	}
 
 	switch fileType {
+	case "docs":
+		return filepath.Join(projPath, "cmd", g.Config.BinaryName, "docs.go")
 	case "main":
-		return filepath.Join(projPath, "cmd", g.Config.ProjectName, "main.go")
+		return filepath.Join(projPath, "cmd", g.Config.BinaryName, "main.go")
+	case "context":
+		return filepath.Join(projPath, "cmd", g.Config.BinaryName, "context.go")
+	case "about":
+		return filepath.Join(projPath, "cmd", g.Config.BinaryName, "about.go")
+	case "setup":
+		return filepath.Join(projPath, "cmd", g.Config.BinaryName, "setup.go")
+	case "env":
+		return filepath.Join(projPath, "cmd", g.Config.BinaryName, "env.go")
+	case "scaffold":
+		return filepath.Join(projPath, "cmd", g.Config.BinaryName, "scaffold.yaml")
+	case "smoke":
+		return filepath.Join(projPath, "cmd", g.Config.BinaryName, "smoke_test.go")
+	case "main_test":
+		return filepath.Join(projPath, "cmd", g.Config.BinaryName, "main_test.go")
 	case "config":
 		return filepath.Join(projPath, "config", "config.go")
+	case "config-remote":
+		return filepath.Join(projPath, "config", "remote.go")
 	case "logs":
 		return filepath.Join(projPath, "logs", "logs.go")
+	case "pathutil":
+		return filepath.Join(projPath, "pathutil", "pathutil.go")
 	case "taskfile":
 		return filepath.Join(projPath, "Taskfile.yaml")
+	case "goreleaser":
+		return filepath.Join(projPath, ".goreleaser.yaml")
+	case "gitignore":
+		return filepath.Join(projPath, ".gitignore")
+	case "air":
+		return filepath.Join(projPath, ".air.toml")
 	case "project":
-		return filepath.Join(projPath, g.Config.ProjectName+".go")
+		return filepath.Join(projPath, g.Config.PackageName+".go")
+	case "readme":
+		return filepath.Join(projPath, "README.md")
+	case "contributing":
+		return filepath.Join(projPath, "CONTRIBUTING.md")
+	case "bench":
+		return filepath.Join(projPath, "bench_test.go")
+	case "catalog":
+		return filepath.Join(projPath, "catalog-info.yaml")
+	case "doc":
+		return filepath.Join(projPath, "doc.go")
+	case "configdoc":
+		return filepath.Join(projPath, "docs", "CONFIG.md")
+	case "envexample":
+		return filepath.Join(projPath, ".env.example")
+	case "example":
+		return filepath.Join(projPath, "example_test.go")
+	case "plugins":
+		return filepath.Join(projPath, "cmd", g.Config.BinaryName, "plugins.go")
+	case "serve":
+		return filepath.Join(projPath, "cmd", g.Config.BinaryName, "serve.go")
+	case "app":
+		return filepath.Join(projPath, "app", "app.go")
+	case "app-store":
+		return filepath.Join(projPath, "app", "store.go")
+	case "app-server":
+		return filepath.Join(projPath, "app", "server.go")
+	case "wire":
+		return filepath.Join(projPath, "app", "wire.go")
+	case "wire-gen":
+		return filepath.Join(projPath, "app", "wire_gen.go")
+	case "tui":
+		return filepath.Join(projPath, "ui", "ui.go")
+	case "tui-keys":
+		return filepath.Join(projPath, "ui", "keys.go")
+	case "tui-styles":
+		return filepath.Join(projPath, "ui", "styles.go")
+	case "tui-home":
+		return filepath.Join(projPath, "ui", "home.go")
+	case "tui-command":
+		return filepath.Join(projPath, "cmd", g.Config.BinaryName, "ui.go")
+	case "plugin-example":
+		return filepath.Join(projPath, "cmd", g.Config.BinaryName+"-hello", "main.go")
+	case "fuzz":
+		return filepath.Join(projPath, "pathutil", "fuzz_test.go")
+	case "wasm":
+		return filepath.Join(projPath, "cmd", g.Config.BinaryName+"-wasm", "main.go")
+	case "wasm-html":
+		return filepath.Join(projPath, "web", "index.html")
+	case "coverage":
+		return filepath.Join(projPath, "scripts", "coverage-check.sh")
+	case "itest":
+		return filepath.Join(projPath, "itest", "itest.go")
+	case "itest-test":
+		return filepath.Join(projPath, "itest", "itest_test.go")
+	case "github-ci":
+		return filepath.Join(projPath, ".github", "workflows", "ci.yml")
+	case "gitlab-ci":
+		return filepath.Join(projPath, ".gitlab-ci.yml")
+	case "subcommand":
+		return filepath.Join(projPath, "cmd", g.Config.Subcommand.Binary, g.Config.Subcommand.File())
+	case "envtarget":
+		return filepath.Join(projPath, "env", g.Config.EnvTarget.Name+".env")
 	default:
 		return filepath.Join(projPath, fileType+".go")
 	}
 }
 
-// postProcessTaskfile replaces VAR: patterns with task variables in the generated Taskfile.yaml
-func (g *Generator) postProcessTaskfile() error {
-	taskfilePath := filepath.Join(g.Config.ProjectPath(), "Taskfile.yaml")
-	content, err := os.ReadFile(taskfilePath)
-	if err != nil {
-		return fmt.Errorf("failed to read Taskfile: %w", err)
-	}
-
+// postProcessTaskfile replaces VAR: patterns with task variables in the rendered Taskfile.yaml
+func postProcessTaskfile(content []byte) []byte {
 	// Replace all VAR: patterns with task variables
 	replacements := []struct{ old, new string }{
 		{"VAR:VERSION", "{{.VERSION}}"},
@@ -175,12 +953,7 @@ func (g *Generator) postProcessTaskfile() error {
 	for _, r := range replacements {
 		newContent = strings.ReplaceAll(newContent, r.old, r.new)
 	}
-
-	if err := os.WriteFile(taskfilePath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write Taskfile: %w", err)
-	}
-
-	return nil
+	return []byte(newContent)
 }
 
 // InitMod runs `go mod init <moduleURL>` in the project folder
@@ -189,22 +962,29 @@ func (g *Generator) InitMod() error {
 	modPath := filepath.Join(pp, "go.mod")
 	
 	// Check if go.mod already exists
-	if _, err := os.Stat(modPath); err == nil {
+	if _, err := g.fsys().Stat(modPath); err == nil {
 		// go.mod exists, skip initialization
 		return nil
 	} else if !os.IsNotExist(err) {
 		// Some other error occurred
 		return fmt.Errorf("failed to check for go.mod: %w", err)
 	}
-	
-	cmd := exec.Command("go", "mod", "init", g.Config.ModuleURL)
-	cmd.Dir = pp
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to run go mod init: %w", err)
+
+	// go can't see a project off the host, so write what go mod init would
+	if !g.onHost() {
+		return g.writeFile(modPath, []byte(modFile(g.Config.ModuleURL)), fileutils.DefaultMode)
 	}
-	return nil
+	return g.goCmd("mod", "init", g.Config.ModuleURL)
+}
+
+// modFile is a new go.mod for module, declaring the go version the
+// generator was built with.
+func modFile(module string) string {
+	out := "module " + module + "\n"
+	if v, ok := strings.CutPrefix(runtime.Version(), "go"); ok && strings.HasPrefix(v, "1.") {
+		out += "\ngo " + strings.Fields(v)[0] + "\n"
+	}
+	return out
 }
 
 // addReplaceDirectives adds replace directives to go.mod for local packages
@@ -212,7 +992,7 @@ func (g *Generator) addReplaceDirectives() error {
 	pp := g.Config.ProjectPath()
 	modPath := filepath.Join(pp, "go.mod")
 
-	content, err := os.ReadFile(modPath)
+	content, err := g.fsys().ReadFile(modPath)
 	if err != nil {
 		return fmt.Errorf("failed to read go.mod: %w", err)
 	}
@@ -229,7 +1009,7 @@ replace (
 
 	if !strings.Contains(string(content), "replace (") {
 		newContent := string(content) + replaces
-		if err := os.WriteFile(modPath, []byte(newContent), 0644); err != nil {
+		if err := g.writeFile(modPath, []byte(newContent), fileutils.DefaultMode); err != nil {
 			return fmt.Errorf("failed to write go.mod: %w", err)
 		}
 	}
@@ -239,10 +1019,5 @@ replace (
 
 // ModTidy runs `go mod tidy` in the project folder
 func (g *Generator) ModTidy() error {
-	pp := g.Config.ProjectPath()
-	cmd := exec.Command("go", "mod", "tidy")
-	cmd.Dir = pp
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return g.goNet("mod", "tidy")
 }
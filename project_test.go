@@ -0,0 +1,88 @@
+package project
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolveOutputDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SRC", filepath.Join(home, "code"))
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dir     string
+		want    string
+		wantErr string
+	}{
+		{dir: "", want: wd},
+		{dir: "out", want: filepath.Join(wd, "out")},
+		{dir: "~/src/{{.Owner}}/{{.ProjectName}}", want: filepath.Join(home, "src", "example", "demo")},
+		{dir: "$SRC/{{.Host}}", want: filepath.Join(home, "code", "github.com")},
+		{dir: "~", want: home},
+		{dir: "{{.Owner", wantErr: "invalid output directory"},
+		{dir: "{{.Nope}}", wantErr: "invalid output directory"},
+		{dir: "~nosuchuser-x/demo", wantErr: "invalid output directory"},
+	}
+	for _, tt := range tests {
+		gc := NewGenConfig("github.com/example/demo", tt.dir)
+		err := gc.ResolveOutputDir()
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ResolveOutputDir(%q) = %v, want an error about %q", tt.dir, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || gc.OutputDir != tt.want {
+			t.Errorf("ResolveOutputDir(%q) = %q, %v; want %q", tt.dir, gc.OutputDir, err, tt.want)
+		}
+	}
+}
+
+func TestCopyStatic(t *testing.T) {
+	pack := fstest.MapFS{
+		"pack.yaml":                 {Data: []byte("name: static-pack\nversion: 1.0.0\n")},
+		"main.tmpl":                 {Data: []byte("package main\n")},
+		"static/.gitattributes":     {Data: []byte("* text=auto\n"), Mode: 0o444},
+		"static/scripts/install.sh": {Data: []byte("#!/bin/sh\n"), Mode: 0o555},
+		"static/assets/logo.svg":    {Data: []byte("<svg/>\n"), Mode: 0o640},
+	}
+	g := &Generator{Templates: pack, Config: NewGenConfig("github.com/example/demo", t.TempDir())}
+	if err := g.CopyStatic(); err != nil {
+		t.Fatalf("CopyStatic() error: %v", err)
+	}
+
+	pp := g.Config.ProjectPath()
+	want := map[string]fs.FileMode{".gitattributes": 0o644, "scripts/install.sh": 0o755, "assets/logo.svg": 0o640}
+	for name, mode := range want {
+		p := filepath.Join(pp, filepath.FromSlash(name))
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Errorf("%s wasn't copied: %v", name, err)
+			continue
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("%s mode = %v, want %v", name, info.Mode().Perm(), mode)
+		}
+		if data, _ := os.ReadFile(p); string(data) != string(pack["static/"+name].Data) {
+			t.Errorf("%s = %q, want it verbatim", name, data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(pp, "main.go")); !os.IsNotExist(err) {
+		t.Errorf("CopyStatic() rendered a template: %v", err)
+	}
+
+	// A pack without static/ copies nothing
+	g = &Generator{Templates: fstest.MapFS{"main.tmpl": pack["main.tmpl"]}, Config: NewGenConfig("github.com/example/demo", t.TempDir())}
+	if err := g.CopyStatic(); err != nil {
+		t.Errorf("CopyStatic() without static/ error: %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package project
+
+import (
+	"bytes"
+
+	"github.com/robbyriverside/project/internal/regions"
+)
+
+// mergeRegions returns what to write for a file edited since the last run
+// whose template has managed regions: the file as the user left it, with
+// the regions' bodies from f. ok is false when the file wasn't edited, or
+// has no regions or lost some, and the usual rules apply.
+func (g *Generator) mergeRegions(f RenderedFile, destPath string) (merged, current []byte, ok bool) {
+	prev := g.prev.File(f.Path)
+	if prev == nil || f.Streamed() || !bytes.Contains(f.Data, []byte("project:begin ")) {
+		return nil, nil, false
+	}
+	current, err := g.fsys().ReadFile(destPath)
+	if err != nil || checksum(current) == prev.SHA256 {
+		return nil, nil, false
+	}
+	merged, err = regions.Merge(current, f.Data)
+	if err != nil {
+		g.log().Debugw("regions", "path", f.Path, "error", err)
+		return nil, nil, false
+	}
+	return merged, current, true
+}
@@ -0,0 +1,132 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+func TestMergeRegions(t *testing.T) {
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	dir := t.TempDir()
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	mainGo := filepath.Join(g.Config.ProjectPath(), "cmd", "demo", "main.go")
+	data, err := os.ReadFile(mainGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The user registers a command of their own and breaks a built-in one
+	edited := strings.Replace(string(data), "  // project:end commands\n", "  // project:end commands\n  parser.AddCommand(\"mine\", \"\", \"\", &MineCommand{})\n", 1)
+	edited = strings.Replace(edited, `"Show version info"`, `"broken"`, 1)
+	if err := os.WriteFile(mainGo, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g = &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("second GenerateAll() error: %v", err)
+	}
+	for _, r := range g.Results {
+		if r.Path == "cmd/demo/main.go" && r.Status != StatusMerged {
+			t.Errorf("main.go status = %s, want %s", r.Status, StatusMerged)
+		}
+	}
+	merged, _ := os.ReadFile(mainGo)
+	if !strings.Contains(string(merged), "&MineCommand{}") {
+		t.Error("update dropped the user's command")
+	}
+	if strings.Contains(string(merged), `"broken"`) || !strings.Contains(string(merged), `"Show version info"`) {
+		t.Error("update didn't restore the built-in commands")
+	}
+
+	// Without its markers the file is rewritten as before
+	if err := os.WriteFile(mainGo, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("third GenerateAll() error: %v", err)
+	}
+	if data, _ := os.ReadFile(mainGo); string(data) == "package main\n" {
+		t.Error("main.go without markers was not rewritten")
+	}
+}
+
+func TestMergeYAML(t *testing.T) {
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	dir := t.TempDir()
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	taskfile := filepath.Join(g.Config.ProjectPath(), "Taskfile.yaml")
+	data, err := os.ReadFile(taskfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The user changes a task and adds one of their own
+	edited := strings.Replace(string(data), "desc: Run the CLI\n", "desc: Run the CLI # mine\n", 1) + "\n  deploy:\n    cmds:\n      - ./deploy.sh\n"
+	if err := os.WriteFile(taskfile, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Then updates with a feature whose tasks the Taskfile includes
+	g = &Generator{Runner: &execx.Recorder{Respond: fakeGo}, Features: []string{"bench"}}
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("second GenerateAll() error: %v", err)
+	}
+	for _, r := range g.Results {
+		if r.Path == "Taskfile.yaml" && r.Status != StatusMerged {
+			t.Errorf("Taskfile.yaml status = %s, want %s", r.Status, StatusMerged)
+		}
+	}
+	merged, _ := os.ReadFile(taskfile)
+	if !strings.HasPrefix(string(merged), edited) {
+		t.Errorf("update changed the user's Taskfile.yaml:\n%s", merged)
+	}
+	if !strings.Contains(string(merged), "\nincludes:\n  bench:\n    taskfile: ./taskfiles/bench.yaml\n") {
+		t.Errorf("update didn't add the bench include:\n%s", merged)
+	}
+}
+
+func TestMergeGo(t *testing.T) {
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	dir := t.TempDir()
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	configGo := filepath.Join(g.Config.ProjectPath(), "config", "config.go")
+	data, err := os.ReadFile(configGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The user adds a function of their own to the config package
+	edited := string(data) + "\n// Mine is the user's.\nfunc Mine() string { return \"mine\" }\n"
+	if err := os.WriteFile(configGo, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Then updates with a blueprint field the Config struct gains
+	g = &Generator{Runner: &execx.Recorder{Respond: fakeGo}, ConfigFields: []ConfigField{{Name: "MaxRetries", Type: "int"}}}
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("second GenerateAll() error: %v", err)
+	}
+	for _, r := range g.Results {
+		if r.Path == "config/config.go" && r.Status != StatusMerged {
+			t.Errorf("config.go status = %s, want %s", r.Status, StatusMerged)
+		}
+	}
+	merged, _ := os.ReadFile(configGo)
+	if !strings.Contains(string(merged), "func Mine() string { return \"mine\" }") {
+		t.Errorf("update lost the user's function:\n%s", merged)
+	}
+	if !strings.Contains(string(merged), "\tMaxRetries string `yaml:\"max_retries\"") {
+		t.Errorf("update didn't add the MaxRetries field:\n%s", merged)
+	}
+}
@@ -0,0 +1,126 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/robbyriverside/project/internal/forge"
+	"github.com/robbyriverside/project/internal/gitops"
+)
+
+// ProtectionName is the optional pack file holding branch protection rules.
+const ProtectionName = "protection.yaml"
+
+// RemoteOptions configures repository creation for --create-remote.
+type RemoteOptions struct {
+	Token       string
+	Description string
+	Private     bool
+}
+
+// CreateRemote creates the repository for g.Config on its forge, commits the
+// generated project (initializing git if needed) and pushes it. A project
+// inside another git repo is refused, since that repo would be pushed.
+// It returns the clone URL of the new repository.
+func (g *Generator) CreateRemote(opts RemoteOptions) (string, error) {
+	if opts.Token == "" {
+		return "", fmt.Errorf("no API token for %s", g.Config.Host)
+	}
+	branch, err := g.defaultBranch()
+	if err != nil {
+		return "", err
+	}
+	dir := g.Config.ProjectPath()
+	root := gitops.Root(dir)
+	if root != "" && !sameDir(root, dir) {
+		return "", fmt.Errorf("%s is inside the git repo %s; its commit and push would take the whole repo, so generate the project outside it", dir, root)
+	}
+
+	f := forge.For(g.ctx(), g.Config.Host, opts.Token, g.Retry)
+	cloneURL, err := f.CreateRepo(forge.Repo{
+		Owner:       g.Config.Owner,
+		Name:        g.Config.ProjectName,
+		Description: opts.Description,
+		Private:     opts.Private,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create repository: %w", err)
+	}
+
+	if root == "" {
+		if err := gitops.Init(dir, branch); err != nil {
+			return cloneURL, err
+		}
+	}
+	if err := gitops.CommitAll(dir, fmt.Sprintf("Initial scaffold from project %s", Version)); err != nil {
+		return cloneURL, err
+	}
+	if err := gitops.SetRemote(dir, "origin", cloneURL); err != nil {
+		return cloneURL, err
+	}
+	if err := gitops.Push(dir, "origin", branch, f.PushAuth()); err != nil {
+		return cloneURL, err
+	}
+	return cloneURL, nil
+}
+
+// sameDir reports whether a and b name the same directory, through
+// symlinks such as macOS's /tmp.
+func sameDir(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	return err == nil && os.SameFile(ai, bi)
+}
+
+// ProtectRemote applies the pack's protection.yaml to the repository's default branch.
+// Packs without one are skipped.
+func (g *Generator) ProtectRemote(token string) error {
+	p, err := g.protection()
+	if err != nil || p == nil {
+		return err
+	}
+	f := forge.For(g.ctx(), g.Config.Host, token, g.Retry)
+	if err := f.Protect(g.Config.Owner, g.Config.ProjectName, *p); err != nil {
+		return fmt.Errorf("failed to protect branch %s: %w", p.Branch, err)
+	}
+	return nil
+}
+
+// defaultBranch is the protected branch from the pack, or "main".
+func (g *Generator) defaultBranch() (string, error) {
+	p, err := g.protection()
+	if err != nil {
+		return "", err
+	}
+	if p == nil {
+		return "main", nil
+	}
+	return p.Branch, nil
+}
+
+// protection reads the pack's branch protection rules, or nil if it has none.
+func (g *Generator) protection() (*forge.Protection, error) {
+	fsys, err := g.pack()
+	if err != nil {
+		return nil, err
+	}
+	data, err := fs.ReadFile(fsys, ProtectionName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ProtectionName, err)
+	}
+	p := forge.Protection{Branch: "main"}
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ProtectionName, err)
+	}
+	return &p, nil
+}
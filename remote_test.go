@@ -0,0 +1,29 @@
+package project
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/gitops"
+)
+
+func TestCreateRemoteInsideRepo(t *testing.T) {
+	defer func(r execx.Runner) { gitops.Runner = r }(gitops.Runner)
+	root := writeTree(t, map[string]string{"services/demo/go.mod": "module github.com/example/demo\n"})
+	rec := fakeGit(root).(*execx.Recorder)
+	gitops.Runner = rec
+
+	// Refused before the forge is asked for a repository
+	g := &Generator{Config: NewGenConfig("github.com/example/demo", filepath.Join(root, "services", "demo"))}
+	_, err := g.CreateRemote(RemoteOptions{Token: "t"})
+	if err == nil || !strings.Contains(err.Error(), "inside the git repo "+root) {
+		t.Fatalf("CreateRemote() = %v, want it refused inside %s", err, root)
+	}
+	for _, c := range rec.Calls() {
+		if c.Args[0] != "rev-parse" {
+			t.Errorf("CreateRemote() ran %s inside another repo", c)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/codemod"
+	"github.com/robbyriverside/project/internal/fileutils"
+	"github.com/robbyriverside/project/internal/naming"
+)
+
+// RenameChange is one file a Rename moves or edits.
+type RenameChange struct {
+	From string // slash path before the rename
+	Path string // slash path after it; From unless the file moves
+
+	Old, New []byte // content before and after; equal unless edited
+	Mode     fs.FileMode
+}
+
+// Moved reports whether the file moves.
+func (c RenameChange) Moved() bool { return c.From != c.Path }
+
+// Edited reports whether the file's content changes.
+func (c RenameChange) Edited() bool { return !bytes.Equal(c.Old, c.New) }
+
+// Rename renames the project in projectDir to newName: its package, and
+// its main binary when that was named after the project, so <old>.go and
+// cmd/<old> move and the Taskfile, README and other generated files name
+// the new binary. The module path is left alone; see RenameModule.
+//
+// Files that are as the templates render them are rendered anew under the
+// new name. Go files that were edited only have the package renamed, in
+// its clause and where other packages of the module use it, and other
+// edited text files have the old names replaced where they stand as
+// words, so user code is never rewritten past what the rename needs.
+// The manifest follows, keeping untouched files untouched for update.
+//
+// With dryRun nothing is written. It returns the changes either way.
+func (g *Generator) Rename(projectDir, newName string, dryRun bool) ([]RenameChange, error) {
+	m, err := g.openProject(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	if newName == "" || naming.PackageName(newName) == "" || strings.ContainsAny(newName, `/\ `) {
+		return nil, fmt.Errorf("invalid project name %q", newName)
+	}
+	if newName == g.Config.ProjectName {
+		return nil, fmt.Errorf("the project is already named %s", newName)
+	}
+	dir := g.Config.ProjectPath()
+	release, err := g.lock(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Render under both names, to tell which files are as generated and
+	// what they become
+	oldFiles, err := g.RenderAll()
+	if err != nil {
+		return nil, err
+	}
+	old := *g.Config
+	g.Config.setName(newName)
+	if old.HomeDir != "~/"+old.ProjectName {
+		g.Config.HomeDir = old.HomeDir
+	}
+	if old.BinaryName != naming.BinaryName(old.ProjectName) {
+		g.Config.BinaryName = old.BinaryName
+	}
+	bins := slices.Clone(old.Binaries)
+	for i, bin := range bins {
+		if bin == old.BinaryName {
+			bins[i] = g.Config.BinaryName
+		}
+	}
+	g.Config.Binaries, g.Binaries = bins, bins
+	newFiles, err := g.RenderAll()
+	if err != nil {
+		return nil, err
+	}
+	rendered := make(map[string]RenderedFile)
+	for _, f := range newFiles {
+		rendered[f.Path] = f
+	}
+
+	r := renamer{old: &old, new: g.Config}
+	paths, err := r.candidates(g.fsys(), dir, m)
+	if err != nil {
+		return nil, err
+	}
+	var changes []RenameChange
+	for _, rel := range paths {
+		file := filepath.Join(dir, filepath.FromSlash(rel))
+		info, err := g.fsys().Stat(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := g.fsys().ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		c := RenameChange{From: rel, Path: r.move(rel), Old: data, New: data, Mode: info.Mode().Perm()}
+
+		i := slices.IndexFunc(oldFiles, func(f RenderedFile) bool { return f.Path == rel })
+		nf, ok := rendered[c.Path]
+		switch {
+		case i >= 0 && ok && !oldFiles[i].Streamed() && !nf.Streamed() && bytes.Equal(oldFiles[i].Data, data):
+			c.New = nf.Data
+		case path.Ext(rel) == ".go":
+			if old.PackageName != g.Config.PackageName {
+				inPackage := path.Dir(rel) == "."
+				if c.New, err = codemod.RenamePackage(rel, data, old.ModuleURL, old.PackageName, g.Config.PackageName, inPackage); err != nil {
+					return nil, err
+				}
+			}
+		case m.File(rel) != nil || strings.HasPrefix(rel, "Dockerfile"):
+			c.New = r.replaceNames(data)
+		}
+		if c.Moved() || c.Edited() {
+			changes = append(changes, c)
+		}
+	}
+
+	for _, c := range changes {
+		if !c.Moved() || slices.Contains(paths, c.Path) {
+			continue
+		}
+		if _, err := g.fsys().Stat(filepath.Join(dir, filepath.FromSlash(c.Path))); err == nil {
+			return nil, fmt.Errorf("can't move %s: %s already exists", c.From, c.Path)
+		}
+	}
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+	return changes, g.applyRename(dir, m, changes)
+}
+
+// applyRename writes the changes and the manifest that follows from them.
+func (g *Generator) applyRename(dir string, m *Manifest, changes []RenameChange) error {
+	for _, c := range changes {
+		if err := g.writeFile(filepath.Join(dir, filepath.FromSlash(c.Path)), c.New, c.Mode); err != nil {
+			return err
+		}
+	}
+	for _, c := range changes {
+		if !c.Moved() || slices.ContainsFunc(changes, func(o RenameChange) bool { return o.Path == c.From }) {
+			continue
+		}
+		if err := g.fsys().Remove(filepath.Join(dir, filepath.FromSlash(c.From))); err != nil {
+			return err
+		}
+		// Drop the directories the move emptied, such as cmd/<old>
+		for d := path.Dir(c.From); d != "."; d = path.Dir(d) {
+			if g.fsys().Remove(filepath.Join(dir, filepath.FromSlash(d))) != nil {
+				break
+			}
+		}
+	}
+
+	for i, f := range m.Files {
+		j := slices.IndexFunc(changes, func(c RenameChange) bool { return c.From == f.Path })
+		if j < 0 {
+			continue
+		}
+		c := changes[j]
+		m.Files[i].Path = c.Path
+		if checksum(c.Old) == f.SHA256 {
+			m.Files[i].SHA256 = checksum(c.New)
+		}
+	}
+	m.ProjectName = g.Config.ProjectName
+	m.BinaryName = g.Config.BinaryName
+	if len(m.Binaries) > 0 {
+		m.Binaries = g.Config.Binaries
+	}
+	m.HomeDir = g.Config.HomeDir
+	out, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+	return g.writeFile(ManifestPath(dir), out, fileutils.DefaultMode)
+}
+
+// renamer maps a project's paths and names from one config to another.
+type renamer struct {
+	old, new *GenConfig
+}
+
+// candidates lists the files a rename may touch: the generated ones, every
+// Go file, all of cmd/<binary> and its plugins, and root Dockerfiles.
+func (r renamer) candidates(fsys fileutils.FS, dir string, m *Manifest) ([]string, error) {
+	var out []string
+	for _, f := range m.Files {
+		out = append(out, f.Path)
+	}
+	files, err := codemod.GoFiles(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var roots []string
+	if r.old.BinaryName != r.new.BinaryName {
+		cmds, _ := fsys.ReadDir(filepath.Join(dir, "cmd"))
+		for _, e := range cmds {
+			if e.Name() == r.old.BinaryName || strings.HasPrefix(e.Name(), r.old.BinaryName+"-") {
+				roots = append(roots, filepath.Join(dir, "cmd", e.Name()))
+			}
+		}
+	}
+	top, _ := fsys.ReadDir(dir)
+	for _, e := range top {
+		if strings.HasPrefix(e.Name(), "Dockerfile") && e.Type().IsRegular() {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	for _, root := range roots {
+		err := fileutils.Walk(fsys, root, func(p string, d fs.DirEntry, err error) error {
+			if err == nil && d.Type().IsRegular() {
+				files = append(files, p)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range files {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, filepath.ToSlash(rel))
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+// move returns where the file at rel goes: <package>.go and the cmd
+// directories of the main binary and its plugins follow the new names.
+func (r renamer) move(rel string) string {
+	if rel == r.old.PackageName+".go" {
+		return r.new.PackageName + ".go"
+	}
+	oldBin, newBin := r.old.BinaryName, r.new.BinaryName
+	if oldBin == newBin {
+		return rel
+	}
+	if rest, ok := strings.CutPrefix(rel, "cmd/"+oldBin+"/"); ok {
+		return "cmd/" + newBin + "/" + rest
+	}
+	if rest, ok := strings.CutPrefix(rel, "cmd/"+oldBin+"-"); ok {
+		return "cmd/" + newBin + "-" + rest
+	}
+	return rel
+}
+
+// replaceNames replaces the old binary and project names in an edited
+// text file where they stand as words, leaving the module path alone.
+func (r renamer) replaceNames(data []byte) []byte {
+	data = replaceWord(data, r.old.BinaryName, r.new.BinaryName, r.old.ModuleURL)
+	if r.old.ProjectName != r.old.BinaryName {
+		data = replaceWord(data, r.old.ProjectName, r.new.ProjectName, r.old.ModuleURL)
+	}
+	return data
+}
+
+// replaceWord replaces each oldWord in data that isn't part of a longer
+// word, nor the end of the module path modulePath.
+func replaceWord(data []byte, oldWord, newWord, modulePath string) []byte {
+	if oldWord == newWord {
+		return data
+	}
+	var out []byte
+	last := 0
+	for i := 0; ; {
+		j := bytes.Index(data[i:], []byte(oldWord))
+		if j < 0 {
+			break
+		}
+		start, end := i+j, i+j+len(oldWord)
+		whole := (start == 0 || !isWordByte(data[start-1])) && (end == len(data) || !isWordByte(data[end]))
+		if whole && !bytes.HasSuffix(data[:end], []byte(modulePath)) {
+			out = append(out, data[last:start]...)
+			out = append(out, newWord...)
+			last = end
+		}
+		i = end
+	}
+	return append(out, data[last:]...)
+}
+
+// isWordByte reports whether c may be part of a name.
+func isWordByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '-'
+}
@@ -0,0 +1,97 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+func TestRename(t *testing.T) {
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	if err := g.GenerateAll("github.com/example/demo", t.TempDir()); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	dir := g.Config.ProjectPath()
+	read := func(rel string) string {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	write := func(rel, text string) {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(rel)), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A customized file of the package, a user's file of the binary that
+	// uses it, and a Dockerfile
+	write("demo.go", read("demo.go")+"\nfunc Extra() {}\n")
+	write("cmd/demo/extra.go", "package main\n\nimport \"github.com/example/demo\"\n\nvar _ = demo.Extra\n")
+	write("Dockerfile", "FROM scratch\nCOPY demo /demo\nENTRYPOINT [\"/demo\"]\n")
+
+	changes, err := (&Generator{}).Rename(dir, "tool", true)
+	if err != nil {
+		t.Fatalf("Rename(dry run) error: %v", err)
+	}
+	if len(changes) == 0 {
+		t.Fatal("Rename(dry run) found nothing to change")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cmd", "tool")); !os.IsNotExist(err) {
+		t.Fatal("Rename(dry run) wrote cmd/tool")
+	}
+
+	if _, err := (&Generator{}).Rename(dir, "tool", false); err != nil {
+		t.Fatalf("Rename() error: %v", err)
+	}
+	for _, gone := range []string{"demo.go", "cmd/demo"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(gone))); !os.IsNotExist(err) {
+			t.Errorf("%s is still there", gone)
+		}
+	}
+	if text := read("tool.go"); !strings.HasPrefix(text, "package tool\n") || !strings.Contains(text, "func Extra()") {
+		t.Errorf("tool.go is not the renamed, customized demo.go:\n%s", text)
+	}
+	if text := read("cmd/tool/extra.go"); !strings.Contains(text, "var _ = tool.Extra") || !strings.Contains(text, `"github.com/example/demo"`) {
+		t.Errorf("cmd/tool/extra.go = %q, want tool.Extra from the same module", text)
+	}
+	if text := read("Taskfile.yaml"); !strings.Contains(text, "APP: tool") {
+		t.Errorf("Taskfile.yaml doesn't name the binary tool:\n%s", text)
+	}
+	if text := read("Dockerfile"); text != "FROM scratch\nCOPY tool /tool\nENTRYPOINT [\"/tool\"]\n" {
+		t.Errorf("Dockerfile = %q", text)
+	}
+	if text := read("go.mod"); !strings.HasPrefix(text, "module github.com/example/demo\n") {
+		t.Errorf("go.mod = %q, want the module kept", text)
+	}
+
+	m, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ProjectName != "tool" || m.BinaryName != "tool" || m.HomeDir != "~/tool" || m.File("cmd/tool/main.go") == nil || m.File("cmd/demo/main.go") != nil {
+		t.Errorf("manifest not renamed: name %s, binary %s, home %s", m.ProjectName, m.BinaryName, m.HomeDir)
+	}
+
+	// Files the user didn't touch are as the templates render them
+	diffs, err := (&Generator{}).Diff(dir)
+	if err != nil {
+		t.Fatalf("Diff() error: %v", err)
+	}
+	for _, d := range diffs {
+		if d.State != DiffClean && d.Path != "tool.go" {
+			t.Errorf("%s is %s after the rename:\n%s", d.Path, d.State, d.Diff)
+		}
+	}
+}
+
+func TestReplaceWord(t *testing.T) {
+	got := replaceWord([]byte("demo demo-tools demos cmd/demo github.com/example/demo ./demo."), "demo", "tool", "github.com/example/demo")
+	want := "tool demo-tools demos cmd/tool github.com/example/demo ./tool."
+	if string(got) != want {
+		t.Errorf("replaceWord() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"github.com/robbyriverside/project/internal/codemod"
+	"github.com/robbyriverside/project/internal/fileutils"
+	"github.com/robbyriverside/project/internal/metadata"
+)
+
+// RenameModule moves the project in projectDir to the module path
+// newModule, for a repository that was moved or renamed. It rewrites
+// go.mod, the imports of every Go file, the module path in the generated
+// text files (Taskfile, CI workflows, README and the like) and the
+// manifest. Files that were as generated keep matching the manifest, so
+// update still sees them as untouched. It returns the files it changed.
+//
+// The project keeps its host, since the host picks its CI.
+func (g *Generator) RenameModule(projectDir, newModule string) ([]string, error) {
+	m, err := loadManifest(g.fsys(), projectDir)
+	if err != nil {
+		return nil, err
+	}
+	mod, err := metadata.Parse(newModule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid module: %w", err)
+	}
+	oldPath, newPath := m.ModuleURL, mod.Path
+	if newPath == oldPath {
+		return nil, fmt.Errorf("the module is already %s", oldPath)
+	}
+	if old, err := metadata.Parse(oldPath); err == nil && old.Host != mod.Host {
+		return nil, fmt.Errorf("can't move %s from %s to %s, whose CI differs; generate it anew there", oldPath, old.Host, mod.Host)
+	}
+
+	g.Config = NewGenConfig(oldPath, projectDir)
+	dir := g.Config.ProjectPath()
+	release, err := g.lock(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Note which files are as generated before anything changes
+	pristine := make(map[string]bool)
+	for _, f := range m.Files {
+		sum, err := fileChecksum(g.fsys(), filepath.Join(dir, filepath.FromSlash(f.Path)))
+		pristine[f.Path] = err == nil && sum == f.SHA256
+	}
+
+	changed, err := codemod.RewriteImports(g.fsys(), dir, oldPath, newPath)
+	if err != nil {
+		return changed, err
+	}
+	texts := []string{"go.mod"}
+	for _, f := range m.Files {
+		if path.Ext(f.Path) != ".go" && f.Path != "go.mod" {
+			texts = append(texts, f.Path)
+		}
+	}
+	for _, rel := range texts {
+		file := filepath.Join(dir, filepath.FromSlash(rel))
+		ok, err := g.rewriteModulePath(file, oldPath, newPath)
+		if err != nil {
+			return changed, err
+		}
+		if ok {
+			changed = append(changed, file)
+		}
+	}
+
+	m.ModuleURL = newPath
+	for i, f := range m.Files {
+		if !pristine[f.Path] {
+			continue
+		}
+		if m.Files[i].SHA256, err = fileChecksum(g.fsys(), filepath.Join(dir, filepath.FromSlash(f.Path))); err != nil {
+			return changed, err
+		}
+	}
+	out, err := m.Marshal()
+	if err != nil {
+		return changed, err
+	}
+	if err := g.writeFile(ManifestPath(dir), out, fileutils.DefaultMode); err != nil {
+		return changed, err
+	}
+	g.Config = NewGenConfig(newPath, projectDir)
+	return append(changed, ManifestPath(dir)), nil
+}
+
+// rewriteModulePath replaces the module path oldPath, and the paths of its
+// packages, with newPath in a text file, reporting whether it changed. A
+// missing file is left alone.
+func (g *Generator) rewriteModulePath(file, oldPath, newPath string) (bool, error) {
+	data, err := g.fsys().ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	out := replaceModulePath(data, oldPath, newPath)
+	if bytes.Equal(out, data) {
+		return false, nil
+	}
+	info, err := g.fsys().Stat(file)
+	if err != nil {
+		return false, err
+	}
+	return true, g.writeFile(file, out, info.Mode().Perm())
+}
+
+// replaceModulePath replaces each oldPath in data that stands on its own
+// or starts a package path, so github.com/acme/tool doesn't also rewrite
+// github.com/acme/toolkit.
+func replaceModulePath(data []byte, oldPath, newPath string) []byte {
+	old := []byte(oldPath)
+	var out []byte
+	for {
+		i := bytes.Index(data, old)
+		if i < 0 {
+			return append(out, data...)
+		}
+		end := i + len(old)
+		// A dot may end a sentence or start .git, so it ends the path too
+		whole := (i == 0 || !isModulePathByte(data[i-1])) && (end == len(data) || data[end] == '.' || !isModulePathByte(data[end]))
+		out = append(out, data[:i]...)
+		if whole {
+			out = append(out, newPath...)
+		} else {
+			out = append(out, old...)
+		}
+		data = data[end:]
+	}
+}
+
+// applyName keeps the project name the previous manifest recorded, and
+// the names derived from it, when the module path no longer ends in it
+// after a RenameModule.
+func (g *Generator) applyName(prev *Manifest) {
+	if prev == nil || prev.ProjectName == "" || prev.ProjectName == g.Config.ProjectName {
+		return
+	}
+	g.Config.setName(prev.ProjectName)
+	if prev.HomeDir != "" {
+		g.Config.HomeDir = prev.HomeDir
+	}
+}
+
+// isModulePathByte reports whether c may appear in a module path element.
+func isModulePathByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || slices.Contains([]byte("-._~"), c)
+}
@@ -0,0 +1,105 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+func TestRenameModule(t *testing.T) {
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	if err := g.GenerateAll("github.com/example/demo", t.TempDir()); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	dir := g.Config.ProjectPath()
+	// A local edit that mentions the module, and a module it is a prefix of
+	readme := filepath.Join(dir, "README.md")
+	data, err := os.ReadFile(readme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(readme, append(data, "\nSee also github.com/example/demo-tools.\n"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	g = &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	changed, err := g.RenameModule(dir, "https://github.com/acme/tool.git")
+	if err != nil {
+		t.Fatalf("RenameModule() error: %v", err)
+	}
+	if len(changed) == 0 {
+		t.Fatal("RenameModule() changed nothing")
+	}
+
+	read := func(rel string) string {
+		data, err := os.ReadFile(filepath.Join(dir, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	gomod := read("go.mod")
+	if !strings.HasPrefix(gomod, "module github.com/acme/tool\n") || !strings.Contains(gomod, "github.com/acme/tool/config => ./config") || strings.Contains(gomod, "example") {
+		t.Errorf("go.mod = %q, want the new module and replaces", gomod)
+	}
+	if main := read("cmd/demo/main.go"); !strings.Contains(main, `"github.com/acme/tool/config"`) || strings.Contains(main, "github.com/example/demo") {
+		t.Errorf("cmd/demo/main.go imports are not rewritten:\n%s", main)
+	}
+	if text := read("README.md"); !strings.Contains(text, "github.com/example/demo-tools") || !strings.Contains(text, "github.com/acme/tool") {
+		t.Errorf("README.md = %q, want the module renamed and demo-tools kept", text)
+	}
+
+	m, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ModuleURL != "github.com/acme/tool" {
+		t.Errorf("manifest module = %s, want github.com/acme/tool", m.ModuleURL)
+	}
+	for _, f := range m.Files {
+		sum, err := fileChecksum(fileutils.OS, filepath.Join(dir, filepath.FromSlash(f.Path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if customized := sum != f.SHA256; customized != (f.Path == "README.md") {
+			t.Errorf("%s customized = %v after the rename", f.Path, customized)
+		}
+	}
+
+	// The templates render what the rename wrote, keeping the name demo
+	diffs, err := (&Generator{}).Diff(dir)
+	if err != nil {
+		t.Fatalf("Diff() error: %v", err)
+	}
+	for _, d := range diffs {
+		if d.State != DiffClean && d.Path != "README.md" {
+			t.Errorf("%s is %s after the rename:\n%s", d.Path, d.State, d.Diff)
+		}
+	}
+
+	if _, err := g.RenameModule(dir, "github.com/acme/tool"); err == nil {
+		t.Error("RenameModule() to the same module succeeded")
+	}
+	if _, err := g.RenameModule(dir, "gitlab.com/acme/tool"); err == nil || !strings.Contains(err.Error(), "CI differs") {
+		t.Errorf("RenameModule() to another host error = %v, want a refusal", err)
+	}
+}
+
+func TestReplaceModulePath(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"github.com/a/b", "example.com/c"},
+		{"-X github.com/a/b/config.Version=v1", "-X example.com/c/config.Version=v1"},
+		{"https://github.com/a/b/actions", "https://example.com/c/actions"},
+		{"github.com/a/bc github.com/a/b.", "github.com/a/bc example.com/c."},
+		{"git@github.com:a/b.git https://github.com/a/b.git", "git@github.com:a/b.git https://example.com/c.git"},
+		{"xgithub.com/a/b", "xgithub.com/a/b"},
+	} {
+		if got := string(replaceModulePath([]byte(tc.in), "github.com/a/b", "example.com/c")); got != tc.want {
+			t.Errorf("replaceModulePath(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
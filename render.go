@@ -0,0 +1,385 @@
+package project
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+// staticDir is the pack folder whose contents are copied without template execution.
+const staticDir = "static"
+
+// DefaultStreamThreshold is the size above which a rendered file is
+// streamed to disk instead of held in memory; see Generator.StreamThreshold.
+const DefaultStreamThreshold = 1 << 20
+
+// RenderedFile is one file of a project, rendered but not yet written.
+// Files up to the stream threshold are held in Data; larger ones leave Data
+// nil and are produced again by Open when written.
+type RenderedFile struct {
+	Path     string // slash-separated, relative to the project root
+	Template string // template (or static/ asset) the file came from
+	Data     []byte
+	Mode     os.FileMode
+
+	// Set for streamed files only
+	Open   func() (io.ReadCloser, error)
+	Size   int64
+	SHA256 string
+}
+
+// Streamed reports whether the content is produced by Open rather than held in Data.
+func (f *RenderedFile) Streamed() bool {
+	return f.Open != nil
+}
+
+// Checksum is the hex sha256 of the file's content.
+func (f *RenderedFile) Checksum() string {
+	if f.Streamed() {
+		return f.SHA256
+	}
+	return checksum(f.Data)
+}
+
+// Reader returns the file's content as a stream.
+func (f *RenderedFile) Reader() (io.ReadCloser, error) {
+	if f.Streamed() {
+		return f.Open()
+	}
+	return io.NopCloser(bytes.NewReader(f.Data)), nil
+}
+
+func (g *Generator) streamThreshold() int64 {
+	if g.StreamThreshold > 0 {
+		return g.StreamThreshold
+	}
+	return DefaultStreamThreshold
+}
+
+// RenderAll renders every template (including those of enabled features)
+// and static asset for g.Config without touching the project directory.
+// The pack is parsed once for the whole run.
+func (g *Generator) RenderAll() ([]RenderedFile, error) {
+	var files []RenderedFile
+	err := g.withTemplates(func() (err error) {
+		files, err = g.renderAll()
+		return err
+	})
+	return files, err
+}
+
+func (g *Generator) renderAll() ([]RenderedFile, error) {
+	var files []RenderedFile
+	for _, ft := range g.fileTypes() {
+		if binaryFiles[ft] {
+			rendered, err := g.renderBinaries(ft)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate %s: %w", ft, err)
+			}
+			files = append(files, rendered...)
+			continue
+		}
+		f, err := g.Render(ft)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate %s: %w", ft, err)
+		}
+		files = append(files, *f)
+	}
+	extras, err := g.renderExtras()
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, extras...)
+
+	static, err := g.RenderStatic()
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy static files: %w", err)
+	}
+	files = append(files, static...)
+
+	scripted, err := g.renderScript()
+	if err != nil {
+		return nil, err
+	}
+	files = mergePlanned(files, scripted)
+	if err := g.addHeaders(files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Preview renders the project a first GenerateAll of moduleURL would
+// write, in memory: no directory is read or written and no command runs.
+// Like GenerateAll, it fails if the files break g.Policy.
+func (g *Generator) Preview(moduleURL string) ([]RenderedFile, error) {
+	g.Config = NewGenConfig(moduleURL, "")
+	g.prev = nil
+	if err := g.applyNew(); err != nil {
+		return nil, err
+	}
+	files, err := g.RenderAll()
+	if err != nil || g.Policy == nil {
+		return files, err
+	}
+	return files, g.Policy.Check(g, files)
+}
+
+// applyNew applies the generator's options to g.Config for a project
+// generated for the first time, with no manifest to keep settings from.
+func (g *Generator) applyNew() error {
+	if err := g.applyReproducible(); err != nil {
+		return err
+	}
+	if err := g.applyKind(nil); err != nil {
+		return err
+	}
+	if err := g.applyFeatures(nil); err != nil {
+		return err
+	}
+	if err := g.applyBinaries(nil); err != nil {
+		return err
+	}
+	if err := g.applyTargets(nil); err != nil {
+		return err
+	}
+	if err := g.applyExtras(nil); err != nil {
+		return err
+	}
+	g.applyShared(nil)
+	if err := g.ResolveVars(nil); err != nil {
+		return err
+	}
+	g.applyCatalog(nil)
+	g.applyVendor(nil)
+	return nil
+}
+
+// mergePlanned adds the files of the pack script to files, replacing any
+// at the same path.
+func mergePlanned(files, scripted []RenderedFile) []RenderedFile {
+	index := make(map[string]int, len(files))
+	for i, f := range files {
+		index[f.Path] = i
+	}
+	for _, f := range scripted {
+		if i, ok := index[f.Path]; ok {
+			files[i] = f
+			continue
+		}
+		index[f.Path] = len(files)
+		files = append(files, f)
+	}
+	return files
+}
+
+// plannedFiles renders the files this run should write: everything for
+// generated projects, but only the manifest's files for adopted ones.
+func (g *Generator) plannedFiles() ([]RenderedFile, error) {
+	files, err := g.RenderAll()
+	if err != nil || g.prev == nil || !g.prev.Adopted {
+		return files, err
+	}
+	var out []RenderedFile
+	for _, f := range files {
+		if g.prev.File(f.Path) != nil {
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+// stampGenerator fills in what is rendering g.Config, for templates that
+// record it.
+func (g *Generator) stampGenerator() error {
+	pack, err := g.PackInfo()
+	if err != nil {
+		return err
+	}
+	g.Config.GeneratorVersion = Version
+	g.Config.TemplatePack = pack.Name
+	g.Config.TemplateVersion = pack.Version
+	return nil
+}
+
+// Render executes <fileType>.tmpl (or its localized variant for g.Lang) with g.Config.
+func (g *Generator) Render(fileType string) (*RenderedFile, error) {
+	tplName, err := g.templateName(fileType)
+	if err != nil {
+		return nil, err
+	}
+	g.log().Debugw("template", "type", fileType, "template", tplName, "lang", g.Lang)
+	start := time.Now()
+	tpl, err := g.readTemplate(tplName)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.stampGenerator(); err != nil {
+		return nil, err
+	}
+
+	rel, err := filepath.Rel(g.Config.ProjectPath(), g.filePath(fileType))
+	if err != nil {
+		return nil, err
+	}
+	f := &RenderedFile{
+		Path:     filepath.ToSlash(rel),
+		Template: tplName,
+		Mode:     g.fileMode(fileType),
+	}
+
+	// Execute, keeping the output only while it stays under the threshold.
+	// Taskfiles are post-processed as a whole, so they are never streamed.
+	limit := g.streamThreshold()
+	if isTaskfile(fileType) {
+		limit = math.MaxInt64
+	}
+	out := newCappedBuffer(limit)
+	if err := tpl.Execute(out, g.Config); err != nil {
+		return nil, fmt.Errorf("failed to execute template %s: %w", fileType, err)
+	}
+	if out.over {
+		cfg := g.Config
+		f.Size, f.SHA256 = out.size, hex.EncodeToString(out.hash.Sum(nil))
+		path, size, sum := f.Path, f.Size, f.SHA256
+		f.Open = func() (io.ReadCloser, error) {
+			pr, pw := io.Pipe()
+			go func() { pw.CloseWithError(tpl.Execute(pw, cfg)) }()
+			return &checkedReader{ReadCloser: pr, path: path, hash: sha256.New(), wantSize: size, wantSum: sum}, nil
+		}
+		g.log().Debugw("render", "template", tplName, "path", f.Path, "bytes", f.Size, "streamed", true, "duration", time.Since(start))
+		return f, nil
+	}
+
+	f.Data = out.buf.Bytes()
+	if isTaskfile(fileType) {
+		f.Data = postProcessTaskfile(f.Data)
+	}
+	g.log().Debugw("render", "template", tplName, "path", f.Path, "bytes", len(f.Data), "duration", time.Since(start))
+	return f, nil
+}
+
+// cappedBuffer keeps what is written up to limit bytes. Past the limit it
+// drops the buffer and only tracks the size and sha256 of the whole output.
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	limit int64
+	size  int64
+	over  bool
+	hash  hash.Hash
+}
+
+func newCappedBuffer(limit int64) *cappedBuffer {
+	return &cappedBuffer{limit: limit, hash: sha256.New()}
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	c.hash.Write(p)
+	c.size += int64(len(p))
+	switch {
+	case c.over:
+	case c.size > c.limit:
+		c.over = true
+		c.buf = bytes.Buffer{}
+	default:
+		c.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// checkedReader reads a streamed file's second execution and fails at its
+// end unless the output matches the size and sha256 of the first, which
+// the plan and manifest were made from.
+type checkedReader struct {
+	io.ReadCloser
+	path     string
+	hash     hash.Hash
+	size     int64
+	wantSize int64
+	wantSum  string
+}
+
+func (c *checkedReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.hash.Write(p[:n])
+	c.size += int64(n)
+	if err == io.EOF {
+		if sum := hex.EncodeToString(c.hash.Sum(nil)); c.size != c.wantSize || sum != c.wantSum {
+			return n, fmt.Errorf("%s rendered differently when written (%d bytes, sha256 %s) than when planned (%d bytes, sha256 %s)",
+				c.path, c.size, sum, c.wantSize, c.wantSum)
+		}
+	}
+	return n, err
+}
+
+// RenderStatic reads the pack's static/ tree verbatim.
+// Packs without a static/ folder return nothing.
+func (g *Generator) RenderStatic() ([]RenderedFile, error) {
+	fsys, err := g.pack()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fs.Stat(fsys, staticDir); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to stat %s: %w", staticDir, err)
+	}
+
+	var files []RenderedFile
+	err = fs.WalkDir(fsys, staticDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f := RenderedFile{
+			Path:     strings.TrimPrefix(p, staticDir+"/"),
+			Template: p,
+			Mode:     fileutils.NormalizeMode(info.Mode()),
+		}
+		if info.Size() > g.streamThreshold() {
+			// Large assets are hashed now and copied straight from the pack when written
+			f.Open = func() (io.ReadCloser, error) { return fsys.Open(p) }
+			if f.Size, f.SHA256, err = streamChecksum(f.Open); err != nil {
+				return fmt.Errorf("failed to read %s: %w", p, err)
+			}
+		} else if f.Data, err = fs.ReadFile(fsys, p); err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		files = append(files, f)
+		return nil
+	})
+	return files, err
+}
+
+// streamChecksum reads everything open returns, for its size and hex sha256.
+func streamChecksum(open func() (io.ReadCloser, error)) (int64, string, error) {
+	r, err := open()
+	if err != nil {
+		return 0, "", err
+	}
+	defer r.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
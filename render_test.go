@@ -0,0 +1,272 @@
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+// generateBudget is how long generating the standard scaffold in memory
+// may take. It is generous, to hold on slow CI machines; the point is to
+// catch rendering going quadratic or re-reading the pack per file.
+const generateBudget = 2 * time.Second
+
+// embeddedPack returns a copy of the embedded pack that tests can change.
+func embeddedPack(tb testing.TB) fstest.MapFS {
+	tb.Helper()
+	embedded, err := fs.Sub(templateFS, "templates")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	pack := fstest.MapFS{}
+	err = fs.WalkDir(embedded, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(embedded, p)
+		pack[p] = &fstest.MapFile{Data: data, Mode: 0644}
+		return err
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return pack
+}
+
+// largePack returns the embedded pack plus n service templates, each
+// rendered once by a pack script, for a pack of over n templates.
+func largePack(tb testing.TB, n int) fs.FS {
+	tb.Helper()
+	pack := embeddedPack(tb)
+	for i := range n {
+		name := fmt.Sprintf("svc%d.tmpl", i)
+		pack[name] = &fstest.MapFile{Data: []byte("// Package {{.Data}} is a service of {{.ModuleURL}}.\npackage {{.Data}}\n\n// Name is {{printf \"%q\" .Data}}, in {{.ProjectName}} {{year}}.\nconst Name = \"{{.Data}}\"\n")}
+	}
+	pack[ScriptName] = &fstest.MapFile{Data: []byte(fmt.Sprintf(`
+def generate(ctx):
+    return [
+        {"path": "internal/svc%%d/svc.go" %% i, "template": "svc%%d.tmpl" %% i, "data": "svc%%d" %% i}
+        for i in range(%d)
+    ]
+`, n))}
+	return pack
+}
+
+func TestLargePack(t *testing.T) {
+	g := &Generator{Templates: largePack(t, 200)}
+	names, err := g.TemplateNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) < 200 {
+		t.Fatalf("pack has %d templates, want 200 or more", len(names))
+	}
+	files, err := g.Preview("github.com/example/demo")
+	if err != nil {
+		t.Fatalf("Preview() error: %v", err)
+	}
+	var services int
+	for _, f := range files {
+		if strings.HasPrefix(f.Path, "internal/svc") {
+			services++
+		}
+		if f.Path == "internal/svc199/svc.go" && !strings.Contains(string(f.Data), "package svc199\n") {
+			t.Errorf("%s = %q", f.Path, f.Data)
+		}
+	}
+	if services != 200 {
+		t.Errorf("rendered %d service files, want 200", services)
+	}
+}
+
+func TestTemplateCache(t *testing.T) {
+	pack := scriptPack(t, `
+def generate(ctx):
+    return [{"path": "internal/billing/billing.go", "template": "service.tmpl", "data": {"name": "billing"}}]
+`)
+	write := func(name, content string, mtime time.Time) {
+		t.Helper()
+		file := filepath.Join(pack, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	write("service.tmpl", "{{template \"partials/header.tmpl\" .}}package {{.Data.name}}\n", start)
+	write("partials/header.tmpl", "// Code for {{.ModuleURL}}.\n", start)
+
+	g := &Generator{Templates: os.DirFS(pack)}
+	render := func() string {
+		t.Helper()
+		files, err := g.Preview("github.com/example/demo")
+		if err != nil {
+			t.Fatalf("Preview() error: %v", err)
+		}
+		for _, f := range files {
+			if strings.HasPrefix(f.Path, "partials/") {
+				t.Errorf("partial rendered as %s", f.Path)
+			}
+			if f.Path == "internal/billing/billing.go" {
+				return string(f.Data)
+			}
+		}
+		t.Fatal("service not rendered")
+		return ""
+	}
+
+	if got, want := render(), "// Code for github.com/example/demo.\npackage billing\n"; got != want {
+		t.Errorf("service = %q, want %q", got, want)
+	}
+	set := g.templates
+	render()
+	if g.templates != set {
+		t.Error("unchanged pack parsed again")
+	}
+
+	// Editing the partial, as in --templates dev mode, is picked up
+	write("partials/header.tmpl", "// Service of {{.ProjectName}}.\n", start.Add(time.Minute))
+	if got, want := render(), "// Service of demo.\npackage billing\n"; got != want {
+		t.Errorf("after edit, service = %q, want %q", got, want)
+	}
+}
+
+func TestGenerateBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test")
+	}
+	const runs = 5
+	var total time.Duration
+	for range runs {
+		g := &Generator{FS: &fileutils.MemFS{}, Runner: &execx.Recorder{Respond: fakeGo}}
+		start := time.Now()
+		if err := g.GenerateAll("github.com/example/demo", filepath.Join(t.TempDir(), "demo")); err != nil {
+			t.Fatalf("GenerateAll() error: %v", err)
+		}
+		total += time.Since(start)
+	}
+	if mean := total / runs; mean > generateBudget {
+		t.Errorf("generating the standard scaffold took %v, over the %v budget", mean, generateBudget)
+	}
+}
+
+func BenchmarkRenderAll(b *testing.B) {
+	g := &Generator{}
+	for b.Loop() {
+		if _, err := g.Preview("github.com/example/demo"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderAllLargePack(b *testing.B) {
+	g := &Generator{Templates: largePack(b, 250)}
+	for b.Loop() {
+		if _, err := g.Preview("github.com/example/demo"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateAllInMemory(b *testing.B) {
+	for b.Loop() {
+		g := &Generator{FS: &fileutils.MemFS{}, Runner: &execx.Recorder{Respond: fakeGo}}
+		if err := g.GenerateAll("github.com/example/demo", "/demo"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestStreamedRender(t *testing.T) {
+	inMemory, err := (&Generator{Features: []string{"bench"}}).Preview("github.com/example/demo")
+	if err != nil {
+		t.Fatalf("Preview() error: %v", err)
+	}
+	want := make(map[string][]byte)
+	for _, f := range inMemory {
+		want[f.Path] = f.Data
+	}
+
+	const threshold = 512
+	g := &Generator{Features: []string{"bench"}, StreamThreshold: threshold}
+	files, err := g.Preview("github.com/example/demo")
+	if err != nil {
+		t.Fatalf("Preview() error: %v", err)
+	}
+	var streamed int
+	for _, f := range files {
+		if !f.Streamed() {
+			continue
+		}
+		streamed++
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Errorf("reading streamed %s: %v", f.Path, err)
+		}
+		if !bytes.Equal(data, want[f.Path]) {
+			t.Errorf("streamed %s differs from the in-memory render", f.Path)
+		}
+		if f.Size != int64(len(want[f.Path])) || f.SHA256 != checksum(want[f.Path]) || f.Checksum() != f.SHA256 {
+			t.Errorf("streamed %s has size %d, sha256 %s; want %d, %s", f.Path, f.Size, f.SHA256, len(want[f.Path]), checksum(want[f.Path]))
+		}
+	}
+	if streamed == 0 {
+		t.Fatalf("nothing was streamed over %d bytes", threshold)
+	}
+
+	// Written, the streamed files hold the same bytes
+	g.Runner = &execx.Recorder{Respond: fakeGo}
+	if err := g.GenerateAll("github.com/example/demo", t.TempDir()); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	for _, r := range g.Results {
+		data, err := os.ReadFile(filepath.Join(g.Config.ProjectPath(), filepath.FromSlash(r.Path)))
+		if err != nil || !bytes.Equal(data, want[r.Path]) || r.SHA256 != checksum(want[r.Path]) {
+			t.Errorf("written %s differs from the in-memory render (%v)", r.Path, err)
+		}
+	}
+}
+
+func TestStreamedRenderMismatch(t *testing.T) {
+	// The clock moves on a year each time it is read, so the file renders
+	// differently when written than when planned
+	pack := embeddedPack(t)
+	pack["readme.tmpl"] = &fstest.MapFile{Data: []byte("Copyright {{year}}\n" + strings.Repeat("padding\n", 100))}
+	year := 2000
+	g := &Generator{
+		Templates:       pack,
+		StreamThreshold: 64,
+		Clock: func() time.Time {
+			year++
+			return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+		},
+		Runner: &execx.Recorder{Respond: fakeGo},
+	}
+	err := g.GenerateAll("github.com/example/demo", t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "README.md rendered differently when written") {
+		t.Fatalf("GenerateAll() = %v, want README.md's checksum mismatch", err)
+	}
+	if _, err := os.Stat(filepath.Join(g.Config.ProjectPath(), "README.md")); !os.IsNotExist(err) {
+		t.Errorf("mismatched README.md was written: %v", err)
+	}
+}
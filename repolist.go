@@ -0,0 +1,110 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/robbyriverside/project/internal/forge"
+	"github.com/robbyriverside/project/internal/retry"
+)
+
+// RepoList names the repositories project watch and project fleet work
+// on, e.g.
+//
+//	repos:
+//	  - repo: github.com/acme/api
+//	  - repo: gitlab.com/acme/tools/cli
+//	    branch: develop
+//	labels: [scaffolding]
+type RepoList struct {
+	Repos []ListedRepo `yaml:"repos"`
+
+	// Labels are put on the issues project watch files, and narrow its
+	// search for ones already open.
+	Labels []string `yaml:"labels,omitempty"`
+
+	// Source is the file the list came from.
+	Source string `yaml:"-"`
+}
+
+// ListedRepo is a generated repository in a RepoList.
+type ListedRepo struct {
+	Repo   string `yaml:"repo" json:"repo"`                         // host/owner/name, like a module path
+	Branch string `yaml:"branch,omitempty" json:"branch,omitempty"` // "" for the repository's default branch
+}
+
+// ParseRepoList decodes a repo list, rejecting keys it doesn't know.
+func ParseRepoList(data []byte, source string) (*RepoList, error) {
+	l := &RepoList{Source: source}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(l); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid repo list %s: %w", source, err)
+	}
+	if len(l.Repos) == 0 {
+		return nil, fmt.Errorf("invalid repo list %s: no repos", source)
+	}
+	for i := range l.Repos {
+		r := &l.Repos[i]
+		r.Repo = strings.TrimSuffix(strings.TrimPrefix(r.Repo, "https://"), ".git")
+		if strings.Count(r.Repo, "/") < 2 {
+			return nil, fmt.Errorf("invalid repo list %s: repo %q is not host/owner/name", source, r.Repo)
+		}
+	}
+	return l, nil
+}
+
+// OrgRepoList lists the repositories of org, a host/owner such as
+// github.com/acme or gitlab.com/acme/tools, from its forge. Archived ones
+// are left out.
+func OrgRepoList(ctx context.Context, org, token string, policy retry.Policy) (*RepoList, error) {
+	host, owner, ok := strings.Cut(strings.TrimPrefix(org, "https://"), "/")
+	if !ok || owner == "" {
+		return nil, fmt.Errorf("invalid org %q, expected host/owner", org)
+	}
+	paths, err := forge.For(ctx, host, token, policy).ListRepos(owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the repositories of %s: %w", org, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%s has no repositories", org)
+	}
+	l := &RepoList{Source: org}
+	for _, p := range paths {
+		l.Repos = append(l.Repos, ListedRepo{Repo: host + "/" + p})
+	}
+	return l, nil
+}
+
+// Find returns the listed repo whose owner/name is repoPath, as a webhook
+// names it, or nil.
+func (l *RepoList) Find(repoPath string) *ListedRepo {
+	for i, r := range l.Repos {
+		if strings.EqualFold(r.Path(), repoPath) {
+			return &l.Repos[i]
+		}
+	}
+	return nil
+}
+
+// Host is the forge the repo is on.
+func (r ListedRepo) Host() string {
+	host, _, _ := strings.Cut(r.Repo, "/")
+	return host
+}
+
+// Path is the repo's owner/name, which for GitLab may include subgroups.
+func (r ListedRepo) Path() string {
+	_, p, _ := strings.Cut(r.Repo, "/")
+	return p
+}
+
+// cloneURL is the https URL the repo is cloned from.
+func (r ListedRepo) cloneURL() string {
+	return "https://" + r.Repo + ".git"
+}
@@ -0,0 +1,25 @@
+package project
+
+import "testing"
+
+func TestParseRepoList(t *testing.T) {
+	w, err := ParseRepoList([]byte("repos:\n  - repo: https://github.com/acme/api.git\n  - repo: gitlab.com/acme/tools/cli\n    branch: develop\n"), "repos.yaml")
+	if err != nil {
+		t.Fatalf("ParseRepoList() error: %v", err)
+	}
+	if r := w.Find("Acme/API"); r == nil || r.Repo != "github.com/acme/api" || r.Branch != "" {
+		t.Errorf("Find(Acme/API) = %+v, want github.com/acme/api on its default branch", r)
+	}
+	if r := w.Find("acme/tools/cli"); r == nil || r.Host() != "gitlab.com" || r.Branch != "develop" {
+		t.Errorf("Find(acme/tools/cli) = %+v, want the GitLab repo on develop", r)
+	}
+	if w.Find("acme/web") != nil {
+		t.Error("Find(acme/web) found an unregistered repo")
+	}
+
+	for _, bad := range []string{"", "repos: []\n", "repos:\n  - repo: acme/api\n", "repos:\n  - repo: github.com/acme/api\n    brnach: main\n"} {
+		if _, err := ParseRepoList([]byte(bad), "bad.yaml"); err == nil {
+			t.Errorf("ParseRepoList(%q) succeeded", bad)
+		}
+	}
+}
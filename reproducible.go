@@ -0,0 +1,103 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// SourceDateEpoch is the fixed time of reproducible runs: $SOURCE_DATE_EPOCH
+// (seconds since the Unix epoch, as in reproducible-builds.org) if set,
+// else the Unix epoch itself.
+func SourceDateEpoch() (time.Time, error) {
+	v := os.Getenv("SOURCE_DATE_EPOCH")
+	if v == "" {
+		return time.Unix(0, 0).UTC(), nil
+	}
+	secs, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", v, err)
+	}
+	return time.Unix(secs, 0).UTC(), nil
+}
+
+// applyReproducible fixes the clock for a reproducible run.
+func (g *Generator) applyReproducible() error {
+	if !g.Reproducible || g.Clock != nil {
+		return nil
+	}
+	t, err := SourceDateEpoch()
+	if err != nil {
+		return err
+	}
+	g.Clock = func() time.Time { return t }
+	return nil
+}
+
+func (g *Generator) now() time.Time {
+	if g.Clock != nil {
+		return g.Clock()
+	}
+	return time.Now()
+}
+
+// funcs are the functions templates may call besides the builtins. All of
+// them are deterministic given g.Clock; note that ranging over a map in a
+// template already visits keys in sorted order. year is the copyright year
+// of the README's license; T translates a message from the pack's
+// MessagesDir; configFields lists the keys of the Config struct the pack's
+// config template declares.
+func (g *Generator) funcs() template.FuncMap {
+	return template.FuncMap{
+		"year":         func() int { return g.now().Year() },
+		"configFields": g.configFields,
+		"T": func(key string, args ...any) (string, error) {
+			p, err := g.messages()
+			if err != nil {
+				return "", err
+			}
+			return p.Sprintf(key, args...), nil
+		},
+	}
+}
+
+// stampFiles sets the modification time of everything the run wrote,
+// plus the manifest, go.mod/go.sum and the directories holding them, to
+// the fixed clock.
+func (g *Generator) stampFiles() error {
+	if !g.Reproducible {
+		return nil
+	}
+	t := g.now()
+	projPath := g.Config.ProjectPath()
+	paths := []string{ManifestPath(projPath), filepath.Join(projPath, "go.mod"), filepath.Join(projPath, "go.sum")}
+	if g.Attest {
+		dir := filepath.Join(projPath, AttestDir)
+		paths = append(paths, filepath.Join(dir, sbomName), filepath.Join(dir, provenanceName))
+	}
+	for _, r := range g.Results {
+		if r.Status != StatusSkipped && r.Status != StatusKept {
+			paths = append(paths, filepath.Join(projPath, filepath.FromSlash(r.Path)))
+		}
+	}
+	// Directories last, since writing files into them bumps their times
+	dirs := make(map[string]bool)
+	for _, p := range paths {
+		for d := filepath.Dir(p); !dirs[d] && strings.HasPrefix(d, projPath); d = filepath.Dir(d) {
+			dirs[d] = true
+		}
+	}
+	for d := range dirs {
+		paths = append(paths, d)
+	}
+	for _, p := range paths {
+		if err := g.fsys().Chtimes(p, t, t); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to set time on %s: %w", p, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package project
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+// snapshot reads every file under dir with its mtime.
+func snapshot(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		entry := info.ModTime().UTC().Format(time.RFC3339Nano)
+		if !d.IsDir() {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			entry += " " + string(data)
+		}
+		files[filepath.ToSlash(rel)] = entry
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestReproducible(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1609459200") // 2021-01-01
+	dir := t.TempDir()
+	run := func() map[string]string {
+		g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}, Reproducible: true, Attest: true, Features: []string{"bench"}}
+		if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+			t.Fatalf("GenerateAll() error: %v", err)
+		}
+		return snapshot(t, dir)
+	}
+
+	first := run()
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	second := run()
+
+	if len(first) != len(second) {
+		t.Errorf("the runs wrote %d and %d entries", len(first), len(second))
+	}
+	for path, want := range first {
+		if got, ok := second[path]; !ok || got != want {
+			t.Errorf("%s differs between runs:\n%.200s\n---\n%.200s", path, want, got)
+		}
+	}
+	if !strings.HasPrefix(first["cmd/demo/main.go"], "2021-01-01T00:00:00Z ") {
+		t.Errorf("main.go mtime = %.20s, want SOURCE_DATE_EPOCH", first["cmd/demo/main.go"])
+	}
+	if !strings.Contains(first["README.md"], "Copyright 2021 example.") {
+		t.Errorf("README.md doesn't carry the SOURCE_DATE_EPOCH year:\n%s", first["README.md"])
+	}
+}
@@ -0,0 +1,359 @@
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+// Rubric weighs the checks project score grades a repository on. The
+// built-in one weighs scoreChecks as listed there; an organization
+// publishes its own and points the rubric config key at it, e.g.
+//
+//	weights: {tests: 30, security: 25, readme: 5, coverage: 0}
+//	files:
+//	  codeowners: {weight: 10, patterns: [CODEOWNERS, .github/CODEOWNERS]}
+//	pass: 80
+//
+// Checks it doesn't weigh keep their built-in weight; weight 0 drops one.
+type Rubric struct {
+	// Weights override the built-in checks' weights by name.
+	Weights map[string]int `yaml:"weights,omitempty"`
+
+	// Files are checks of the organization's own, each met by a file
+	// matching one of its patterns.
+	Files map[string]FileCheck `yaml:"files,omitempty"`
+
+	// Pass is the score, out of 100, a project needs to pass.
+	Pass int `yaml:"pass,omitempty"`
+
+	// Source is the file or URL the rubric came from, "" for the built-in one.
+	Source string `yaml:"-"`
+}
+
+// FileCheck is a rubric check met by any file matching one of Patterns,
+// which match the base name at any depth unless they contain a slash.
+type FileCheck struct {
+	Weight   int      `yaml:"weight"`
+	Patterns []string `yaml:"patterns"`
+}
+
+// scoreChecks are the built-in checks in the order they're reported, with
+// their weights out of 100.
+var scoreChecks = []struct {
+	name   string
+	weight int
+	grade  func(*repo) (float64, string)
+}{
+	{"tests", 25, gradeTests},
+	{"ci", 20, gradeCI},
+	{"lint", 15, gradeLint},
+	{"security", 15, gradeSecurity},
+	{"readme", 10, gradeReadme},
+	{"coverage", 15, gradeCoverage},
+}
+
+// ParseRubric decodes a rubric file, rejecting keys it doesn't know so a
+// typo can't quietly change a weight.
+func ParseRubric(data []byte, source string) (*Rubric, error) {
+	r := &Rubric{Source: source}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(r); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid rubric %s: %w", source, err)
+	}
+	known := make(map[string]bool)
+	for _, c := range scoreChecks {
+		known[c.name] = true
+	}
+	for name, w := range r.Weights {
+		if !known[name] {
+			return nil, fmt.Errorf("invalid rubric %s: weights: unknown check %q", source, name)
+		}
+		if w < 0 {
+			return nil, fmt.Errorf("invalid rubric %s: weights: %s is negative", source, name)
+		}
+	}
+	for name, f := range r.Files {
+		if known[name] {
+			return nil, fmt.Errorf("invalid rubric %s: files: %s is a built-in check; set its weight instead", source, name)
+		}
+		if f.Weight <= 0 || len(f.Patterns) == 0 {
+			return nil, fmt.Errorf("invalid rubric %s: files: %s needs a positive weight and patterns", source, name)
+		}
+	}
+	if r.Pass < 0 || r.Pass > 100 {
+		return nil, fmt.Errorf("invalid rubric %s: pass is %d, want 0 to 100", source, r.Pass)
+	}
+	if r.total() == 0 {
+		return nil, fmt.Errorf("invalid rubric %s: every check weighs 0", source)
+	}
+	return r, nil
+}
+
+// weight is the weight of the built-in check name under r.
+func (r *Rubric) weight(name string, builtin int) int {
+	if w, ok := r.Weights[name]; ok {
+		return w
+	}
+	return builtin
+}
+
+func (r *Rubric) total() int {
+	n := 0
+	for _, c := range scoreChecks {
+		n += r.weight(c.name, c.weight)
+	}
+	for _, f := range r.Files {
+		n += f.Weight
+	}
+	return n
+}
+
+// Scorecard is the outcome of Score.
+type Scorecard struct {
+	ProjectDir string       `json:"project_dir"`
+	Rubric     string       `json:"rubric"` // its source, "built-in" for the default
+	Checks     []ScoreCheck `json:"checks"`
+	Score      int          `json:"score"` // out of 100
+	Pass       int          `json:"pass,omitempty"`
+}
+
+// Passed reports whether the score reaches the rubric's pass mark.
+func (s *Scorecard) Passed() bool {
+	return s.Score >= s.Pass
+}
+
+// ScoreCheck is how a project did on one check: the share of its weight
+// earned, and what fell short.
+type ScoreCheck struct {
+	Name   string  `json:"name"`
+	Weight int     `json:"weight"`
+	Earned float64 `json:"earned"` // 0 to 1
+	Detail string  `json:"detail,omitempty"`
+}
+
+// Points is the check's share of the weight, rounded.
+func (c ScoreCheck) Points() int {
+	return int(math.Round(c.Earned * float64(c.Weight)))
+}
+
+// Score grades the repository in projectDir against rubric, the built-in
+// one if nil: tests, CI, lint config, security files, README quality and a
+// coverage task, plus the rubric's own files. It reads the working tree
+// only, so any Go repository can be scored, generated or not.
+func Score(projectDir string, rubric *Rubric) (*Scorecard, error) {
+	if rubric == nil {
+		rubric = &Rubric{}
+	}
+	files, err := projectFiles(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	r := &repo{dir: projectDir, files: files}
+	card := &Scorecard{ProjectDir: projectDir, Rubric: rubric.Source, Pass: rubric.Pass}
+	if card.Rubric == "" {
+		card.Rubric = "built-in"
+	}
+
+	for _, c := range scoreChecks {
+		w := rubric.weight(c.name, c.weight)
+		if w == 0 {
+			continue
+		}
+		earned, detail := c.grade(r)
+		card.Checks = append(card.Checks, ScoreCheck{Name: c.name, Weight: w, Earned: earned, Detail: detail})
+	}
+	names := make([]string, 0, len(rubric.Files))
+	for name := range rubric.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := rubric.Files[name]
+		check := ScoreCheck{Name: name, Weight: f.Weight}
+		if r.has(f.Patterns...) {
+			check.Earned = 1
+		} else {
+			check.Detail = "no " + strings.Join(f.Patterns, " or ")
+		}
+		card.Checks = append(card.Checks, check)
+	}
+
+	var earned, total float64
+	for _, c := range card.Checks {
+		earned += c.Earned * float64(c.Weight)
+		total += float64(c.Weight)
+	}
+	if total > 0 {
+		card.Score = int(math.Round(earned * 100 / total))
+	}
+	return card, nil
+}
+
+// repo is the working tree being scored.
+type repo struct {
+	dir   string
+	files []string // slash-separated, relative to dir
+}
+
+// has reports whether a file matches one of patterns.
+func (r *repo) has(patterns ...string) bool {
+	return anyMatch(r.files, patterns)
+}
+
+// text returns the contents of the files matching patterns, joined.
+func (r *repo) text(patterns ...string) string {
+	var b strings.Builder
+	for _, f := range r.files {
+		if anyMatch([]string{f}, patterns) {
+			data, err := fileutils.OS.ReadFile(filepath.Join(r.dir, filepath.FromSlash(f)))
+			if err == nil {
+				b.Write(data)
+				b.WriteString("\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+var (
+	ciFiles   = []string{".github/workflows/*.yml", ".github/workflows/*.yaml", ".gitlab-ci.yml"}
+	taskFiles = []string{"Taskfile.yml", "Taskfile.yaml", "taskfiles/*.yaml", "taskfiles/*.yml", "Makefile"}
+)
+
+// gradeTests is the share of Go packages with a _test.go file.
+func gradeTests(r *repo) (float64, string) {
+	pkgs, tested := make(map[string]bool), make(map[string]bool)
+	for _, f := range r.files {
+		if !strings.HasSuffix(f, ".go") || strings.Contains("/"+f, "/vendor/") || strings.Contains("/"+f, "/testdata/") {
+			continue
+		}
+		if strings.HasSuffix(f, "_test.go") {
+			tested[path.Dir(f)] = true
+		} else {
+			pkgs[path.Dir(f)] = true
+		}
+	}
+	if len(pkgs) == 0 {
+		return 0, "no Go packages"
+	}
+	var untested []string
+	for p := range pkgs {
+		if !tested[p] {
+			untested = append(untested, p)
+		}
+	}
+	if len(untested) == 0 {
+		return 1, ""
+	}
+	sort.Strings(untested)
+	return float64(len(pkgs)-len(untested)) / float64(len(pkgs)), "no tests in " + strings.Join(untested, ", ")
+}
+
+// gradeCI gives full marks to a CI pipeline that runs the tests.
+func gradeCI(r *repo) (float64, string) {
+	if !r.has(ciFiles...) {
+		return 0, "no CI pipeline"
+	}
+	if !strings.Contains(r.text(ciFiles...), "go test") {
+		return 0.5, "CI doesn't run go test"
+	}
+	return 1, ""
+}
+
+// gradeLint gives full marks to a linter config, half to running go vet
+// or a linter without one.
+func gradeLint(r *repo) (float64, string) {
+	if r.has(".golangci.yml", ".golangci.yaml", ".golangci.toml", ".golangci.json", "staticcheck.conf") {
+		return 1, ""
+	}
+	text := r.text(append(ciFiles, taskFiles...)...)
+	for _, tool := range []string{"golangci-lint", "staticcheck", "go vet"} {
+		if strings.Contains(text, tool) {
+			return 0.5, "runs " + tool + " but has no linter config"
+		}
+	}
+	return 0, "no linter config"
+}
+
+// gradeSecurity gives a third each for a security policy, automated
+// dependency updates and vulnerability scanning.
+func gradeSecurity(r *repo) (float64, string) {
+	var missing []string
+	if !r.has("SECURITY.md", ".github/SECURITY.md") {
+		missing = append(missing, "SECURITY.md")
+	}
+	if !r.has(".github/dependabot.yml", ".github/dependabot.yaml", "renovate.json", "renovate.json5", ".renovaterc", ".renovaterc.json", ".github/renovate.json") {
+		missing = append(missing, "Dependabot or Renovate config")
+	}
+	if text := r.text(append(ciFiles, taskFiles...)...); !strings.Contains(text, "govulncheck") && !strings.Contains(text, "gosec") {
+		missing = append(missing, "govulncheck or gosec run")
+	}
+	if len(missing) == 0 {
+		return 1, ""
+	}
+	return float64(3-len(missing)) / 3, "no " + strings.Join(missing, ", ")
+}
+
+var (
+	installHeading = regexp.MustCompile(`(?im)^#+\s*(install|getting started|setup)`)
+	usageHeading   = regexp.MustCompile(`(?im)^#+\s*(usage|examples?|quick ?start)`)
+)
+
+// gradeReadme gives a quarter each for a README, install and usage
+// sections, and a code block.
+func gradeReadme(r *repo) (float64, string) {
+	readme := []string{"README.md", "README"}
+	var top []string
+	for _, f := range r.files {
+		if !strings.Contains(f, "/") {
+			top = append(top, f)
+		}
+	}
+	if !anyMatch(top, readme) {
+		return 0, "no README.md"
+	}
+	text := (&repo{dir: r.dir, files: top}).text(readme...)
+	earned, missing := 0.25, []string(nil)
+	for _, part := range []struct {
+		name string
+		ok   bool
+	}{
+		{"install section", installHeading.MatchString(text)},
+		{"usage section", usageHeading.MatchString(text)},
+		{"code example", strings.Contains(text, "```")},
+	} {
+		if part.ok {
+			earned += 0.25
+		} else {
+			missing = append(missing, part.name)
+		}
+	}
+	if len(missing) == 0 {
+		return 1, ""
+	}
+	return earned, "README.md has no " + strings.Join(missing, ", ")
+}
+
+// gradeCoverage gives full marks to a task that measures coverage, half
+// to CI that does.
+func gradeCoverage(r *repo) (float64, string) {
+	if strings.Contains(r.text(taskFiles...), "-cover") {
+		return 1, ""
+	}
+	if strings.Contains(r.text(ciFiles...), "-cover") {
+		return 0.5, "only CI measures coverage; add a task, e.g. with --with-coverage"
+	}
+	return 0, "no coverage task; add one, e.g. with --with-coverage"
+}
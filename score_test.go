@@ -0,0 +1,85 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestScore(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"main.go":                  "package main\n",
+		"main_test.go":             "package main\n",
+		"util/util.go":             "package util\n",
+		".github/workflows/ci.yml": "steps:\n  - run: go vet ./...\n  - run: go test -cover ./...\n",
+		"SECURITY.md":              "Report issues privately.\n",
+		"README.md":                "# demo\n\n## Install\n\n```\ngo install\n```\n",
+		"CODEOWNERS":               "* @acme/platform\n",
+	})
+
+	card, err := Score(dir, nil)
+	if err != nil {
+		t.Fatalf("Score() error: %v", err)
+	}
+	earned := map[string]float64{}
+	for _, c := range card.Checks {
+		earned[c.Name] = c.Earned
+	}
+	want := map[string]float64{"tests": 0.5, "ci": 1, "lint": 0.5, "security": 1.0 / 3, "readme": 0.75, "coverage": 0.5}
+	for name, w := range want {
+		if earned[name] != w {
+			t.Errorf("%s earned %v, want %v", name, earned[name], w)
+		}
+	}
+	// 12.5 + 20 + 7.5 + 5 + 7.5 + 7.5 = 60
+	if card.Score != 60 || card.Rubric != "built-in" || !card.Passed() {
+		t.Errorf("score = %d with rubric %s, passed %v; want 60 with the built-in rubric, passed", card.Score, card.Rubric, card.Passed())
+	}
+
+	rubric, err := ParseRubric([]byte("weights: {tests: 0, lint: 0, security: 0, readme: 0, coverage: 0}\nfiles:\n  codeowners: {weight: 20, patterns: [CODEOWNERS]}\n  adr: {weight: 20, patterns: [docs/adr/*.md]}\npass: 70\n"), "acme.yaml")
+	if err != nil {
+		t.Fatalf("ParseRubric() error: %v", err)
+	}
+	card, err = Score(dir, rubric)
+	if err != nil {
+		t.Fatalf("Score() error: %v", err)
+	}
+	// ci 20 + codeowners 20 of 60
+	if len(card.Checks) != 3 || card.Score != 67 || card.Passed() {
+		t.Errorf("checks = %+v, score %d; want ci, adr and codeowners scoring 67, failing 70", card.Checks, card.Score)
+	}
+}
+
+func TestParseRubric(t *testing.T) {
+	for _, bad := range []string{
+		"weights: {tests: -1}\n",
+		"weights: {docs: 10}\n",
+		"wieghts: {tests: 10}\n",
+		"files: {ci: {weight: 5, patterns: [x]}}\n",
+		"files: {adr: {weight: 5}}\n",
+		"pass: 101\n",
+		"weights: {tests: 0, ci: 0, lint: 0, security: 0, readme: 0, coverage: 0}\n",
+	} {
+		if _, err := ParseRubric([]byte(bad), "bad.yaml"); err == nil {
+			t.Errorf("ParseRubric(%q) succeeded", bad)
+		}
+	}
+	if r, err := ParseRubric(nil, "empty.yaml"); err != nil || r.total() != 100 {
+		t.Errorf("ParseRubric() of an empty rubric = %+v, %v; want the built-in weights", r, err)
+	}
+}
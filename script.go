@@ -0,0 +1,337 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+	"gopkg.in/yaml.v3"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+)
+
+// ScriptName is the optional pack script that plans files beyond the
+// pack's fixed templates, e.g. one package per service a user defines.
+//
+// It is Starlark and defines generate(ctx), returning a list of dicts:
+//
+//	{"path": "internal/billing/billing.go", "template": "service.tmpl", "data": {...}}
+//	{"path": "docs/services.txt", "content": "billing\n", "mode": 0o644}
+//
+// Templates render with the usual fields plus .Data. ctx carries module,
+// project_name, package_name, binary_name, binaries, kind, features and
+// vars, and load_yaml(path), which reads a YAML file of the project (None
+// when missing). Scripts can't otherwise touch the filesystem, network or
+// environment, and are stopped after maxScriptSteps or scriptTimeout.
+const ScriptName = "generate.star"
+
+const (
+	maxScriptSteps = 10_000_000
+	scriptTimeout  = 10 * time.Second
+)
+
+// ScriptData is what templates named by the pack script render with.
+type ScriptData struct {
+	*GenConfig
+	Data any
+}
+
+// renderScript runs the pack's generate.star, if it has one, and renders
+// the files it plans.
+func (g *Generator) renderScript() ([]RenderedFile, error) {
+	fsys, err := g.pack()
+	if err != nil {
+		return nil, err
+	}
+	src, err := fs.ReadFile(fsys, ScriptName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ScriptName, err)
+	}
+
+	start := time.Now()
+	plan, err := g.runScript(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ScriptName, err)
+	}
+	g.log().Debugw("script", "name", ScriptName, "files", len(plan), "duration", time.Since(start))
+
+	var files []RenderedFile
+	for i, entry := range plan {
+		f, err := g.renderPlanned(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: file %d: %w", ScriptName, i, err)
+		}
+		files = append(files, *f)
+	}
+	return files, nil
+}
+
+// runScript evaluates src and calls its generate function.
+func (g *Generator) runScript(src []byte) ([]starlark.Value, error) {
+	thread := &starlark.Thread{
+		Name:  ScriptName,
+		Print: func(_ *starlark.Thread, msg string) { g.log().Debugw("script print", "msg", msg) },
+		Load: func(_ *starlark.Thread, module string) (starlark.StringDict, error) {
+			return nil, fmt.Errorf("load(%q): scripts can't load modules", module)
+		},
+	}
+	thread.SetMaxExecutionSteps(maxScriptSteps)
+	timer := time.AfterFunc(scriptTimeout, func() { thread.Cancel("timed out after " + scriptTimeout.String()) })
+	defer timer.Stop()
+
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, ScriptName, src, nil)
+	if err != nil {
+		return nil, scriptError(err)
+	}
+	generate, ok := globals["generate"].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("no generate(ctx) function")
+	}
+	result, err := starlark.Call(thread, generate, starlark.Tuple{g.scriptContext()}, nil)
+	if err != nil {
+		return nil, scriptError(err)
+	}
+	list, ok := result.(*starlark.List)
+	if !ok {
+		return nil, fmt.Errorf("generate returned %s, want a list", result.Type())
+	}
+	plan := make([]starlark.Value, list.Len())
+	for i := range plan {
+		plan[i] = list.Index(i)
+	}
+	return plan, nil
+}
+
+// scriptError includes the Starlark backtrace, which locates the failure.
+func scriptError(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return errors.New(evalErr.Backtrace())
+	}
+	return err
+}
+
+// scriptContext is the ctx argument of generate.
+func (g *Generator) scriptContext() starlark.Value {
+	features := make([]starlark.Value, 0, len(g.Config.Features))
+	for _, name := range sortedKeys(g.Config.Features) {
+		if g.Config.Features[name] {
+			features = append(features, starlark.String(name))
+		}
+	}
+	binaries := make([]starlark.Value, 0, len(g.Config.Binaries))
+	for _, b := range g.Config.Binaries {
+		binaries = append(binaries, starlark.String(b))
+	}
+	vars := starlark.NewDict(len(g.Config.Vars))
+	for _, k := range sortedKeys(g.Config.Vars) {
+		vars.SetKey(starlark.String(k), starlark.String(g.Config.Vars[k]))
+	}
+	return starlarkstruct.FromStringDict(starlark.String("ctx"), starlark.StringDict{
+		"module":       starlark.String(g.Config.ModuleURL),
+		"project_name": starlark.String(g.Config.ProjectName),
+		"package_name": starlark.String(g.Config.PackageName),
+		"binary_name":  starlark.String(g.Config.BinaryName),
+		"binaries":     starlark.NewList(binaries),
+		"kind":         starlark.String(g.Config.Kind),
+		"features":     starlark.NewList(features),
+		"vars":         vars,
+		"load_yaml":    starlark.NewBuiltin("load_yaml", g.scriptLoadYAML),
+	})
+}
+
+// sortedKeys keeps what scripts see independent of map order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// scriptLoadYAML is ctx.load_yaml(path): a YAML file inside the project,
+// converted to Starlark values, or None when it doesn't exist.
+func (g *Generator) scriptLoadYAML(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var rel string
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &rel); err != nil {
+		return nil, err
+	}
+	if err := checkPlannedPath(rel); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	data, err := g.fsys().ReadFile(filepath.Join(g.Config.ProjectPath(), filepath.FromSlash(rel)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return starlark.None, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	var v any
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", fn.Name(), rel, err)
+	}
+	return toStarlark(v)
+}
+
+// renderPlanned renders one entry of the script's plan.
+func (g *Generator) renderPlanned(entry starlark.Value) (*RenderedFile, error) {
+	dict, ok := entry.(*starlark.Dict)
+	if !ok {
+		return nil, fmt.Errorf("got %s, want a dict", entry.Type())
+	}
+	fields := make(map[string]any)
+	for _, kv := range dict.Items() {
+		key, ok := starlark.AsString(kv[0])
+		if !ok {
+			return nil, fmt.Errorf("key %s is not a string", kv[0])
+		}
+		v, err := fromStarlark(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		fields[key] = v
+	}
+
+	rel, _ := fields["path"].(string)
+	if err := checkPlannedPath(rel); err != nil {
+		return nil, err
+	}
+	f := &RenderedFile{Path: path.Clean(rel), Template: ScriptName, Mode: fileutils.DefaultMode}
+	if mode, ok := fields["mode"].(int64); ok {
+		f.Mode = os.FileMode(mode)
+	}
+
+	tplName, hasTemplate := fields["template"].(string)
+	content, hasContent := fields["content"].(string)
+	switch {
+	case hasTemplate == hasContent:
+		return nil, fmt.Errorf("%s: want one of template or content", rel)
+	case hasContent:
+		f.Data = []byte(content)
+	default:
+		tpl, err := g.readTemplate(tplName)
+		if err != nil {
+			return nil, err
+		}
+		if err := g.stampGenerator(); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := tpl.Execute(&buf, ScriptData{GenConfig: g.Config, Data: fields["data"]}); err != nil {
+			return nil, fmt.Errorf("failed to execute template %s: %w", tplName, err)
+		}
+		f.Template, f.Data = tplName, buf.Bytes()
+	}
+	g.log().Debugw("render", "template", f.Template, "path", f.Path, "bytes", len(f.Data))
+	return f, nil
+}
+
+// checkPlannedPath keeps script paths inside the project.
+func checkPlannedPath(rel string) error {
+	switch {
+	case rel == "":
+		return fmt.Errorf("missing path")
+	case path.IsAbs(rel) || strings.Contains(rel, `\`):
+		return fmt.Errorf("path %q must be slash-separated and relative", rel)
+	case path.Clean(rel) == ".." || strings.HasPrefix(path.Clean(rel), "../"):
+		return fmt.Errorf("path %q is outside the project", rel)
+	}
+	return nil
+}
+
+// fromStarlark converts a Starlark value to plain Go values for templates:
+// dicts become map[string]any and lists []any.
+func fromStarlark(v starlark.Value) (any, error) {
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.Int:
+		n, ok := v.Int64()
+		if !ok {
+			return nil, fmt.Errorf("integer %s is too large", v)
+		}
+		return n, nil
+	case starlark.Float:
+		return float64(v), nil
+	case starlark.String:
+		return string(v), nil
+	case starlark.Indexable: // list, tuple
+		out := make([]any, v.Len())
+		for i := range out {
+			var err error
+			if out[i], err = fromStarlark(v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case *starlark.Dict:
+		out := make(map[string]any, v.Len())
+		for _, kv := range v.Items() {
+			key, ok := starlark.AsString(kv[0])
+			if !ok {
+				return nil, fmt.Errorf("dict key %s is not a string", kv[0])
+			}
+			val, err := fromStarlark(kv[1])
+			if err != nil {
+				return nil, err
+			}
+			out[key] = val
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("can't use a %s in a template", v.Type())
+}
+
+// toStarlark converts a decoded YAML value to Starlark.
+func toStarlark(v any) (starlark.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(v), nil
+	case int:
+		return starlark.MakeInt(v), nil
+	case float64:
+		return starlark.Float(v), nil
+	case string:
+		return starlark.String(v), nil
+	case time.Time:
+		return starlark.String(v.Format(time.RFC3339)), nil
+	case []any:
+		elems := make([]starlark.Value, len(v))
+		for i, e := range v {
+			var err error
+			if elems[i], err = toStarlark(e); err != nil {
+				return nil, err
+			}
+		}
+		return starlark.NewList(elems), nil
+	case map[string]any:
+		d := starlark.NewDict(len(v))
+		for _, k := range sortedKeys(v) {
+			sv, err := toStarlark(v[k])
+			if err != nil {
+				return nil, err
+			}
+			d.SetKey(starlark.String(k), sv)
+		}
+		return d, nil
+	}
+	return nil, fmt.Errorf("unsupported YAML value %T", v)
+}
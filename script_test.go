@@ -0,0 +1,96 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+// scriptPack returns a copy of the embedded pack with a generate.star.
+func scriptPack(t *testing.T, script string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "pack")
+	if _, err := EjectTemplates(dir, false); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		ScriptName:     script,
+		"service.tmpl": "package {{.Data.name}}\n\n// Port of the {{.Data.name}} service in {{.ModuleURL}}.\nconst Port = {{.Data.port}}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestScript(t *testing.T) {
+	pack := scriptPack(t, `
+def generate(ctx):
+    services = ctx.load_yaml("services.yaml") or []
+    files = [
+        {"path": "internal/%s/%s.go" % (s["name"], s["name"]), "template": "service.tmpl", "data": s}
+        for s in services
+    ]
+    files.append({"path": "SERVICES", "content": "\n".join([s["name"] for s in services]) + "\n"})
+    return files
+`)
+	dir := filepath.Join(t.TempDir(), "demo")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	services := "- name: billing\n  port: 8081\n- name: search\n  port: 8082\n"
+	if err := os.WriteFile(filepath.Join(dir, "services.yaml"), []byte(services), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}, Templates: os.DirFS(pack)}
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	want := map[string]string{
+		"internal/billing/billing.go": "package billing\n\n// Port of the billing service in github.com/example/demo.\nconst Port = 8081\n",
+		"internal/search/search.go":   "package search\n\n// Port of the search service in github.com/example/demo.\nconst Port = 8082\n",
+		"SERVICES":                    "billing\nsearch\n",
+	}
+	for rel, content := range want {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil || string(data) != content {
+			t.Errorf("%s = %q, %v; want %q", rel, data, err, content)
+		}
+	}
+	m, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := m.File("internal/billing/billing.go"); f == nil || f.Template != "service.tmpl" {
+		t.Errorf("manifest entry = %+v, want one from service.tmpl", f)
+	}
+}
+
+func TestScriptErrors(t *testing.T) {
+	tests := []struct {
+		name, script, want string
+	}{
+		{"no generate", "x = 1\n", "no generate(ctx) function"},
+		{"not a list", "def generate(ctx):\n    return {}\n", "want a list"},
+		{"escape", "def generate(ctx):\n    return [{\"path\": \"../evil\", \"content\": \"\"}]\n", "outside the project"},
+		{"both", "def generate(ctx):\n    return [{\"path\": \"a\", \"content\": \"\", \"template\": \"service.tmpl\"}]\n", "one of template or content"},
+		{"load", "load(\"x.star\", \"y\")\n", "can't load modules"},
+		{"runaway", "def generate(ctx):\n    for i in range(1000000000):\n        pass\n", "too many steps"},
+		{"failure", "def generate(ctx):\n    fail(\"no services\")\n", "no services"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}, Templates: os.DirFS(scriptPack(t, tt.script))}
+			err := g.GenerateAll("github.com/example/demo", filepath.Join(t.TempDir(), "demo"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("GenerateAll() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/semver"
+)
+
+// StatusReport summarizes a generated project's health for project status.
+// A part that couldn't be checked has its Error set; the rest still are.
+type StatusReport struct {
+	ProjectDir string            `json:"project_dir"`
+	Manifest   ManifestStatus    `json:"manifest"`
+	Drift      DriftStatus       `json:"drift"`
+	Pack       PackStatus        `json:"pack"`
+	Deps       DepsStatus        `json:"deps"`
+	Components []ComponentStatus `json:"components"`
+}
+
+// ManifestStatus says whether .project.yaml is there and readable.
+type ManifestStatus struct {
+	Present          bool   `json:"present"`
+	Valid            bool   `json:"valid"`
+	GeneratorVersion string `json:"generator_version,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// DriftStatus counts the files that differ from the templates, by state.
+type DriftStatus struct {
+	Files map[DiffState]int `json:"files,omitempty"`
+	Error string            `json:"error,omitempty"`
+}
+
+// Count is the number of files not clean.
+func (d DriftStatus) Count() int {
+	n := 0
+	for state, count := range d.Files {
+		if state != DiffClean {
+			n += count
+		}
+	}
+	return n
+}
+
+// PackStatus compares the template pack the project was generated with to
+// the one in use, the latest this generator has.
+type PackStatus struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	Latest  string `json:"latest,omitempty"`
+	Behind  bool   `json:"behind"`
+	Error   string `json:"error,omitempty"`
+}
+
+// DepsStatus compares the project's scaffold dependencies to PinnedDeps.
+type DepsStatus struct {
+	Modules []DepStatus `json:"modules,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// Stale lists the modules behind their pins.
+func (d DepsStatus) Stale() []DepStatus {
+	var stale []DepStatus
+	for _, m := range d.Modules {
+		if m.Stale {
+			stale = append(stale, m)
+		}
+	}
+	return stale
+}
+
+// DepStatus is one pinned dependency of the project.
+type DepStatus struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Pinned  string `json:"pinned"`
+	Stale   bool   `json:"stale"` // older than the pin; upgrade-deps raises it
+}
+
+// ComponentStatus says whether the project has a recommended component.
+type ComponentStatus struct {
+	Name    string `json:"name"`
+	Present bool   `json:"present"`
+}
+
+// recommended are the components status looks for, each found by a file
+// matching one of its patterns; a pattern without a slash matches the
+// file's base name at any depth.
+var recommended = []struct {
+	name     string
+	patterns []string
+}{
+	{"ci", []string{".github/workflows/*.yml", ".github/workflows/*.yaml", ".gitlab-ci.yml"}},
+	{"lint", []string{".golangci.yml", ".golangci.yaml", ".golangci.toml", ".golangci.json"}},
+	{"tests", []string{"*_test.go"}},
+	{"readme", []string{"README.md", "README"}},
+	{"license", []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "COPYING"}},
+}
+
+// Healthy reports whether nothing in r needs attention.
+func (r *StatusReport) Healthy() bool {
+	if !r.Manifest.Valid || r.Drift.Error != "" || r.Drift.Count() > 0 || r.Pack.Behind || len(r.Deps.Stale()) > 0 {
+		return false
+	}
+	for _, c := range r.Components {
+		if !c.Present {
+			return false
+		}
+	}
+	return true
+}
+
+// Status checks the project in projectDir: its manifest, the files that
+// drifted from the templates in use, whether those are a newer pack than
+// the project was generated with, which scaffold dependencies are behind
+// their pins, and which recommended components it lacks. Nothing is
+// written.
+func (g *Generator) Status(projectDir string) (*StatusReport, error) {
+	report := &StatusReport{ProjectDir: projectDir}
+	g.Config = NewGenConfig("", projectDir)
+	m, err := loadManifest(g.fsys(), projectDir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		report.Manifest = ManifestStatus{Present: true, Error: err.Error()}
+	case m.ModuleURL == "":
+		report.Manifest = ManifestStatus{Present: true, Error: fmt.Sprintf("%s has no module", ManifestName)}
+	default:
+		report.Manifest = ManifestStatus{Present: true, Valid: true, GeneratorVersion: m.GeneratorVersion}
+	}
+
+	if !report.Manifest.Valid {
+		report.Pack.Error = "no valid manifest"
+		report.Drift.Error = report.Pack.Error
+	} else if report.Pack = g.packStatus(m); report.Pack.Error != "" {
+		// Drift from another pack's templates would be noise
+		report.Drift.Error = report.Pack.Error
+	} else {
+		report.Drift = g.driftStatus(projectDir)
+	}
+	report.Deps = g.depsStatus()
+
+	files, err := projectFiles(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range recommended {
+		report.Components = append(report.Components, ComponentStatus{Name: c.name, Present: anyMatch(files, c.patterns)})
+	}
+	return report, nil
+}
+
+func (g *Generator) driftStatus(projectDir string) DriftStatus {
+	diffs, err := g.Diff(projectDir)
+	if err != nil {
+		return DriftStatus{Error: err.Error()}
+	}
+	d := DriftStatus{Files: make(map[DiffState]int)}
+	for _, f := range diffs {
+		d.Files[f.State]++
+	}
+	return d
+}
+
+func (g *Generator) packStatus(m *Manifest) PackStatus {
+	p := PackStatus{Name: m.TemplatePack, Version: m.TemplateVersion}
+	pack, err := g.PackInfo()
+	if err != nil {
+		p.Error = err.Error()
+		return p
+	}
+	if pack.Name != m.TemplatePack {
+		p.Error = fmt.Sprintf("generated with pack %s, not %s; check with --templates", m.TemplatePack, pack.Name)
+		return p
+	}
+	p.Latest = pack.Version
+	p.Behind = semver.Compare(m.TemplateVersion, pack.Version) < 0
+	return p
+}
+
+func (g *Generator) depsStatus() DepsStatus {
+	if _, err := g.fsys().Stat(filepath.Join(g.Config.ProjectPath(), "go.mod")); err != nil {
+		return DepsStatus{Error: "no go.mod"}
+	}
+	required, err := g.requiredModules()
+	if err != nil {
+		return DepsStatus{Error: err.Error()}
+	}
+	var d DepsStatus
+	for _, dep := range PinnedDeps {
+		version, ok := required[dep.Path]
+		if !ok {
+			continue
+		}
+		d.Modules = append(d.Modules, DepStatus{Path: dep.Path, Version: version, Pinned: dep.Version, Stale: semver.Compare(version, dep.Version) < 0})
+	}
+	return d
+}
+
+// anyMatch reports whether a file of files matches one of patterns.
+func anyMatch(files, patterns []string) bool {
+	for _, f := range files {
+		for _, p := range patterns {
+			name := f
+			if !strings.Contains(p, "/") {
+				name = path.Base(f)
+			}
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+func TestStatus(t *testing.T) {
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	dir := t.TempDir()
+	if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	root := g.Config.ProjectPath()
+	readme := filepath.Join(root, "README.md")
+	data, err := os.ReadFile(readme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(readme, append(data, "mine\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// go.mod requires an older zap than the generator pins
+	staleZap := func(c execx.Call) (execx.Result, error) {
+		if c.String() == "go mod edit -json" {
+			return execx.Result{Stdout: []byte(`{"Require": [{"Path": "go.uber.org/zap", "Version": "v1.26.0"}, {"Path": "gopkg.in/yaml.v3", "Version": "v3.0.1"}]}`)}, nil
+		}
+		return fakeGo(c)
+	}
+	g = &Generator{Runner: &execx.Recorder{Respond: staleZap}}
+	report, err := g.Status(root)
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+	if !report.Manifest.Valid || report.Manifest.GeneratorVersion != Version {
+		t.Errorf("manifest = %+v, want valid, generated by %s", report.Manifest, Version)
+	}
+	if report.Drift.Count() != 1 || report.Drift.Files[DiffCustomized] != 1 {
+		t.Errorf("drift = %+v, want README.md customized", report.Drift)
+	}
+	if report.Pack.Error != "" || report.Pack.Behind || report.Pack.Latest != report.Pack.Version {
+		t.Errorf("pack = %+v, want the latest", report.Pack)
+	}
+	if stale := report.Deps.Stale(); len(stale) != 1 || stale[0].Path != "go.uber.org/zap" || stale[0].Pinned != "v1.27.0" {
+		t.Errorf("stale deps = %+v, want zap", stale)
+	}
+	present := map[string]bool{}
+	for _, c := range report.Components {
+		present[c.Name] = c.Present
+	}
+	if !present["ci"] || !present["readme"] || present["lint"] {
+		t.Errorf("components = %+v, want ci and readme but no lint", report.Components)
+	}
+	if report.Healthy() {
+		t.Error("Healthy() = true for a project with drift")
+	}
+
+	// Without a manifest the rest is still checked
+	empty := t.TempDir()
+	report, err = (&Generator{Runner: &execx.Recorder{Respond: fakeGo}}).Status(empty)
+	if err != nil {
+		t.Fatalf("Status() without a manifest error: %v", err)
+	}
+	if report.Manifest.Present || report.Drift.Error == "" || report.Pack.Error == "" {
+		t.Errorf("report = %+v, want no manifest and no drift or pack checks", report)
+	}
+	if len(report.Components) == 0 || report.Components[0].Present {
+		t.Errorf("components = %+v, want ci missing", report.Components)
+	}
+}
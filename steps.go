@@ -0,0 +1,176 @@
+package project
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// step is one named stage of GenerateAll. Steps that run go or hooks in
+// the project need it on the host.
+type step struct {
+	name string
+	run  func(g *Generator) error
+	host bool
+}
+
+// steps are the stages of GenerateAll, in order, once the project's
+// settings are resolved. Hooks post-process the code after tidy, so they
+// can build it.
+var steps = []step{
+	{"render", (*Generator).stepRender, false},
+	{"modinit", (*Generator).stepModInit, false},
+	{"replace", (*Generator).stepReplace, false},
+	{"tidy", (*Generator).stepTidy, true},
+	{"postprocess", (*Generator).runHooks, true},
+	{"attest", (*Generator).stepAttest, false},
+	{"stamp", (*Generator).stampFiles, false},
+}
+
+// StepNames lists the steps of GenerateAll in order, for SkipSteps and
+// OnlySteps.
+func StepNames() []string {
+	names := make([]string, len(steps))
+	for i, s := range steps {
+		names[i] = s.name
+	}
+	return names
+}
+
+// plannedSteps picks the steps of this run: those the pack's steps list,
+// or all, narrowed to g.OnlySteps when set and without g.SkipSteps, and
+// without those that need the host when g.FS is elsewhere.
+func (g *Generator) plannedSteps() ([]step, error) {
+	pack, err := g.PackInfo()
+	if err != nil {
+		return nil, err
+	}
+	for _, names := range [][]string{pack.Steps, g.OnlySteps, g.SkipSteps} {
+		if err := checkSteps(names); err != nil {
+			return nil, err
+		}
+	}
+
+	var out []step
+	for _, s := range steps {
+		if (len(pack.Steps) > 0 && !slices.Contains(pack.Steps, s.name)) ||
+			(len(g.OnlySteps) > 0 && !slices.Contains(g.OnlySteps, s.name)) ||
+			slices.Contains(g.SkipSteps, s.name) {
+			g.log().Debugw("step", "name", s.name, "skipped", true)
+			continue
+		}
+		if s.host && !g.onHost() {
+			g.log().Debugw("step", "name", s.name, "skipped", true, "reason", "not on the host filesystem")
+			continue
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// runSteps runs the planned steps in order, stopping at the first error.
+func (g *Generator) runSteps() error {
+	planned, err := g.plannedSteps()
+	if err != nil {
+		return err
+	}
+	for i, s := range planned {
+		if err := g.ctx().Err(); err != nil {
+			return err
+		}
+		g.event(Event{Kind: EventStepStart, Step: s.name, Index: i + 1, Total: len(planned)})
+		start := time.Now()
+		err := s.run(g)
+		took := time.Since(start)
+		g.log().Debugw("step", "name", s.name, "duration", took)
+		g.event(Event{Kind: EventStepDone, Step: s.name, Index: i + 1, Total: len(planned), Duration: took, Err: errString(err)})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSteps reports any name that isn't a step.
+func checkSteps(names []string) error {
+	for _, n := range names {
+		if !slices.Contains(StepNames(), n) {
+			return fmt.Errorf("unknown step %q; want one of %s", n, strings.Join(StepNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// stepRender writes every planned file, then the manifest recording them,
+// once g.Policy passes them.
+func (g *Generator) stepRender() error {
+	files, err := g.plannedFiles()
+	if err != nil {
+		return err
+	}
+	if g.Policy != nil {
+		if err := g.Policy.Check(g, files); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		if err := g.ctx().Err(); err != nil {
+			return err
+		}
+		if err := g.emit(f); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", f.Path, err)
+		}
+	}
+	if err := g.WriteManifest(); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+func (g *Generator) stepModInit() error {
+	if err := g.InitMod(); err != nil {
+		return fmt.Errorf("go mod init failed: %w", err)
+	}
+	return nil
+}
+
+// stepReplace points the CLI's imports of its own packages at the working
+// tree, and the modules of sibling projects it uses at theirs; adopted
+// projects are left alone.
+func (g *Generator) stepReplace() error {
+	if g.prev != nil && g.prev.Adopted {
+		return nil
+	}
+	if g.Kind != "library" {
+		if err := g.addReplaceDirectives(); err != nil {
+			return fmt.Errorf("failed to add replace directives: %w", err)
+		}
+	}
+	if err := g.replaceUses(); err != nil {
+		return fmt.Errorf("failed to replace sibling modules: %w", err)
+	}
+	return nil
+}
+
+// stepTidy pins the scaffold's dependencies, tidies, and vendors when the
+// project does.
+func (g *Generator) stepTidy() error {
+	if err := g.pinDeps(); err != nil {
+		return fmt.Errorf("failed to pin dependencies: %w", err)
+	}
+	if err := g.ModTidy(); err != nil {
+		return fmt.Errorf("go mod tidy failed: %w", err)
+	}
+	if err := g.modVendor(); err != nil {
+		return fmt.Errorf("go mod vendor failed: %w", err)
+	}
+	return nil
+}
+
+func (g *Generator) stepAttest() error {
+	if err := g.writeAttestations(); err != nil {
+		return fmt.Errorf("failed to write attestations: %w", err)
+	}
+	return nil
+}
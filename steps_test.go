@@ -0,0 +1,52 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+func TestGenerateAllSteps(t *testing.T) {
+	tests := []struct {
+		name       string
+		skip, only []string
+		commands   string
+		rendered   bool
+	}{
+		{"skip tidy", []string{"tidy"}, nil, "go mod init", true},
+		{"only render", nil, []string{"render"}, "", true},
+		{"only modinit", nil, []string{"modinit"}, "go mod init", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &execx.Recorder{Respond: fakeGo}
+			g := &Generator{Runner: rec, SkipSteps: tt.skip, OnlySteps: tt.only}
+			if err := g.GenerateAll("github.com/example/demo", t.TempDir()); err != nil {
+				t.Fatalf("GenerateAll() error: %v", err)
+			}
+
+			var got []string
+			for _, c := range rec.Calls() {
+				got = append(got, c.Name+" "+strings.Join(c.Args[:2], " "))
+			}
+			if strings.Join(got, "|") != tt.commands {
+				t.Errorf("commands = %q, want %q", got, tt.commands)
+			}
+			_, err := os.Stat(filepath.Join(g.Config.ProjectPath(), ManifestName))
+			if (err == nil) != tt.rendered {
+				t.Errorf("manifest written = %v, want %v", err == nil, tt.rendered)
+			}
+		})
+	}
+}
+
+func TestGenerateAllUnknownStep(t *testing.T) {
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}, SkipSteps: []string{"lint"}}
+	err := g.GenerateAll("github.com/example/demo", t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), `unknown step "lint"`) {
+		t.Errorf("GenerateAll() error = %v, want unknown step", err)
+	}
+}
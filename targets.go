@@ -0,0 +1,85 @@
+package project
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Target is a GOOS/GOARCH pair the build:all task cross-compiles for,
+// written "linux/amd64" in blueprints, flags and the manifest.
+type Target struct {
+	OS   string
+	Arch string
+}
+
+func (t Target) String() string {
+	return t.OS + "/" + t.Arch
+}
+
+// Name names the target in task and file names, e.g. "linux-amd64".
+func (t Target) Name() string {
+	return t.OS + "-" + t.Arch
+}
+
+// Ext is the extension of the target's executables: ".exe" on Windows.
+func (t Target) Ext() string {
+	if t.OS == "windows" {
+		return ".exe"
+	}
+	return ""
+}
+
+// knownOS and knownArch are the GOOS and GOARCH values of go tool dist
+// list. Whether a pair of them is supported is left to go build.
+var (
+	knownOS   = []string{"aix", "android", "darwin", "dragonfly", "freebsd", "illumos", "ios", "js", "linux", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows"}
+	knownArch = []string{"386", "amd64", "arm", "arm64", "loong64", "mips", "mips64", "mips64le", "mipsle", "ppc64", "ppc64le", "riscv64", "s390x", "wasm"}
+)
+
+// ParseTarget reads a target written GOOS/GOARCH, e.g. "darwin/arm64".
+func ParseTarget(s string) (Target, error) {
+	goos, goarch, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "/")
+	if !ok {
+		return Target{}, fmt.Errorf("invalid target %q, want GOOS/GOARCH such as linux/amd64", s)
+	}
+	if !slices.Contains(knownOS, goos) {
+		return Target{}, fmt.Errorf("unknown GOOS %q in target %q (known: %s)", goos, s, strings.Join(knownOS, ", "))
+	}
+	if !slices.Contains(knownArch, goarch) {
+		return Target{}, fmt.Errorf("unknown GOARCH %q in target %q (known: %s)", goarch, s, strings.Join(knownArch, ", "))
+	}
+	return Target{OS: goos, Arch: goarch}, nil
+}
+
+// applyTargets settles the targets build:all builds for, those of the
+// previous manifest then g.Targets, and exposes them to templates as
+// g.Config.Targets. With none, the Taskfile has no build:all.
+func (g *Generator) applyTargets(prev *Manifest) error {
+	var names []string
+	if prev != nil {
+		names = append(names, prev.Targets...)
+	}
+	names = append(names, g.Targets...)
+	if len(names) > 0 && g.Config.Kind == "library" {
+		return fmt.Errorf("library projects have no binaries to build for targets")
+	}
+	seen := make(map[Target]bool)
+	var targets []Target
+	for _, name := range names {
+		t, err := ParseTarget(name)
+		if err != nil {
+			return err
+		}
+		if !seen[t] {
+			seen[t] = true
+			targets = append(targets, t)
+		}
+	}
+	g.Targets = g.Targets[:0]
+	for _, t := range targets {
+		g.Targets = append(g.Targets, t.String())
+	}
+	g.Config.Targets = targets
+	return nil
+}
@@ -0,0 +1,53 @@
+package project
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		in, want, err string
+	}{
+		{in: "linux/amd64", want: "linux/amd64"},
+		{in: " Darwin/ARM64 ", want: "darwin/arm64"},
+		{in: "linux", err: "want GOOS/GOARCH"},
+		{in: "linx/amd64", err: `unknown GOOS "linx"`},
+		{in: "linux/x86", err: `unknown GOARCH "x86"`},
+	}
+	for _, tt := range tests {
+		got, err := ParseTarget(tt.in)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseTarget(%q) error = %v, want %q", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("ParseTarget(%q) = %v, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+	if w := (Target{OS: "windows", Arch: "amd64"}); w.Name()+w.Ext() != "windows-amd64.exe" {
+		t.Errorf("windows target names %s%s, want windows-amd64.exe", w.Name(), w.Ext())
+	}
+}
+
+func TestApplyTargets(t *testing.T) {
+	g := &Generator{Config: NewGenConfig("github.com/example/demo", ""), Targets: []string{"darwin/arm64", "linux/amd64"}}
+	if err := g.applyTargets(&Manifest{Targets: []string{"linux/amd64"}}); err != nil {
+		t.Fatalf("applyTargets() error: %v", err)
+	}
+	if want := []string{"linux/amd64", "darwin/arm64"}; !slices.Equal(g.Targets, want) {
+		t.Errorf("Targets = %v, want %v", g.Targets, want)
+	}
+	if len(g.Config.Targets) != 2 || g.Config.Targets[1] != (Target{OS: "darwin", Arch: "arm64"}) {
+		t.Errorf("Config.Targets = %v", g.Config.Targets)
+	}
+
+	g = &Generator{Config: NewGenConfig("github.com/example/demo", ""), Targets: []string{"linux/amd64"}}
+	g.Config.Kind = "library"
+	if err := g.applyTargets(nil); err == nil || !strings.Contains(err.Error(), "library") {
+		t.Errorf("applyTargets() on a library = %v, want an error", err)
+	}
+}
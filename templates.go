@@ -2,5 +2,10 @@ package project
 
 import "embed"
 
-//go:embed templates/*
+// The embedded pack is the templates and pack files, without the
+// templates' own Go tests. It has no static/ folder, so those patterns
+// leave nothing out; one added to it needs its own all:templates/static
+// pattern, the all: prefix keeping dotfiles such as static/.gitattributes.
+//
+//go:embed templates/*.tmpl templates/*.yaml
 var templateFS embed.FS
@@ -3,22 +3,51 @@ package templates
 import (
 	"bytes"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/robbyriverside/project"
 )
 
 // TemplateData only contains the project configuration fields
 // that should be replaced during template generation
 type TemplateData struct {
 	ProjectName string
+	PackageName string
+	BinaryName  string
+	Binaries    []string
+	Targets     []project.Target
+	EnvPrefix   string
 	ModuleURL   string
+	Host        string
+	Owner       string
+	RepoURL     string
 	HomeDir     string
 	MainPath    string
 	Version     string
+	Kind        string
+	Features    map[string]bool
+	Taskfiles   []string
+	Vars        map[string]string
+	Vendor      bool
+	Catalog     project.Catalog
+
+	Subcommands  []project.Subcommand
+	ConfigFields []project.ConfigField
+	EnvTargets   []project.EnvTarget
+	Subcommand   project.Subcommand
+	EnvTarget    project.EnvTarget
+
+	GeneratorVersion string
+	TemplatePack     string
+	TemplateVersion  string
 }
 
 // taskVarMap is a map of task variables that should be preserved in the output
@@ -29,18 +58,32 @@ func (t taskVarMap) Get(name string) string {
 	return fmt.Sprintf("{{.%s}}", name)
 }
 
+// componentTasks are the tasks each component taskfile defines.
+var componentTasks = map[string][]string{
+	"taskfile-bench.tmpl":    {"bench", "profile"},
+	"taskfile-coverage.tmpl": {"cover", "cover-html"},
+	"taskfile-mocks.tmpl":    {"generate"},
+	"taskfile-app.tmpl":      {"serve", "dev"},
+	"taskfile-wire.tmpl":     {"wire"},
+	"taskfile-plugins.tmpl":  {"plugins"},
+	"taskfile-itest.tmpl":    {"itest"},
+	"taskfile-fuzz.tmpl":     {"fuzz"},
+	"taskfile-wasm.tmpl":     {"build:wasm", "serve:wasm"},
+}
+
+// A command, config field and env target as a blueprint would add them
+var (
+	testSubcommand  = project.Subcommand{Name: "sync-users", Binary: "testapp", Short: "Sync users", Long: "Copies users from the \"directory\""}
+	testConfigField = project.ConfigField{Name: "MaxRetries", Key: "max_retries", Type: "int", Desc: "Retries per request", Default: "3"}
+	testEnvTarget   = project.EnvTarget{Name: "staging", Values: map[string]string{"log_fmt": "text", "max_retries": "5"}}
+)
+
 func TestTemplates(t *testing.T) {
 	// Create a temporary output directory
-	outputDir := "../testout"
-	if err := os.RemoveAll(outputDir); err != nil {
-		t.Fatalf("failed to clean output dir: %v", err)
-	}
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		t.Fatalf("failed to create output dir: %v", err)
-	}
+	outputDir := t.TempDir()
 
 	// Create package directories
-	for _, dir := range []string{"cmd/testapp", "logs", "config"} {
+	for _, dir := range []string{"cmd/testapp", "cmd/testapp-hello", "cmd/testapp-wasm", "web", "taskfiles", "logs", "config", "pathutil", "app"} {
 		if err := os.MkdirAll(filepath.Join(outputDir, dir), 0755); err != nil {
 			t.Fatalf("failed to create dir %s: %v", dir, err)
 		}
@@ -77,84 +120,910 @@ replace (
 		}
 	}{
 		{
-			name:       "project template",
-			tmplFile:   "project.tmpl",
-			outputFile: "project.go",
+			name:       "project template",
+			tmplFile:   "project.tmpl",
+			outputFile: "project.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					Version:     "v0.1.0",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "project template with license",
+			tmplFile:   "project.tmpl",
+			outputFile: "project.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					Version:     "v0.1.0",
+					ModuleURL:   "github.com/example/testapp",
+					Vars:        map[string]string{"license": "Apache-2.0"},
+				},
+			},
+		},
+		{
+			name:       "taskfile template",
+			tmplFile:   "taskfile.tmpl",
+			outputFile: "Taskfile.yaml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					Binaries:    []string{"testapp"},
+					MainPath:    "./cmd/testapp",
+					ModuleURL:   "github.com/example/testapp",
+					Features:    map[string]bool{"bench": true, "coverage": true, "fuzz": true, "wasm": true},
+					Taskfiles:   []string{"bench", "coverage", "fuzz", "wasm"},
+					EnvTargets:  []project.EnvTarget{testEnvTarget},
+				},
+				Task: taskVarMap{
+					"VERSION":   "",
+					"COMMIT":    "",
+					"BUILDTIME": "",
+					"LDFLAGS":   "",
+					"OUT":       "",
+					"MAIN":      "",
+					"CLI_ARGS":  "",
+				},
+			},
+		},
+		{
+			name:       "bench taskfile template",
+			tmplFile:   "taskfile-bench.tmpl",
+			outputFile: "taskfiles/bench.yaml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "coverage taskfile template",
+			tmplFile:   "taskfile-coverage.tmpl",
+			outputFile: "taskfiles/coverage.yaml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "mocks taskfile template",
+			tmplFile:   "taskfile-mocks.tmpl",
+			outputFile: "taskfiles/mocks.yaml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "app taskfile template",
+			tmplFile:   "taskfile-app.tmpl",
+			outputFile: "taskfiles/app.yaml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "wire taskfile template",
+			tmplFile:   "taskfile-wire.tmpl",
+			outputFile: "taskfiles/wire.yaml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "plugins taskfile template",
+			tmplFile:   "taskfile-plugins.tmpl",
+			outputFile: "taskfiles/plugins.yaml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "itest taskfile template",
+			tmplFile:   "taskfile-itest.tmpl",
+			outputFile: "taskfiles/itest.yaml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "fuzz taskfile template",
+			tmplFile:   "taskfile-fuzz.tmpl",
+			outputFile: "taskfiles/fuzz.yaml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "wasm taskfile template",
+			tmplFile:   "taskfile-wasm.tmpl",
+			outputFile: "taskfiles/wasm.yaml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "multi-binary taskfile template",
+			tmplFile:   "taskfile.tmpl",
+			outputFile: "Taskfile.multi.yaml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					Binaries:    []string{"testapp", "testctl"},
+					Targets:     []project.Target{{OS: "linux", Arch: "amd64"}, {OS: "windows", Arch: "arm64"}},
+					ModuleURL:   "github.com/example/testapp",
+					Features:    map[string]bool{"bench": true, "coverage": true, "fuzz": true},
+					Taskfiles:   []string{"bench", "coverage", "fuzz"},
+				},
+			},
+		},
+		{
+			name:       "goreleaser template",
+			tmplFile:   "goreleaser.tmpl",
+			outputFile: ".goreleaser.yaml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					Binaries:    []string{"testapp", "testctl"},
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "main template",
+			tmplFile:   "main.tmpl",
+			outputFile: "cmd/testapp/main.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+					Features:    map[string]bool{"plugins": true},
+					Subcommands: []project.Subcommand{testSubcommand},
+				},
+			},
+		},
+		{
+			name:       "subcommand template",
+			tmplFile:   "subcommand.tmpl",
+			outputFile: "cmd/testapp/sync_users_cmd.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+					Subcommand:  testSubcommand,
+				},
+			},
+		},
+		{
+			name:       "env target template",
+			tmplFile:   "envtarget.tmpl",
+			outputFile: "staging.env",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+					EnvTarget:   testEnvTarget,
+				},
+			},
+		},
+		{
+			name:       "plugins template",
+			tmplFile:   "plugins.tmpl",
+			outputFile: "cmd/testapp/plugins.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "plugin example template",
+			tmplFile:   "plugin-example.tmpl",
+			outputFile: "cmd/testapp-hello/main.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "wasm template",
+			tmplFile:   "wasm.tmpl",
+			outputFile: "cmd/testapp-wasm/main.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "wasm page template",
+			tmplFile:   "wasm-html.tmpl",
+			outputFile: "web/index.html",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "config template",
+			tmplFile:   "config.tmpl",
+			outputFile: "config/config.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName:  "testapp",
+					PackageName:  "testapp",
+					BinaryName:   "testapp",
+					HomeDir:      "~/testapp",
+					EnvPrefix:    "TESTAPP",
+					ModuleURL:    "github.com/example/testapp",
+					Features:     map[string]bool{"remoteconfig": true},
+					ConfigFields: []project.ConfigField{testConfigField},
+				},
+			},
+		},
+		{
+			name:       "pathutil template",
+			tmplFile:   "pathutil.tmpl",
+			outputFile: "pathutil/pathutil.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "logs template",
+			tmplFile:   "logs.tmpl",
+			outputFile: "logs/logs.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "bench template",
+			tmplFile:   "bench.tmpl",
+			outputFile: "bench_test.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "fuzz template",
+			tmplFile:   "fuzz.tmpl",
+			outputFile: "pathutil/fuzz_test.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "github ci template",
+			tmplFile:   "github-ci.tmpl",
+			outputFile: "ci.yml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+					Host:        "github.com",
+					Owner:       "example",
+					Features:    map[string]bool{"coverage": true, "private": true, "wasm": true},
+				},
+			},
+		},
+		{
+			name:       "gitlab ci template",
+			tmplFile:   "gitlab-ci.tmpl",
+			outputFile: ".gitlab-ci.yml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+					Host:        "github.com",
+					Owner:       "example",
+					Features:    map[string]bool{"coverage": true, "private": true, "wasm": true},
+				},
+			},
+		},
+		{
+			name:       "coverage script template",
+			tmplFile:   "coverage.tmpl",
+			outputFile: "coverage-check.sh",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+					Features:    map[string]bool{"coverage": true},
+				},
+			},
+		},
+		{
+			name:       "library doc template",
+			tmplFile:   "doc.tmpl",
+			outputFile: "doc.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+					Kind:        "library",
+				},
+			},
+		},
+		{
+			name:       "library example template",
+			tmplFile:   "example.tmpl",
+			outputFile: "example_test.go",
 			data: struct {
 				TemplateData
 				Task taskVarMap
 			}{
 				TemplateData: TemplateData{
 					ProjectName: "testapp",
-					Version:    "v0.1.0",
-					ModuleURL:  "github.com/example/testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+					Kind:        "library",
 				},
 			},
 		},
 		{
-			name:       "taskfile template",
-			tmplFile:   "taskfile.tmpl",
-			outputFile: "Taskfile.yaml",
+			name:       "library readme template",
+			tmplFile:   "readme.tmpl",
+			outputFile: "README.library.md",
 			data: struct {
 				TemplateData
 				Task taskVarMap
 			}{
 				TemplateData: TemplateData{
 					ProjectName: "testapp",
-					MainPath:    "./cmd/testapp",
-					ModuleURL:  "github.com/example/testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+					Kind:        "library",
 				},
-				Task: taskVarMap{
-					"VERSION":   "",
-					"COMMIT":    "",
-					"BUILDTIME": "",
-					"LDFLAGS":   "",
-					"OUT":       "",
-					"MAIN":      "",
-					"CLI_ARGS":  "",
+			},
+		},
+		{
+			name:       "remote config template",
+			tmplFile:   "config-remote.tmpl",
+			outputFile: "config/remote.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+					Features:    map[string]bool{"remoteconfig": true},
 				},
 			},
 		},
 		{
-			name:       "main template",
-			tmplFile:   "main.tmpl",
-			outputFile: "cmd/testapp/main.go",
+			name:       "docs command template",
+			tmplFile:   "docs.tmpl",
+			outputFile: "cmd/testapp/docs.go",
 			data: struct {
 				TemplateData
 				Task taskVarMap
 			}{
 				TemplateData: TemplateData{
 					ProjectName: "testapp",
-					ModuleURL:  "github.com/example/testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
 				},
 			},
 		},
 		{
-			name:       "config template",
-			tmplFile:   "config.tmpl",
-			outputFile: "config/config.go",
+			name:       "about command template",
+			tmplFile:   "about.tmpl",
+			outputFile: "cmd/testapp/about.go",
 			data: struct {
 				TemplateData
 				Task taskVarMap
 			}{
 				TemplateData: TemplateData{
 					ProjectName: "testapp",
-					HomeDir:     "~/testapp",
-					ModuleURL:  "github.com/example/testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
 				},
 			},
 		},
 		{
-			name:       "logs template",
-			tmplFile:   "logs.tmpl",
-			outputFile: "logs/logs.go",
+			name:       "setup command template",
+			tmplFile:   "setup.tmpl",
+			outputFile: "cmd/testapp/setup.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "env command template",
+			tmplFile:   "env.tmpl",
+			outputFile: "cmd/testapp/env.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+					EnvPrefix:   "TESTAPP",
+				},
+			},
+		},
+		{
+			name:       "scaffold metadata template",
+			tmplFile:   "scaffold.tmpl",
+			outputFile: "cmd/testapp/scaffold.yaml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName:      "testapp",
+					PackageName:      "testapp",
+					BinaryName:       "testapp",
+					ModuleURL:        "github.com/example/testapp",
+					Kind:             "cli",
+					Features:         map[string]bool{"bench": true, "fuzz": false, "itest": true},
+					GeneratorVersion: "0.0.1",
+					TemplatePack:     "go-cli",
+					TemplateVersion:  "1.10.0",
+				},
+			},
+		},
+		{
+			name:       "app template",
+			tmplFile:   "app.tmpl",
+			outputFile: "app/app.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+					Features:    map[string]bool{"app": true, "wire": true, "remoteconfig": true},
+				},
+			},
+		},
+		{
+			name:       "app store template",
+			tmplFile:   "app-store.tmpl",
+			outputFile: "app/store.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "app server template",
+			tmplFile:   "app-server.tmpl",
+			outputFile: "app/server.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "wire injector template",
+			tmplFile:   "wire.tmpl",
+			outputFile: "app/wire.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "wire generated template",
+			tmplFile:   "wire-gen.tmpl",
+			outputFile: "app/wire_gen.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "smoke test template",
+			tmplFile:   "smoke.tmpl",
+			outputFile: "cmd/testapp/smoke_test.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "main test template",
+			tmplFile:   "main_test.tmpl",
+			outputFile: "cmd/testapp/main_test.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "air template",
+			tmplFile:   "air.tmpl",
+			outputFile: ".air.toml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "catalog template",
+			tmplFile:   "catalog.tmpl",
+			outputFile: "catalog-info.yaml",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+					Host:        "github.com",
+					Owner:       "example",
+					RepoURL:     "https://github.com/example/testapp",
+					Features:    map[string]bool{"app": true, "catalog": true},
+					Catalog:     project.Catalog{Owner: "group:platform", System: "billing", Lifecycle: "production"},
+				},
+			},
+		},
+		{
+			name:       "gitignore template",
+			tmplFile:   "gitignore.tmpl",
+			outputFile: ".gitignore",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					ModuleURL:   "github.com/example/testapp",
+					Features:    map[string]bool{"coverage": true, "wasm": true},
+				},
+			},
+		},
+		{
+			name:       "context template",
+			tmplFile:   "context.tmpl",
+			outputFile: "cmd/testapp/context.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "serve command template",
+			tmplFile:   "serve.tmpl",
+			outputFile: "cmd/testapp/serve.go",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					EnvPrefix:   "TESTAPP",
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "readme template",
+			tmplFile:   "readme.tmpl",
+			outputFile: "README.md",
+			data: struct {
+				TemplateData
+				Task taskVarMap
+			}{
+				TemplateData: TemplateData{
+					ProjectName: "testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					Binaries:    []string{"testapp"},
+					ModuleURL:   "github.com/example/testapp",
+				},
+			},
+		},
+		{
+			name:       "japanese readme template",
+			tmplFile:   "readme.ja.tmpl",
+			outputFile: "README.ja.md",
 			data: struct {
 				TemplateData
 				Task taskVarMap
 			}{
 				TemplateData: TemplateData{
 					ProjectName: "testapp",
-					ModuleURL:  "github.com/example/testapp",
+					PackageName: "testapp",
+					BinaryName:  "testapp",
+					Binaries:    []string{"testapp", "testctl"},
+					ModuleURL:   "github.com/example/testapp",
 				},
 			},
 		},
@@ -178,8 +1047,9 @@ replace (
 				}
 			}
 
-			// Parse template
-			tmpl, err := template.New(tc.tmplFile).Parse(content)
+			// Parse template, with the generator's funcs at a fixed clock
+			funcs := template.FuncMap{"year": func() int { return 2021 }}
+			tmpl, err := template.New(tc.tmplFile).Funcs(funcs).Parse(content)
 			if err != nil {
 				t.Fatalf("failed to parse template: %v", err)
 			}
@@ -222,22 +1092,257 @@ replace (
 				if !strings.Contains(output, "version + `") {
 					t.Errorf("project output missing version parameter")
 				}
+				license := tc.data.Vars["license"]
+				if license == "" {
+					license = "MIT"
+				}
+				if !strings.Contains(output, "License: "+license) {
+					t.Errorf("project output missing license %s", license)
+				}
+			case "taskfile-bench.tmpl", "taskfile-coverage.tmpl", "taskfile-mocks.tmpl", "taskfile-app.tmpl", "taskfile-wire.tmpl", "taskfile-plugins.tmpl", "taskfile-itest.tmpl", "taskfile-fuzz.tmpl", "taskfile-wasm.tmpl":
+				var taskfile struct {
+					Version string         `yaml:"version"`
+					Tasks   map[string]any `yaml:"tasks"`
+				}
+				if err := yaml.Unmarshal([]byte(output), &taskfile); err != nil {
+					t.Fatalf("%s output is not valid YAML: %v", tc.tmplFile, err)
+				}
+				for _, task := range componentTasks[tc.tmplFile] {
+					if _, ok := taskfile.Tasks[task]; !ok || taskfile.Version != "3" {
+						t.Errorf("%s output missing task %s", tc.tmplFile, task)
+					}
+				}
 			case "taskfile.tmpl":
 				if !strings.Contains(output, tc.data.ProjectName) {
 					t.Errorf("taskfile output missing project name")
 				}
+				for _, name := range tc.data.Taskfiles {
+					if !strings.Contains(output, "\n  "+name+":\n    taskfile: ./taskfiles/"+name+".yaml\n    flatten: true\n") {
+						t.Errorf("taskfile output missing include of taskfiles/%s.yaml", name)
+					}
+				}
+				if len(tc.data.Binaries) > 1 {
+					for _, bin := range tc.data.Binaries {
+						if !strings.Contains(output, "  build:"+bin+":") || !strings.Contains(output, "./cmd/"+bin) {
+							t.Errorf("taskfile output missing build task for %s", bin)
+						}
+					}
+				}
+				for _, target := range tc.data.Targets {
+					if !strings.Contains(output, "      - task: build:"+target.Name()+"\n") || !strings.Contains(output, "  build:"+target.Name()+":") {
+						t.Errorf("taskfile output missing build task for %s", target)
+					}
+				}
+				if len(tc.data.Targets) > 0 && !strings.Contains(output, "-o dist/testctl-windows-arm64.exe ./cmd/testctl") {
+					t.Errorf("taskfile output missing windows build of testctl")
+				}
+				for _, e := range tc.data.EnvTargets {
+					if !strings.Contains(output, "  run:"+e.Name+":") || !strings.Contains(output, "DOTENV_PATH: env/"+e.Name+".env") {
+						t.Errorf("taskfile output missing run task for %s", e.Name)
+					}
+				}
+				var parsed map[string]any
+				if err := yaml.Unmarshal([]byte(output), &parsed); err != nil {
+					t.Errorf("taskfile output is not valid YAML: %v", err)
+				}
+			case "goreleaser.tmpl":
+				var parsed struct {
+					Builds []struct {
+						Main    string   `yaml:"main"`
+						Ldflags []string `yaml:"ldflags"`
+					} `yaml:"builds"`
+				}
+				if err := yaml.Unmarshal([]byte(output), &parsed); err != nil {
+					t.Fatalf("goreleaser output is not valid YAML: %v", err)
+				}
+				if len(parsed.Builds) != len(tc.data.Binaries) || parsed.Builds[1].Main != "./cmd/testctl" {
+					t.Errorf("goreleaser builds = %+v, want one per binary", parsed.Builds)
+				}
+				if !strings.Contains(parsed.Builds[0].Ldflags[0], "-X main.Version={{.Version}}") {
+					t.Errorf("goreleaser ldflags missing GoReleaser version template")
+				}
 			case "main.tmpl":
 				if !strings.Contains(output, tc.data.ProjectName) {
 					t.Errorf("main output missing project name")
 				}
+				if !strings.Contains(output, `choice:"env"`) {
+					t.Errorf("main output missing config describe --output")
+				}
+				if !strings.Contains(output, "&SyncUsersCommand{},") {
+					t.Errorf("main output missing blueprint command")
+				}
+				if !strings.Contains(output, "config.Export(cmd.Output)") {
+					t.Errorf("main output missing config get --all")
+				}
+				if !strings.Contains(output, "func newParser(opts *Options) *flags.Parser {") {
+					t.Errorf("main output missing newParser")
+				}
 			case "config.tmpl":
 				if !strings.Contains(output, tc.data.ProjectName) {
 					t.Errorf("config output missing project name")
 				}
+				if !strings.Contains(output, "MaxRetries string `yaml:\"max_retries\" config:\"desc=Retries per request,default=3,type=int\"`") || !strings.Contains(output, `MaxRetries: "3",`) {
+					t.Errorf("config output missing blueprint field")
+				}
+				if !strings.Contains(output, "func DescribeEnv() ([]string, error) {") || !strings.Contains(output, "func DescribeYAML() ([]byte, error) {") {
+					t.Errorf("config output missing describe formats")
+				}
+				if !strings.Contains(output, "func GetDuration(key string) (time.Duration, error) {") {
+					t.Errorf("config output missing typed accessors")
+				}
+				if !strings.Contains(output, "func Migrate() ([]string, error) {") {
+					t.Errorf("config output missing key migration")
+				}
 			case "logs.tmpl":
 				if !strings.Contains(output, tc.data.ProjectName) {
 					t.Errorf("logs output missing project name")
 				}
+			case "bench.tmpl":
+				if !strings.Contains(output, "func BenchmarkAbout(") {
+					t.Errorf("bench output missing BenchmarkAbout")
+				}
+			case "fuzz.tmpl":
+				if !strings.Contains(output, "func FuzzContract(") {
+					t.Errorf("fuzz output missing FuzzContract")
+				}
+			case "github-ci.tmpl", "gitlab-ci.tmpl":
+				if !strings.Contains(output, "coverage-check.sh") {
+					t.Errorf("ci output missing coverage step")
+				}
+				if !strings.Contains(output, "GOPRIVATE: github.com/example/*") || !strings.Contains(output, `.insteadOf "https://github.com/"`) {
+					t.Errorf("ci output missing private module setup")
+				}
+				if !strings.Contains(output, "go build -o web/testapp.wasm ./cmd/testapp-wasm") {
+					t.Errorf("ci output missing wasm build")
+				}
+			case "coverage.tmpl":
+				if !strings.HasPrefix(output, "#!/bin/sh\n") {
+					t.Errorf("coverage script missing shebang")
+				}
+			case "config-remote.tmpl":
+				if !strings.Contains(output, "func (s *RemoteStore) Load() (*Config, error) {") {
+					t.Errorf("remote config output missing RemoteStore")
+				}
+			case "subcommand.tmpl":
+				if !strings.Contains(output, "type SyncUsersCommand struct{}") || !strings.Contains(output, `syncUsersLong  = "Copies users from the \"directory\""`) {
+					t.Errorf("subcommand output missing the command type or descriptions")
+				}
+			case "envtarget.tmpl":
+				if !strings.Contains(output, "\nTESTAPP_LOG_FMT=\"text\"\nTESTAPP_MAX_RETRIES=\"5\"\n") {
+					t.Errorf("env target output missing its variables:\n%s", output)
+				}
+			case "plugins.tmpl":
+				if !strings.Contains(output, `const pluginPrefix = "testapp-"`) {
+					t.Errorf("plugins output missing plugin prefix")
+				}
+			case "wasm.tmpl":
+				if !strings.HasPrefix(output, "//go:build js && wasm\n") || !strings.Contains(output, `js.Global().Set("testapp"`) {
+					t.Errorf("wasm output missing build constraint or the testapp global")
+				}
+			case "wasm-html.tmpl":
+				if !strings.Contains(output, `<script src="wasm_exec.js"></script>`) || !strings.Contains(output, `fetch("testapp.wasm")`) {
+					t.Errorf("wasm page missing wasm_exec.js or testapp.wasm")
+				}
+			case "plugin-example.tmpl":
+				if !strings.Contains(output, `os.Getenv("TESTAPP_BIN")`) {
+					t.Errorf("plugin example missing TESTAPP_BIN")
+				}
+			case "about.tmpl":
+				if !strings.Contains(output, "//go:embed scaffold.yaml") {
+					t.Errorf("about output missing embedded scaffold metadata")
+				}
+			case "setup.tmpl":
+				if !strings.Contains(output, "func firstRun(opts *Options, cmd flags.Commander) error {") {
+					t.Errorf("setup output missing first-run check")
+				}
+			case "env.tmpl":
+				if !strings.Contains(output, `vars = append(vars, [2]string{"TESTAPP_BIN", self})`) {
+					t.Errorf("env output missing TESTAPP_BIN")
+				}
+			case "scaffold.tmpl":
+				var meta struct {
+					Module   string   `yaml:"module"`
+					Features []string `yaml:"features"`
+				}
+				if err := yaml.Unmarshal([]byte(output), &meta); err != nil {
+					t.Fatalf("scaffold output is not valid YAML: %v", err)
+				}
+				if meta.Module != tc.data.ModuleURL || strings.Join(meta.Features, ",") != "bench,itest" {
+					t.Errorf("scaffold metadata = %+v, want module %s with bench and itest", meta, tc.data.ModuleURL)
+				}
+			case "app.tmpl":
+				if !strings.Contains(output, "var ProviderSet = wire.NewSet(") {
+					t.Errorf("app output missing wire provider set")
+				}
+			case "smoke.tmpl":
+				if !strings.Contains(output, "func TestSmoke(t *testing.T) {") {
+					t.Errorf("smoke output missing TestSmoke")
+				}
+			case "main_test.tmpl":
+				if !strings.Contains(output, "parser := newParser(&opts)") || !strings.Contains(output, "func TestConfigSetGet(t *testing.T) {") {
+					t.Errorf("main_test output missing the parser harness")
+				}
+			case "air.tmpl":
+				if !strings.Contains(output, `cmd = "go build -o ./tmp/testapp ./cmd/testapp"`) || !strings.Contains(output, `args_bin = ["serve"]`) {
+					t.Errorf("air output missing build command or serve args")
+				}
+			case "gitignore.tmpl":
+				if !strings.Contains(output, "/bin/") || !strings.Contains(output, "/vendor/") || !strings.Contains(output, "/coverage.out") || !strings.Contains(output, "/web/*.wasm") {
+					t.Errorf("gitignore output missing bin/, vendor/, coverage.out or web/*.wasm")
+				}
+			case "catalog.tmpl":
+				var entity struct {
+					Metadata struct {
+						Name        string            `yaml:"name"`
+						Annotations map[string]string `yaml:"annotations"`
+					} `yaml:"metadata"`
+					Spec map[string]string `yaml:"spec"`
+				}
+				if err := yaml.Unmarshal([]byte(output), &entity); err != nil {
+					t.Fatalf("catalog output is not valid YAML: %v", err)
+				}
+				want := map[string]string{"type": "service", "lifecycle": "production", "owner": "group:platform", "system": "billing"}
+				if entity.Metadata.Name != "testapp" || entity.Metadata.Annotations["github.com/project-slug"] != "example/testapp" || !maps.Equal(entity.Spec, want) {
+					t.Errorf("catalog entity = %+v, want testapp owned by group:platform as a service", entity)
+				}
+			case "context.tmpl":
+				if !strings.Contains(output, "signal.NotifyContext(") {
+					t.Errorf("context output missing signal.NotifyContext")
+				}
+			case "app-server.tmpl":
+				if !strings.Contains(output, `os.Getenv("TESTAPP_ADDR")`) {
+					t.Errorf("app server missing TESTAPP_ADDR")
+				}
+			case "wire.tmpl", "wire-gen.tmpl":
+				if !strings.Contains(output, "func New() (*App, error) {") {
+					t.Errorf("wire output missing the New injector")
+				}
+			case "docs.tmpl":
+				if !strings.Contains(output, "func writeMarkdown(") {
+					t.Errorf("docs output missing writeMarkdown")
+				}
+			case "doc.tmpl":
+				if !strings.Contains(output, "// Package "+tc.data.PackageName+" ") {
+					t.Errorf("doc output missing package comment")
+				}
+			case "example.tmpl":
+				if !strings.Contains(output, "// Output: Project: "+tc.data.ProjectName) {
+					t.Errorf("example output missing expected output")
+				}
+			case "readme.tmpl", "readme.ja.tmpl":
+				if tc.data.Kind == "library" && !strings.Contains(output, "go get "+tc.data.ModuleURL) {
+					t.Errorf("library readme missing go get")
+				}
+				if !strings.Contains(output, "# "+tc.data.ProjectName) || tc.data.Kind != "library" && !strings.Contains(output, "cmd/"+tc.data.BinaryName) {
+					t.Errorf("readme output missing project or binary name")
+				}
+				if !strings.Contains(output, "Copyright 2021 ") {
+					t.Errorf("readme output missing the license year")
+				}
+			case "pathutil.tmpl":
+				if !strings.Contains(output, "func Expand(") || !strings.Contains(output, "func Contract(") {
+					t.Errorf("pathutil output missing Expand/Contract")
+				}
 			}
 		})
 	}
@@ -290,4 +1395,12 @@ replace (
 	if output, err := cmd.CombinedOutput(); err != nil {
 		t.Fatalf("generated code failed to compile: %v\n%s", err, output)
 	}
+
+	// The browser build only compiles for js/wasm
+	cmd = exec.Command("go", "build", "-o", os.DevNull, "./cmd/testapp-wasm")
+	cmd.Dir = outputDir
+	cmd.Env = append(os.Environ(), "GO111MODULE=on", "GOOS=js", "GOARCH=wasm")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generated wasm code failed to compile: %v\n%s", err, output)
+	}
 }
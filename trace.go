@@ -0,0 +1,39 @@
+package project
+
+import (
+	"context"
+
+	"github.com/robbyriverside/project/internal/execx"
+	"go.uber.org/zap"
+)
+
+var nopLog = zap.NewNop().Sugar()
+
+// log returns g.Log, or a logger that drops everything when it is unset.
+func (g *Generator) log() *zap.SugaredLogger {
+	if g.Log != nil {
+		return g.Log
+	}
+	return nopLog
+}
+
+// run runs a command through g.Runner and traces its arguments,
+// directory, duration and any error at debug level.
+func (g *Generator) run(ctx context.Context, dir, name string, args ...string) (execx.Result, error) {
+	res, err := g.runner().Run(ctx, dir, name, args...)
+	kv := []any{"args", append([]string{name}, args...), "dir", dir, "duration", res.Duration}
+	if err != nil {
+		kv = append(kv, "error", err)
+	}
+	g.log().Debugw("exec", kv...)
+	return res, err
+}
+
+// runner returns g.Runner, or one that runs commands on the host when it
+// is unset.
+func (g *Generator) runner() execx.Runner {
+	if g.Runner != nil {
+		return g.Runner
+	}
+	return execx.Host{}
+}
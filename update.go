@@ -0,0 +1,52 @@
+package project
+
+import (
+	"fmt"
+
+	"github.com/robbyriverside/project/internal/semver"
+	"github.com/robbyriverside/project/migrations"
+)
+
+// Update brings an existing project up to the current templates: it runs any
+// migrations between the manifest's template version and the pack's version,
+// then regenerates. It returns the migrations that were applied.
+func (g *Generator) Update(projectDir string) ([]migrations.Migration, error) {
+	m, err := loadManifest(g.fsys(), projectDir)
+	if err != nil {
+		return nil, err
+	}
+	// Held across migrations and regeneration
+	release, err := g.lock(NewGenConfig(m.ModuleURL, projectDir).ProjectPath())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	pack, err := g.PackInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	from := m.TemplateVersion
+	if from == "" {
+		from = baselineTemplateVersion
+	}
+
+	// Migrations only describe the history of one pack
+	var pending []migrations.Migration
+	if m.TemplatePack == "" || m.TemplatePack == pack.Name {
+		if semver.Compare(pack.Version, from) < 0 {
+			return nil, fmt.Errorf("project uses %s templates %s, newer than available %s", pack.Name, from, pack.Version)
+		}
+		pending = migrations.Pending(from, pack.Version)
+	}
+
+	ctx := &migrations.Context{Dir: NewGenConfig(m.ModuleURL, projectDir).ProjectPath(), ModuleURL: m.ModuleURL, FS: g.FS}
+	if err := migrations.Run(ctx, pending); err != nil {
+		return nil, err
+	}
+
+	if err := g.GenerateAll(m.ModuleURL, projectDir); err != nil {
+		return pending, err
+	}
+	return pending, nil
+}
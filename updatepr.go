@@ -0,0 +1,146 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/forge"
+	"github.com/robbyriverside/project/internal/gitops"
+	"github.com/robbyriverside/project/migrations"
+)
+
+// PullRequestOptions configures UpdatePullRequest.
+type PullRequestOptions struct {
+	Token  string
+	Branch string // default project-update-<pack version>
+	Remote string // default origin
+}
+
+// UpdatePullRequest runs Update on a new branch of the git repo holding
+// projectDir, commits the result, pushes the branch and opens a pull
+// request into the branch that was checked out, with a changelog of the
+// template changes. The repo is left on the branch it started on. It
+// returns the pull request's URL, "" if the project was already up to
+// date, and the migrations that were applied.
+func (g *Generator) UpdatePullRequest(projectDir string, opts PullRequestOptions) (string, []migrations.Migration, error) {
+	if opts.Token == "" {
+		return "", nil, errors.New("no API token for the forge")
+	}
+	if gitops.Root(projectDir) == "" {
+		return "", nil, fmt.Errorf("%s is not in a git repository", projectDir)
+	}
+	if dirty, err := gitops.Dirty(projectDir); err != nil {
+		return "", nil, err
+	} else if len(dirty) > 0 {
+		return "", nil, fmt.Errorf("the repository has uncommitted changes, e.g. %s; commit or stash them first", dirty[0])
+	}
+	base, err := gitops.Branch(projectDir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	m, err := loadManifest(g.fsys(), projectDir)
+	if err != nil {
+		return "", nil, err
+	}
+	pack, err := g.PackInfo()
+	if err != nil {
+		return "", nil, err
+	}
+	branch := opts.Branch
+	if branch == "" {
+		branch = "project-update-" + pack.Version
+	}
+	remote := opts.Remote
+	if remote == "" {
+		remote = "origin"
+	}
+
+	if err := gitops.Checkout(projectDir, branch, true); err != nil {
+		return "", nil, err
+	}
+	applied, err := g.Update(projectDir)
+	if err != nil {
+		return "", applied, fmt.Errorf("%w (left on branch %s)", err, branch)
+	}
+	if dirty, err := gitops.Dirty(projectDir); err != nil {
+		return "", applied, err
+	} else if len(dirty) == 0 {
+		if err := gitops.Checkout(projectDir, base, false); err != nil {
+			return "", applied, err
+		}
+		return "", applied, gitops.DeleteBranch(projectDir, branch)
+	}
+
+	title := fmt.Sprintf("Update scaffolding to %s %s", pack.Name, pack.Version)
+	if err := gitops.CommitAll(projectDir, title); err != nil {
+		return "", applied, err
+	}
+	f := forge.For(g.ctx(), g.Config.Host, opts.Token, g.Retry)
+	if err := gitops.Push(projectDir, remote, branch, f.PushAuth()); err != nil {
+		return "", applied, err
+	}
+	url, err := f.OpenPullRequest(g.Config.Owner, g.Config.ProjectName, forge.PullRequest{
+		Title: title,
+		Body:  updateChangelog(m, pack, applied, g.Results),
+		Head:  branch,
+		Base:  base,
+	})
+	if err != nil {
+		return "", applied, fmt.Errorf("failed to open pull request: %w", err)
+	}
+	return url, applied, gitops.Checkout(projectDir, base, false)
+}
+
+// updateChangelog describes an update from the manifest m was before it
+// to pack: the version change, the migrations run and the files changed,
+// grouped by what update did to them.
+func updateChangelog(m *Manifest, pack *Pack, applied []migrations.Migration, results []FileResult) string {
+	var b strings.Builder
+	name, from := m.TemplatePack, m.TemplateVersion
+	if name == "" {
+		name = pack.Name
+	}
+	if from == "" {
+		from = baselineTemplateVersion
+	}
+	if name == pack.Name && from == pack.Version {
+		fmt.Fprintf(&b, "Regenerates the scaffolding from template pack %s %s with project %s.\n", pack.Name, pack.Version, Version)
+	} else {
+		fmt.Fprintf(&b, "Updates the scaffolding from template pack %s %s to %s %s with project %s.\n", name, from, pack.Name, pack.Version, Version)
+	}
+
+	if len(applied) > 0 {
+		b.WriteString("\n### Migrations\n\n")
+		for _, mig := range applied {
+			fmt.Fprintf(&b, "- %s: %s\n", mig.Version, mig.Name)
+		}
+	}
+
+	for _, group := range []struct {
+		status  FileStatus
+		heading string
+	}{
+		{StatusCreated, "Added"},
+		{StatusUpdated, "Updated"},
+		{StatusMerged, "Merged with local edits"},
+		{StatusConflict, "Conflicts to resolve"},
+	} {
+		var paths []string
+		for _, r := range results {
+			if r.Status == group.status {
+				paths = append(paths, r.Path)
+			}
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", group.heading)
+		for _, p := range paths {
+			fmt.Fprintf(&b, "- `%s`\n", p)
+		}
+	}
+	b.WriteString("\nOpened by `project update --pr`.\n")
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package project
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/gitops"
+	"github.com/robbyriverside/project/migrations"
+)
+
+func TestUpdatePullRequestUpToDate(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for k, v := range map[string]string{"GIT_AUTHOR_NAME": "t", "GIT_AUTHOR_EMAIL": "t@example.com", "GIT_COMMITTER_NAME": "t", "GIT_COMMITTER_EMAIL": "t@example.com"} {
+		t.Setenv(k, v)
+	}
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	if err := g.GenerateAll("github.com/example/demo", t.TempDir()); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	root := g.Config.ProjectPath()
+	if err := gitops.Init(root, "main"); err != nil {
+		t.Fatal(err)
+	}
+	if err := gitops.CommitAll(root, "scaffold"); err != nil {
+		t.Fatal(err)
+	}
+
+	g = &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	url, _, err := g.UpdatePullRequest(root, PullRequestOptions{Token: "t"})
+	if err != nil || url != "" {
+		t.Fatalf("UpdatePullRequest() = %q, %v; want no pull request for an up-to-date project", url, err)
+	}
+	if branch, _ := gitops.Branch(root); branch != "main" {
+		t.Errorf("left on branch %q, want main", branch)
+	}
+}
+
+func TestUpdateChangelog(t *testing.T) {
+	m := &Manifest{TemplatePack: "go-cli", TemplateVersion: "1.30.0"}
+	pack := &Pack{Name: "go-cli", Version: "1.33.0"}
+	applied := []migrations.Migration{{Version: "1.31.0", Name: "move tasks to taskfiles/"}}
+	results := []FileResult{
+		{Path: "README.md", Status: StatusUpdated},
+		{Path: "main.go", Status: StatusUnchanged},
+		{Path: "taskfiles/lint.yaml", Status: StatusCreated},
+		{Path: "config/config.go", Status: StatusConflict},
+	}
+	got := updateChangelog(m, pack, applied, results)
+	for _, want := range []string{
+		"from template pack go-cli 1.30.0 to go-cli 1.33.0",
+		"### Migrations\n\n- 1.31.0: move tasks to taskfiles/\n",
+		"### Added\n\n- `taskfiles/lint.yaml`\n",
+		"### Updated\n\n- `README.md`\n",
+		"### Conflicts to resolve\n\n- `config/config.go`\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("changelog has no %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "main.go") {
+		t.Errorf("changelog lists an unchanged file:\n%s", got)
+	}
+}
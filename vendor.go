@@ -0,0 +1,21 @@
+package project
+
+// VendorDir holds the dependencies of projects generated with Generator.Vendor.
+const VendorDir = "vendor"
+
+// applyVendor keeps a vendored project vendored and exposes the choice to
+// templates as g.Config.Vendor.
+func (g *Generator) applyVendor(prev *Manifest) {
+	if prev != nil && prev.Vendor {
+		g.Vendor = true
+	}
+	g.Config.Vendor = g.Vendor
+}
+
+// modVendor refreshes vendor/ after tidy, so it matches go.mod and go.sum.
+func (g *Generator) modVendor() error {
+	if !g.Vendor {
+		return nil
+	}
+	return g.goNet("mod", "vendor")
+}
@@ -0,0 +1,136 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/fileutils"
+	"github.com/robbyriverside/project/internal/gitops"
+)
+
+// VerifyState classifies a project file against the checksum in its manifest.
+type VerifyState string
+
+const (
+	VerifyOK       VerifyState = "ok"       // content matches the manifest
+	VerifyModified VerifyState = "modified" // content changed since generation
+	VerifyMissing  VerifyState = "missing"  // listed in the manifest but not on disk
+	VerifyExtra    VerifyState = "extra"    // on disk but not listed in the manifest
+)
+
+// VerifyResult is the check of one file.
+type VerifyResult struct {
+	Path     string      `json:"path"`
+	State    VerifyState `json:"state"`
+	Expected string      `json:"expected,omitempty"` // sha256 from the manifest
+	Actual   string      `json:"actual,omitempty"`   // sha256 of the working tree
+}
+
+// VerifyReport is the outcome of Verify, with files in path order.
+type VerifyReport struct {
+	ProjectDir string         `json:"project_dir"`
+	Files      []VerifyResult `json:"files"`
+}
+
+// Clean reports whether every file listed in the manifest is present and
+// unmodified. Extra files don't count against it.
+func (r *VerifyReport) Clean() bool {
+	for _, f := range r.Files {
+		if f.State == VerifyModified || f.State == VerifyMissing {
+			return false
+		}
+	}
+	return true
+}
+
+// bookkeeping are the files a generated project has outside its manifest.
+var bookkeeping = map[string]bool{
+	ManifestName: true,
+	LockName:     true,
+	"go.mod":     true,
+	"go.sum":     true,
+}
+
+// Verify re-hashes the files listed in projectDir's manifest and reports
+// which are modified or missing, plus the files the manifest doesn't list.
+// Unlike Diff it doesn't render templates, so it needs no template pack.
+func Verify(projectDir string) (*VerifyReport, error) {
+	m, err := LoadManifest(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{ProjectDir: projectDir}
+	listed := make(map[string]bool, len(m.Files))
+	for _, f := range m.Files {
+		listed[f.Path] = true
+		res := VerifyResult{Path: f.Path, State: VerifyOK, Expected: f.SHA256}
+		sum, err := fileChecksum(fileutils.OS, filepath.Join(projectDir, filepath.FromSlash(f.Path)))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			res.State = VerifyMissing
+		case err != nil:
+			return nil, fmt.Errorf("failed to hash %s: %w", f.Path, err)
+		case sum != f.SHA256:
+			res.State = VerifyModified
+			res.Actual = sum
+		default:
+			res.Actual = sum
+		}
+		report.Files = append(report.Files, res)
+	}
+
+	present, err := projectFiles(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range present {
+		if listed[p] || bookkeeping[p] || strings.HasPrefix(p, AttestDir+"/") || m.Vendor && strings.HasPrefix(p, VendorDir+"/") {
+			continue
+		}
+		report.Files = append(report.Files, VerifyResult{Path: p, State: VerifyExtra})
+	}
+
+	sort.Slice(report.Files, func(i, j int) bool { return report.Files[i].Path < report.Files[j].Path })
+	return report, nil
+}
+
+// projectFiles lists the files under projectDir, slash-separated. Inside a
+// git repo it asks git, so ignored files (build output and the like) are
+// left out; otherwise it walks the tree, skipping only .git.
+func projectFiles(projectDir string) ([]string, error) {
+	if gitops.Root(projectDir) != "" {
+		files, err := gitops.Files(projectDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list project files: %w", err)
+		}
+		return files, nil
+	}
+
+	var files []string
+	err := filepath.WalkDir(projectDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(projectDir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project files: %w", err)
+	}
+	return files, nil
+}
@@ -0,0 +1,128 @@
+package project
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+	"github.com/robbyriverside/project/internal/gitops"
+)
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name  string
+		git   bool
+		edit  func(t *testing.T, dir string)
+		clean bool
+		want  map[string]VerifyState // the files not ok
+	}{
+		{name: "clean", clean: true},
+		{
+			name: "drifted",
+			edit: func(t *testing.T, dir string) { writeFile(t, dir, "README.md", "# mine\n") },
+			want: map[string]VerifyState{"README.md": VerifyModified},
+		},
+		{
+			name:  "extra",
+			edit:  func(t *testing.T, dir string) { writeFile(t, dir, "notes/todo.txt", "x") },
+			clean: true,
+			want:  map[string]VerifyState{"notes/todo.txt": VerifyExtra},
+		},
+		{
+			name: "deleted",
+			edit: func(t *testing.T, dir string) { removeFile(t, dir, "config/config.go") },
+			want: map[string]VerifyState{"config/config.go": VerifyMissing},
+		},
+		{
+			name: "deleted in git",
+			git:  true,
+			edit: func(t *testing.T, dir string) {
+				removeFile(t, dir, "config/config.go")
+				removeFile(t, dir, "notes/todo.txt")
+			},
+			want: map[string]VerifyState{"config/config.go": VerifyMissing},
+		},
+		{
+			name: "ignored in git",
+			git:  true,
+			edit: func(t *testing.T, dir string) {
+				writeFile(t, dir, "bin/demo", "\x7fELF")
+				writeFile(t, dir, "draft.go", "package demo\n")
+			},
+			clean: true,
+			want:  map[string]VerifyState{"draft.go": VerifyExtra, "notes/todo.txt": VerifyExtra},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+			dir := t.TempDir()
+			if err := g.GenerateAll("github.com/example/demo", dir); err != nil {
+				t.Fatalf("GenerateAll() error: %v", err)
+			}
+			if tt.git {
+				if _, err := exec.LookPath("git"); err != nil {
+					t.Skip("git not installed")
+				}
+				// A tracked file the manifest doesn't list
+				writeFile(t, dir, "notes/todo.txt", "x")
+				if err := gitops.Init(dir, "main"); err != nil {
+					t.Fatal(err)
+				}
+				for _, v := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_NAME", "GIT_COMMITTER_EMAIL"} {
+					t.Setenv(v, "test")
+				}
+				if err := gitops.CommitAll(dir, "Generate"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.edit != nil {
+				tt.edit(t, dir)
+			}
+
+			report, err := Verify(dir)
+			if err != nil {
+				t.Fatalf("Verify() error: %v", err)
+			}
+			if report.Clean() != tt.clean {
+				t.Errorf("Clean() = %v, want %v", report.Clean(), tt.clean)
+			}
+			got := make(map[string]VerifyState)
+			for _, f := range report.Files {
+				if f.State != VerifyOK {
+					got[f.Path] = f.State
+				}
+			}
+			for path, state := range tt.want {
+				if got[path] != state {
+					t.Errorf("%s is %q, want %q", path, got[path], state)
+				}
+			}
+			for path, state := range got {
+				if _, ok := tt.want[path]; !ok {
+					t.Errorf("%s is %q, want ok", path, state)
+				}
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, dir, name, data string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func removeFile(t *testing.T, dir, name string) {
+	t.Helper()
+	if err := os.Remove(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,4 @@
+package project
+
+// Version is the generator release, recorded in each project's manifest.
+const Version = "0.0.1"
@@ -0,0 +1,129 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/robbyriverside/project/internal/forge"
+	"github.com/robbyriverside/project/internal/gitops"
+)
+
+// RepoCheck is the outcome of CheckRepo.
+type RepoCheck struct {
+	Repo     string     `json:"repo"`
+	Modified []string   `json:"modified,omitempty"` // generated files edited or deleted since generation
+	Updates  []string   `json:"updates,omitempty"`  // files the templates now render differently
+	Pack     PackStatus `json:"pack"`
+	Issues   []string   `json:"issues,omitempty"` // URLs of the issues filed or found open
+}
+
+// Drifted reports whether the check found anything to file an issue about.
+func (c *RepoCheck) Drifted() bool {
+	return len(c.Modified) > 0 || len(c.Updates) > 0 || c.Pack.Behind
+}
+
+// CheckRepo clones repo's branch, verifies it against its manifest and
+// diffs it against g's templates, as project verify and diff do, and files
+// an issue for each finding: scaffolding edited away from the manifest, and
+// template updates it hasn't taken. An open issue with the same title is
+// left as it is, so checking on every push doesn't pile them up.
+func (g *Generator) CheckRepo(repo ListedRepo, token string, labels []string) (*RepoCheck, error) {
+	f := forge.For(g.ctx(), repo.Host(), token, g.Retry)
+	tmp, err := os.MkdirTemp("", "project-watch-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(tmp, path.Base(repo.Repo))
+	if err := gitops.Clone(repo.cloneURL(), dir, repo.Branch, f.PushAuth()); err != nil {
+		return nil, fmt.Errorf("failed to clone %s: %w", repo.Repo, err)
+	}
+
+	check, err := g.checkClone(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", repo.Repo, err)
+	}
+	check.Repo = repo.Repo
+
+	owner, name := path.Split(repo.Path())
+	for _, issue := range driftIssues(check) {
+		issue.Labels = labels
+		url, err := f.OpenIssue(strings.TrimSuffix(owner, "/"), name, issue)
+		if err != nil {
+			return check, fmt.Errorf("failed to file issue on %s: %w", repo.Repo, err)
+		}
+		check.Issues = append(check.Issues, url)
+	}
+	return check, nil
+}
+
+// checkClone runs CheckRepo's checks on the working tree in dir.
+func (g *Generator) checkClone(dir string) (*RepoCheck, error) {
+	verify, err := Verify(dir)
+	if err != nil {
+		return nil, err
+	}
+	check := &RepoCheck{}
+	for _, f := range verify.Files {
+		if f.State == VerifyModified || f.State == VerifyMissing {
+			check.Modified = append(check.Modified, f.Path)
+		}
+	}
+
+	m, err := loadManifest(g.fsys(), dir)
+	if err != nil {
+		return nil, err
+	}
+	if check.Pack = g.packStatus(m); check.Pack.Error != "" {
+		return nil, errors.New(check.Pack.Error)
+	}
+	diffs, err := g.Diff(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range diffs {
+		if d.State == DiffDrifted || d.State == DiffConflict || d.State == DiffNew {
+			check.Updates = append(check.Updates, d.Path)
+		}
+	}
+	return check, nil
+}
+
+// driftIssues are the issues to file for c's findings. Titles are stable
+// for the same finding so an open one is found again; the update issue
+// names the pack version, so a newer release files a new one.
+func driftIssues(c *RepoCheck) []forge.Issue {
+	var issues []forge.Issue
+	if len(c.Modified) > 0 {
+		var body strings.Builder
+		body.WriteString("These generated files were edited or deleted since they were generated, so `project verify` fails:\n\n")
+		for _, p := range c.Modified {
+			fmt.Fprintf(&body, "- `%s`\n", p)
+		}
+		body.WriteString("\nRestore them, or see how they differ from the templates with `project diff`.\n")
+		issues = append(issues, forge.Issue{Title: "Scaffolding has drifted from its manifest", Body: body.String()})
+	}
+	if len(c.Updates) > 0 || c.Pack.Behind {
+		var body strings.Builder
+		if c.Pack.Behind {
+			fmt.Fprintf(&body, "This repository was generated with template pack %s %s; %s is available.\n\n", c.Pack.Name, c.Pack.Version, c.Pack.Latest)
+		}
+		if len(c.Updates) > 0 {
+			body.WriteString("The templates now render these files differently:\n\n")
+			for _, p := range c.Updates {
+				fmt.Fprintf(&body, "- `%s`\n", p)
+			}
+			body.WriteString("\n")
+		}
+		body.WriteString("Run `project update` to take the changes; `project diff` shows them first.\n")
+		issues = append(issues, forge.Issue{
+			Title: fmt.Sprintf("Template updates are available from %s %s", c.Pack.Name, c.Pack.Latest),
+			Body:  body.String(),
+		})
+	}
+	return issues
+}
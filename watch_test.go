@@ -0,0 +1,56 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyriverside/project/internal/execx"
+)
+
+func TestCheckClone(t *testing.T) {
+	g := &Generator{Runner: &execx.Recorder{Respond: fakeGo}}
+	if err := g.GenerateAll("github.com/example/demo", t.TempDir()); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	root := g.Config.ProjectPath()
+
+	check, err := (&Generator{}).checkClone(root)
+	if err != nil {
+		t.Fatalf("checkClone() error: %v", err)
+	}
+	if check.Drifted() || len(driftIssues(check)) != 0 {
+		t.Errorf("check = %+v, want a fresh project clean", check)
+	}
+
+	// Edit a generated file and record an older pack
+	if err := os.WriteFile(filepath.Join(root, "README.md"), []byte("mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest := filepath.Join(root, ManifestName)
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadManifest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = []byte(strings.Replace(string(data), "template_version: "+m.TemplateVersion, "template_version: 1.0.0", 1))
+	if err := os.WriteFile(manifest, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err = (&Generator{}).checkClone(root)
+	if err != nil {
+		t.Fatalf("checkClone() error: %v", err)
+	}
+	if len(check.Modified) != 1 || check.Modified[0] != "README.md" || !check.Pack.Behind {
+		t.Errorf("check = %+v, want README.md modified and the pack behind", check)
+	}
+	issues := driftIssues(check)
+	if len(issues) != 2 || !strings.Contains(issues[0].Body, "`README.md`") || !strings.Contains(issues[1].Title, m.TemplateVersion) {
+		t.Errorf("issues = %+v, want a drift issue naming README.md and an update issue naming %s", issues, m.TemplateVersion)
+	}
+}
@@ -0,0 +1,29 @@
+package project
+
+import (
+	"path"
+
+	"github.com/robbyriverside/project/internal/yamlmerge"
+)
+
+// mergeYAML returns what to write for a YAML file edited since the last
+// run, such as Taskfile.yaml or a CI workflow: the file as the user left
+// it, plus the keys f adds, like a new task or job. Keys the user's copy
+// has stay as they are. ok is false when the file wasn't edited, isn't
+// YAML or can't be merged, and the usual rules apply.
+func (g *Generator) mergeYAML(f RenderedFile, destPath string) (merged, current []byte, ok bool) {
+	prev := g.prev.File(f.Path)
+	if ext := path.Ext(f.Path); prev == nil || f.Streamed() || ext != ".yaml" && ext != ".yml" {
+		return nil, nil, false
+	}
+	current, err := g.fsys().ReadFile(destPath)
+	if err != nil || checksum(current) == prev.SHA256 {
+		return nil, nil, false
+	}
+	merged, err = yamlmerge.Merge(current, f.Data)
+	if err != nil {
+		g.log().Debugw("yamlmerge", "path", f.Path, "error", err)
+		return nil, nil, false
+	}
+	return merged, current, true
+}